@@ -7,8 +7,11 @@ go 1.24.7
 require (
 	github.com/go-resty/resty/v2 v2.16.5
 	github.com/go-rod/rod v0.116.2
+	github.com/mattn/go-sqlite3 v1.14.32
 	github.com/pb33f/libopenapi v0.27.0
 	github.com/stretchr/testify v1.11.1
+	golang.org/x/net v0.44.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -25,6 +28,5 @@ require (
 	github.com/ysmood/gson v0.7.3 // indirect
 	github.com/ysmood/leakless v0.9.0 // indirect
 	go.yaml.in/yaml/v4 v4.0.0-rc.2 // indirect
-	golang.org/x/net v0.44.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	golang.org/x/text v0.29.0 // indirect
 )
@@ -4,12 +4,17 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/gowright/framework/pkg/assertions"
 	"github.com/gowright/framework/pkg/config"
 	"github.com/gowright/framework/pkg/core"
+	"golang.org/x/net/http/httpguts"
 )
 
 // APITester implements the APITester interface for HTTP API testing
@@ -18,12 +23,18 @@ type APITester struct {
 	asserter    *assertions.Asserter
 	initialized bool
 	client      *resty.Client
+	tokenSource *tokenSource    // set for oauth2 and jwt auth
+	history     *requestHistory // recent requests for reproducing failures; nil when disabled
+	variables   *core.Variables // suite scope for captured variables
+	cookies     http.CookieJar  // session cookies, kept when the tester is initialized again
 }
 
 // NewAPITester creates a new API tester instance
 func NewAPITester() *APITester {
 	return &APITester{
-		asserter: assertions.NewAsserter(),
+		asserter:  assertions.NewAsserter(),
+		variables: core.NewVariables(),
+		cookies:   newCookieJar(),
 	}
 }
 
@@ -34,13 +45,57 @@ func (at *APITester) Initialize(cfg interface{}) error {
 		return core.NewGowrightError(core.ConfigurationError, "invalid configuration type for API tester", nil)
 	}
 
+	// Exchanges recorded under the previous configuration are saved before the client is replaced
+	if err := at.flushRecording(); err != nil {
+		return err
+	}
+
 	at.config = apiConfig
+	if err := validateReproduceFormats(apiConfig.ReproduceFormats); err != nil {
+		return err
+	}
+
+	transport, err := buildTransport(apiConfig)
+	if err != nil {
+		return err
+	}
 
 	// Initialize HTTP client
 	at.client = resty.New()
+	at.client.SetTransport(transport)
+	if apiConfig.Recording != nil {
+		recorder, err := newRecorder(apiConfig.Recording, transport)
+		if err != nil {
+			return err
+		}
+		recorder.secrets = at.secretReplacer
+		at.client.SetTransport(recorder)
+	}
+	at.history = nil
+	if apiConfig.RequestHistory >= 0 {
+		limit := apiConfig.RequestHistory
+		if limit == 0 {
+			limit = defaultRequestHistory
+		}
+		at.history = &requestHistory{limit: limit}
+		at.client.SetPreRequestHook(at.history.record)
+	}
+	if at.cookies == nil {
+		at.cookies = newCookieJar()
+	}
+	at.client.SetCookieJar(at.cookies)
 	at.client.SetBaseURL(apiConfig.BaseURL)
 	at.client.SetTimeout(apiConfig.Timeout)
 
+	// Without FollowRedirects the 3xx response itself is returned
+	if apiConfig.FollowRedirects {
+		at.client.SetRedirectPolicy(resty.FlexibleRedirectPolicy(10))
+	} else {
+		at.client.SetRedirectPolicy(resty.RedirectPolicyFunc(func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}))
+	}
+
 	// Set default headers if provided
 	if apiConfig.DefaultHeaders != nil {
 		at.client.SetHeaders(apiConfig.DefaultHeaders)
@@ -54,6 +109,11 @@ func (at *APITester) Initialize(cfg interface{}) error {
 		}
 	}
 
+	// Attach tokens from the OAuth2 or JWT token source, renewing them as they expire
+	at.tokenSource = nil
+	at.client.OnBeforeRequest(at.applyToken)
+	at.client.OnAfterResponse(at.invalidateToken)
+
 	// Set authentication if provided
 	if apiConfig.Auth != nil {
 		if err := at.setAuthFromConfig(apiConfig.Auth); err != nil {
@@ -65,10 +125,54 @@ func (at *APITester) Initialize(cfg interface{}) error {
 	return nil
 }
 
-// Cleanup performs cleanup operations
+// buildTransport creates the HTTP transport for the TLS, proxy and connection settings of an API config
+func buildTransport(apiConfig *config.APIConfig) (*http.Transport, error) {
+	transportConfig := DefaultHTTPTransportConfig()
+	transportConfig.DisableKeepAlives = !apiConfig.KeepAlive
+	if apiConfig.MaxConnections > 0 {
+		transportConfig.MaxConnsPerHost = apiConfig.MaxConnections
+		transportConfig.MaxIdleConnsPerHost = apiConfig.MaxConnections
+	}
+
+	if apiConfig.TLSConfig != nil {
+		tlsConfig, err := BuildTLSConfig(apiConfig.TLSConfig)
+		if err != nil {
+			return nil, err
+		}
+		transportConfig.TLSClientConfig = tlsConfig
+	}
+
+	if apiConfig.Proxy != nil {
+		proxyURL, err := BuildProxyURL(apiConfig.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transportConfig.ProxyURL = proxyURL
+	}
+
+	return transportConfig.Build(), nil
+}
+
+// Cleanup performs cleanup operations and saves a recording made in record mode
 func (at *APITester) Cleanup() error {
+	err := at.flushRecording()
+
 	// Close connections, cleanup resources
+	if at.client != nil {
+		at.client.GetClient().CloseIdleConnections()
+	}
 	at.initialized = false
+	return err
+}
+
+// flushRecording writes the exchanges recorded so far to the recording file
+func (at *APITester) flushRecording() error {
+	if at.client == nil {
+		return nil
+	}
+	if recording, ok := at.client.GetClient().Transport.(*recorder); ok {
+		return recording.flush()
+	}
 	return nil
 }
 
@@ -79,72 +183,84 @@ func (at *APITester) GetName() string {
 
 // Get performs a GET request to the specified endpoint
 func (at *APITester) Get(endpoint string, headers map[string]string) (*core.APIResponse, error) {
-	if !at.initialized {
-		return nil, core.NewGowrightError(core.APIError, "API tester not initialized", nil)
-	}
-
-	start := time.Now()
-
-	req := at.client.R()
-	if headers != nil {
-		req.SetHeaders(headers)
-	}
-
-	resp, err := req.Get(endpoint)
-	if err != nil {
-		return nil, core.NewGowrightError(core.APIError, fmt.Sprintf("GET request failed: %v", err), err)
-	}
-
-	duration := time.Since(start)
-
-	return at.buildAPIResponse(resp, duration), nil
+	return at.Do(http.MethodGet, endpoint, nil, headers)
 }
 
 // Post performs a POST request to the specified endpoint
 func (at *APITester) Post(endpoint string, body interface{}, headers map[string]string) (*core.APIResponse, error) {
-	if !at.initialized {
-		return nil, core.NewGowrightError(core.APIError, "API tester not initialized", nil)
-	}
+	return at.Do(http.MethodPost, endpoint, body, headers)
+}
 
-	start := time.Now()
+// Put performs a PUT request to the specified endpoint
+func (at *APITester) Put(endpoint string, body interface{}, headers map[string]string) (*core.APIResponse, error) {
+	return at.Do(http.MethodPut, endpoint, body, headers)
+}
 
-	req := at.client.R()
-	if headers != nil {
-		req.SetHeaders(headers)
-	}
-	if body != nil {
-		req.SetBody(body)
-	}
+// Patch performs a PATCH request to the specified endpoint
+func (at *APITester) Patch(endpoint string, body interface{}, headers map[string]string) (*core.APIResponse, error) {
+	return at.Do(http.MethodPatch, endpoint, body, headers)
+}
 
-	resp, err := req.Post(endpoint)
-	if err != nil {
-		return nil, core.NewGowrightError(core.APIError, fmt.Sprintf("POST request failed: %v", err), err)
-	}
+// Delete performs a DELETE request to the specified endpoint
+func (at *APITester) Delete(endpoint string, headers map[string]string) (*core.APIResponse, error) {
+	return at.Do(http.MethodDelete, endpoint, nil, headers)
+}
 
-	duration := time.Since(start)
+// Head performs a HEAD request to the specified endpoint
+func (at *APITester) Head(endpoint string, headers map[string]string) (*core.APIResponse, error) {
+	return at.Do(http.MethodHead, endpoint, nil, headers)
+}
 
-	return at.buildAPIResponse(resp, duration), nil
+// Options performs an OPTIONS request to the specified endpoint
+func (at *APITester) Options(endpoint string, headers map[string]string) (*core.APIResponse, error) {
+	return at.Do(http.MethodOptions, endpoint, nil, headers)
 }
 
-// Put performs a PUT request to the specified endpoint
-func (at *APITester) Put(endpoint string, body interface{}, headers map[string]string) (*core.APIResponse, error) {
+// Do performs a request with the given HTTP method, which may be any RFC 7230 token such as TRACE
+// or PROPFIND; the method is case-insensitive
+func (at *APITester) Do(method, endpoint string, body interface{}, headers map[string]string) (*core.APIResponse, error) {
+	return at.doWith(at.client, method, endpoint, body, headers)
+}
+
+// doWith performs a request with a client configured by the tester, such as a virtual user's client
+func (at *APITester) doWith(client *resty.Client, method, endpoint string, body interface{}, headers map[string]string) (*core.APIResponse, error) {
 	if !at.initialized {
 		return nil, core.NewGowrightError(core.APIError, "API tester not initialized", nil)
 	}
 
+	method = strings.ToUpper(method)
+	// A method has the same token syntax as a header field name
+	if !httpguts.ValidHeaderFieldName(method) {
+		return nil, core.NewGowrightError(core.APIError, fmt.Sprintf("invalid HTTP method: %q", method), nil)
+	}
+
 	start := time.Now()
 
-	req := at.client.R()
+	req := client.R().EnableTrace()
 	if headers != nil {
 		req.SetHeaders(headers)
 	}
+	if requestBody, ok := body.(core.RequestBody); ok {
+		body = &requestBody
+	}
+	if requestBody, ok := body.(*core.RequestBody); ok {
+		reader, contentType, err := encodeRequestBody(requestBody)
+		if err != nil {
+			return nil, err
+		}
+		if closer, ok := reader.(io.Closer); ok {
+			defer func() { _ = closer.Close() }()
+		}
+		req.SetHeader("Content-Type", contentType)
+		body = reader
+	}
 	if body != nil {
 		req.SetBody(body)
 	}
 
-	resp, err := req.Put(endpoint)
+	resp, err := req.Execute(method, endpoint)
 	if err != nil {
-		return nil, core.NewGowrightError(core.APIError, fmt.Sprintf("PUT request failed: %v", err), err)
+		return nil, core.NewGowrightError(core.APIError, fmt.Sprintf("%s request failed: %v", method, err), err)
 	}
 
 	duration := time.Since(start)
@@ -152,36 +268,62 @@ func (at *APITester) Put(endpoint string, body interface{}, headers map[string]s
 	return at.buildAPIResponse(resp, duration), nil
 }
 
-// Delete performs a DELETE request to the specified endpoint
-func (at *APITester) Delete(endpoint string, headers map[string]string) (*core.APIResponse, error) {
+// SetAuth sets authentication for API requests
+func (at *APITester) SetAuth(auth *config.AuthConfig) error {
 	if !at.initialized {
-		return nil, core.NewGowrightError(core.APIError, "API tester not initialized", nil)
+		return core.NewGowrightError(core.APIError, "API tester not initialized", nil)
 	}
 
-	start := time.Now()
+	return at.setAuthFromConfig(auth)
+}
 
-	req := at.client.R()
-	if headers != nil {
-		req.SetHeaders(headers)
-	}
+// Variables returns the suite-scoped variables that tests capture and interpolate
+func (at *APITester) Variables() *core.Variables {
+	return at.variables
+}
 
-	resp, err := req.Delete(endpoint)
+// UseVariables replaces the suite-scoped variables, for example to share one store between testers
+func (at *APITester) UseVariables(variables *core.Variables) {
+	at.variables = variables
+}
+
+// doInterpolated performs a request after replacing {{name}} placeholders in its endpoint, headers
+// and body, using a test scope with testVariables on top of the suite variables
+func (at *APITester) doInterpolated(method, endpoint string, body interface{}, headers map[string]string, testVariables map[string]interface{}) (*core.APIResponse, error) {
+	scope := at.variables.NewScope()
+	scope.SetAll(testVariables)
+	return at.doInScope(at.client, scope, method, endpoint, body, headers)
+}
+
+// doInScope performs a request with a client after replacing placeholders with the values of scope
+func (at *APITester) doInScope(client *resty.Client, scope *core.Variables, method, endpoint string, body interface{}, headers map[string]string) (*core.APIResponse, error) {
+	endpoint, err := scope.Interpolate(endpoint)
 	if err != nil {
-		return nil, core.NewGowrightError(core.APIError, fmt.Sprintf("DELETE request failed: %v", err), err)
+		return nil, err
+	}
+	if headers, err = scope.InterpolateHeaders(headers); err != nil {
+		return nil, err
+	}
+	if requestBody, ok := body.(*core.RequestBody); ok {
+		if body, err = interpolateRequestBody(scope, requestBody); err != nil {
+			return nil, err
+		}
+	} else if body, err = scope.InterpolateValue(body); err != nil {
+		return nil, err
 	}
 
-	duration := time.Since(start)
-
-	return at.buildAPIResponse(resp, duration), nil
+	return at.doWith(client, method, endpoint, body, headers)
 }
 
-// SetAuth sets authentication for API requests
-func (at *APITester) SetAuth(auth *config.AuthConfig) error {
-	if !at.initialized {
-		return core.NewGowrightError(core.APIError, "API tester not initialized", nil)
+// captureVariables stores the captured values of a response in the suite variables and logs their names
+func (at *APITester) captureVariables(result *core.TestCaseResult, response *core.APIResponse, captures []core.APICapture) error {
+	if err := at.variables.Capture(response, captures); err != nil {
+		return err
 	}
-
-	return at.setAuthFromConfig(auth)
+	for _, capture := range captures {
+		result.Logs = append(result.Logs, "Captured variable "+capture.Variable)
+	}
+	return nil
 }
 
 // ExecuteTest executes an API test and returns the result
@@ -196,29 +338,16 @@ func (at *APITester) ExecuteTest(test *core.APITest) *core.TestCaseResult {
 	at.asserter.Reset()
 
 	// Execute HTTP request
+	sent := at.requestCount()
+	body, err := requestPayload(test.Body, test.RequestBody)
 	var response *core.APIResponse
-	var err error
-
-	switch test.Method {
-	case "GET":
-		response, err = at.Get(test.Endpoint, test.Headers)
-	case "POST":
-		response, err = at.Post(test.Endpoint, test.Body, test.Headers)
-	case "PUT":
-		response, err = at.Put(test.Endpoint, test.Body, test.Headers)
-	case "DELETE":
-		response, err = at.Delete(test.Endpoint, test.Headers)
-	default:
-		result.Status = core.TestStatusError
-		result.Error = core.NewGowrightError(core.APIError, "unsupported HTTP method: "+test.Method, nil)
-		result.EndTime = time.Now()
-		result.Duration = result.EndTime.Sub(result.StartTime)
-		return result
+	if err == nil {
+		response, err = at.doInterpolated(test.Method, test.Endpoint, body, test.Headers, test.Variables)
 	}
-
 	if err != nil {
 		result.Status = core.TestStatusError
 		result.Error = err
+		at.attachReproduction(result, sent)
 		result.EndTime = time.Now()
 		result.Duration = result.EndTime.Sub(result.StartTime)
 		return result
@@ -229,11 +358,17 @@ func (at *APITester) ExecuteTest(test *core.APITest) *core.TestCaseResult {
 		at.validateResponse(response, test.Expected)
 	}
 
+	recordTimings(result, response)
+
 	// Check for assertion failures
 	if at.asserter.HasFailures() {
 		result.Status = core.TestStatusFailed
 		result.Error = core.NewGowrightError(core.AssertionError, "one or more assertions failed", nil)
+	} else if err := at.captureVariables(result, response, test.Captures); err != nil {
+		result.Status = core.TestStatusFailed
+		result.Error = err
 	}
+	at.attachReproduction(result, sent)
 
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
@@ -244,25 +379,80 @@ func (at *APITester) ExecuteTest(test *core.APITest) *core.TestCaseResult {
 
 // validateResponse validates the API response against expectations
 func (at *APITester) validateResponse(response *core.APIResponse, expected *core.APIExpectation) {
+	validateAPIResponse(at.asserter, response, expected)
+}
+
+// validateAPIResponse records an assertion step for each expectation a response is checked against
+func validateAPIResponse(asserter *assertions.Asserter, response *core.APIResponse, expected *core.APIExpectation) {
 	// Validate status code
 	if expected.StatusCode != 0 {
-		at.asserter.Equal(expected.StatusCode, response.StatusCode, "Status code validation")
+		asserter.Equal(expected.StatusCode, response.StatusCode, "Status code validation")
 	}
 
 	// Validate headers
 	for key, expectedValue := range expected.Headers {
 		if actualValue, exists := response.Headers[key]; exists {
-			at.asserter.Equal(expectedValue, actualValue, "Header validation: "+key)
+			asserter.Equal(expectedValue, actualValue, "Header validation: "+key)
 		} else {
-			at.asserter.True(false, "Header exists: "+key)
+			asserter.True(false, "Header exists: "+key)
+		}
+	}
+
+	validateLatencyAndPatterns(asserter, response, expected)
+
+	if expected.Body != nil || len(expected.JSONPath) > 0 {
+		document := assertions.ParseJSONBody(response.Body)
+		asserter.JSONExpectations(document, expected.Body, expected.JSONPath, "Body validation", "JSON path validation: ")
+	}
+
+	// Validate the body against a JSON schema, one step per violation
+	if expected.JSONSchema != nil {
+		steps, _ := SchemaAssertionSteps(response.Body, expected.JSONSchema)
+		for _, step := range steps {
+			asserter.AddStep(step)
 		}
 	}
+}
 
-	// Additional body and JSON path validations would go here
+// validateLatencyAndPatterns checks the response time and the body and header patterns of an expectation
+func validateLatencyAndPatterns(asserter *assertions.Asserter, response *core.APIResponse, expected *core.APIExpectation) {
+	if expected.MaxResponseTime > 0 {
+		asserter.Less(response.Duration, expected.MaxResponseTime, fmt.Sprintf("Response time within %s", expected.MaxResponseTime))
+	}
+
+	if expected.BodyRegex != "" {
+		asserter.Match(assertions.MatchRegex(expected.BodyRegex), string(response.Body), "Body pattern validation")
+	}
+
+	for _, name := range sortedKeys(expected.HeaderRegex) {
+		value, exists := response.Headers[name]
+		if !exists {
+			value, exists = response.Headers[http.CanonicalHeaderKey(name)]
+		}
+		var actual interface{}
+		if exists {
+			actual = value
+		}
+		asserter.Match(assertions.MatchRegex(expected.HeaderRegex[name]), actual, "Header pattern validation: "+name)
+	}
+}
+
+// recordTimings attaches the request timings of a response to a test result
+func recordTimings(result *core.TestCaseResult, response *core.APIResponse) {
+	if response.Timings == nil {
+		return
+	}
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata["timings"] = response.Timings
+	result.Logs = append(result.Logs, "Request timings: "+response.Timings.String())
 }
 
 // setAuthFromConfig configures authentication on the HTTP client
 func (at *APITester) setAuthFromConfig(auth *config.AuthConfig) error {
+	at.tokenSource = nil
+
 	switch auth.Type {
 	case "bearer":
 		if auth.Token == "" {
@@ -280,6 +470,16 @@ func (at *APITester) setAuthFromConfig(auth *config.AuthConfig) error {
 		}
 		// Set API key as header (common pattern)
 		at.client.SetHeader("X-API-Key", auth.APIKey)
+	case "oauth2", "jwt":
+		newSource := newOAuth2TokenSource
+		if auth.Type == "jwt" {
+			newSource = newJWTTokenSource
+		}
+		source, err := newSource(auth, at.tokenClient)
+		if err != nil {
+			return err
+		}
+		at.tokenSource = source
 	default:
 		return core.NewGowrightError(core.ConfigurationError, fmt.Sprintf("unsupported auth type: %s", auth.Type), nil)
 	}
@@ -292,6 +492,36 @@ func (at *APITester) setAuthFromConfig(auth *config.AuthConfig) error {
 	return nil
 }
 
+// tokenClient returns the HTTP client for token requests, which bypass a recording transport so
+// client secrets, passwords and issued tokens are never written to a recording
+func (at *APITester) tokenClient() *http.Client {
+	client := *at.client.GetClient()
+	client.Transport = baseTransport(client.Transport)
+	return &client
+}
+
+// applyToken sets the bearer token of a request from the token source, unless the request
+// carries its own Authorization header
+func (at *APITester) applyToken(_ *resty.Client, req *resty.Request) error {
+	if at.tokenSource == nil || req.Header.Get("Authorization") != "" {
+		return nil
+	}
+	token, err := at.tokenSource.Token()
+	if err != nil {
+		return err
+	}
+	req.SetAuthToken(token)
+	return nil
+}
+
+// invalidateToken drops the cached token of the token source when a request using it is rejected
+func (at *APITester) invalidateToken(_ *resty.Client, resp *resty.Response) error {
+	if resp.StatusCode() == http.StatusUnauthorized && resp.Request.Token != "" && at.tokenSource != nil {
+		at.tokenSource.Invalidate()
+	}
+	return nil
+}
+
 // buildAPIResponse converts a resty response to our APIResponse format
 func (at *APITester) buildAPIResponse(resp *resty.Response, duration time.Duration) *core.APIResponse {
 	headers := make(map[string]string)
@@ -302,22 +532,56 @@ func (at *APITester) buildAPIResponse(resp *resty.Response, duration time.Durati
 	}
 
 	apiResp := &core.APIResponse{
-		StatusCode: resp.StatusCode(),
-		Headers:    headers,
-		Body:       resp.Body(),
-		Duration:   duration,
-	}
-
-	// Try to parse JSON if content type is JSON
-	contentType := headers["Content-Type"]
-	if contentType == "application/json" && len(resp.Body()) > 0 {
-		var jsonData interface{}
-		if err := json.Unmarshal(resp.Body(), &jsonData); err == nil {
-			if jsonMap, ok := jsonData.(map[string]interface{}); ok {
-				apiResp.JSON = jsonMap
+		StatusCode:   resp.StatusCode(),
+		Headers:      headers,
+		Body:         resp.Body(),
+		Duration:     duration,
+		HeaderValues: resp.Header().Clone(),
+		Cookies:      resp.Cookies(),
+	}
+
+	if resp.Request != nil {
+		trace := resp.Request.TraceInfo()
+		apiResp.Timings = &core.ResponseTimings{
+			DNSLookup:    trace.DNSLookup,
+			Connect:      trace.TCPConnTime,
+			TLSHandshake: trace.TLSHandshake,
+			TTFB:         trace.TotalTime - trace.ResponseTime,
+			Total:        trace.TotalTime,
+			ConnReused:   trace.IsConnReused,
+		}
+	}
+
+	// Parse JSON and XML bodies; a body that does not parse is only available as bytes
+	if mediaType, params, err := mime.ParseMediaType(resp.Header().Get("Content-Type")); err == nil {
+		apiResp.ContentType, apiResp.Charset = mediaType, params["charset"]
+	}
+	if len(resp.Body()) > 0 {
+		switch {
+		case isJSONMediaType(apiResp.ContentType):
+			var jsonData interface{}
+			if err := json.Unmarshal(resp.Body(), &jsonData); err == nil {
+				apiResp.JSONValue = jsonData
+				if jsonMap, ok := jsonData.(map[string]interface{}); ok {
+					apiResp.JSON = jsonMap
+				}
+			}
+		case isXMLMediaType(apiResp.ContentType):
+			if document, err := core.ParseXML(resp.Body()); err == nil {
+				apiResp.XML = document
 			}
 		}
 	}
 
 	return apiResp
 }
+
+// isJSONMediaType reports whether a media type is application/json or a +json suffix type
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// isXMLMediaType reports whether a media type is application/xml, text/xml or a +xml suffix type
+func isXMLMediaType(mediaType string) bool {
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
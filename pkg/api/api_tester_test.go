@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gowright/framework/pkg/assertions"
 	gwconfig "github.com/gowright/framework/pkg/config"
 	"github.com/gowright/framework/pkg/core"
 	"github.com/stretchr/testify/assert"
@@ -122,8 +124,8 @@ func TestAPITester_HTTPMethods(t *testing.T) {
 			"headers": r.Header,
 		}
 
-		// Handle request body for POST/PUT
-		if r.Method == "POST" || r.Method == "PUT" {
+		// Handle request body for POST/PUT/PATCH
+		if r.Method == "POST" || r.Method == "PUT" || r.Method == "PATCH" {
 			var body interface{}
 			if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
 				response["body"] = body
@@ -227,6 +229,73 @@ func TestAPITester_HTTPMethods(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, "DELETE", responseData["method"])
 	})
+
+	t.Run("PATCH request", func(t *testing.T) {
+		response, err := tester.Patch("/test", map[string]string{"status": "active"}, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+
+		var responseData map[string]interface{}
+		err = json.Unmarshal(response.Body, &responseData)
+		assert.NoError(t, err)
+		assert.Equal(t, "PATCH", responseData["method"])
+		assert.Equal(t, map[string]interface{}{"status": "active"}, responseData["body"])
+	})
+
+	t.Run("HEAD request", func(t *testing.T) {
+		response, err := tester.Head("/test", nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "test-value", response.Headers["X-Test-Header"])
+		assert.Empty(t, response.Body)
+	})
+
+	t.Run("OPTIONS request", func(t *testing.T) {
+		response, err := tester.Options("/test", nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+
+		var responseData map[string]interface{}
+		err = json.Unmarshal(response.Body, &responseData)
+		assert.NoError(t, err)
+		assert.Equal(t, "OPTIONS", responseData["method"])
+	})
+
+	t.Run("Do with lowercase method", func(t *testing.T) {
+		response, err := tester.Do("patch", "/items/1", map[string]int{"count": 2}, nil)
+
+		assert.NoError(t, err)
+
+		var responseData map[string]interface{}
+		err = json.Unmarshal(response.Body, &responseData)
+		assert.NoError(t, err)
+		assert.Equal(t, "PATCH", responseData["method"])
+		assert.Equal(t, "/items/1", responseData["path"])
+	})
+
+	t.Run("Do with extension methods", func(t *testing.T) {
+		for _, method := range []string{"TRACE", "propfind", "X-CUSTOM"} {
+			response, err := tester.Do(method, "/test", nil, nil)
+			require.NoError(t, err, method)
+
+			var responseData map[string]interface{}
+			require.NoError(t, json.Unmarshal(response.Body, &responseData))
+			assert.Equal(t, strings.ToUpper(method), responseData["method"])
+		}
+	})
+
+	t.Run("Do with invalid method", func(t *testing.T) {
+		for _, method := range []string{"", "GET /admin", "BAD\r\nMETHOD", "(GET)"} {
+			response, err := tester.Do(method, "/test", nil, nil)
+
+			assert.Nil(t, response)
+			require.Error(t, err, method)
+			assert.Contains(t, err.Error(), "invalid HTTP method")
+		}
+	})
 }
 
 func TestAPITester_ErrorHandling(t *testing.T) {
@@ -286,10 +355,57 @@ func TestAPITester_ExecuteTest(t *testing.T) {
 		assert.Nil(t, result.Error)
 	})
 
-	t.Run("unsupported method", func(t *testing.T) {
+	t.Run("PATCH method", func(t *testing.T) {
 		test := &core.APITest{
-			Name:     "Test Unsupported Method",
-			Method:   "UNSUPPORTED",
+			Name:     "Test PATCH",
+			Method:   "PATCH",
+			Endpoint: "/test",
+			Body:     map[string]string{"status": "active"},
+			Expected: &core.APIExpectation{StatusCode: 200},
+		}
+
+		result := tester.ExecuteTest(test)
+
+		assert.Equal(t, core.TestStatusPassed, result.Status)
+		assert.Nil(t, result.Error)
+	})
+
+	t.Run("body and JSON path matchers", func(t *testing.T) {
+		test := &core.APITest{
+			Name:     "Test Body",
+			Method:   "GET",
+			Endpoint: "/test",
+			Expected: &core.APIExpectation{
+				Body: map[string]interface{}{"status": assertions.AnyOf("success", "ok")},
+				JSONPath: map[string]interface{}{
+					"$.status": assertions.MatchRegex("^succ"),
+					"status":   "success",
+				},
+			},
+		}
+
+		result := tester.ExecuteTest(test)
+		assert.Equal(t, core.TestStatusPassed, result.Status, "%v", result.Error)
+		assert.Len(t, result.Steps, 3)
+
+		test.Expected.JSONPath["$..status"] = []interface{}{"failure"}
+		result = tester.ExecuteTest(test)
+		assert.Equal(t, core.TestStatusFailed, result.Status)
+
+		apiTest := NewAPITest("Test Body", "GET", "/test", tester).
+			SetExpectedJSONPath("$.status", assertions.MatchType("string"))
+		assert.Equal(t, core.TestStatusPassed, apiTest.Execute().Status)
+
+		apiTest.SetExpectedJSONPath("$.status", assertions.MatchType("number"))
+		apiResult := apiTest.Execute()
+		assert.Equal(t, core.TestStatusFailed, apiResult.Status)
+		assert.Contains(t, apiResult.Error.Error(), "expected a value of type number")
+	})
+
+	t.Run("invalid method", func(t *testing.T) {
+		test := &core.APITest{
+			Name:     "Test Invalid Method",
+			Method:   "NOT A METHOD",
 			Endpoint: "/test",
 		}
 
@@ -301,6 +417,158 @@ func TestAPITester_ExecuteTest(t *testing.T) {
 	})
 }
 
+func TestAPITester_LatencyAndPatterns(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(30 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("X-Request-Id", "req-1234")
+		_, _ = w.Write([]byte(`{"order": "A-100"}`))
+	}))
+	defer server.Close()
+
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, Timeout: 5 * time.Second}))
+
+	t.Run("passing expectations", func(t *testing.T) {
+		result := tester.ExecuteTest(&core.APITest{
+			Name:     "fast order",
+			Method:   "GET",
+			Endpoint: "/orders/1",
+			Expected: &core.APIExpectation{
+				MaxResponseTime: 5 * time.Second,
+				BodyRegex:       `"order":\s*"A-\d+"`,
+				HeaderRegex:     map[string]string{"x-request-id": `^req-\d+$`, "Content-Type": "^application/json"},
+			},
+		})
+
+		assert.Equal(t, core.TestStatusPassed, result.Status, "%v", result.Error)
+		assert.Len(t, result.Steps, 4)
+
+		timings, ok := result.Metadata["timings"].(*core.ResponseTimings)
+		require.True(t, ok)
+		assert.Greater(t, timings.Total, time.Duration(0))
+		assert.GreaterOrEqual(t, timings.Total, timings.TTFB)
+		assert.Contains(t, result.Logs[len(result.Logs)-1], "Request timings: dns=")
+	})
+
+	t.Run("failing expectations", func(t *testing.T) {
+		result := tester.ExecuteTest(&core.APITest{
+			Name:     "slow order",
+			Method:   "GET",
+			Endpoint: "/slow",
+			Expected: &core.APIExpectation{
+				MaxResponseTime: 10 * time.Millisecond,
+				BodyRegex:       "B-",
+				HeaderRegex:     map[string]string{"X-Missing": ".*"},
+			},
+		})
+
+		assert.Equal(t, core.TestStatusFailed, result.Status)
+		require.Len(t, result.Steps, 3)
+		for _, step := range result.Steps {
+			assert.Equal(t, core.TestStatusFailed, step.Status, step.Description)
+		}
+		assert.Equal(t, "Response time within 10ms", result.Steps[0].Description)
+	})
+
+	t.Run("APITest validators", func(t *testing.T) {
+		test := NewAPITest("slow order", "GET", "/slow", tester)
+		assert.Error(t, test.ValidateResponseTime(0))
+		assert.Error(t, test.ValidateRegex("("))
+		require.NoError(t, test.ValidateRegex("A-100"))
+		test.SetExpectedHeaderRegex("X-Request-Id", "^req-")
+
+		result := test.Execute()
+		assert.Equal(t, core.TestStatusPassed, result.Status, "%v", result.Error)
+		assert.Len(t, result.Steps, 2)
+		assert.NotNil(t, result.Metadata["timings"])
+
+		require.NoError(t, test.ValidateResponseTime(time.Millisecond))
+		result = test.Execute()
+		assert.Equal(t, core.TestStatusFailed, result.Status)
+		assert.Equal(t, time.Millisecond, test.Clone().Expected.MaxResponseTime)
+	})
+}
+
+func TestAPITester_RequestChaining(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/login":
+			var credentials map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&credentials)
+			w.Header().Set("X-Session", "session-"+credentials["user"].(string))
+			_, _ = w.Write([]byte(`{"token": "tok-123", "user": {"id": 7}}`))
+		case "/users/7/orders":
+			var order map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&order)
+			order["authorization"] = r.Header.Get("Authorization")
+			order["session"] = r.Header.Get("X-Session")
+			_ = json.NewEncoder(w).Encode(order)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, Timeout: 5 * time.Second}))
+	tester.Variables().Set("user", "alice")
+
+	login := tester.ExecuteTest(&core.APITest{
+		Name:     "login",
+		Method:   "POST",
+		Endpoint: "/login",
+		Body:     map[string]interface{}{"user": "{{user}}"},
+		Expected: &core.APIExpectation{StatusCode: http.StatusOK},
+		Captures: []core.APICapture{
+			{Variable: "token", JSONPath: "$.token"},
+			{Variable: "userId", JSONPath: "$.user.id"},
+			{Variable: "session", Header: "X-Session"},
+		},
+	})
+	require.Equal(t, core.TestStatusPassed, login.Status, "%v", login.Error)
+	assert.Contains(t, login.Logs, "Captured variable token")
+
+	order := NewAPITest("create order", "POST", "/users/{{userId}}/orders", tester).
+		SetHeader("Authorization", "Bearer {{token}}").
+		SetHeader("X-Session", "{{session}}").
+		SetBody(map[string]interface{}{"user": "{{userId}}", "quantity": "{{quantity}}"}).
+		SetVariable("quantity", 3).
+		SetExpectedJSONPath("$.authorization", "Bearer tok-123").
+		SetExpectedJSONPath("$.session", "session-alice").
+		SetExpectedJSONPath("$.user", 7).
+		SetExpectedJSONPath("$.quantity", 3).
+		CaptureRegex("orderSession", `"session":"session-(\w+)"`)
+
+	result := order.Execute()
+	require.Equal(t, core.TestStatusPassed, result.Status, "%v", result.Error)
+
+	value, _ := tester.Variables().Get("orderSession")
+	assert.Equal(t, "alice", value)
+	// Test-scoped variables do not leak into the suite
+	_, exists := tester.Variables().Get("quantity")
+	assert.False(t, exists)
+
+	t.Run("errors", func(t *testing.T) {
+		result := tester.ExecuteTest(&core.APITest{Name: "undefined", Method: "GET", Endpoint: "/users/{{unknown}}"})
+		assert.Equal(t, core.TestStatusError, result.Status)
+		assert.Contains(t, result.Error.Error(), `undefined variable "unknown"`)
+
+		result = tester.ExecuteTest(&core.APITest{
+			Name:     "missing capture",
+			Method:   "POST",
+			Endpoint: "/login",
+			Body:     map[string]interface{}{"user": "bob"},
+			Captures: []core.APICapture{{Variable: "refresh", JSONPath: "$.refresh_token"}},
+		})
+		assert.Equal(t, core.TestStatusFailed, result.Status)
+		assert.Contains(t, result.Error.Error(), `capture of "refresh" failed`)
+	})
+}
+
 func TestAPITester_NotInitialized(t *testing.T) {
 	tester := NewAPITester()
 
@@ -312,6 +580,10 @@ func TestAPITester_NotInitialized(t *testing.T) {
 		{"Post", func() error { _, err := tester.Post("/test", nil, nil); return err }},
 		{"Put", func() error { _, err := tester.Put("/test", nil, nil); return err }},
 		{"Delete", func() error { _, err := tester.Delete("/test", nil); return err }},
+		{"Patch", func() error { _, err := tester.Patch("/test", nil, nil); return err }},
+		{"Head", func() error { _, err := tester.Head("/test", nil); return err }},
+		{"Options", func() error { _, err := tester.Options("/test", nil); return err }},
+		{"Do", func() error { _, err := tester.Do("GET", "/test", nil, nil); return err }},
 		{"SetAuth", func() error { return tester.SetAuth(&gwconfig.AuthConfig{Type: "bearer", Token: "test"}) }},
 	}
 
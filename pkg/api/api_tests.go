@@ -1,13 +1,12 @@
 package api
 
 import (
-	"encoding/json"
 	"fmt"
-	"reflect"
-	"strconv"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/gowright/framework/pkg/assertions"
 	"github.com/gowright/framework/pkg/core"
 )
 
@@ -19,7 +18,12 @@ type APITestImpl struct {
 	Headers  map[string]string    `json:"headers,omitempty"`
 	Body     interface{}          `json:"body,omitempty"`
 	Expected *core.APIExpectation `json:"expected"`
-	tester   *APITester
+
+	Variables map[string]interface{} `json:"variables,omitempty"` // test-scoped values for {{name}} placeholders
+	Captures  []core.APICapture      `json:"captures,omitempty"`
+
+	RequestBody *core.RequestBody `json:"request_body,omitempty"` // form, multipart, raw or XML body; replaces Body
+	tester      *APITester
 }
 
 // NewAPITest creates a new API test instance
@@ -50,29 +54,16 @@ func (at *APITestImpl) Execute() *core.TestCaseResult {
 	}
 
 	// Execute the HTTP request
+	sent := at.tester.requestCount()
+	body, err := requestPayload(at.Body, at.RequestBody)
 	var response *core.APIResponse
-	var err error
-
-	switch at.Method {
-	case "GET":
-		response, err = at.tester.Get(at.Endpoint, at.Headers)
-	case "POST":
-		response, err = at.tester.Post(at.Endpoint, at.Body, at.Headers)
-	case "PUT":
-		response, err = at.tester.Put(at.Endpoint, at.Body, at.Headers)
-	case "DELETE":
-		response, err = at.tester.Delete(at.Endpoint, at.Headers)
-	default:
-		result.Status = core.TestStatusError
-		result.Error = core.NewGowrightError(core.APIError, fmt.Sprintf("unsupported HTTP method: %s", at.Method), nil)
-		result.EndTime = time.Now()
-		result.Duration = result.EndTime.Sub(startTime)
-		return result
+	if err == nil {
+		response, err = at.tester.doInterpolated(at.Method, at.Endpoint, body, at.Headers, at.Variables)
 	}
-
 	if err != nil {
 		result.Status = core.TestStatusError
 		result.Error = err
+		at.tester.attachReproduction(result, sent)
 		result.EndTime = time.Now()
 		result.Duration = result.EndTime.Sub(startTime)
 		return result
@@ -83,9 +74,36 @@ func (at *APITestImpl) Execute() *core.TestCaseResult {
 		if err := at.validateResponse(response); err != nil {
 			result.Status = core.TestStatusFailed
 			result.Error = err
+		} else if at.Expected.JSONSchema != nil {
+			steps, err := SchemaAssertionSteps(response.Body, at.Expected.JSONSchema)
+			result.Steps = append(result.Steps, steps...)
+			if err != nil {
+				result.Status = core.TestStatusFailed
+				result.Error = err
+			}
+		}
+
+		// Latency and pattern expectations are reported as assertion steps
+		asserter := assertions.NewAsserter()
+		validateLatencyAndPatterns(asserter, response, at.Expected)
+		result.Steps = append(result.Steps, asserter.GetSteps()...)
+		if asserter.HasFailures() && result.Error == nil {
+			result.Status = core.TestStatusFailed
+			result.Error = core.NewGowrightError(core.AssertionError, "one or more assertions failed", nil)
+		}
+	}
+
+	recordTimings(result, response)
+
+	// Values are only captured from responses that met the expectations
+	if result.Error == nil {
+		if err := at.tester.captureVariables(result, response, at.Captures); err != nil {
+			result.Status = core.TestStatusFailed
+			result.Error = err
 		}
 	}
 
+	at.tester.attachReproduction(result, sent)
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(startTime)
 	result.Logs = append(result.Logs, fmt.Sprintf("API %s request to %s completed", at.Method, at.Endpoint))
@@ -112,88 +130,18 @@ func (at *APITestImpl) validateResponse(response *core.APIResponse) error {
 		}
 	}
 
-	// Validate JSON body if expected
-	if at.Expected.Body != nil {
-		var actualBody interface{}
-		if err := json.Unmarshal(response.Body, &actualBody); err != nil {
-			return core.NewGowrightError(core.AssertionError, "failed to parse response body as JSON", err)
-		}
-
-		if !reflect.DeepEqual(at.Expected.Body, actualBody) {
-			return core.NewGowrightError(core.AssertionError,
-				fmt.Sprintf("response body mismatch: expected %v, got %v", at.Expected.Body, actualBody), nil)
-		}
-	}
-
-	// Validate JSON path expressions
-	for path, expectedValue := range at.Expected.JSONPath {
-		actualValue, err := at.extractJSONPath(response.Body, path)
-		if err != nil {
-			return core.NewGowrightError(core.AssertionError,
-				fmt.Sprintf("failed to extract JSON path '%s': %v", path, err), err)
-		}
-
-		if !reflect.DeepEqual(expectedValue, actualValue) {
-			return core.NewGowrightError(core.AssertionError,
-				fmt.Sprintf("JSON path '%s' mismatch: expected %v, got %v", path, expectedValue, actualValue), nil)
-		}
-	}
-
-	return nil
-}
-
-// extractJSONPath extracts a value from JSON using a simple path expression
-func (at *APITestImpl) extractJSONPath(jsonData []byte, path string) (interface{}, error) {
-	var data interface{}
-	if err := json.Unmarshal(jsonData, &data); err != nil {
-		return nil, err
-	}
-
-	// Simple path parsing (e.g., "data.users[0].name")
-	parts := strings.Split(path, ".")
-	current := data
-
-	for _, part := range parts {
-		// Handle array indexing
-		if strings.Contains(part, "[") && strings.Contains(part, "]") {
-			arrayPart := part[:strings.Index(part, "[")]
-			indexPart := part[strings.Index(part, "[")+1 : strings.Index(part, "]")]
-
-			// Navigate to array
-			if arrayPart != "" {
-				if obj, ok := current.(map[string]interface{}); ok {
-					current = obj[arrayPart]
-				} else {
-					return nil, fmt.Errorf("expected object at path '%s'", arrayPart)
-				}
-			}
-
-			// Parse index
-			index, err := strconv.Atoi(indexPart)
-			if err != nil {
-				return nil, fmt.Errorf("invalid array index '%s'", indexPart)
-			}
-
-			// Navigate to array element
-			if arr, ok := current.([]interface{}); ok {
-				if index < 0 || index >= len(arr) {
-					return nil, fmt.Errorf("array index %d out of bounds", index)
-				}
-				current = arr[index]
-			} else {
-				return nil, fmt.Errorf("expected array at path")
-			}
-		} else {
-			// Navigate to object property
-			if obj, ok := current.(map[string]interface{}); ok {
-				current = obj[part]
-			} else {
-				return nil, fmt.Errorf("expected object at path '%s'", part)
+	if at.Expected.Body != nil || len(at.Expected.JSONPath) > 0 {
+		asserter := assertions.NewAsserter()
+		document := assertions.ParseJSONBody(response.Body)
+		asserter.JSONExpectations(document, at.Expected.Body, at.Expected.JSONPath, "response body mismatch", "JSON path assertion failed: ")
+		for _, step := range asserter.GetSteps() {
+			if step.Status != assertions.TestStatusPassed {
+				return core.NewGowrightError(core.AssertionError, step.Description, step.Error)
 			}
 		}
 	}
 
-	return current, nil
+	return nil
 }
 
 // SetHeader sets a header for the API request
@@ -222,6 +170,43 @@ func (at *APITestImpl) SetBody(body interface{}) *APITestImpl {
 	return at
 }
 
+// SetFormBody sends the fields as an application/x-www-form-urlencoded body
+func (at *APITestImpl) SetFormBody(fields map[string]interface{}) *APITestImpl {
+	at.RequestBody = &core.RequestBody{Form: fields}
+	return at
+}
+
+// AddMultipartField adds a text field to a multipart/form-data body
+func (at *APITestImpl) AddMultipartField(name, value string) *APITestImpl {
+	return at.AddMultipartPart(core.MultipartPart{Name: name, Value: value})
+}
+
+// AddMultipartFile adds a file read from disk to a multipart/form-data body
+func (at *APITestImpl) AddMultipartFile(name, path string) *APITestImpl {
+	return at.AddMultipartPart(core.MultipartPart{Name: name, File: path})
+}
+
+// AddMultipartPart adds a part to a multipart/form-data body
+func (at *APITestImpl) AddMultipartPart(part core.MultipartPart) *APITestImpl {
+	if at.RequestBody == nil || at.RequestBody.Multipart == nil {
+		at.RequestBody = &core.RequestBody{}
+	}
+	at.RequestBody.Multipart = append(at.RequestBody.Multipart, part)
+	return at
+}
+
+// SetRawBody sends data with the given content type
+func (at *APITestImpl) SetRawBody(data []byte, contentType string) *APITestImpl {
+	at.RequestBody = &core.RequestBody{Raw: string(data), ContentType: contentType}
+	return at
+}
+
+// SetXMLBody sends an XML document; strings and byte slices are sent as they are
+func (at *APITestImpl) SetXMLBody(document interface{}) *APITestImpl {
+	at.RequestBody = &core.RequestBody{XML: document}
+	return at
+}
+
 // SetExpectedStatusCode sets the expected status code
 func (at *APITestImpl) SetExpectedStatusCode(statusCode int) *APITestImpl {
 	if at.Expected == nil {
@@ -264,27 +249,94 @@ func (at *APITestImpl) SetExpectedJSONPath(path string, value interface{}) *APIT
 	return at
 }
 
-// ValidateJSONSchema validates the response against a JSON schema
+// SetExpectedJSONSchema sets the JSON schema the response body must satisfy
+func (at *APITestImpl) SetExpectedJSONSchema(source *core.JSONSchemaSource) *APITestImpl {
+	if at.Expected == nil {
+		at.Expected = &core.APIExpectation{}
+	}
+	at.Expected.JSONSchema = source
+	return at
+}
+
+// ValidateJSONSchema compiles an inline JSON schema and expects the response body to satisfy it
 func (at *APITestImpl) ValidateJSONSchema(schema string) error {
-	// This would implement JSON schema validation
-	// For now, it's a placeholder
+	if _, err := CompileJSONSchema(schema, ""); err != nil {
+		return err
+	}
+
+	at.SetExpectedJSONSchema(&core.JSONSchemaSource{Inline: schema})
 	return nil
 }
 
-// ValidateResponseTime validates that the response time is within acceptable limits
+// ValidateResponseTime expects the response to arrive within maxDuration
 func (at *APITestImpl) ValidateResponseTime(maxDuration time.Duration) error {
-	// This would be implemented by tracking response time during execution
-	// For now, it's a placeholder
+	if maxDuration <= 0 {
+		return core.NewGowrightError(core.ConfigurationError, "maximum response time must be positive", nil)
+	}
+
+	if at.Expected == nil {
+		at.Expected = &core.APIExpectation{}
+	}
+	at.Expected.MaxResponseTime = maxDuration
 	return nil
 }
 
-// ValidateRegex validates response body against a regex pattern
+// ValidateRegex expects the response body to match a regex pattern
 func (at *APITestImpl) ValidateRegex(pattern string) error {
-	// This would validate response body against regex
-	// For now, it's a placeholder
+	if _, err := regexp.Compile(pattern); err != nil {
+		return core.NewGowrightError(core.ConfigurationError, "invalid body pattern", err).
+			WithContext("pattern", pattern)
+	}
+
+	if at.Expected == nil {
+		at.Expected = &core.APIExpectation{}
+	}
+	at.Expected.BodyRegex = pattern
 	return nil
 }
 
+// SetExpectedHeaderRegex expects a response header to match a regex pattern
+func (at *APITestImpl) SetExpectedHeaderRegex(key, pattern string) *APITestImpl {
+	if at.Expected == nil {
+		at.Expected = &core.APIExpectation{}
+	}
+	if at.Expected.HeaderRegex == nil {
+		at.Expected.HeaderRegex = make(map[string]string)
+	}
+	at.Expected.HeaderRegex[key] = pattern
+	return at
+}
+
+// SetVariable sets a test-scoped value for {{name}} placeholders, shadowing a suite variable of the same name
+func (at *APITestImpl) SetVariable(name string, value interface{}) *APITestImpl {
+	if at.Variables == nil {
+		at.Variables = make(map[string]interface{})
+	}
+	at.Variables[name] = value
+	return at
+}
+
+// CaptureJSONPath stores the value at a JSON path of the response body in a suite variable
+func (at *APITestImpl) CaptureJSONPath(variable, path string) *APITestImpl {
+	return at.Capture(core.APICapture{Variable: variable, JSONPath: path})
+}
+
+// CaptureHeader stores a response header in a suite variable
+func (at *APITestImpl) CaptureHeader(variable, header string) *APITestImpl {
+	return at.Capture(core.APICapture{Variable: variable, Header: header})
+}
+
+// CaptureRegex stores the first group of a pattern matched against the response body in a suite variable
+func (at *APITestImpl) CaptureRegex(variable, pattern string) *APITestImpl {
+	return at.Capture(core.APICapture{Variable: variable, Regex: pattern})
+}
+
+// Capture adds a capture to run once the response meets the expectations
+func (at *APITestImpl) Capture(capture core.APICapture) *APITestImpl {
+	at.Captures = append(at.Captures, capture)
+	return at
+}
+
 // Clone creates a copy of the API test
 func (at *APITestImpl) Clone() *APITestImpl {
 	clone := &APITestImpl{
@@ -301,11 +353,29 @@ func (at *APITestImpl) Clone() *APITestImpl {
 		clone.Headers[k] = v
 	}
 
+	// Copy variables and captures
+	if at.Variables != nil {
+		clone.Variables = make(map[string]interface{})
+		for k, v := range at.Variables {
+			clone.Variables[k] = v
+		}
+	}
+	clone.Captures = append([]core.APICapture(nil), at.Captures...)
+	if at.RequestBody != nil {
+		requestBody := *at.RequestBody
+		requestBody.Multipart = append([]core.MultipartPart(nil), at.RequestBody.Multipart...)
+		clone.RequestBody = &requestBody
+	}
+
 	// Copy expectations
 	if at.Expected != nil {
 		clone.Expected = &core.APIExpectation{
 			StatusCode: at.Expected.StatusCode,
 			Body:       at.Expected.Body,
+			JSONSchema: at.Expected.JSONSchema,
+
+			MaxResponseTime: at.Expected.MaxResponseTime,
+			BodyRegex:       at.Expected.BodyRegex,
 		}
 
 		if at.Expected.Headers != nil {
@@ -321,6 +391,13 @@ func (at *APITestImpl) Clone() *APITestImpl {
 				clone.Expected.JSONPath[k] = v
 			}
 		}
+
+		if at.Expected.HeaderRegex != nil {
+			clone.Expected.HeaderRegex = make(map[string]string)
+			for k, v := range at.Expected.HeaderRegex {
+				clone.Expected.HeaderRegex[k] = v
+			}
+		}
 	}
 
 	return clone
@@ -0,0 +1,440 @@
+package api
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	_ "crypto/sha512" // registers SHA-384 and SHA-512 for HS384 and HS512
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gowright/framework/pkg/config"
+	"github.com/gowright/framework/pkg/core"
+)
+
+const (
+	// defaultRefreshSkew is how long before expiry cached tokens are renewed
+	defaultRefreshSkew = 30 * time.Second
+
+	// defaultJWTTTL is the lifetime of locally signed JWTs
+	defaultJWTTTL = 5 * time.Minute
+
+	// jwtBearerGrantType is the RFC 7523 grant for exchanging a signed JWT for an access token
+	jwtBearerGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+)
+
+// oauthToken is an access token with its expiry
+type oauthToken struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+	Expiry       time.Time // zero when the token does not expire
+}
+
+// tokenSource caches a bearer token and renews it shortly before it expires
+type tokenSource struct {
+	mutex     sync.Mutex
+	token     *oauthToken
+	refreshAt time.Time
+	skew      time.Duration
+
+	// fetch obtains a new token, using refreshToken when it is not empty
+	fetch func(refreshToken string) (*oauthToken, error)
+}
+
+// newTokenSource creates a token source; skew defaults to 30s
+func newTokenSource(skew time.Duration, fetch func(refreshToken string) (*oauthToken, error)) *tokenSource {
+	if skew <= 0 {
+		skew = defaultRefreshSkew
+	}
+	return &tokenSource{skew: skew, fetch: fetch}
+}
+
+// Token returns the cached access token, fetching a new one when none is cached or it is about to expire
+func (ts *tokenSource) Token() (string, error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if ts.token != nil && (ts.refreshAt.IsZero() || time.Now().Before(ts.refreshAt)) {
+		return ts.token.AccessToken, nil
+	}
+
+	var token *oauthToken
+	var err error
+	if ts.token != nil && ts.token.RefreshToken != "" {
+		token, err = ts.fetch(ts.token.RefreshToken)
+		if err == nil && token.RefreshToken == "" {
+			// Servers may keep the refresh token unchanged without returning it again
+			token.RefreshToken = ts.token.RefreshToken
+		}
+	}
+	if token == nil {
+		// No refresh token, or the refresh failed: repeat the original grant
+		if token, err = ts.fetch(""); err != nil {
+			return "", err
+		}
+	}
+
+	ts.token = token
+	ts.refreshAt = time.Time{}
+	if !token.Expiry.IsZero() {
+		// Never renew earlier than half way through the token lifetime
+		skew := min(ts.skew, time.Until(token.Expiry)/2)
+		ts.refreshAt = token.Expiry.Add(-skew)
+	}
+	return token.AccessToken, nil
+}
+
+// Invalidate drops the cached token so the next request fetches a new one
+func (ts *tokenSource) Invalidate() {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	ts.refreshAt = time.Now()
+}
+
+// secrets returns the cached access and refresh tokens
+func (ts *tokenSource) secrets() []string {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	if ts.token == nil {
+		return nil
+	}
+	return []string{ts.token.AccessToken, ts.token.RefreshToken}
+}
+
+// newOAuth2TokenSource creates a token source for the oauth2 auth type
+func newOAuth2TokenSource(auth *config.AuthConfig, client func() *http.Client) (*tokenSource, error) {
+	cfg := auth.OAuth2
+	if cfg == nil || cfg.TokenURL == "" {
+		return nil, core.NewGowrightError(core.ConfigurationError, "token URL is required for oauth2 auth", nil)
+	}
+	if cfg.ClientID == "" {
+		return nil, core.NewGowrightError(core.ConfigurationError, "client ID is required for oauth2 auth", nil)
+	}
+
+	grantType := cfg.GrantType
+	switch grantType {
+	case "", "client_credentials":
+		grantType = "client_credentials"
+	case "password":
+		if auth.Username == "" || auth.Password == "" {
+			return nil, core.NewGowrightError(core.ConfigurationError, "username and password are required for the oauth2 password grant", nil)
+		}
+	default:
+		return nil, core.NewGowrightError(core.ConfigurationError, fmt.Sprintf("unsupported oauth2 grant type: %s", cfg.GrantType), nil)
+	}
+
+	switch cfg.AuthStyle {
+	case "", "header", "params":
+	default:
+		return nil, core.NewGowrightError(core.ConfigurationError, fmt.Sprintf("unsupported oauth2 auth style: %s", cfg.AuthStyle), nil)
+	}
+
+	return newTokenSource(cfg.RefreshSkew, func(refreshToken string) (*oauthToken, error) {
+		form := url.Values{}
+		if refreshToken != "" {
+			form.Set("grant_type", "refresh_token")
+			form.Set("refresh_token", refreshToken)
+		} else {
+			form.Set("grant_type", grantType)
+			if grantType == "password" {
+				form.Set("username", auth.Username)
+				form.Set("password", auth.Password)
+			}
+			for key, value := range cfg.Params {
+				form.Set(key, value)
+			}
+		}
+		if len(cfg.Scopes) > 0 {
+			form.Set("scope", strings.Join(cfg.Scopes, " "))
+		}
+
+		clientID, clientSecret := "", ""
+		if cfg.AuthStyle == "params" {
+			form.Set("client_id", cfg.ClientID)
+			if cfg.ClientSecret != "" {
+				form.Set("client_secret", cfg.ClientSecret)
+			}
+		} else {
+			clientID, clientSecret = cfg.ClientID, cfg.ClientSecret
+		}
+
+		return requestToken(client(), cfg.TokenURL, form, clientID, clientSecret)
+	}), nil
+}
+
+// newJWTTokenSource creates a token source for the jwt auth type
+func newJWTTokenSource(auth *config.AuthConfig, client func() *http.Client) (*tokenSource, error) {
+	cfg := auth.JWT
+	if cfg == nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "jwt settings are required for jwt auth", nil)
+	}
+
+	signer, err := newJWTSigner(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = defaultJWTTTL
+	}
+
+	return newTokenSource(cfg.RefreshSkew, func(string) (*oauthToken, error) {
+		now := time.Now()
+		assertion, err := signer.sign(cfg, now, ttl)
+		if err != nil {
+			return nil, core.NewGowrightError(core.APIError, "failed to sign JWT", err)
+		}
+		if cfg.TokenURL == "" {
+			return &oauthToken{AccessToken: assertion, TokenType: "Bearer", Expiry: now.Add(ttl)}, nil
+		}
+
+		form := url.Values{}
+		form.Set("grant_type", jwtBearerGrantType)
+		form.Set("assertion", assertion)
+		if len(cfg.Scopes) > 0 {
+			form.Set("scope", strings.Join(cfg.Scopes, " "))
+		}
+		return requestToken(client(), cfg.TokenURL, form, "", "")
+	}), nil
+}
+
+// requestToken posts a token request and parses the JSON or form-encoded token response
+func requestToken(client *http.Client, tokenURL string, form url.Values, clientID, clientSecret string) (*oauthToken, error) {
+	tokenError := func(message string, cause error) error {
+		return core.NewGowrightError(core.APIError, "failed to obtain OAuth2 token: "+message, cause).
+			WithContext("token_url", tokenURL).
+			WithContext("grant_type", form.Get("grant_type"))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, tokenError("invalid token request", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientID != "" {
+		// RFC 6749 section 2.3.1 form-encodes the credentials before basic auth
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, tokenError("token request failed", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, tokenError("failed to read token response", err)
+	}
+
+	values := map[string]interface{}{}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" || mediaType == "text/plain" {
+		parsed, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, tokenError("invalid token response", err)
+		}
+		for key := range parsed {
+			values[key] = parsed.Get(key)
+		}
+	} else if err := json.Unmarshal(body, &values); err != nil && resp.StatusCode < 300 {
+		return nil, tokenError("invalid token response", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message := fmt.Sprintf("token endpoint returned status %d", resp.StatusCode)
+		if code, ok := values["error"].(string); ok && code != "" {
+			message += ": " + code
+			if description, ok := values["error_description"].(string); ok && description != "" {
+				message += " (" + description + ")"
+			}
+		}
+		return nil, tokenError(message, nil)
+	}
+
+	token := &oauthToken{}
+	token.AccessToken, _ = values["access_token"].(string)
+	token.TokenType, _ = values["token_type"].(string)
+	token.RefreshToken, _ = values["refresh_token"].(string)
+	if token.AccessToken == "" {
+		return nil, tokenError("token response has no access_token", nil)
+	}
+
+	var expiresIn float64
+	switch value := values["expires_in"].(type) {
+	case float64:
+		expiresIn = value
+	case string:
+		expiresIn, _ = strconv.ParseFloat(value, 64)
+	}
+	if expiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(expiresIn * float64(time.Second)))
+	}
+
+	return token, nil
+}
+
+// jwtSigner signs JWTs with one algorithm and key
+type jwtSigner struct {
+	algorithm string
+	hash      crypto.Hash
+	secret    []byte // HMAC key, nil for asymmetric algorithms
+	key       crypto.Signer
+}
+
+// newJWTSigner validates the algorithm and loads the signing key
+func newJWTSigner(cfg *config.JWTConfig) (*jwtSigner, error) {
+	algorithm := cfg.Algorithm
+	if algorithm == "" {
+		algorithm = "HS256"
+	}
+	signer := &jwtSigner{algorithm: algorithm}
+
+	switch algorithm {
+	case "HS256", "HS384", "HS512":
+		if cfg.Secret == "" {
+			return nil, core.NewGowrightError(core.ConfigurationError, "secret is required for "+algorithm+" JWTs", nil)
+		}
+		signer.hash = map[string]crypto.Hash{"HS256": crypto.SHA256, "HS384": crypto.SHA384, "HS512": crypto.SHA512}[algorithm]
+		signer.secret = []byte(cfg.Secret)
+		return signer, nil
+	case "RS256", "ES256":
+		signer.hash = crypto.SHA256
+	default:
+		return nil, core.NewGowrightError(core.ConfigurationError, fmt.Sprintf("unsupported JWT algorithm: %s", algorithm), nil)
+	}
+
+	if cfg.PrivateKeyFile == "" {
+		return nil, core.NewGowrightError(core.ConfigurationError, "private key file is required for "+algorithm+" JWTs", nil)
+	}
+	data, err := os.ReadFile(cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "failed to read JWT private key", err).
+			WithContext("file", cfg.PrivateKeyFile)
+	}
+	key, err := parsePrivateKey(data)
+	if err != nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "failed to parse JWT private key", err).
+			WithContext("file", cfg.PrivateKeyFile)
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if algorithm != "RS256" {
+			return nil, core.NewGowrightError(core.ConfigurationError, "ES256 requires an ECDSA P-256 key, got an RSA key", nil)
+		}
+	case *ecdsa.PrivateKey:
+		if algorithm != "ES256" || k.Curve.Params().BitSize != 256 {
+			return nil, core.NewGowrightError(core.ConfigurationError, algorithm+" does not match the ECDSA private key", nil)
+		}
+	default:
+		return nil, core.NewGowrightError(core.ConfigurationError, fmt.Sprintf("unsupported JWT private key type %T", key), nil)
+	}
+	signer.key = key
+	return signer, nil
+}
+
+// parsePrivateKey decodes a PEM encoded PKCS#8, PKCS#1 or SEC 1 private key
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+// sign creates a compact JWT with the configured claims, issued at now
+func (s *jwtSigner) sign(cfg *config.JWTConfig, now time.Time, ttl time.Duration) (string, error) {
+	header := map[string]interface{}{"alg": s.algorithm, "typ": "JWT"}
+	if cfg.KeyID != "" {
+		header["kid"] = cfg.KeyID
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	claims := map[string]interface{}{
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(ttl).Unix(),
+		"jti": hex.EncodeToString(nonce),
+	}
+	for name, value := range map[string]string{"iss": cfg.Issuer, "sub": cfg.Subject, "aud": cfg.Audience} {
+		if value != "" {
+			claims[name] = value
+		}
+	}
+	for name, value := range cfg.Claims {
+		claims[name] = value
+	}
+
+	encodedHeader, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	encodedClaims, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(encodedHeader) + "." + base64.RawURLEncoding.EncodeToString(encodedClaims)
+
+	var signature []byte
+	if s.secret != nil {
+		mac := hmac.New(s.hash.New, s.secret)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	} else {
+		digest := sha256.Sum256([]byte(signingInput))
+		switch key := s.key.(type) {
+		case *rsa.PrivateKey:
+			if signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:]); err != nil {
+				return "", err
+			}
+		case *ecdsa.PrivateKey:
+			// JWS uses the fixed-width r || s form rather than ASN.1
+			r, sv, err := ecdsa.Sign(rand.Reader, key, digest[:])
+			if err != nil {
+				return "", err
+			}
+			signature = make([]byte, 64)
+			r.FillBytes(signature[:32])
+			sv.FillBytes(signature[32:])
+		}
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
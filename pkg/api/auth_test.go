@@ -0,0 +1,319 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gwconfig "github.com/gowright/framework/pkg/config"
+	"github.com/gowright/framework/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenServer is an OAuth2 token endpoint plus a protected resource that echoes the bearer token
+type tokenServer struct {
+	*httptest.Server
+
+	mutex     sync.Mutex
+	requests  []map[string]string // form values of each token request, plus the basic auth user
+	expiresIn int
+	issued    int
+	revoked   string // access token the protected resource rejects
+}
+
+// newTokenServer starts a token server issuing tokens that expire after expiresIn seconds
+func newTokenServer(t *testing.T, expiresIn int) *tokenServer {
+	ts := &tokenServer{expiresIn: expiresIn}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+
+		ts.mutex.Lock()
+		defer ts.mutex.Unlock()
+
+		request := map[string]string{}
+		for key := range r.PostForm {
+			request[key] = r.PostForm.Get(key)
+		}
+		if user, password, ok := r.BasicAuth(); ok {
+			request["basic"] = user + ":" + password
+		}
+		ts.requests = append(ts.requests, request)
+
+		w.Header().Set("Content-Type", "application/json")
+		if request["client_id"] == "bad" || request["basic"] == "bad:secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error": "invalid_client", "error_description": "unknown client"}`))
+			return
+		}
+
+		ts.issued++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "token-" + string(rune('0'+ts.issued)),
+			"token_type":    "Bearer",
+			"expires_in":    ts.expiresIn,
+			"refresh_token": "refresh-" + string(rune('0'+ts.issued)),
+		})
+	})
+	mux.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		ts.mutex.Lock()
+		revoked := ts.revoked != "" && auth == "Bearer "+ts.revoked
+		ts.mutex.Unlock()
+		if revoked {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(auth))
+	})
+	ts.Server = httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// tokenRequests returns a copy of the recorded token requests
+func (ts *tokenServer) tokenRequests() []map[string]string {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	return append([]map[string]string{}, ts.requests...)
+}
+
+// newAuthTester creates an initialized tester for the token server using the given auth
+func newAuthTester(t *testing.T, server *tokenServer, auth *gwconfig.AuthConfig) *APITester {
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, Timeout: 5 * time.Second, Auth: auth}))
+	return tester
+}
+
+// authorization performs a request to the protected resource and returns the Authorization header it received
+func authorization(t *testing.T, tester *APITester) string {
+	response, err := tester.Get("/me", nil)
+	require.NoError(t, err)
+	return string(response.Body)
+}
+
+func TestAPITester_OAuth2(t *testing.T) {
+	t.Run("client credentials are cached", func(t *testing.T) {
+		server := newTokenServer(t, 3600)
+		tester := newAuthTester(t, server, &gwconfig.AuthConfig{
+			Type: "oauth2",
+			OAuth2: &gwconfig.OAuth2Config{
+				TokenURL:     server.URL + "/token",
+				ClientID:     "gowright",
+				ClientSecret: "s3cret",
+				Scopes:       []string{"read", "write"},
+				Params:       map[string]string{"audience": "api"},
+			},
+		})
+
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, "Bearer token-1", authorization(t, tester))
+		}
+		assert.Equal(t, []map[string]string{{
+			"grant_type": "client_credentials",
+			"scope":      "read write",
+			"audience":   "api",
+			"basic":      "gowright:s3cret",
+		}}, server.tokenRequests())
+
+		// An explicit Authorization header wins over the token source
+		response, err := tester.Get("/me", map[string]string{"Authorization": "Basic abc"})
+		require.NoError(t, err)
+		assert.Equal(t, "Basic abc", string(response.Body))
+	})
+
+	t.Run("password grant with credentials in params", func(t *testing.T) {
+		server := newTokenServer(t, 3600)
+		tester := newAuthTester(t, server, &gwconfig.AuthConfig{
+			Type:     "oauth2",
+			Username: "alice",
+			Password: "wonderland",
+			OAuth2: &gwconfig.OAuth2Config{
+				TokenURL:  server.URL + "/token",
+				GrantType: "password",
+				ClientID:  "gowright",
+				AuthStyle: "params",
+			},
+		})
+
+		assert.Equal(t, "Bearer token-1", authorization(t, tester))
+		assert.Equal(t, []map[string]string{{
+			"grant_type": "password",
+			"username":   "alice",
+			"password":   "wonderland",
+			"client_id":  "gowright",
+		}}, server.tokenRequests())
+	})
+
+	t.Run("tokens are refreshed before they expire", func(t *testing.T) {
+		server := newTokenServer(t, 1)
+		tester := newAuthTester(t, server, &gwconfig.AuthConfig{
+			Type:   "oauth2",
+			OAuth2: &gwconfig.OAuth2Config{TokenURL: server.URL + "/token", ClientID: "gowright"},
+		})
+
+		assert.Equal(t, "Bearer token-1", authorization(t, tester))
+		// The 30s refresh skew is capped at half of the one second lifetime
+		time.Sleep(600 * time.Millisecond)
+		assert.Equal(t, "Bearer token-2", authorization(t, tester))
+
+		requests := server.tokenRequests()
+		require.Len(t, requests, 2)
+		assert.Equal(t, "refresh_token", requests[1]["grant_type"])
+		assert.Equal(t, "refresh-1", requests[1]["refresh_token"])
+	})
+
+	t.Run("unauthorized responses invalidate the token", func(t *testing.T) {
+		server := newTokenServer(t, 3600)
+		tester := newAuthTester(t, server, &gwconfig.AuthConfig{
+			Type:   "oauth2",
+			OAuth2: &gwconfig.OAuth2Config{TokenURL: server.URL + "/token", ClientID: "gowright"},
+		})
+
+		assert.Equal(t, "Bearer token-1", authorization(t, tester))
+		server.mutex.Lock()
+		server.revoked = "token-1"
+		server.mutex.Unlock()
+
+		response, err := tester.Get("/me", nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+		assert.Equal(t, "Bearer token-2", authorization(t, tester))
+	})
+
+	t.Run("token endpoint errors", func(t *testing.T) {
+		server := newTokenServer(t, 3600)
+		tester := newAuthTester(t, server, &gwconfig.AuthConfig{
+			Type:   "oauth2",
+			OAuth2: &gwconfig.OAuth2Config{TokenURL: server.URL + "/token", ClientID: "bad", ClientSecret: "secret"},
+		})
+
+		_, err := tester.Get("/me", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "token endpoint returned status 401: invalid_client (unknown client)")
+		assert.NotContains(t, err.Error(), "secret")
+	})
+
+	t.Run("configuration errors", func(t *testing.T) {
+		server := newTokenServer(t, 3600)
+		tester := newAuthTester(t, server, nil)
+
+		for name, auth := range map[string]*gwconfig.AuthConfig{
+			"missing settings":   {Type: "oauth2"},
+			"missing client":     {Type: "oauth2", OAuth2: &gwconfig.OAuth2Config{TokenURL: server.URL}},
+			"missing password":   {Type: "oauth2", OAuth2: &gwconfig.OAuth2Config{TokenURL: server.URL, ClientID: "c", GrantType: "password"}},
+			"unknown grant":      {Type: "oauth2", OAuth2: &gwconfig.OAuth2Config{TokenURL: server.URL, ClientID: "c", GrantType: "implicit"}},
+			"missing jwt secret": {Type: "jwt", JWT: &gwconfig.JWTConfig{}},
+			"unknown algorithm":  {Type: "jwt", JWT: &gwconfig.JWTConfig{Algorithm: "none"}},
+			"missing key file":   {Type: "jwt", JWT: &gwconfig.JWTConfig{Algorithm: "ES256"}},
+		} {
+			err := tester.SetAuth(auth)
+			require.Error(t, err, name)
+			assert.Equal(t, core.ConfigurationError, core.GetErrorType(err), name)
+		}
+	})
+}
+
+func TestAPITester_JWTAuth(t *testing.T) {
+	t.Run("HS256 signed bearer token", func(t *testing.T) {
+		server := newTokenServer(t, 3600)
+		tester := newAuthTester(t, server, &gwconfig.AuthConfig{
+			Type: "jwt",
+			JWT: &gwconfig.JWTConfig{
+				Secret:   "signing-key",
+				Issuer:   "gowright",
+				Audience: "api",
+				Claims:   map[string]interface{}{"role": "admin"},
+				TTL:      time.Minute,
+			},
+		})
+
+		header := authorization(t, tester)
+		require.True(t, strings.HasPrefix(header, "Bearer "))
+		parts := strings.Split(strings.TrimPrefix(header, "Bearer "), ".")
+		require.Len(t, parts, 3)
+
+		mac := hmac.New(sha256.New, []byte("signing-key"))
+		mac.Write([]byte(parts[0] + "." + parts[1]))
+		assert.Equal(t, base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), parts[2])
+
+		claims := decodeSegment(t, parts[1])
+		assert.Equal(t, "gowright", claims["iss"])
+		assert.Equal(t, "api", claims["aud"])
+		assert.Equal(t, "admin", claims["role"])
+		assert.Equal(t, claims["iat"].(float64)+60, claims["exp"])
+		assert.Equal(t, map[string]interface{}{"alg": "HS256", "typ": "JWT"}, decodeSegment(t, parts[0]))
+
+		// The signed token is cached like an access token
+		assert.Equal(t, header, authorization(t, tester))
+		assert.Empty(t, server.tokenRequests())
+	})
+
+	t.Run("ES256 assertion exchanged for an access token", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		keyFile := filepath.Join(t.TempDir(), "key.pem")
+		require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+
+		server := newTokenServer(t, 3600)
+		tester := newAuthTester(t, server, &gwconfig.AuthConfig{
+			Type: "jwt",
+			JWT: &gwconfig.JWTConfig{
+				Algorithm:      "ES256",
+				PrivateKeyFile: keyFile,
+				KeyID:          "key-1",
+				Subject:        "service-account",
+				TokenURL:       server.URL + "/token",
+				Scopes:         []string{"read"},
+			},
+		})
+
+		assert.Equal(t, "Bearer token-1", authorization(t, tester))
+
+		requests := server.tokenRequests()
+		require.Len(t, requests, 1)
+		assert.Equal(t, jwtBearerGrantType, requests[0]["grant_type"])
+		assert.Equal(t, "read", requests[0]["scope"])
+
+		parts := strings.Split(requests[0]["assertion"], ".")
+		require.Len(t, parts, 3)
+		assert.Equal(t, "key-1", decodeSegment(t, parts[0])["kid"])
+		assert.Equal(t, "service-account", decodeSegment(t, parts[1])["sub"])
+
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+		require.Len(t, signature, 64)
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		assert.True(t, ecdsa.Verify(&key.PublicKey, digest[:], r, s))
+	})
+}
+
+// decodeSegment decodes a base64url JSON segment of a JWT
+func decodeSegment(t *testing.T, segment string) map[string]interface{} {
+	t.Helper()
+
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	return decoded
+}
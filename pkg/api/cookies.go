@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+
+	"github.com/gowright/framework/pkg/core"
+	"golang.org/x/net/publicsuffix"
+)
+
+// newCookieJar creates a jar that scopes cookies by the public suffix list, as browsers do
+func newCookieJar() http.CookieJar {
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	return jar
+}
+
+// Cookies returns the cookies the session sends to an endpoint, given relative to the base URL or absolute
+func (at *APITester) Cookies(endpoint string) ([]*http.Cookie, error) {
+	target, err := at.resolveURL(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return at.cookies.Cookies(target), nil
+}
+
+// SetCookies adds cookies to the session as if an endpoint had set them
+func (at *APITester) SetCookies(endpoint string, cookies ...*http.Cookie) error {
+	target, err := at.resolveURL(endpoint)
+	if err != nil {
+		return err
+	}
+	at.cookies.SetCookies(target, cookies)
+	return nil
+}
+
+// ClearCookies discards the cookies of the session
+func (at *APITester) ClearCookies() {
+	at.cookies = newCookieJar()
+	if at.client != nil {
+		at.client.SetCookieJar(at.cookies)
+	}
+}
+
+// cookieHeader returns the Cookie header the session sends to a URL, mapping ws and wss to http and https
+func (at *APITester) cookieHeader(target *url.URL) string {
+	cookieURL := *target
+	switch cookieURL.Scheme {
+	case "ws":
+		cookieURL.Scheme = "http"
+	case "wss":
+		cookieURL.Scheme = "https"
+	}
+
+	var pairs []string
+	for _, cookie := range at.cookies.Cookies(&cookieURL) {
+		pairs = append(pairs, cookie.Name+"="+cookie.Value)
+	}
+	return strings.Join(pairs, "; ")
+}
+
+// resolveURL resolves an endpoint against the base URL, the way requests do
+func (at *APITester) resolveURL(endpoint string) (*url.URL, error) {
+	if !strings.Contains(endpoint, "://") {
+		baseURL := ""
+		if at.config != nil {
+			baseURL = at.config.BaseURL
+		}
+		endpoint = strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(endpoint, "/")
+	}
+	target, err := url.Parse(endpoint)
+	if err != nil || target.Host == "" {
+		return nil, core.NewGowrightError(core.ConfigurationError, "invalid endpoint URL", err).
+			WithContext("endpoint", endpoint)
+	}
+	return target, nil
+}
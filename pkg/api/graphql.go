@@ -0,0 +1,350 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gowright/framework/pkg/assertions"
+	"github.com/gowright/framework/pkg/core"
+)
+
+// persistedQueryNotFound is the error a server returns for a persisted query hash it does not know
+const persistedQueryNotFound = "PersistedQueryNotFound"
+
+// graphQLRequest is the JSON body of a GraphQL request
+type graphQLRequest struct {
+	Query         string                 `json:"query,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     interface{}            `json:"variables,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLResponse is a decoded GraphQL response body
+type GraphQLResponse struct {
+	Data       interface{}            `json:"data"`
+	Errors     []GraphQLError         `json:"errors,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLError is an entry of the errors array of a GraphQL response
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// ParseGraphQLResponse decodes a GraphQL response body
+func ParseGraphQLResponse(body []byte) (*GraphQLResponse, error) {
+	var response GraphQLResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, core.NewGowrightError(core.AssertionError, "response is not a GraphQL JSON document", err)
+	}
+	return &response, nil
+}
+
+// messages returns the messages of the response errors
+func (r *GraphQLResponse) messages() []string {
+	messages := make([]string, 0, len(r.Errors))
+	for _, graphQLError := range r.Errors {
+		messages = append(messages, graphQLError.Message)
+	}
+	return messages
+}
+
+// persistedQueryMissing reports whether the server asked for the full text of a persisted query
+func (r *GraphQLResponse) persistedQueryMissing() bool {
+	for _, graphQLError := range r.Errors {
+		if graphQLError.Message == persistedQueryNotFound || graphQLError.Extensions["code"] == "PERSISTED_QUERY_NOT_FOUND" {
+			return true
+		}
+	}
+	return false
+}
+
+// sendGraphQL posts a GraphQL test's operation after interpolating its endpoint, headers and
+// variables. A persisted query is sent as its SHA-256 hash first, and again with the full
+// query if the server does not know the hash.
+func (at *APITester) sendGraphQL(test *core.GraphQLTest, result *core.TestCaseResult) (*core.APIResponse, error) {
+	scope := at.variables.NewScope()
+	endpoint, err := scope.Interpolate(test.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := scope.InterpolateHeaders(test.Headers)
+	if err != nil {
+		return nil, err
+	}
+	variables, err := scope.InterpolateValue(test.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	request := graphQLRequest{Query: test.Query, OperationName: test.OperationName, Variables: variables}
+	if !test.PersistedQuery {
+		return at.Do("POST", endpoint, request, headers)
+	}
+
+	hash := sha256.Sum256([]byte(test.Query))
+	request.Query = ""
+	request.Extensions = map[string]interface{}{
+		"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hex.EncodeToString(hash[:])},
+	}
+	response, err := at.Do("POST", endpoint, request, headers)
+	if err != nil {
+		return nil, err
+	}
+	if decoded, err := ParseGraphQLResponse(response.Body); err != nil || !decoded.persistedQueryMissing() {
+		return response, nil
+	}
+
+	result.Logs = append(result.Logs, "Persisted query not found on the server, sending the full query")
+	request.Query = test.Query
+	return at.Do("POST", endpoint, request, headers)
+}
+
+// ExecuteGraphQLTest executes a GraphQL test and returns the result. When the test names a schema,
+// the query is validated against it first and not sent if it is invalid.
+func (at *APITester) ExecuteGraphQLTest(test *core.GraphQLTest) *core.TestCaseResult {
+	startTime := time.Now()
+	result := &core.TestCaseResult{
+		Name:      test.Name,
+		StartTime: startTime,
+		Status:    core.TestStatusPassed,
+		Logs:      []string{},
+	}
+	sent := at.requestCount()
+	finish := func() *core.TestCaseResult {
+		at.attachReproduction(result, sent)
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(startTime)
+		return result
+	}
+
+	asserter := assertions.NewAsserter()
+	if test.Schema != "" {
+		schema, err := LoadGraphQLSchema(test.Schema)
+		if err != nil {
+			result.Status = core.TestStatusError
+			result.Error = err
+			return finish()
+		}
+		problems := schema.ValidateQuery(test.Query, test.OperationName)
+		for _, problem := range problems {
+			asserter.AddStep(graphQLStep("GraphQL schema validation", nil, nil, problem))
+		}
+		if len(problems) > 0 {
+			result.Steps = asserter.GetSteps()
+			result.Status = core.TestStatusFailed
+			result.Error = core.NewGowrightError(core.ValidationError,
+				fmt.Sprintf("GraphQL query has %d schema violation(s)", len(problems)), errors.Join(problems...)).
+				WithContext("schema", test.Schema)
+			return finish()
+		}
+		asserter.AddStep(graphQLStep("GraphQL schema validation", nil, nil, nil))
+	}
+
+	response, err := at.sendGraphQL(test, result)
+	if err != nil {
+		result.Status = core.TestStatusError
+		result.Error = err
+		return finish()
+	}
+
+	expected := test.Expected
+	if expected == nil {
+		expected = &core.GraphQLExpectation{}
+	}
+	validateGraphQLResponse(asserter, response, expected)
+	recordTimings(result, response)
+	result.Steps = asserter.GetSteps()
+
+	if asserter.HasFailures() {
+		result.Status = core.TestStatusFailed
+		result.Error = core.NewGowrightError(core.AssertionError, "one or more assertions failed", nil)
+	} else if err := at.captureVariables(result, response, test.Captures); err != nil {
+		result.Status = core.TestStatusFailed
+		result.Error = err
+	}
+
+	operation := test.OperationName
+	if operation == "" {
+		operation = "operation"
+	}
+	result.Logs = append(result.Logs, fmt.Sprintf("GraphQL %s sent to %s", operation, test.Endpoint))
+	return finish()
+}
+
+// validateGraphQLResponse checks a GraphQL response against an expectation
+func validateGraphQLResponse(asserter *assertions.Asserter, response *core.APIResponse, expected *core.GraphQLExpectation) {
+	if expected.StatusCode != 0 {
+		asserter.Equal(expected.StatusCode, response.StatusCode, "Status code validation")
+	}
+	validateLatencyAndPatterns(asserter, response, &core.APIExpectation{MaxResponseTime: expected.MaxResponseTime})
+
+	document, err := ParseGraphQLResponse(response.Body)
+	if err != nil {
+		asserter.AddStep(graphQLStep("GraphQL response", nil, string(response.Body), err))
+		return
+	}
+	messages := document.messages()
+
+	switch {
+	case len(expected.Errors) > 0:
+		for _, pattern := range expected.Errors {
+			matcher := assertions.MatchRegex(pattern)
+			var err error = fmt.Errorf("no GraphQL error message %s, got %q", matcher, messages)
+			for _, message := range messages {
+				if matcher.Match(message) == nil {
+					err = nil
+					break
+				}
+			}
+			asserter.AddStep(graphQLStep("GraphQL error expected: "+pattern, pattern, messages, err))
+		}
+	case !expected.AllowErrors:
+		var err error
+		if len(messages) > 0 {
+			err = fmt.Errorf("response has %d GraphQL error(s): %s", len(messages), strings.Join(messages, "; "))
+		}
+		asserter.AddStep(graphQLStep("No GraphQL errors", nil, messages, err))
+	}
+
+	if expected.Data != nil {
+		asserter.Match(expected.Data, document.Data, "GraphQL data validation")
+	}
+	for _, path := range sortedKeys(expected.JSONPath) {
+		asserter.JSONPath(document.Data, path, expected.JSONPath[path], "GraphQL data validation: "+path)
+	}
+}
+
+// graphQLStep builds an assertion step for a GraphQL check; a non-nil err marks it failed
+func graphQLStep(description string, expected, actual interface{}, err error) core.AssertionStep {
+	now := time.Now()
+	step := core.AssertionStep{
+		Name:        "GraphQL",
+		Description: description,
+		Status:      core.TestStatusPassed,
+		Expected:    expected,
+		Actual:      actual,
+		StartTime:   now,
+		EndTime:     now,
+	}
+	if err != nil {
+		step.Status = core.TestStatusFailed
+		step.Error = err
+	}
+	return step
+}
+
+// GraphQLTestImpl implements the Test interface for GraphQL testing
+type GraphQLTestImpl struct {
+	core.GraphQLTest
+	tester *APITester
+}
+
+// NewGraphQLTest creates a new GraphQL test instance
+func NewGraphQLTest(name, endpoint, query string, tester *APITester) *GraphQLTestImpl {
+	return &GraphQLTestImpl{
+		GraphQLTest: core.GraphQLTest{
+			Name:      name,
+			Endpoint:  endpoint,
+			Query:     query,
+			Variables: make(map[string]interface{}),
+			Headers:   make(map[string]string),
+		},
+		tester: tester,
+	}
+}
+
+// GetName returns the name of the test
+func (gt *GraphQLTestImpl) GetName() string {
+	return gt.Name
+}
+
+// Execute runs the GraphQL test and returns the result
+func (gt *GraphQLTestImpl) Execute() *core.TestCaseResult {
+	return gt.tester.ExecuteGraphQLTest(&gt.GraphQLTest)
+}
+
+// SetOperationName selects the operation to run from a document with several operations
+func (gt *GraphQLTestImpl) SetOperationName(name string) *GraphQLTestImpl {
+	gt.OperationName = name
+	return gt
+}
+
+// SetVariable sets a GraphQL variable
+func (gt *GraphQLTestImpl) SetVariable(name string, value interface{}) *GraphQLTestImpl {
+	if gt.Variables == nil {
+		gt.Variables = make(map[string]interface{})
+	}
+	gt.Variables[name] = value
+	return gt
+}
+
+// SetHeader sets a header for the GraphQL request
+func (gt *GraphQLTestImpl) SetHeader(key, value string) *GraphQLTestImpl {
+	if gt.Headers == nil {
+		gt.Headers = make(map[string]string)
+	}
+	gt.Headers[key] = value
+	return gt
+}
+
+// UsePersistedQuery sends the query as a persisted query hash
+func (gt *GraphQLTestImpl) UsePersistedQuery() *GraphQLTestImpl {
+	gt.PersistedQuery = true
+	return gt
+}
+
+// ValidateAgainst validates the query against an SDL schema file before sending it
+func (gt *GraphQLTestImpl) ValidateAgainst(schemaFile string) *GraphQLTestImpl {
+	gt.Schema = schemaFile
+	return gt
+}
+
+// SetExpectedData sets the expected data object
+func (gt *GraphQLTestImpl) SetExpectedData(data interface{}) *GraphQLTestImpl {
+	gt.expectation().Data = data
+	return gt
+}
+
+// SetExpectedJSONPath sets an expected value at a JSON path within the data object
+func (gt *GraphQLTestImpl) SetExpectedJSONPath(path string, value interface{}) *GraphQLTestImpl {
+	expected := gt.expectation()
+	if expected.JSONPath == nil {
+		expected.JSONPath = make(map[string]interface{})
+	}
+	expected.JSONPath[path] = value
+	return gt
+}
+
+// SetExpectedErrors expects errors whose messages match each of the patterns
+func (gt *GraphQLTestImpl) SetExpectedErrors(patterns ...string) *GraphQLTestImpl {
+	gt.expectation().Errors = patterns
+	return gt
+}
+
+// AllowErrors lets the response contain errors, such as a partial result
+func (gt *GraphQLTestImpl) AllowErrors() *GraphQLTestImpl {
+	gt.expectation().AllowErrors = true
+	return gt
+}
+
+// CaptureJSONPath stores the value at a JSON path of the response, e.g. "$.data.user.id"
+func (gt *GraphQLTestImpl) CaptureJSONPath(variable, path string) *GraphQLTestImpl {
+	gt.Captures = append(gt.Captures, core.APICapture{Variable: variable, JSONPath: path})
+	return gt
+}
+
+// expectation returns the test's expectation, creating it when needed
+func (gt *GraphQLTestImpl) expectation() *core.GraphQLExpectation {
+	if gt.Expected == nil {
+		gt.Expected = &core.GraphQLExpectation{}
+	}
+	return gt.Expected
+}
@@ -0,0 +1,979 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gowright/framework/pkg/core"
+)
+
+// GraphQLSchema is a schema parsed from SDL. It validates queries before they are sent: fields,
+// arguments, fragments and variables must exist, and selections must match the field types.
+type GraphQLSchema struct {
+	types map[string]*graphQLType
+	roots map[string]string // operation type to root type name
+}
+
+// graphQLType is a named type of a schema
+type graphQLType struct {
+	name    string
+	kind    string // scalar, type, interface, union, enum or input
+	fields  map[string]*graphQLField
+	members []string // possible types of a union
+}
+
+// graphQLField is a field of an object or interface type
+type graphQLField struct {
+	typ  string
+	args map[string]graphQLInputValue
+}
+
+// graphQLInputValue is an argument or variable definition
+type graphQLInputValue struct {
+	typ        string
+	hasDefault bool
+}
+
+// required reports whether a value must be supplied
+func (v graphQLInputValue) required() bool {
+	return strings.HasSuffix(v.typ, "!") && !v.hasDefault
+}
+
+// composite reports whether selections can be made on the type
+func (t *graphQLType) composite() bool {
+	return t.kind == "type" || t.kind == "interface" || t.kind == "union"
+}
+
+// input reports whether variables can have the type
+func (t *graphQLType) input() bool {
+	return t.kind == "scalar" || t.kind == "enum" || t.kind == "input"
+}
+
+// namedType strips list and non-null wrappers from a type reference such as "[User!]!"
+func namedType(typ string) string {
+	return strings.Trim(typ, "[]!")
+}
+
+// LoadGraphQLSchema reads and parses an SDL file
+func LoadGraphQLSchema(path string) (*GraphQLSchema, error) {
+	content, err := os.ReadFile(path) // #nosec G304 -- schema paths are supplied by the test author
+	if err != nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "failed to read GraphQL schema", err).
+			WithContext("path", path)
+	}
+	schema, err := ParseGraphQLSchema(string(content))
+	if err != nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "failed to parse GraphQL schema", err).
+			WithContext("path", path)
+	}
+	return schema, nil
+}
+
+// ParseGraphQLSchema parses a schema written in SDL
+func ParseGraphQLSchema(sdl string) (*GraphQLSchema, error) {
+	tokens, err := lexGraphQL(sdl)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &GraphQLSchema{types: make(map[string]*graphQLType), roots: make(map[string]string)}
+	for _, scalar := range []string{"Int", "Float", "String", "Boolean", "ID"} {
+		schema.types[scalar] = &graphQLType{name: scalar, kind: "scalar"}
+	}
+
+	p := &graphQLParser{tokens: tokens}
+	for !p.done() {
+		if err := p.parseTypeSystemDefinition(schema); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, operation := range []string{"query", "mutation", "subscription"} {
+		name := strings.ToUpper(operation[:1]) + operation[1:]
+		if _, exists := schema.roots[operation]; !exists && schema.types[name] != nil {
+			schema.roots[operation] = name
+		}
+	}
+	if schema.roots["query"] == "" {
+		return nil, fmt.Errorf("schema has no query type")
+	}
+	return schema, nil
+}
+
+// ValidateQuery checks a query document against the schema and returns every problem found. When
+// operationName is set, the document must define an operation with that name.
+func (s *GraphQLSchema) ValidateQuery(query, operationName string) []error {
+	document, err := parseGraphQLQuery(query)
+	if err != nil {
+		return []error{err}
+	}
+
+	v := &graphQLValidator{schema: s, document: document}
+	found := operationName == ""
+	for _, operation := range document.operations {
+		found = found || operation.name == operationName
+		v.validateOperation(operation)
+	}
+	if !found {
+		v.errors = append(v.errors, fmt.Errorf("operation %q is not defined", operationName))
+	}
+	for _, name := range sortedKeys(document.fragments) {
+		if fragment := document.fragments[name]; !v.usedFragments[name] {
+			v.errorf(fragment.position, "fragment %q is never used", name)
+		}
+	}
+	return v.errors
+}
+
+// graphQLDocument is a parsed query document
+type graphQLDocument struct {
+	operations []*graphQLOperation
+	fragments  map[string]*graphQLFragment
+}
+
+// graphQLOperation is a query, mutation or subscription
+type graphQLOperation struct {
+	kind       string
+	name       string
+	variables  map[string]graphQLInputValue
+	selections []*graphQLSelection
+	position   graphQLToken
+}
+
+// graphQLFragment is a named fragment definition
+type graphQLFragment struct {
+	typeCondition string
+	selections    []*graphQLSelection
+	position      graphQLToken
+}
+
+// graphQLSelection is a field, fragment spread or inline fragment
+type graphQLSelection struct {
+	name          string   // field name
+	arguments     []string // argument names of a field
+	fragment      string   // name of a spread fragment
+	inline        bool
+	typeCondition string // type condition of an inline fragment
+	hasSelection  bool
+	selections    []*graphQLSelection
+	variables     []string // variables referenced by arguments and directives
+	position      graphQLToken
+}
+
+// graphQLValidator collects the problems of a query document
+type graphQLValidator struct {
+	schema        *GraphQLSchema
+	document      *graphQLDocument
+	usedFragments map[string]bool
+	errors        []error
+}
+
+// errorf records a problem at the position of a token
+func (v *graphQLValidator) errorf(position graphQLToken, format string, args ...interface{}) {
+	v.errors = append(v.errors, fmt.Errorf("line %d, column %d: %s", position.line, position.column, fmt.Sprintf(format, args...)))
+}
+
+// validateOperation checks an operation's variables and selections
+func (v *graphQLValidator) validateOperation(operation *graphQLOperation) {
+	root := v.schema.types[v.schema.roots[operation.kind]]
+	if root == nil {
+		v.errorf(operation.position, "schema does not support %s operations", operation.kind)
+		return
+	}
+
+	for _, name := range sortedKeys(operation.variables) {
+		typ := operation.variables[name].typ
+		if t := v.schema.types[namedType(typ)]; t == nil {
+			v.errorf(operation.position, "variable \"$%s\" has unknown type %q", name, typ)
+		} else if !t.input() {
+			v.errorf(operation.position, "variable \"$%s\" cannot have non-input type %q", name, typ)
+		}
+	}
+
+	used := make(map[string]bool)
+	v.validateSelections(root, operation.selections, used, make(map[string]bool))
+	for _, name := range sortedKeys(used) {
+		if _, defined := operation.variables[name]; !defined {
+			v.errorf(operation.position, "variable \"$%s\" is not defined by the operation", name)
+		}
+	}
+	for _, name := range sortedKeys(operation.variables) {
+		if !used[name] {
+			v.errorf(operation.position, "variable \"$%s\" is never used", name)
+		}
+	}
+}
+
+// validateSelections checks selections made on a composite type
+func (v *graphQLValidator) validateSelections(parent *graphQLType, selections []*graphQLSelection, used, visited map[string]bool) {
+	for _, selection := range selections {
+		for _, name := range selection.variables {
+			used[name] = true
+		}
+
+		switch {
+		case selection.fragment != "":
+			fragment := v.document.fragments[selection.fragment]
+			if fragment == nil {
+				v.errorf(selection.position, "unknown fragment %q", selection.fragment)
+				continue
+			}
+			if v.usedFragments == nil {
+				v.usedFragments = make(map[string]bool)
+			}
+			v.usedFragments[selection.fragment] = true
+			if visited[selection.fragment] {
+				continue
+			}
+			visited[selection.fragment] = true
+			if t := v.conditionType(fragment.typeCondition, fragment.position); t != nil {
+				v.validateSelections(t, fragment.selections, used, visited)
+			}
+
+		case selection.inline:
+			t := parent
+			if selection.typeCondition != "" {
+				if t = v.conditionType(selection.typeCondition, selection.position); t == nil {
+					continue
+				}
+			}
+			v.validateSelections(t, selection.selections, used, visited)
+
+		default:
+			v.validateField(parent, selection, used, visited)
+		}
+	}
+}
+
+// conditionType returns the composite type a fragment applies to
+func (v *graphQLValidator) conditionType(name string, position graphQLToken) *graphQLType {
+	t := v.schema.types[name]
+	switch {
+	case t == nil:
+		v.errorf(position, "unknown type %q", name)
+		return nil
+	case !t.composite():
+		v.errorf(position, "fragment cannot condition on non-composite type %q", name)
+		return nil
+	}
+	return t
+}
+
+// validateField checks a field, its arguments and its subselection
+func (v *graphQLValidator) validateField(parent *graphQLType, selection *graphQLSelection, used, visited map[string]bool) {
+	if selection.name == "__typename" {
+		if selection.hasSelection {
+			v.errorf(selection.position, "field \"__typename\" must not have a selection since it is a String")
+		}
+		return
+	}
+	// Introspection queries are not checked
+	if (selection.name == "__schema" || selection.name == "__type") && parent.name == v.schema.roots["query"] {
+		return
+	}
+	if parent.kind == "union" {
+		v.errorf(selection.position, "cannot query field %q on union type %q; use a fragment", selection.name, parent.name)
+		return
+	}
+
+	field := parent.fields[selection.name]
+	if field == nil {
+		v.errorf(selection.position, "cannot query field %q on type %q", selection.name, parent.name)
+		return
+	}
+
+	provided := make(map[string]bool, len(selection.arguments))
+	for _, argument := range selection.arguments {
+		provided[argument] = true
+		if _, exists := field.args[argument]; !exists {
+			v.errorf(selection.position, "unknown argument %q on field \"%s.%s\"", argument, parent.name, selection.name)
+		}
+	}
+	for _, argument := range sortedKeys(field.args) {
+		if field.args[argument].required() && !provided[argument] {
+			v.errorf(selection.position, "field \"%s.%s\" argument %q of type %q is required", parent.name, selection.name, argument, field.args[argument].typ)
+		}
+	}
+
+	fieldType := v.schema.types[namedType(field.typ)]
+	switch {
+	case fieldType == nil:
+		return
+	case fieldType.composite() && !selection.hasSelection:
+		v.errorf(selection.position, "field %q of type %q must have a selection of subfields", selection.name, field.typ)
+	case fieldType.composite():
+		v.validateSelections(fieldType, selection.selections, used, visited)
+	case selection.hasSelection:
+		v.errorf(selection.position, "field %q must not have a selection since type %q has no subfields", selection.name, field.typ)
+	}
+}
+
+// graphQLTokenKind classifies lexical tokens
+type graphQLTokenKind int
+
+const (
+	graphQLPunctuator graphQLTokenKind = iota
+	graphQLName
+	graphQLNumber
+	graphQLString
+	graphQLEOF
+)
+
+// graphQLToken is a lexical token with its position
+type graphQLToken struct {
+	kind   graphQLTokenKind
+	value  string
+	line   int
+	column int
+}
+
+// lexGraphQL splits GraphQL source into tokens; commas, whitespace and comments are ignored
+func lexGraphQL(source string) ([]graphQLToken, error) {
+	var tokens []graphQLToken
+	line, lineStart := 1, 0
+	for i := 0; i < len(source); {
+		c := source[i]
+		token := graphQLToken{line: line, column: i - lineStart + 1}
+		switch {
+		case c == '\n':
+			i++
+			line, lineStart = line+1, i
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			i++
+			continue
+		case c == '#':
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(source[i:], "..."):
+			token.kind, token.value = graphQLPunctuator, "..."
+			i += 3
+		case strings.IndexByte("!$&()=:@[]{}|", c) >= 0:
+			token.kind, token.value = graphQLPunctuator, string(c)
+			i++
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(source) && (source[i] == '_' || isLetter(source[i]) || isDigit(source[i])) {
+				i++
+			}
+			token.kind, token.value = graphQLName, source[start:i]
+		case c == '-' || isDigit(c):
+			start := i
+			i++
+			for i < len(source) && (isDigit(source[i]) || strings.IndexByte(".eE+-", source[i]) >= 0) {
+				i++
+			}
+			token.kind, token.value = graphQLNumber, source[start:i]
+		case strings.HasPrefix(source[i:], `"""`):
+			end := strings.Index(source[i+3:], `"""`)
+			for end >= 0 && source[i+3+end-1] == '\\' {
+				next := strings.Index(source[i+3+end+3:], `"""`)
+				if next < 0 {
+					end = -1
+					break
+				}
+				end += 3 + next
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("line %d, column %d: unterminated block string", token.line, token.column)
+			}
+			value := source[i+3 : i+3+end]
+			token.kind, token.value = graphQLString, value
+			if newlines := strings.Count(value, "\n"); newlines > 0 {
+				line += newlines
+				lineStart = i + 3 + strings.LastIndex(value, "\n") + 1
+			}
+			i += 3 + end + 3
+		case c == '"':
+			end := i + 1
+			for end < len(source) && source[end] != '"' && source[end] != '\n' {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) || source[end] != '"' {
+				return nil, fmt.Errorf("line %d, column %d: unterminated string", token.line, token.column)
+			}
+			var value string
+			if err := json.Unmarshal([]byte(source[i:end+1]), &value); err != nil {
+				return nil, fmt.Errorf("line %d, column %d: invalid string: %v", token.line, token.column, err)
+			}
+			token.kind, token.value = graphQLString, value
+			i = end + 1
+		case strings.HasPrefix(source[i:], "\ufeff"):
+			i += len("\ufeff")
+			continue
+		default:
+			return nil, fmt.Errorf("line %d, column %d: unexpected character %q", token.line, token.column, c)
+		}
+		tokens = append(tokens, token)
+	}
+	return append(tokens, graphQLToken{kind: graphQLEOF, line: line, column: len(source) - lineStart + 1}), nil
+}
+
+// isLetter reports whether c is an ASCII letter
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// graphQLParser is a recursive descent parser over GraphQL tokens
+type graphQLParser struct {
+	tokens []graphQLToken
+	pos    int
+}
+
+// peek returns the current token
+func (p *graphQLParser) peek() graphQLToken {
+	return p.tokens[p.pos]
+}
+
+// next consumes and returns the current token
+func (p *graphQLParser) next() graphQLToken {
+	token := p.tokens[p.pos]
+	if token.kind != graphQLEOF {
+		p.pos++
+	}
+	return token
+}
+
+// done reports whether all tokens were consumed
+func (p *graphQLParser) done() bool {
+	return p.peek().kind == graphQLEOF
+}
+
+// is reports whether the current token is the given punctuator or keyword
+func (p *graphQLParser) is(value string) bool {
+	token := p.peek()
+	return (token.kind == graphQLPunctuator || token.kind == graphQLName) && token.value == value
+}
+
+// skip consumes the current token if it is the given punctuator or keyword
+func (p *graphQLParser) skip(value string) bool {
+	if p.is(value) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// errorf returns a syntax error at the current token
+func (p *graphQLParser) errorf(format string, args ...interface{}) error {
+	token := p.peek()
+	return fmt.Errorf("syntax error at line %d, column %d: %s", token.line, token.column, fmt.Sprintf(format, args...))
+}
+
+// describe names the current token for error messages
+func (p *graphQLParser) describe() string {
+	token := p.peek()
+	if token.kind == graphQLEOF {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q", token.value)
+}
+
+// expect consumes the given punctuator or keyword
+func (p *graphQLParser) expect(value string) error {
+	if !p.skip(value) {
+		return p.errorf("expected %q, found %s", value, p.describe())
+	}
+	return nil
+}
+
+// name consumes a name
+func (p *graphQLParser) name() (string, error) {
+	if p.peek().kind != graphQLName {
+		return "", p.errorf("expected a name, found %s", p.describe())
+	}
+	return p.next().value, nil
+}
+
+// parseType parses a type reference such as [ID!]!
+func (p *graphQLParser) parseType() (string, error) {
+	var typ string
+	if p.skip("[") {
+		inner, err := p.parseType()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect("]"); err != nil {
+			return "", err
+		}
+		typ = "[" + inner + "]"
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return "", err
+		}
+		typ = name
+	}
+	if p.skip("!") {
+		typ += "!"
+	}
+	return typ, nil
+}
+
+// parseValue parses a value and returns the variables it references
+func (p *graphQLParser) parseValue() ([]string, error) {
+	token := p.peek()
+	switch {
+	case p.skip("$"):
+		name, err := p.name()
+		return []string{name}, err
+	case token.kind == graphQLNumber || token.kind == graphQLString || token.kind == graphQLName:
+		p.next()
+		return nil, nil
+	case p.skip("["):
+		var variables []string
+		for !p.skip("]") {
+			if p.done() {
+				return nil, p.errorf("unterminated list")
+			}
+			referenced, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			variables = append(variables, referenced...)
+		}
+		return variables, nil
+	case p.skip("{"):
+		var variables []string
+		for !p.skip("}") {
+			if _, err := p.name(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			referenced, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			variables = append(variables, referenced...)
+		}
+		return variables, nil
+	}
+	return nil, p.errorf("expected a value, found %s", p.describe())
+}
+
+// parseArguments parses (name: value ...) and returns the argument names and referenced variables
+func (p *graphQLParser) parseArguments() ([]string, []string, error) {
+	var names, variables []string
+	if !p.skip("(") {
+		return nil, nil, nil
+	}
+	for !p.skip(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, nil, err
+		}
+		referenced, err := p.parseValue()
+		if err != nil {
+			return nil, nil, err
+		}
+		names = append(names, name)
+		variables = append(variables, referenced...)
+	}
+	return names, variables, nil
+}
+
+// parseDirectives parses @name(arguments) directives and returns the variables they reference
+func (p *graphQLParser) parseDirectives() ([]string, error) {
+	var variables []string
+	for p.skip("@") {
+		if _, err := p.name(); err != nil {
+			return nil, err
+		}
+		_, referenced, err := p.parseArguments()
+		if err != nil {
+			return nil, err
+		}
+		variables = append(variables, referenced...)
+	}
+	return variables, nil
+}
+
+// parseGraphQLQuery parses an executable document of operations and fragments
+func parseGraphQLQuery(query string) (*graphQLDocument, error) {
+	tokens, err := lexGraphQL(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &graphQLParser{tokens: tokens}
+	document := &graphQLDocument{fragments: make(map[string]*graphQLFragment)}
+	for !p.done() {
+		position := p.peek()
+		switch {
+		case p.is("{"):
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			document.operations = append(document.operations, &graphQLOperation{kind: "query", selections: selections, position: position})
+
+		case p.is("query") || p.is("mutation") || p.is("subscription"):
+			operation, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			document.operations = append(document.operations, operation)
+
+		case p.skip("fragment"):
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("on"); err != nil {
+				return nil, err
+			}
+			fragment := &graphQLFragment{position: position}
+			if fragment.typeCondition, err = p.name(); err != nil {
+				return nil, err
+			}
+			if _, err := p.parseDirectives(); err != nil {
+				return nil, err
+			}
+			if fragment.selections, err = p.parseSelectionSet(); err != nil {
+				return nil, err
+			}
+			if _, exists := document.fragments[name]; exists {
+				return nil, fmt.Errorf("line %d, column %d: fragment %q is defined more than once", position.line, position.column, name)
+			}
+			document.fragments[name] = fragment
+
+		default:
+			return nil, p.errorf("expected an operation or fragment, found %s", p.describe())
+		}
+	}
+
+	if len(document.operations) == 0 {
+		return nil, fmt.Errorf("document has no operations")
+	}
+	return document, nil
+}
+
+// parseOperation parses a named or anonymous query, mutation or subscription
+func (p *graphQLParser) parseOperation() (*graphQLOperation, error) {
+	operation := &graphQLOperation{position: p.peek(), kind: p.next().value, variables: make(map[string]graphQLInputValue)}
+	if p.peek().kind == graphQLName {
+		operation.name = p.next().value
+	}
+
+	if p.skip("(") {
+		for !p.skip(")") {
+			if err := p.expect("$"); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			variable := graphQLInputValue{}
+			if variable.typ, err = p.parseType(); err != nil {
+				return nil, err
+			}
+			if p.skip("=") {
+				variable.hasDefault = true
+				if _, err := p.parseValue(); err != nil {
+					return nil, err
+				}
+			}
+			if _, err := p.parseDirectives(); err != nil {
+				return nil, err
+			}
+			operation.variables[name] = variable
+		}
+	}
+
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	operation.selections = selections
+	return operation, nil
+}
+
+// parseSelectionSet parses { selection ... }
+func (p *graphQLParser) parseSelectionSet() ([]*graphQLSelection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var selections []*graphQLSelection
+	for !p.skip("}") {
+		if p.done() {
+			return nil, p.errorf("unterminated selection set")
+		}
+		selection, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+	if len(selections) == 0 {
+		return nil, p.errorf("selection set is empty")
+	}
+	return selections, nil
+}
+
+// parseSelection parses a field, a fragment spread or an inline fragment
+func (p *graphQLParser) parseSelection() (*graphQLSelection, error) {
+	selection := &graphQLSelection{position: p.peek()}
+	var err error
+
+	if p.skip("...") {
+		if p.peek().kind == graphQLName && !p.is("on") {
+			selection.fragment = p.next().value
+			selection.variables, err = p.parseDirectives()
+			return selection, err
+		}
+		selection.inline = true
+		if p.skip("on") {
+			if selection.typeCondition, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		if selection.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if p.skip(":") {
+			if selection.name, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if selection.arguments, selection.variables, err = p.parseArguments(); err != nil {
+			return nil, err
+		}
+	}
+
+	referenced, err := p.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+	selection.variables = append(selection.variables, referenced...)
+
+	if selection.inline || p.is("{") {
+		selection.hasSelection = true
+		if selection.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return selection, nil
+}
+
+// parseTypeSystemDefinition parses one SDL definition into the schema
+func (p *graphQLParser) parseTypeSystemDefinition(schema *GraphQLSchema) error {
+	p.skipDescription()
+	extend := p.skip("extend")
+
+	keyword, err := p.name()
+	if err != nil {
+		return err
+	}
+	switch keyword {
+	case "schema":
+		if _, err := p.parseDirectives(); err != nil {
+			return err
+		}
+		if err := p.expect("{"); err != nil {
+			return err
+		}
+		for !p.skip("}") {
+			operation, err := p.name()
+			if err != nil {
+				return err
+			}
+			if err := p.expect(":"); err != nil {
+				return err
+			}
+			if schema.roots[operation], err = p.name(); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "directive":
+		if err := p.expect("@"); err != nil {
+			return err
+		}
+		if _, err := p.name(); err != nil {
+			return err
+		}
+		if _, err := p.parseArgumentDefinitions(); err != nil {
+			return err
+		}
+		p.skip("repeatable")
+		if err := p.expect("on"); err != nil {
+			return err
+		}
+		p.skip("|")
+		for {
+			if _, err := p.name(); err != nil {
+				return err
+			}
+			if !p.skip("|") {
+				return nil
+			}
+		}
+
+	case "scalar", "type", "interface", "union", "enum", "input":
+	default:
+		return fmt.Errorf("syntax error at line %d, column %d: unexpected %q", p.tokens[p.pos-1].line, p.tokens[p.pos-1].column, keyword)
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	t := schema.types[name]
+	if t == nil || !extend {
+		if t != nil && t.kind != "scalar" {
+			return p.errorf("type %q is defined more than once", name)
+		}
+		t = &graphQLType{name: name, kind: keyword, fields: make(map[string]*graphQLField)}
+		schema.types[name] = t
+	}
+
+	if keyword == "type" || keyword == "interface" {
+		if p.skip("implements") {
+			p.skip("&")
+			for {
+				if _, err := p.name(); err != nil {
+					return err
+				}
+				if !p.skip("&") {
+					break
+				}
+			}
+		}
+	}
+	if _, err := p.parseDirectives(); err != nil {
+		return err
+	}
+
+	switch keyword {
+	case "type", "interface", "input":
+		if !p.skip("{") {
+			return nil
+		}
+		for !p.skip("}") {
+			p.skipDescription()
+			fieldName, err := p.name()
+			if err != nil {
+				return err
+			}
+			field := &graphQLField{}
+			if keyword != "input" {
+				if field.args, err = p.parseArgumentDefinitions(); err != nil {
+					return err
+				}
+			}
+			if err := p.expect(":"); err != nil {
+				return err
+			}
+			if field.typ, err = p.parseType(); err != nil {
+				return err
+			}
+			if keyword == "input" && p.skip("=") {
+				if _, err := p.parseValue(); err != nil {
+					return err
+				}
+			}
+			if _, err := p.parseDirectives(); err != nil {
+				return err
+			}
+			t.fields[fieldName] = field
+		}
+
+	case "enum":
+		if !p.skip("{") {
+			return nil
+		}
+		for !p.skip("}") {
+			p.skipDescription()
+			if _, err := p.name(); err != nil {
+				return err
+			}
+			if _, err := p.parseDirectives(); err != nil {
+				return err
+			}
+		}
+
+	case "union":
+		if !p.skip("=") {
+			return nil
+		}
+		p.skip("|")
+		for {
+			member, err := p.name()
+			if err != nil {
+				return err
+			}
+			t.members = append(t.members, member)
+			if !p.skip("|") {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// parseArgumentDefinitions parses (name: Type = default ...) in SDL
+func (p *graphQLParser) parseArgumentDefinitions() (map[string]graphQLInputValue, error) {
+	args := make(map[string]graphQLInputValue)
+	if !p.skip("(") {
+		return args, nil
+	}
+	for !p.skip(")") {
+		p.skipDescription()
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		arg := graphQLInputValue{}
+		if arg.typ, err = p.parseType(); err != nil {
+			return nil, err
+		}
+		if p.skip("=") {
+			arg.hasDefault = true
+			if _, err := p.parseValue(); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := p.parseDirectives(); err != nil {
+			return nil, err
+		}
+		args[name] = arg
+	}
+	return args, nil
+}
+
+// skipDescription consumes a description string
+func (p *graphQLParser) skipDescription() {
+	if p.peek().kind == graphQLString {
+		p.next()
+	}
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gowright/framework/pkg/assertions"
+	"github.com/gowright/framework/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGraphQLSchema = `
+"""The root query"""
+type Query {
+  user(id: ID!): User
+  users(first: Int = 10, role: Role): [User!]!
+  search(term: String!): [SearchResult!]!
+}
+
+type Mutation {
+  createUser(input: CreateUserInput!): User!
+}
+
+"A person"
+type User implements Node @key(fields: "id") {
+  id: ID!
+  name: String
+  friends(first: Int): [User!]!
+}
+
+interface Node { id: ID! }
+type Post implements Node { id: ID! title: String! }
+union SearchResult = User | Post
+enum Role { ADMIN USER }
+input CreateUserInput { name: String! role: Role = USER }
+directive @key(fields: String!) on OBJECT | INTERFACE
+extend type Query { node(id: ID!): Node }
+`
+
+func TestGraphQLSchema_ValidateQuery(t *testing.T) {
+	schema, err := ParseGraphQLSchema(testGraphQLSchema)
+	require.NoError(t, err)
+
+	valid := []string{
+		`{ user(id: "1") { id name } }`,
+		`query Users($role: Role) { users(role: $role) { ...UserFields friends(first: 2) { __typename id } } }
+		 fragment UserFields on User { id name }`,
+		`query { search(term: "a") { __typename ... on User { name } ... on Post { title } } }`,
+		`mutation Create($name: String!) { createUser(input: {name: $name, role: ADMIN}) { id } }`,
+		`query Node($id: ID!, $withName: Boolean = true) { node(id: $id) { id ... on User @include(if: $withName) { name } } }`,
+		`{ __schema { types { name } } }`,
+	}
+	for _, query := range valid {
+		assert.Empty(t, schema.ValidateQuery(query, ""), query)
+	}
+
+	invalid := map[string]string{
+		`{ user(id: "1") { email } }`:                         `line 1, column 19: cannot query field "email" on type "User"`,
+		`{ user { id } }`:                                     `field "Query.user" argument "id" of type "ID!" is required`,
+		`{ users(limit: 1) { id } }`:                          `unknown argument "limit" on field "Query.users"`,
+		`{ user(id: "1") }`:                                   `field "user" of type "User" must have a selection of subfields`,
+		`{ user(id: "1") { name { first } } }`:                `field "name" must not have a selection since type "String" has no subfields`,
+		`{ search(term: "a") { name } }`:                      `cannot query field "name" on union type "SearchResult"`,
+		`query { user(id: $id) { id } }`:                      `variable "$id" is not defined by the operation`,
+		`query Q($id: ID!, $x: Int) { user(id: $id) { id } }`: `variable "$x" is never used`,
+		`query Q($u: User) { users { id } }`:                  `variable "$u" cannot have non-input type "User"`,
+		`{ users { ...Missing } }`:                            `unknown fragment "Missing"`,
+		`{ users { id } } fragment F on User { id }`:          `fragment "F" is never used`,
+		`{ users { ... on Nothing { id } } }`:                 `unknown type "Nothing"`,
+		`subscription { users { id } }`:                       `schema does not support subscription operations`,
+		`{ users { id }`:                                      `syntax error at line 1, column 15: unterminated selection set`,
+	}
+	for query, message := range invalid {
+		problems := schema.ValidateQuery(query, "")
+		require.NotEmpty(t, problems, query)
+		assert.Contains(t, problems[0].Error(), message, query)
+	}
+
+	problems := schema.ValidateQuery(`query A { users { id } }`, "B")
+	require.Len(t, problems, 1)
+	assert.EqualError(t, problems[0], `operation "B" is not defined`)
+
+	_, err = ParseGraphQLSchema(`type User { id: ID }`)
+	assert.EqualError(t, err, "schema has no query type")
+}
+
+func TestAPITester_ExecuteGraphQLTest(t *testing.T) {
+	server, tester := newMockServer(t)
+	schemaFile := filepath.Join(t.TempDir(), "schema.graphql")
+	require.NoError(t, os.WriteFile(schemaFile, []byte(testGraphQLSchema), 0600))
+
+	server.Stub("POST", "/graphql").
+		WithJSONPath("$.query", assertions.MatchRegex(`user\(id: \$id\)`)).
+		WithJSONPath("$.variables.id", "42").
+		Respond(http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"user": map[string]interface{}{"id": "42", "name": "Ada"}}})
+	server.Stub("POST", "/graphql").
+		WithJSONPath("$.variables.id", "0").
+		Respond(http.StatusOK, map[string]interface{}{
+			"data":   map[string]interface{}{"user": nil},
+			"errors": []map[string]interface{}{{"message": "user 0 not found", "path": []string{"user"}}},
+		})
+	userQuery := `query User($id: ID!) { user(id: $id) { id name } }`
+
+	t.Run("data and JSON path expectations", func(t *testing.T) {
+		tester.Variables().Set("userId", "42")
+		test := NewGraphQLTest("user", "/graphql", userQuery, tester).
+			SetOperationName("User").
+			SetVariable("id", "{{userId}}").
+			ValidateAgainst(schemaFile).
+			SetExpectedJSONPath("$.user.name", "Ada").
+			SetExpectedData(map[string]interface{}{"user": map[string]interface{}{"id": "42", "name": assertions.MatchType("string")}}).
+			CaptureJSONPath("name", "$.data.user.name")
+
+		result := test.Execute()
+		require.NoError(t, result.Error)
+		assert.Equal(t, core.TestStatusPassed, result.Status)
+		assert.Len(t, result.Steps, 4)
+		name, _ := tester.Variables().Get("name")
+		assert.Equal(t, "Ada", name)
+	})
+
+	t.Run("errors fail unless expected", func(t *testing.T) {
+		result := tester.ExecuteGraphQLTest(&core.GraphQLTest{Name: "missing", Endpoint: "/graphql", Query: userQuery, Variables: map[string]interface{}{"id": "0"}})
+		assert.Equal(t, core.TestStatusFailed, result.Status)
+		require.Len(t, result.Steps, 1)
+		assert.EqualError(t, result.Steps[0].Error, "response has 1 GraphQL error(s): user 0 not found")
+
+		result = tester.ExecuteGraphQLTest(&core.GraphQLTest{
+			Name: "expected error", Endpoint: "/graphql", Query: userQuery, Variables: map[string]interface{}{"id": "0"},
+			Expected: &core.GraphQLExpectation{Errors: []string{"not found$"}, JSONPath: map[string]interface{}{"$.user": nil}},
+		})
+		assert.Equal(t, core.TestStatusPassed, result.Status, "%v", result.Error)
+
+		result = tester.ExecuteGraphQLTest(&core.GraphQLTest{
+			Name: "partial", Endpoint: "/graphql", Query: userQuery, Variables: map[string]interface{}{"id": "0"},
+			Expected: &core.GraphQLExpectation{AllowErrors: true},
+		})
+		assert.Equal(t, core.TestStatusPassed, result.Status)
+
+		result = tester.ExecuteGraphQLTest(&core.GraphQLTest{
+			Name: "unexpected success", Endpoint: "/graphql", Query: userQuery, Variables: map[string]interface{}{"id": "42"},
+			Expected: &core.GraphQLExpectation{Errors: []string{"not found"}},
+		})
+		assert.Equal(t, core.TestStatusFailed, result.Status)
+	})
+
+	t.Run("invalid queries are not sent", func(t *testing.T) {
+		before := len(server.Requests())
+		test := NewGraphQLTest("invalid", "/graphql", `{ user(id: "1") { email } }`, tester).ValidateAgainst(schemaFile)
+		result := test.Execute()
+		assert.Equal(t, core.TestStatusFailed, result.Status)
+		assert.Equal(t, core.ValidationError, core.GetErrorType(result.Error))
+		require.Len(t, result.Steps, 1)
+		assert.Contains(t, result.Steps[0].Error.Error(), `cannot query field "email" on type "User"`)
+		assert.Len(t, server.Requests(), before)
+
+		result = NewGraphQLTest("no schema", "/graphql", `{ users { id } }`, tester).ValidateAgainst(filepath.Join(t.TempDir(), "missing.graphql")).Execute()
+		assert.Equal(t, core.TestStatusError, result.Status)
+	})
+
+	t.Run("persisted queries", func(t *testing.T) {
+		query := `{ users { id } }`
+		server.Stub("POST", "/persisted").Respond(http.StatusOK, map[string]interface{}{"errors": []map[string]interface{}{{"message": "PersistedQueryNotFound"}}})
+		server.Stub("POST", "/persisted").
+			WithJSONPath("$.extensions.persistedQuery.sha256Hash", "b2abc043a4d432b6ba17d37369fcf972de147c06d0b82390ffde0272fcefb33e").
+			WithJSONPath("$.query", query).
+			Respond(http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"users": []interface{}{}}})
+
+		result := NewGraphQLTest("persisted", "/persisted", query, tester).UsePersistedQuery().SetExpectedJSONPath("$.users", []interface{}{}).Execute()
+		assert.Equal(t, core.TestStatusPassed, result.Status, "%v", result.Error)
+		assert.Contains(t, result.Logs, "Persisted query not found on the server, sending the full query")
+		assert.Equal(t, core.TestStatusPassed, server.Verify("POST", "/persisted").WithJSONPath("$.extensions.persistedQuery.version", 1).Times(2).Status)
+		assert.Equal(t, core.TestStatusPassed, server.Verify("POST", "/persisted").WithJSONPath("$.query", query).Times(1).Status)
+	})
+}
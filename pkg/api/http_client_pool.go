@@ -17,6 +17,7 @@ type HTTPClientPool struct {
 	mutex       sync.RWMutex
 	stats       *HTTPClientPoolStats
 	initialized bool
+	newClient   func() *resty.Client // creates clients on demand; nil for clients with the pool defaults
 }
 
 // HTTPClientInstance represents an HTTP client instance in the pool
@@ -59,6 +60,17 @@ func NewHTTPClientPool(maxSize int, timeout time.Duration) (*HTTPClientPool, err
 	return pool, nil
 }
 
+// NewHTTPClientPoolWithFactory creates a pool whose clients are created by newClient, for example
+// with the base URL and credentials of an API tester
+func NewHTTPClientPoolWithFactory(maxSize int, timeout time.Duration, newClient func() *resty.Client) (*HTTPClientPool, error) {
+	pool, err := NewHTTPClientPool(maxSize, timeout)
+	if err != nil {
+		return nil, err
+	}
+	pool.newClient = newClient
+	return pool, nil
+}
+
 // Initialize initializes the HTTP client pool
 func (hcp *HTTPClientPool) Initialize() error {
 	hcp.mutex.Lock()
@@ -249,6 +261,10 @@ transferComplete:
 
 // createClientInstance creates a new HTTP client instance
 func (hcp *HTTPClientPool) createClientInstance() *HTTPClientInstance {
+	if hcp.newClient != nil {
+		return &HTTPClientInstance{Client: hcp.newClient(), CreatedAt: time.Now()}
+	}
+
 	client := resty.New()
 
 	// Configure client with reasonable defaults
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,6 +33,28 @@ func TestNewHTTPClientPool_InvalidMaxSize(t *testing.T) {
 	assert.Contains(t, err.Error(), "must be positive")
 }
 
+func TestNewHTTPClientPoolWithFactory(t *testing.T) {
+	created := 0
+	pool, err := NewHTTPClientPoolWithFactory(2, time.Second, func() *resty.Client {
+		created++
+		return resty.New().SetBaseURL("http://example.com")
+	})
+	assert.NoError(t, err)
+
+	instance, err := pool.AcquireClient(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "http://example.com", instance.Client.BaseURL)
+	assert.NoError(t, pool.ReleaseClient(instance))
+
+	// Released clients are reused rather than created again
+	_, err = pool.AcquireClient(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, created)
+
+	_, err = NewHTTPClientPoolWithFactory(0, time.Second, resty.New)
+	assert.Error(t, err)
+}
+
 func TestHTTPClientPool_GetStats(t *testing.T) {
 	pool, err := NewHTTPClientPool(3, 30*time.Second)
 	assert.NoError(t, err)
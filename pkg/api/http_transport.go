@@ -1,9 +1,19 @@
 package api
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/gowright/framework/pkg/config"
+	"github.com/gowright/framework/pkg/core"
 )
 
 // HTTPTransportConfig holds configuration for HTTP transport
@@ -16,6 +26,9 @@ type HTTPTransportConfig struct {
 	ResponseHeaderTimeout time.Duration `json:"response_header_timeout"`
 	DisableKeepAlives     bool          `json:"disable_keep_alives"`
 	DisableCompression    bool          `json:"disable_compression"`
+	MaxConnsPerHost       int           `json:"max_conns_per_host"` // 0 means no limit
+	TLSClientConfig       *tls.Config   `json:"-"`
+	ProxyURL              *url.URL      `json:"-"` // nil uses the proxy environment variables
 }
 
 // DefaultHTTPTransportConfig returns default HTTP transport configuration
@@ -42,10 +55,16 @@ func (config *HTTPTransportConfig) Build() *http.Transport {
 		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
 		DisableKeepAlives:     config.DisableKeepAlives,
 		DisableCompression:    config.DisableCompression,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		TLSClientConfig:       config.TLSClientConfig,
+		Proxy:                 http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
 			Timeout: config.DialTimeout,
 		}).DialContext,
 	}
+	if config.ProxyURL != nil {
+		transport.Proxy = http.ProxyURL(config.ProxyURL)
+	}
 
 	// Set defaults if not specified
 	if transport.MaxIdleConns == 0 {
@@ -116,3 +135,69 @@ func SecureTransport() *http.Transport {
 	}
 	return config.Build()
 }
+
+// BuildTLSConfig creates a client TLS configuration trusting the system roots plus CAFile, and
+// presenting the client certificate in CertFile and KeyFile for mutual TLS
+func BuildTLSConfig(cfg *config.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, // #nosec G402 -- opt-in for test environments
+	}
+
+	if cfg.CAFile != "" {
+		data, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, core.NewGowrightError(core.ConfigurationError, "failed to read CA file", err).
+				WithContext("ca_file", cfg.CAFile)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, core.NewGowrightError(core.ConfigurationError, "CA file contains no PEM certificates", nil).
+				WithContext("ca_file", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, core.NewGowrightError(core.ConfigurationError, "both cert file and key file are required for client certificates", nil)
+		}
+		certificate, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, core.NewGowrightError(core.ConfigurationError, "failed to load client certificate", err).
+				WithContext("cert_file", cfg.CertFile).
+				WithContext("key_file", cfg.KeyFile)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// BuildProxyURL returns the URL of a proxy. Host may include a scheme such as https:// or
+// socks5://; plain HTTP is assumed otherwise.
+func BuildProxyURL(cfg *config.ProxyConfig) (*url.URL, error) {
+	if cfg.Host == "" {
+		return nil, core.NewGowrightError(core.ConfigurationError, "proxy host is required", nil)
+	}
+
+	raw := cfg.Host
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	proxyURL, err := url.Parse(raw)
+	if err != nil || proxyURL.Hostname() == "" {
+		return nil, core.NewGowrightError(core.ConfigurationError, fmt.Sprintf("invalid proxy host: %s", cfg.Host), err)
+	}
+
+	if cfg.Port > 0 {
+		proxyURL.Host = net.JoinHostPort(proxyURL.Hostname(), strconv.Itoa(cfg.Port))
+	}
+	if cfg.Username != "" {
+		proxyURL.User = url.UserPassword(cfg.Username, cfg.Password)
+	}
+	return proxyURL, nil
+}
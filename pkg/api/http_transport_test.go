@@ -0,0 +1,191 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	gwconfig "github.com/gowright/framework/pkg/config"
+	"github.com/gowright/framework/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeClientCertificate writes a self-signed client certificate and its key to dir
+func writeClientCertificate(t *testing.T, dir string) (*x509.Certificate, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gowright-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certificate, certFile, keyFile
+}
+
+func TestAPITester_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	clientCert, certFile, keyFile := writeClientCertificate(t, dir)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+
+	request := func(tlsConfig *gwconfig.TLSConfig) (*core.APIResponse, error) {
+		tester := NewAPITester()
+		require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, Timeout: 5 * time.Second, TLSConfig: tlsConfig}))
+		defer func() { _ = tester.Cleanup() }()
+		return tester.Get("/", nil)
+	}
+
+	response, err := request(&gwconfig.TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile})
+	require.NoError(t, err)
+	assert.Equal(t, "gowright-client", string(response.Body))
+
+	// The server certificate is not trusted without the CA file or InsecureSkipVerify
+	_, err = request(&gwconfig.TLSConfig{CertFile: certFile, KeyFile: keyFile})
+	assert.Error(t, err)
+
+	response, err = request(&gwconfig.TLSConfig{InsecureSkipVerify: true, CertFile: certFile, KeyFile: keyFile})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	// The server rejects clients without a certificate
+	_, err = request(&gwconfig.TLSConfig{CAFile: caFile})
+	assert.Error(t, err)
+
+	tester := NewAPITester()
+	for _, invalid := range []*gwconfig.TLSConfig{
+		{CAFile: filepath.Join(dir, "missing.pem")},
+		{CAFile: keyFile},
+		{CertFile: certFile},
+		{CertFile: certFile, KeyFile: caFile},
+	} {
+		err := tester.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, TLSConfig: invalid})
+		require.Error(t, err, "%+v", invalid)
+		assert.Equal(t, core.ConfigurationError, core.GetErrorType(err))
+	}
+}
+
+func TestAPITester_ProxyAndRedirects(t *testing.T) {
+	t.Run("proxy", func(t *testing.T) {
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// A forward proxy receives the absolute URL of the target
+			w.Header().Set("X-Proxy-Authorization", r.Header.Get("Proxy-Authorization"))
+			_, _ = w.Write([]byte(r.URL.String()))
+		}))
+		defer proxy.Close()
+
+		proxyURL, err := BuildProxyURL(&gwconfig.ProxyConfig{Host: proxy.URL})
+		require.NoError(t, err)
+
+		tester := NewAPITester()
+		require.NoError(t, tester.Initialize(&gwconfig.APIConfig{
+			BaseURL: "http://api.internal.test",
+			Timeout: 5 * time.Second,
+			Proxy:   &gwconfig.ProxyConfig{Host: proxyURL.Hostname(), Port: mustAtoi(t, proxyURL.Port()), Username: "user", Password: "pass"},
+		}))
+
+		response, err := tester.Get("/users?id=1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "http://api.internal.test/users?id=1", string(response.Body))
+		assert.Equal(t, "Basic dXNlcjpwYXNz", response.Headers["X-Proxy-Authorization"])
+	})
+
+	t.Run("proxy URLs", func(t *testing.T) {
+		proxyURL, err := BuildProxyURL(&gwconfig.ProxyConfig{Host: "socks5://proxy.local", Port: 1080})
+		require.NoError(t, err)
+		assert.Equal(t, "socks5://proxy.local:1080", proxyURL.String())
+
+		_, err = BuildProxyURL(&gwconfig.ProxyConfig{})
+		assert.Error(t, err)
+	})
+
+	t.Run("redirects", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/old" {
+				http.Redirect(w, r, "/new", http.StatusFound)
+				return
+			}
+			_, _ = w.Write([]byte(r.URL.Path))
+		}))
+		defer server.Close()
+
+		following := NewAPITester()
+		require.NoError(t, following.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, Timeout: 5 * time.Second, FollowRedirects: true}))
+		response, err := following.Get("/old", nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "/new", string(response.Body))
+
+		notFollowing := NewAPITester()
+		require.NoError(t, notFollowing.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, Timeout: 5 * time.Second}))
+		response, err = notFollowing.Get("/old", nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusFound, response.StatusCode)
+		assert.Equal(t, "/new", response.Headers["Location"])
+	})
+
+	t.Run("connection settings", func(t *testing.T) {
+		tester := NewAPITester()
+		require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: "http://localhost", MaxConnections: 4, KeepAlive: true}))
+
+		transport, ok := tester.client.GetClient().Transport.(*http.Transport)
+		require.True(t, ok)
+		assert.Equal(t, 4, transport.MaxConnsPerHost)
+		assert.Equal(t, 4, transport.MaxIdleConnsPerHost)
+		assert.False(t, transport.DisableKeepAlives)
+
+		require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: "http://localhost"}))
+		transport = tester.client.GetClient().Transport.(*http.Transport)
+		assert.True(t, transport.DisableKeepAlives)
+		assert.Zero(t, transport.MaxConnsPerHost)
+	})
+}
+
+// mustAtoi parses a port number
+func mustAtoi(t *testing.T, value string) int {
+	t.Helper()
+
+	port, err := strconv.Atoi(value)
+	require.NoError(t, err)
+	return port
+}
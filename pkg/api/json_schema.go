@@ -0,0 +1,1084 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gowright/framework/pkg/core"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported JSON Schema drafts
+const (
+	SchemaDraft202012 = "2020-12"
+	SchemaDraft07     = "draft-07"
+)
+
+// maxSchemaRefDepth bounds $ref chains that do not consume any of the instance
+const maxSchemaRefDepth = 64
+
+// SchemaViolation describes one way in which a document fails a JSON Schema
+type SchemaViolation struct {
+	InstancePath string `json:"instance_path"` // JSON pointer to the offending value, "" for the document root
+	SchemaPath   string `json:"schema_path"`   // JSON pointer to the failing keyword, following $ref
+	Keyword      string `json:"keyword"`
+	Message      string `json:"message"`
+}
+
+// Error implements the error interface
+func (v SchemaViolation) Error() string {
+	return fmt.Sprintf("%s: %s: %s", displayPointer(v.InstancePath), v.Keyword, v.Message)
+}
+
+// JSONSchema is a loaded JSON Schema. References are resolved against the document containing
+// them; relative file references are resolved against that document's directory.
+type JSONSchema struct {
+	doc      *schemaDocument
+	schema   interface{}
+	draft    string
+	nullable bool // OpenAPI 3.0 documents mark optional null values with "nullable"
+
+	mutex    sync.Mutex
+	docs     map[string]*schemaDocument
+	patterns map[string]*regexp.Regexp
+}
+
+// schemaDocument is a parsed document that $ref pointers are resolved against
+type schemaDocument struct {
+	path string // empty for inline schemas
+	root interface{}
+}
+
+// CompileJSONSchema compiles an inline schema given as a JSON string, []byte, json.RawMessage
+// or an already decoded value. draft is used when the schema has no $schema keyword.
+func CompileJSONSchema(schema interface{}, draft string) (*JSONSchema, error) {
+	var root interface{}
+	switch s := schema.(type) {
+	case string:
+		if err := json.Unmarshal([]byte(s), &root); err != nil {
+			return nil, core.NewGowrightError(core.ConfigurationError, "failed to parse JSON schema", err)
+		}
+	case []byte:
+		if err := json.Unmarshal(s, &root); err != nil {
+			return nil, core.NewGowrightError(core.ConfigurationError, "failed to parse JSON schema", err)
+		}
+	case json.RawMessage:
+		if err := json.Unmarshal(s, &root); err != nil {
+			return nil, core.NewGowrightError(core.ConfigurationError, "failed to parse JSON schema", err)
+		}
+	default:
+		normalized, err := normalizeJSON(s)
+		if err != nil {
+			return nil, core.NewGowrightError(core.ConfigurationError, "failed to convert JSON schema", err)
+		}
+		root = normalized
+	}
+
+	return newJSONSchema(&schemaDocument{root: root}, "", draft)
+}
+
+// LoadJSONSchemaFile loads a JSON or YAML schema file
+func LoadJSONSchemaFile(path, draft string) (*JSONSchema, error) {
+	return LoadJSONSchemaRef(path, draft)
+}
+
+// LoadJSONSchemaRef loads the schema a reference points to, e.g.
+// "openapi.yaml#/components/schemas/User". Without a fragment the whole document is the schema.
+func LoadJSONSchemaRef(ref, draft string) (*JSONSchema, error) {
+	path, fragment, _ := strings.Cut(ref, "#")
+	if path == "" {
+		return nil, core.NewGowrightError(core.ConfigurationError, "schema reference must name a document", nil).
+			WithContext("ref", ref)
+	}
+
+	doc, err := loadSchemaDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	return newJSONSchema(doc, fragment, draft)
+}
+
+// LoadJSONSchema loads the schema described by a source
+func LoadJSONSchema(source *core.JSONSchemaSource) (*JSONSchema, error) {
+	switch {
+	case source == nil:
+		return nil, core.NewGowrightError(core.ConfigurationError, "a JSON schema source is required", nil)
+	case source.Inline != nil:
+		return CompileJSONSchema(source.Inline, source.Draft)
+	case source.File != "":
+		return LoadJSONSchemaFile(source.File, source.Draft)
+	case source.Ref != "":
+		return LoadJSONSchemaRef(source.Ref, source.Draft)
+	default:
+		return nil, core.NewGowrightError(core.ConfigurationError, "JSON schema source must set inline, file or ref", nil)
+	}
+}
+
+// newJSONSchema creates a schema whose entry point is the given fragment of a document
+func newJSONSchema(doc *schemaDocument, fragment, draft string) (*JSONSchema, error) {
+	entry, err := resolvePointer(doc.root, fragment)
+	if err != nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "failed to resolve JSON schema", err).
+			WithContext("document", doc.path).
+			WithContext("pointer", fragment)
+	}
+
+	switch entry.(type) {
+	case bool, map[string]interface{}:
+	default:
+		return nil, core.NewGowrightError(core.ConfigurationError, "JSON schema must be an object or a boolean", nil).
+			WithContext("document", doc.path).
+			WithContext("pointer", fragment)
+	}
+
+	s := &JSONSchema{
+		doc:      doc,
+		schema:   entry,
+		docs:     make(map[string]*schemaDocument),
+		patterns: make(map[string]*regexp.Regexp),
+	}
+	if doc.path != "" {
+		s.docs[doc.path] = doc
+	}
+
+	if s.draft, s.nullable, err = detectDraft(doc.root, entry, draft); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Draft returns the draft the schema is evaluated with
+func (s *JSONSchema) Draft() string {
+	return s.draft
+}
+
+// Validate validates a decoded JSON value, or any value that marshals to JSON, and returns
+// every violation found
+func (s *JSONSchema) Validate(document interface{}) ([]SchemaViolation, error) {
+	instance, err := normalizeJSON(document)
+	if err != nil {
+		return nil, core.NewGowrightError(core.AssertionError, "failed to convert document to JSON", err)
+	}
+
+	violations, _ := s.validate(s.doc, s.schema, instance, "", "", 0)
+	return violations, nil
+}
+
+// ValidateJSON validates a JSON document and returns every violation found
+func (s *JSONSchema) ValidateJSON(data []byte) ([]SchemaViolation, error) {
+	var instance interface{}
+	if err := json.Unmarshal(data, &instance); err != nil {
+		return nil, core.NewGowrightError(core.AssertionError, "failed to parse response body as JSON", err)
+	}
+
+	violations, _ := s.validate(s.doc, s.schema, instance, "", "", 0)
+	return violations, nil
+}
+
+// evaluated records the properties and items of an instance that subschemas have evaluated,
+// as required by unevaluatedProperties and unevaluatedItems
+type evaluated struct {
+	props    map[string]bool
+	allProps bool
+	items    map[int]bool
+	allItems bool
+}
+
+// newEvaluated creates an empty evaluation record
+func newEvaluated() *evaluated {
+	return &evaluated{props: make(map[string]bool), items: make(map[int]bool)}
+}
+
+// merge adds the evaluations of a valid subschema
+func (e *evaluated) merge(other *evaluated) {
+	if other == nil {
+		return
+	}
+	for name := range other.props {
+		e.props[name] = true
+	}
+	for index := range other.items {
+		e.items[index] = true
+	}
+	e.allProps = e.allProps || other.allProps
+	e.allItems = e.allItems || other.allItems
+}
+
+// validate validates an instance against a schema and returns the violations and, when the
+// instance is valid, what the schema evaluated
+func (s *JSONSchema) validate(doc *schemaDocument, schema, instance interface{}, instancePath, schemaPath string, depth int) ([]SchemaViolation, *evaluated) {
+	var violations []SchemaViolation
+	fail := func(keyword, format string, args ...interface{}) {
+		violations = append(violations, SchemaViolation{
+			InstancePath: instancePath,
+			SchemaPath:   schemaPath + "/" + keyword,
+			Keyword:      keyword,
+			Message:      fmt.Sprintf(format, args...),
+		})
+	}
+
+	var keywords map[string]interface{}
+	switch sch := schema.(type) {
+	case bool:
+		if sch {
+			return nil, newEvaluated()
+		}
+		return []SchemaViolation{{
+			InstancePath: instancePath,
+			SchemaPath:   schemaPath,
+			Keyword:      "false",
+			Message:      "no value is allowed here",
+		}}, nil
+	case map[string]interface{}:
+		keywords = sch
+	default:
+		return []SchemaViolation{{
+			InstancePath: instancePath,
+			SchemaPath:   schemaPath,
+			Keyword:      "schema",
+			Message:      fmt.Sprintf("invalid schema of type %T", schema),
+		}}, nil
+	}
+
+	ev := newEvaluated()
+	apply := func(sub interface{}, path string) bool {
+		subViolations, subEvaluated := s.validate(doc, sub, instance, instancePath, schemaPath+path, depth)
+		violations = append(violations, subViolations...)
+		if len(subViolations) == 0 {
+			ev.merge(subEvaluated)
+		}
+		return len(subViolations) == 0
+	}
+
+	if ref, ok := keywords["$ref"].(string); ok {
+		target, targetDoc, err := s.resolveRef(doc, ref)
+		switch {
+		case err != nil:
+			fail("$ref", "cannot resolve %q: %v", ref, err)
+		case depth >= maxSchemaRefDepth:
+			fail("$ref", "maximum reference depth of %d exceeded at %q", maxSchemaRefDepth, ref)
+		default:
+			refViolations, refEvaluated := s.validate(targetDoc, target, instance, instancePath, schemaPath+"/$ref", depth+1)
+			violations = append(violations, refViolations...)
+			if len(refViolations) == 0 {
+				ev.merge(refEvaluated)
+			}
+		}
+		// Keywords next to $ref are ignored before 2019-09
+		if s.draft == SchemaDraft07 {
+			return violations, ev
+		}
+	}
+
+	if instance == nil && s.nullable && keywords["nullable"] == true {
+		return violations, ev
+	}
+
+	if expected, ok := keywords["type"]; ok && !matchesType(expected, instance) {
+		fail("type", "expected %s, got %s", describeTypes(expected), jsonType(instance))
+	}
+	if values, ok := keywords["enum"].([]interface{}); ok {
+		found := false
+		for _, value := range values {
+			if jsonEqual(value, instance) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("enum", "value %s is not one of %s", compactJSON(instance), compactJSON(values))
+		}
+	}
+	if value, ok := keywords["const"]; ok && !jsonEqual(value, instance) {
+		fail("const", "value %s must equal %s", compactJSON(instance), compactJSON(value))
+	}
+
+	switch value := instance.(type) {
+	case float64:
+		s.validateNumber(keywords, value, fail)
+	case string:
+		s.validateString(keywords, value, fail)
+	case []interface{}:
+		itemViolations := s.validateArray(doc, keywords, value, instancePath, schemaPath, depth, ev, fail)
+		violations = append(violations, itemViolations...)
+	case map[string]interface{}:
+		propViolations := s.validateObject(doc, keywords, value, instancePath, schemaPath, depth, ev, fail)
+		violations = append(violations, propViolations...)
+	}
+
+	if subschemas, ok := keywords["allOf"].([]interface{}); ok {
+		for i, sub := range subschemas {
+			apply(sub, "/allOf/"+strconv.Itoa(i))
+		}
+	}
+	if subschemas, ok := keywords["anyOf"].([]interface{}); ok {
+		matched := 0
+		for i, sub := range subschemas {
+			if subEvaluated, ok := s.matches(doc, sub, instance, instancePath, schemaPath+"/anyOf/"+strconv.Itoa(i), depth); ok {
+				matched++
+				ev.merge(subEvaluated)
+			}
+		}
+		if matched == 0 {
+			fail("anyOf", "value does not match any of the %d schemas", len(subschemas))
+		}
+	}
+	if subschemas, ok := keywords["oneOf"].([]interface{}); ok {
+		var matched []string
+		for i, sub := range subschemas {
+			if subEvaluated, ok := s.matches(doc, sub, instance, instancePath, schemaPath+"/oneOf/"+strconv.Itoa(i), depth); ok {
+				matched = append(matched, strconv.Itoa(i))
+				ev.merge(subEvaluated)
+			}
+		}
+		switch len(matched) {
+		case 1:
+		case 0:
+			fail("oneOf", "value does not match any of the %d schemas", len(subschemas))
+		default:
+			fail("oneOf", "value matches schemas %s, expected exactly one", strings.Join(matched, ", "))
+		}
+	}
+	if sub, ok := keywords["not"]; ok {
+		if _, matched := s.matches(doc, sub, instance, instancePath, schemaPath+"/not", depth); matched {
+			fail("not", "value must not match the schema")
+		}
+	}
+	if condition, ok := keywords["if"]; ok {
+		ifEvaluated, matched := s.matches(doc, condition, instance, instancePath, schemaPath+"/if", depth)
+		if matched {
+			ev.merge(ifEvaluated)
+			if then, ok := keywords["then"]; ok {
+				apply(then, "/then")
+			}
+		} else if otherwise, ok := keywords["else"]; ok {
+			apply(otherwise, "/else")
+		}
+	}
+
+	if s.draft == SchemaDraft202012 {
+		switch value := instance.(type) {
+		case []interface{}:
+			if sub, ok := keywords["unevaluatedItems"]; ok && !ev.allItems {
+				for i, item := range value {
+					if ev.items[i] {
+						continue
+					}
+					itemViolations, _ := s.validate(doc, sub, item, instancePath+"/"+strconv.Itoa(i), schemaPath+"/unevaluatedItems", depth)
+					violations = append(violations, itemViolations...)
+				}
+				ev.allItems = true
+			}
+		case map[string]interface{}:
+			if sub, ok := keywords["unevaluatedProperties"]; ok && !ev.allProps {
+				for _, name := range sortedKeys(value) {
+					if ev.props[name] {
+						continue
+					}
+					propViolations, _ := s.validate(doc, sub, value[name], instancePath+"/"+escapePointer(name), schemaPath+"/unevaluatedProperties", depth)
+					violations = append(violations, propViolations...)
+				}
+				ev.allProps = true
+			}
+		}
+	}
+
+	return violations, ev
+}
+
+// matches reports whether an instance is valid against a subschema
+func (s *JSONSchema) matches(doc *schemaDocument, schema, instance interface{}, instancePath, schemaPath string, depth int) (*evaluated, bool) {
+	violations, ev := s.validate(doc, schema, instance, instancePath, schemaPath, depth)
+	return ev, len(violations) == 0
+}
+
+// validateNumber applies the numeric keywords
+func (s *JSONSchema) validateNumber(keywords map[string]interface{}, value float64, fail func(string, string, ...interface{})) {
+	if limit, ok := keywords["minimum"].(float64); ok && value < limit {
+		fail("minimum", "%v is less than the minimum of %v", value, limit)
+	}
+	if limit, ok := keywords["maximum"].(float64); ok && value > limit {
+		fail("maximum", "%v is greater than the maximum of %v", value, limit)
+	}
+	if limit, ok := keywords["exclusiveMinimum"].(float64); ok && value <= limit {
+		fail("exclusiveMinimum", "%v must be greater than %v", value, limit)
+	}
+	if limit, ok := keywords["exclusiveMaximum"].(float64); ok && value >= limit {
+		fail("exclusiveMaximum", "%v must be less than %v", value, limit)
+	}
+	if divisor, ok := keywords["multipleOf"].(float64); ok && divisor > 0 {
+		quotient := value / divisor
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9*math.Max(1, math.Abs(quotient)) {
+			fail("multipleOf", "%v is not a multiple of %v", value, divisor)
+		}
+	}
+}
+
+// validateString applies the string keywords
+func (s *JSONSchema) validateString(keywords map[string]interface{}, value string, fail func(string, string, ...interface{})) {
+	length := utf8.RuneCountInString(value)
+	if limit, ok := keywords["minLength"].(float64); ok && float64(length) < limit {
+		fail("minLength", "length %d is less than the minimum of %v", length, limit)
+	}
+	if limit, ok := keywords["maxLength"].(float64); ok && float64(length) > limit {
+		fail("maxLength", "length %d is greater than the maximum of %v", length, limit)
+	}
+	if pattern, ok := keywords["pattern"].(string); ok {
+		re, err := s.compilePattern(pattern)
+		if err != nil {
+			fail("pattern", "invalid pattern %q: %v", pattern, err)
+		} else if !re.MatchString(value) {
+			fail("pattern", "%q does not match pattern %q", value, pattern)
+		}
+	}
+	if format, ok := keywords["format"].(string); ok {
+		if check, known := schemaFormats[format]; known && !check(value) {
+			fail("format", "%q is not a valid %s", value, format)
+		}
+	}
+}
+
+// validateArray applies the array keywords and records the items they evaluate
+func (s *JSONSchema) validateArray(doc *schemaDocument, keywords map[string]interface{}, items []interface{}, instancePath, schemaPath string, depth int, ev *evaluated, fail func(string, string, ...interface{})) []SchemaViolation {
+	var violations []SchemaViolation
+	validateItem := func(i int, sub interface{}, keywordPath string) bool {
+		itemViolations, _ := s.validate(doc, sub, items[i], instancePath+"/"+strconv.Itoa(i), schemaPath+keywordPath, depth)
+		violations = append(violations, itemViolations...)
+		return len(itemViolations) == 0
+	}
+
+	if limit, ok := keywords["minItems"].(float64); ok && float64(len(items)) < limit {
+		fail("minItems", "array has %d items, fewer than the minimum of %v", len(items), limit)
+	}
+	if limit, ok := keywords["maxItems"].(float64); ok && float64(len(items)) > limit {
+		fail("maxItems", "array has %d items, more than the maximum of %v", len(items), limit)
+	}
+	if unique, ok := keywords["uniqueItems"].(bool); ok && unique {
+		for i := 1; i < len(items); i++ {
+			for j := 0; j < i; j++ {
+				if jsonEqual(items[i], items[j]) {
+					fail("uniqueItems", "items %d and %d are equal", j, i)
+					i = len(items)
+					break
+				}
+			}
+		}
+	}
+
+	validateTuple := func(tuple []interface{}, keyword string) {
+		for i := 0; i < len(tuple) && i < len(items); i++ {
+			validateItem(i, tuple[i], "/"+keyword+"/"+strconv.Itoa(i))
+			ev.items[i] = true
+		}
+	}
+	validateRest := func(from int, sub interface{}, keyword string) {
+		for i := from; i < len(items); i++ {
+			validateItem(i, sub, "/"+keyword)
+		}
+		ev.allItems = true
+	}
+
+	// Tuple validation moved from "items" and "additionalItems" to "prefixItems" and "items" in 2020-12
+	if s.draft == SchemaDraft07 {
+		switch itemsKeyword := keywords["items"].(type) {
+		case nil:
+		case []interface{}:
+			validateTuple(itemsKeyword, "items")
+			if sub, ok := keywords["additionalItems"]; ok {
+				validateRest(len(itemsKeyword), sub, "additionalItems")
+			}
+		default:
+			validateRest(0, itemsKeyword, "items")
+		}
+	} else {
+		prefix := 0
+		if tuple, ok := keywords["prefixItems"].([]interface{}); ok {
+			validateTuple(tuple, "prefixItems")
+			prefix = len(tuple)
+		}
+		if sub, ok := keywords["items"]; ok {
+			validateRest(prefix, sub, "items")
+		}
+	}
+
+	if sub, ok := keywords["contains"]; ok {
+		matched := 0
+		for i, item := range items {
+			if _, valid := s.matches(doc, sub, item, instancePath+"/"+strconv.Itoa(i), schemaPath+"/contains", depth); valid {
+				matched++
+				ev.items[i] = true
+			}
+		}
+
+		minimum, maximum := 1.0, math.Inf(1)
+		if s.draft == SchemaDraft202012 {
+			if limit, ok := keywords["minContains"].(float64); ok {
+				minimum = limit
+			}
+			if limit, ok := keywords["maxContains"].(float64); ok {
+				maximum = limit
+			}
+		}
+		if float64(matched) < minimum {
+			fail("contains", "array contains %d matching items, expected at least %v", matched, minimum)
+		}
+		if float64(matched) > maximum {
+			fail("maxContains", "array contains %d matching items, expected at most %v", matched, maximum)
+		}
+	}
+
+	return violations
+}
+
+// validateObject applies the object keywords and records the properties they evaluate
+func (s *JSONSchema) validateObject(doc *schemaDocument, keywords map[string]interface{}, object map[string]interface{}, instancePath, schemaPath string, depth int, ev *evaluated, fail func(string, string, ...interface{})) []SchemaViolation {
+	var violations []SchemaViolation
+	validateProperty := func(name string, sub interface{}, keywordPath string) bool {
+		propViolations, _ := s.validate(doc, sub, object[name], instancePath+"/"+escapePointer(name), schemaPath+keywordPath, depth)
+		violations = append(violations, propViolations...)
+		return len(propViolations) == 0
+	}
+
+	if limit, ok := keywords["minProperties"].(float64); ok && float64(len(object)) < limit {
+		fail("minProperties", "object has %d properties, fewer than the minimum of %v", len(object), limit)
+	}
+	if limit, ok := keywords["maxProperties"].(float64); ok && float64(len(object)) > limit {
+		fail("maxProperties", "object has %d properties, more than the maximum of %v", len(object), limit)
+	}
+	if required, ok := keywords["required"].([]interface{}); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, exists := object[name]; !exists {
+					fail("required", "missing required property %q", name)
+				}
+			}
+		}
+	}
+
+	names := sortedKeys(object)
+	matched := make(map[string]bool)
+
+	if properties, ok := keywords["properties"].(map[string]interface{}); ok {
+		for _, name := range names {
+			if sub, defined := properties[name]; defined {
+				validateProperty(name, sub, "/properties/"+escapePointer(name))
+				matched[name] = true
+			}
+		}
+	}
+	if patterns, ok := keywords["patternProperties"].(map[string]interface{}); ok {
+		for _, pattern := range sortedKeys(patterns) {
+			re, err := s.compilePattern(pattern)
+			if err != nil {
+				fail("patternProperties", "invalid pattern %q: %v", pattern, err)
+				continue
+			}
+			for _, name := range names {
+				if re.MatchString(name) {
+					validateProperty(name, patterns[pattern], "/patternProperties/"+escapePointer(pattern))
+					matched[name] = true
+				}
+			}
+		}
+	}
+	for name := range matched {
+		ev.props[name] = true
+	}
+	if sub, ok := keywords["additionalProperties"]; ok {
+		for _, name := range names {
+			if matched[name] {
+				continue
+			}
+			if sub == false {
+				violations = append(violations, SchemaViolation{
+					InstancePath: instancePath + "/" + escapePointer(name),
+					SchemaPath:   schemaPath + "/additionalProperties",
+					Keyword:      "additionalProperties",
+					Message:      fmt.Sprintf("property %q is not allowed", name),
+				})
+				continue
+			}
+			validateProperty(name, sub, "/additionalProperties")
+		}
+		ev.allProps = true
+	}
+
+	if sub, ok := keywords["propertyNames"]; ok {
+		for _, name := range names {
+			nameViolations, _ := s.validate(doc, sub, name, instancePath+"/"+escapePointer(name), schemaPath+"/propertyNames", depth)
+			violations = append(violations, nameViolations...)
+		}
+	}
+
+	dependentRequired := map[string]interface{}{}
+	dependentSchemas := map[string]interface{}{}
+	requiredKeyword, schemaKeyword := "dependentRequired", "dependentSchemas"
+	if s.draft == SchemaDraft07 {
+		// draft-07 combines both forms in "dependencies"
+		requiredKeyword, schemaKeyword = "dependencies", "dependencies"
+		if dependencies, ok := keywords["dependencies"].(map[string]interface{}); ok {
+			for name, dependency := range dependencies {
+				if _, isList := dependency.([]interface{}); isList {
+					dependentRequired[name] = dependency
+				} else {
+					dependentSchemas[name] = dependency
+				}
+			}
+		}
+	} else {
+		if dependencies, ok := keywords["dependentRequired"].(map[string]interface{}); ok {
+			dependentRequired = dependencies
+		}
+		if dependencies, ok := keywords["dependentSchemas"].(map[string]interface{}); ok {
+			dependentSchemas = dependencies
+		}
+	}
+
+	for _, name := range sortedKeys(dependentRequired) {
+		if _, present := object[name]; !present {
+			continue
+		}
+		required, _ := dependentRequired[name].([]interface{})
+		var missing []string
+		for _, dependency := range required {
+			if dependency, ok := dependency.(string); ok {
+				if _, exists := object[dependency]; !exists {
+					missing = append(missing, dependency)
+				}
+			}
+		}
+		if len(missing) > 0 {
+			fail(requiredKeyword, "property %q requires properties: %s", name, strings.Join(missing, ", "))
+		}
+	}
+	for _, name := range sortedKeys(dependentSchemas) {
+		if _, present := object[name]; !present {
+			continue
+		}
+		depViolations, depEvaluated := s.validate(doc, dependentSchemas[name], object, instancePath, schemaPath+"/"+schemaKeyword+"/"+escapePointer(name), depth)
+		violations = append(violations, depViolations...)
+		if len(depViolations) == 0 {
+			ev.merge(depEvaluated)
+		}
+	}
+
+	return violations
+}
+
+// resolveRef resolves a $ref against the document that contains it
+func (s *JSONSchema) resolveRef(doc *schemaDocument, ref string) (interface{}, *schemaDocument, error) {
+	path, fragment, _ := strings.Cut(ref, "#")
+
+	target := doc
+	if path != "" {
+		if strings.Contains(path, "://") {
+			return nil, nil, errors.New("remote references are not supported")
+		}
+		if !filepath.IsAbs(path) && doc.path != "" {
+			path = filepath.Join(filepath.Dir(doc.path), path)
+		}
+
+		loaded, err := s.document(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		target = loaded
+	}
+
+	schema, err := resolvePointer(target.root, fragment)
+	if err != nil {
+		return nil, nil, err
+	}
+	return schema, target, nil
+}
+
+// document returns a referenced document, loading it on first use
+func (s *JSONSchema) document(path string) (*schemaDocument, error) {
+	if absolute, err := filepath.Abs(path); err == nil {
+		path = absolute
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if doc, exists := s.docs[path]; exists {
+		return doc, nil
+	}
+	doc, err := loadSchemaDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	s.docs[path] = doc
+	return doc, nil
+}
+
+// compilePattern compiles a pattern once per schema
+func (s *JSONSchema) compilePattern(pattern string) (*regexp.Regexp, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if re, exists := s.patterns[pattern]; exists {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	s.patterns[pattern] = re
+	return re, nil
+}
+
+// SchemaAssertionSteps validates a response body against a schema source and returns one failed
+// step per violation, or a single passed step when the body is valid. The returned error is
+// non-nil when the schema cannot be loaded or the body does not satisfy it.
+func SchemaAssertionSteps(body []byte, source *core.JSONSchemaSource) ([]core.AssertionStep, error) {
+	start := time.Now()
+	step := func(description string, status core.TestStatus, err error, expected, actual interface{}) core.AssertionStep {
+		end := time.Now()
+		return core.AssertionStep{
+			Name:        "JSON schema",
+			Description: description,
+			Status:      status,
+			Error:       err,
+			Expected:    expected,
+			Actual:      actual,
+			StartTime:   start,
+			EndTime:     end,
+			Duration:    end.Sub(start),
+		}
+	}
+
+	schema, err := LoadJSONSchema(source)
+	if err == nil {
+		var violations []SchemaViolation
+		if violations, err = schema.ValidateJSON(body); err == nil {
+			if len(violations) == 0 {
+				return []core.AssertionStep{step("Response body matches JSON schema", core.TestStatusPassed, nil, nil, nil)}, nil
+			}
+
+			steps := make([]core.AssertionStep, 0, len(violations))
+			errs := make([]error, 0, len(violations))
+			for _, violation := range violations {
+				steps = append(steps, step(
+					fmt.Sprintf("JSON schema violation at %s (%s)", displayPointer(violation.InstancePath), violation.Keyword),
+					core.TestStatusFailed, violation, violation.SchemaPath, violation.InstancePath))
+				errs = append(errs, violation)
+			}
+			return steps, core.NewGowrightError(core.AssertionError,
+				fmt.Sprintf("response body has %d JSON schema violation(s)", len(violations)), errors.Join(errs...)).
+				WithContext("violations", violations)
+		}
+	}
+
+	return []core.AssertionStep{step("JSON schema validation", core.TestStatusFailed, err, nil, nil)}, err
+}
+
+// schemaFormats holds the "format" values that are checked; other formats are annotations only
+var schemaFormats = map[string]func(string) bool{
+	"date-time": func(value string) bool {
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	},
+	"date": func(value string) bool {
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	},
+	"time": func(value string) bool {
+		_, err := time.Parse("15:04:05.999999999Z07:00", value)
+		return err == nil
+	},
+	"email": func(value string) bool {
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	},
+	"uuid": regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString,
+	"uri": func(value string) bool {
+		parsed, err := url.Parse(value)
+		return err == nil && parsed.IsAbs()
+	},
+	"uri-reference": func(value string) bool {
+		_, err := url.Parse(value)
+		return err == nil
+	},
+	"ipv4": func(value string) bool {
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	},
+	"ipv6": func(value string) bool {
+		return net.ParseIP(value) != nil && strings.Contains(value, ":")
+	},
+	"hostname": regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*$`).MatchString,
+	"regex": func(value string) bool {
+		_, err := regexp.Compile(value)
+		return err == nil
+	},
+}
+
+// loadSchemaDocument reads a JSON or YAML document
+func loadSchemaDocument(path string) (*schemaDocument, error) {
+	content, err := os.ReadFile(path) // #nosec G304 -- schema paths are supplied by the test author
+	if err != nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "failed to read JSON schema", err).
+			WithContext("path", path)
+	}
+	if absolute, err := filepath.Abs(path); err == nil {
+		path = absolute
+	}
+
+	var root interface{}
+	if err := json.Unmarshal(content, &root); err != nil {
+		var decoded interface{}
+		if yamlErr := yaml.Unmarshal(content, &decoded); yamlErr != nil {
+			return nil, core.NewGowrightError(core.ConfigurationError, "failed to parse JSON schema", yamlErr).
+				WithContext("path", path)
+		}
+		if root, err = normalizeJSON(stringKeys(decoded)); err != nil {
+			return nil, core.NewGowrightError(core.ConfigurationError, "failed to convert YAML schema", err).
+				WithContext("path", path)
+		}
+	}
+
+	return &schemaDocument{path: path, root: root}, nil
+}
+
+// detectDraft picks the draft from $schema, the OpenAPI version of the document or the fallback
+func detectDraft(root, entry interface{}, fallback string) (string, bool, error) {
+	for _, candidate := range []interface{}{entry, root} {
+		keywords, ok := candidate.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if uri, ok := keywords["$schema"].(string); ok {
+			switch {
+			case strings.Contains(uri, "draft-07"), strings.Contains(uri, "draft-06"):
+				return SchemaDraft07, false, nil
+			case strings.Contains(uri, "2020-12"), strings.Contains(uri, "2019-09"):
+				return SchemaDraft202012, false, nil
+			default:
+				return "", false, core.NewGowrightError(core.ConfigurationError, "unsupported JSON schema draft", nil).
+					WithContext("$schema", uri)
+			}
+		}
+		if version, ok := keywords["openapi"].(string); ok {
+			if strings.HasPrefix(version, "3.0") {
+				return SchemaDraft07, true, nil
+			}
+			return SchemaDraft202012, false, nil
+		}
+	}
+
+	switch fallback {
+	case "", SchemaDraft202012:
+		return SchemaDraft202012, false, nil
+	case SchemaDraft07:
+		return SchemaDraft07, false, nil
+	default:
+		return "", false, core.NewGowrightError(core.ConfigurationError, "unsupported JSON schema draft", nil).
+			WithContext("draft", fallback)
+	}
+}
+
+// resolvePointer resolves a JSON pointer fragment such as "/components/schemas/User"
+func resolvePointer(root interface{}, fragment string) (interface{}, error) {
+	if fragment == "" {
+		return root, nil
+	}
+	if !strings.HasPrefix(fragment, "/") {
+		return nil, fmt.Errorf("unsupported fragment %q, expected a JSON pointer", fragment)
+	}
+
+	current := root
+	for _, token := range strings.Split(fragment[1:], "/") {
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, exists := node[token]
+			if !exists {
+				return nil, fmt.Errorf("%q not found in %s", token, fragment)
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("invalid index %q in %s", token, fragment)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("cannot descend into %q in %s", token, fragment)
+		}
+	}
+	return current, nil
+}
+
+// normalizeJSON converts a value into its decoded JSON form, so numbers are float64 throughout
+func normalizeJSON(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case nil, bool, float64, string:
+		return value, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	err = json.Unmarshal(data, &normalized)
+	return normalized, err
+}
+
+// stringKeys converts YAML mappings with non-string keys, such as status codes, to string keys
+func stringKeys(value interface{}) interface{} {
+	switch node := value.(type) {
+	case map[string]interface{}:
+		for key, child := range node {
+			node[key] = stringKeys(child)
+		}
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(node))
+		for key, child := range node {
+			converted[fmt.Sprint(key)] = stringKeys(child)
+		}
+		return converted
+	case []interface{}:
+		for i, child := range node {
+			node[i] = stringKeys(child)
+		}
+	}
+	return value
+}
+
+// matchesType reports whether an instance has one of the types named by a "type" keyword
+func matchesType(expected, instance interface{}) bool {
+	names, ok := expected.([]interface{})
+	if !ok {
+		names = []interface{}{expected}
+	}
+
+	actual := jsonType(instance)
+	for _, name := range names {
+		if name == actual || (name == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// describeTypes formats a "type" keyword for messages
+func describeTypes(expected interface{}) string {
+	names, ok := expected.([]interface{})
+	if !ok {
+		return fmt.Sprint(expected)
+	}
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprint(name)
+	}
+	return strings.Join(parts, " or ")
+}
+
+// jsonType returns the JSON Schema type name of a decoded value
+func jsonType(instance interface{}) string {
+	switch value := instance.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if value == math.Trunc(value) && !math.IsInf(value, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", instance)
+	}
+}
+
+// jsonEqual compares two decoded JSON values
+func jsonEqual(a, b interface{}) bool {
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, value := range x {
+			other, exists := y[key]
+			if !exists || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+// compactJSON formats a value for messages
+func compactJSON(value interface{}) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// escapePointer escapes a property name for use as a JSON pointer token
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// displayPointer shows the document root as "/" in messages
+func displayPointer(pointer string) string {
+	if pointer == "" {
+		return "/"
+	}
+	return pointer
+}
+
+// sortedKeys returns the keys of a map in order, so violations are reported deterministically
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
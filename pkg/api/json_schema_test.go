@@ -0,0 +1,237 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gwconfig "github.com/gowright/framework/pkg/config"
+	"github.com/gowright/framework/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const userSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["id", "email", "roles"],
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"email": {"type": "string", "format": "email"},
+		"roles": {"type": "array", "items": {"$ref": "#/$defs/role"}, "uniqueItems": true}
+	},
+	"additionalProperties": false,
+	"$defs": {
+		"role": {"enum": ["admin", "member"]}
+	}
+}`
+
+const petstoreOpenAPI = `openapi: 3.0.3
+info:
+  title: Pets
+  version: "1"
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name, owner]
+      properties:
+        name:
+          type: string
+          minLength: 1
+        tag:
+          type: string
+          nullable: true
+        owner:
+          $ref: "owner.json"
+`
+
+// violationKeys returns "instance path keyword" pairs for compact assertions
+func violationKeys(violations []SchemaViolation) []string {
+	keys := make([]string, len(violations))
+	for i, violation := range violations {
+		keys[i] = violation.InstancePath + " " + violation.Keyword
+	}
+	return keys
+}
+
+func TestJSONSchema_Validate(t *testing.T) {
+	schema, err := CompileJSONSchema(userSchema, "")
+	require.NoError(t, err)
+	assert.Equal(t, SchemaDraft202012, schema.Draft())
+
+	violations, err := schema.ValidateJSON([]byte(`{"id": 1, "email": "alice@example.com", "roles": ["admin"]}`))
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	violations, err = schema.ValidateJSON([]byte(`{"id": 0.5, "email": "not an email", "roles": ["admin", "root", "admin"], "extra": true}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"/email format",
+		"/id type",
+		"/id minimum",
+		"/roles uniqueItems",
+		"/roles/1 enum",
+		"/extra additionalProperties",
+	}, violationKeys(violations))
+	assert.Equal(t, "/properties/roles/items/$ref/enum", violations[4].SchemaPath)
+	assert.Equal(t, `/roles/1: enum: value "root" is not one of ["admin","member"]`, violations[4].Error())
+
+	_, err = schema.ValidateJSON([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestJSONSchema_Keywords(t *testing.T) {
+	tests := []struct {
+		name       string
+		schema     string
+		draft      string
+		document   interface{}
+		violations []string
+	}{
+		{
+			name:       "2020-12 prefixItems",
+			schema:     `{"prefixItems": [{"type": "string"}], "items": {"type": "integer"}}`,
+			document:   []interface{}{"a", 1, "b"},
+			violations: []string{"/2 type"},
+		},
+		{
+			name:       "draft-07 tuple items",
+			schema:     `{"items": [{"type": "string"}], "additionalItems": false}`,
+			draft:      SchemaDraft07,
+			document:   []interface{}{"a", 1},
+			violations: []string{"/1 false"},
+		},
+		{
+			name:       "draft-07 ignores keywords next to $ref",
+			schema:     `{"definitions": {"s": {"type": "string"}}, "$ref": "#/definitions/s", "minLength": 10}`,
+			draft:      SchemaDraft07,
+			document:   "short",
+			violations: []string{},
+		},
+		{
+			name:       "draft-07 dependencies",
+			schema:     `{"dependencies": {"card": ["billing"], "vip": {"required": ["tier"]}}}`,
+			draft:      SchemaDraft07,
+			document:   map[string]interface{}{"card": 1, "vip": true},
+			violations: []string{" dependencies", " required"},
+		},
+		{
+			name:       "unevaluatedProperties sees allOf",
+			schema:     `{"allOf": [{"properties": {"a": true}}], "properties": {"b": true}, "unevaluatedProperties": false}`,
+			document:   map[string]interface{}{"a": 1, "b": 2, "c": 3},
+			violations: []string{"/c false"},
+		},
+		{
+			name:       "oneOf matching twice",
+			schema:     `{"oneOf": [{"type": "number"}, {"type": "integer"}]}`,
+			document:   3,
+			violations: []string{" oneOf"},
+		},
+		{
+			name:       "if then else",
+			schema:     `{"if": {"properties": {"kind": {"const": "card"}}}, "then": {"required": ["number"]}, "else": {"required": ["iban"]}}`,
+			document:   map[string]interface{}{"kind": "card"},
+			violations: []string{" required"},
+		},
+		{
+			name:       "contains with maxContains",
+			schema:     `{"contains": {"type": "string"}, "maxContains": 1}`,
+			document:   []interface{}{"a", "b", 1},
+			violations: []string{" maxContains"},
+		},
+		{
+			name:       "multipleOf and anyOf",
+			schema:     `{"anyOf": [{"multipleOf": 0.1}, {"const": 0.15}], "not": {"type": "integer"}}`,
+			document:   0.3,
+			violations: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := CompileJSONSchema(tt.schema, tt.draft)
+			require.NoError(t, err)
+
+			violations, err := schema.Validate(tt.document)
+			require.NoError(t, err)
+			assert.Equal(t, tt.violations, append([]string{}, violationKeys(violations)...))
+		})
+	}
+}
+
+func TestLoadJSONSchema(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "openapi.yaml"), []byte(petstoreOpenAPI), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "owner.json"), []byte(`{"type": "object", "required": ["id"]}`), 0600))
+
+	t.Run("ref into an OpenAPI document", func(t *testing.T) {
+		schema, err := LoadJSONSchema(&core.JSONSchemaSource{Ref: filepath.Join(dir, "openapi.yaml") + "#/components/schemas/Pet"})
+		require.NoError(t, err)
+		assert.Equal(t, SchemaDraft07, schema.Draft())
+
+		violations, err := schema.ValidateJSON([]byte(`{"name": "Rex", "tag": null, "owner": {"id": 1}}`))
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+
+		violations, err = schema.ValidateJSON([]byte(`{"name": "", "owner": {}}`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/name minLength", "/owner required"}, violationKeys(violations))
+		assert.Equal(t, "/properties/owner/$ref/required", violations[1].SchemaPath)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		schema, err := LoadJSONSchema(&core.JSONSchemaSource{File: filepath.Join(dir, "owner.json")})
+		require.NoError(t, err)
+
+		violations, err := schema.Validate(map[string]interface{}{"id": 1})
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := LoadJSONSchema(&core.JSONSchemaSource{Ref: filepath.Join(dir, "openapi.yaml") + "#/components/schemas/Missing"})
+		assert.Error(t, err)
+		_, err = LoadJSONSchema(&core.JSONSchemaSource{File: filepath.Join(dir, "missing.json")})
+		assert.Error(t, err)
+		_, err = LoadJSONSchema(&core.JSONSchemaSource{Inline: `{"type": "string"}`, Draft: "draft-04"})
+		assert.Error(t, err)
+		_, err = LoadJSONSchema(&core.JSONSchemaSource{})
+		assert.Error(t, err)
+	})
+}
+
+func TestAPITester_ExecuteTest_JSONSchema(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 1, "email": "bob", "roles": ["member"]}`))
+	}))
+	defer server.Close()
+
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, Timeout: 5 * time.Second}))
+
+	result := tester.ExecuteTest(&core.APITest{
+		Name:     "user schema",
+		Method:   "GET",
+		Endpoint: "/users/1",
+		Expected: &core.APIExpectation{StatusCode: http.StatusOK, JSONSchema: &core.JSONSchemaSource{Inline: userSchema}},
+	})
+	assert.Equal(t, core.TestStatusFailed, result.Status)
+	require.Len(t, result.Steps, 2)
+	assert.Equal(t, "JSON schema violation at /email (format)", result.Steps[1].Description)
+	assert.Equal(t, core.TestStatusFailed, result.Steps[1].Status)
+
+	test := NewAPITest("user schema", "GET", "/users/1", tester)
+	assert.Error(t, test.ValidateJSONSchema(`{"type": `))
+	require.NoError(t, test.ValidateJSONSchema(`{"required": ["id"]}`))
+
+	apiResult := test.Execute()
+	assert.Equal(t, core.TestStatusPassed, apiResult.Status, "%v", apiResult.Error)
+	require.Len(t, apiResult.Steps, 1)
+	assert.Equal(t, core.TestStatusPassed, apiResult.Steps[0].Status)
+}
@@ -0,0 +1,578 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/gowright/framework/pkg/assertions"
+	"github.com/gowright/framework/pkg/core"
+)
+
+// defaultVirtualUsers is the number of users of a load test that does not set one
+const defaultVirtualUsers = 10
+
+// maxLoadErrorMessages bounds the distinct error messages kept in load metrics
+const maxLoadErrorMessages = 20
+
+// loadTick is how often arrivals are scheduled at a target rate
+const loadTick = 5 * time.Millisecond
+
+// Latency histogram buckets grow by 1% from 1µs; the last one holds latencies above about six hours
+const (
+	histogramGrowth  = 1.01
+	histogramBuckets = 2400
+)
+
+// ExecuteLoadTest runs the scenario of a load test for its duration and returns the result. With
+// RPS set, iterations start at that rate and are dropped when every virtual user is busy; otherwise
+// each user runs iterations back to back. The metrics are added to the logs and to the metadata
+// under "load", and each threshold becomes an assertion step.
+func (at *APITester) ExecuteLoadTest(test *core.LoadTest) *core.TestCaseResult {
+	startTime := time.Now()
+	result := &core.TestCaseResult{
+		Name:      test.Name,
+		StartTime: startTime,
+		Status:    core.TestStatusPassed,
+		Logs:      []string{},
+	}
+
+	users := test.VirtualUsers
+	if users == 0 {
+		users = defaultVirtualUsers
+	}
+	err := at.validateLoadTest(test)
+	var pool *HTTPClientPool
+	if err == nil {
+		transport := at.loadTransport(users)
+		if closer, ok := transport.(interface{ CloseIdleConnections() }); ok {
+			defer closer.CloseIdleConnections()
+		}
+		pool, err = NewHTTPClientPoolWithFactory(users, test.Duration, func() *resty.Client {
+			return at.newLoadClient(transport)
+		})
+	}
+	if err != nil {
+		result.Status = core.TestStatusError
+		result.Error = err
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(startTime)
+		return result
+	}
+	defer func() { _ = pool.Cleanup() }()
+
+	runner := &loadRunner{
+		tester:    at,
+		test:      test,
+		pool:      pool,
+		collector: newLoadCollector(),
+		started:   time.Now(),
+	}
+	runner.deadline = runner.started.Add(test.Duration)
+	if test.RPS > 0 {
+		runner.runAtRate(users)
+	} else {
+		runner.runUsers(users)
+	}
+	metrics := runner.collector.metrics(time.Since(runner.started))
+
+	result.Logs = append(result.Logs, loadLogs(metrics, users, test.RPS)...)
+	result.Metadata = map[string]interface{}{"load": metrics}
+
+	asserter := assertions.NewAsserter()
+	checkLoadThresholds(asserter, metrics, test.Thresholds)
+	result.Steps = asserter.GetSteps()
+	if asserter.HasFailures() {
+		result.Status = core.TestStatusFailed
+		result.Error = core.NewGowrightError(core.AssertionError, "one or more load thresholds were exceeded", nil).
+			WithContext("requests", metrics.Requests)
+	}
+
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(startTime)
+	return result
+}
+
+// validateLoadTest checks the settings and scenario of a load test
+func (at *APITester) validateLoadTest(test *core.LoadTest) error {
+	if !at.initialized {
+		return core.NewGowrightError(core.ConfigurationError, "API tester is not initialized", nil)
+	}
+
+	var problem string
+	switch {
+	case len(test.Scenario) == 0:
+		problem = "a load test needs at least one API test in its scenario"
+	case test.Duration <= 0:
+		problem = "the duration of a load test must be positive"
+	case test.VirtualUsers < 0 || test.RPS < 0 || test.RampUp < 0 || test.Interval < 0:
+		problem = "virtual users, rps, ramp-up and interval cannot be negative"
+	case test.RampUp > test.Duration:
+		problem = "the ramp-up of a load test cannot exceed its duration"
+	}
+	for i, step := range test.Scenario {
+		if problem != "" {
+			break
+		}
+		switch {
+		case step == nil:
+			problem = fmt.Sprintf("scenario test %d is nil", i+1)
+		case step.RequestBody != nil && step.RequestBody.Reader != nil:
+			problem = fmt.Sprintf("scenario test %q streams its body from a reader, which can only be sent once", step.Name)
+		}
+	}
+	if problem != "" {
+		return core.NewGowrightError(core.ValidationError, problem, nil).WithContext("load_test", test.Name)
+	}
+	return nil
+}
+
+// loadTransport returns the transport shared by the virtual users: the tester's transport without
+// recording, with its own connections and at least one connection per user, so MaxConnections does
+// not cap the load and waiting for a connection is not measured as latency
+func (at *APITester) loadTransport(users int) http.RoundTripper {
+	transport := baseTransport(at.client.GetClient().Transport)
+	httpTransport, ok := transport.(*http.Transport)
+	if !ok {
+		return transport
+	}
+	sized := httpTransport.Clone()
+	if sized.MaxConnsPerHost > 0 && sized.MaxConnsPerHost < users {
+		sized.MaxConnsPerHost = users
+	}
+	sized.MaxIdleConnsPerHost = max(sized.MaxIdleConnsPerHost, users)
+	return sized
+}
+
+// newLoadClient creates the client of a virtual user on transport. It shares the redirect policy,
+// base URL, headers and credentials of the tester's client but has its own cookie jar, and it
+// neither retries nor records requests, so failures and latencies are measured as they happen.
+func (at *APITester) newLoadClient(transport http.RoundTripper) *resty.Client {
+	base := at.client.GetClient()
+	client := resty.NewWithClient(&http.Client{
+		Transport:     transport,
+		CheckRedirect: base.CheckRedirect,
+		Timeout:       base.Timeout,
+		Jar:           newCookieJar(),
+	})
+	client.SetBaseURL(at.client.BaseURL)
+	client.Header = at.client.Header.Clone()
+	client.Token, client.AuthScheme = at.client.Token, at.client.AuthScheme
+	if user := at.client.UserInfo; user != nil {
+		client.SetBasicAuth(user.Username, user.Password)
+	}
+	client.OnBeforeRequest(at.applyToken)
+	client.OnAfterResponse(at.invalidateToken)
+	return client
+}
+
+// loadRunner runs the iterations of a load test
+type loadRunner struct {
+	tester    *APITester
+	test      *core.LoadTest
+	pool      *HTTPClientPool
+	collector *loadCollector
+	started   time.Time
+	deadline  time.Time
+}
+
+// runUsers starts the virtual users one after another over the ramp-up, each running iterations
+// until the deadline
+func (r *loadRunner) runUsers(users int) {
+	var wg sync.WaitGroup
+	for i := 0; i < users; i++ {
+		delay := r.test.RampUp * time.Duration(i) / time.Duration(users)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if time.Until(r.started.Add(delay)) > 0 {
+				time.Sleep(time.Until(r.started.Add(delay)))
+			}
+			r.virtualUser(func() bool { return time.Now().Before(r.deadline) })
+		}()
+	}
+	r.watch(&wg)
+}
+
+// runAtRate starts iterations at the target rate, growing linearly over the ramp-up, on up to
+// users virtual users
+func (r *loadRunner) runAtRate(users int) {
+	arrivals := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < users; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.virtualUser(func() bool {
+				_, ok := <-arrivals
+				return ok
+			})
+		}()
+	}
+
+	go func() {
+		defer close(arrivals)
+		ticker := time.NewTicker(loadTick)
+		defer ticker.Stop()
+		started := 0
+		for now := range ticker.C {
+			if !now.Before(r.deadline) {
+				return
+			}
+			for due := r.arrivalsBy(now.Sub(r.started)); started < due; started++ {
+				select {
+				case arrivals <- struct{}{}:
+				default:
+					r.collector.drop()
+				}
+			}
+		}
+	}()
+	r.watch(&wg)
+}
+
+// arrivalsBy returns the number of iterations due after elapsed time at the target rate
+func (r *loadRunner) arrivalsBy(elapsed time.Duration) int {
+	seconds, rampUp := elapsed.Seconds(), r.test.RampUp.Seconds()
+	if seconds < rampUp {
+		return int(r.test.RPS * seconds * seconds / (2 * rampUp))
+	}
+	return int(r.test.RPS * (seconds - rampUp/2))
+}
+
+// watch waits for the virtual users to finish, taking a snapshot of the metrics every interval
+func (r *loadRunner) watch(wg *sync.WaitGroup) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	if r.test.Interval <= 0 {
+		<-done
+		return
+	}
+
+	ticker := time.NewTicker(r.test.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			r.collector.snapshot(now.Sub(r.started))
+		}
+	}
+}
+
+// virtualUser runs iterations with its own client and variables while next reports true
+func (r *loadRunner) virtualUser(next func() bool) {
+	instance, err := r.pool.AcquireClient(context.Background())
+	if err != nil {
+		r.collector.record(nil, err)
+		return
+	}
+	defer func() { _ = r.pool.ReleaseClient(instance) }()
+
+	// Variables captured by a user carry over to its later requests and iterations only
+	variables := r.tester.variables.NewScope()
+	for next() {
+		r.collector.iteration()
+		r.iterate(instance.Client, variables)
+	}
+}
+
+// iterate sends the requests of the scenario in order, stopping at the first failed one
+func (r *loadRunner) iterate(client *resty.Client, variables *core.Variables) {
+	for _, step := range r.test.Scenario {
+		if !time.Now().Before(r.deadline) {
+			return
+		}
+		scope := variables.NewScope()
+		scope.SetAll(step.Variables)
+		body, err := requestPayload(step.Body, step.RequestBody)
+		var response *core.APIResponse
+		if err == nil {
+			response, err = r.tester.doInScope(client, scope, step.Method, step.Endpoint, body, step.Headers)
+		}
+		if err == nil && step.Expected != nil {
+			asserter := assertions.NewAsserter()
+			validateAPIResponse(asserter, response, step.Expected)
+			for _, assertion := range asserter.GetSteps() {
+				if assertion.Status != assertions.TestStatusPassed {
+					err = fmt.Errorf("%s: %s", step.Name, assertion.Description)
+					break
+				}
+			}
+		}
+		if err == nil {
+			err = variables.Capture(response, step.Captures)
+		}
+		r.collector.record(response, err)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// loadCollector gathers the outcome of the requests of a load test
+type loadCollector struct {
+	mutex         sync.Mutex
+	latencies     latencyHistogram
+	requests      int
+	errors        int
+	iterations    int
+	dropped       int
+	statusCodes   map[int]int
+	errorMessages map[string]int
+	intervals     []core.LoadInterval
+
+	// requests completed since the last snapshot
+	intervalLatencies latencyHistogram
+	intervalRequests  int
+	intervalErrors    int
+	intervalStart     time.Duration
+}
+
+// newLoadCollector creates an empty collector
+func newLoadCollector() *loadCollector {
+	return &loadCollector{statusCodes: make(map[int]int), errorMessages: make(map[string]int)}
+}
+
+// record adds the outcome of a request; response is nil when no response was received
+func (c *loadCollector) record(response *core.APIResponse, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.requests++
+	c.intervalRequests++
+	if response != nil {
+		c.latencies.add(response.Duration)
+		c.intervalLatencies.add(response.Duration)
+		c.statusCodes[response.StatusCode]++
+	}
+	if err != nil {
+		c.errors++
+		c.intervalErrors++
+		message := err.Error()
+		if _, seen := c.errorMessages[message]; seen || len(c.errorMessages) < maxLoadErrorMessages {
+			c.errorMessages[message]++
+		}
+	}
+}
+
+// iteration counts a started iteration
+func (c *loadCollector) iteration() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.iterations++
+}
+
+// drop counts an iteration that could not start because every user was busy
+func (c *loadCollector) drop() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.dropped++
+}
+
+// snapshot closes the current interval at elapsed time from the start of the run
+func (c *loadCollector) snapshot(elapsed time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	interval := core.LoadInterval{
+		Elapsed:  elapsed,
+		Requests: c.intervalRequests,
+		Errors:   c.intervalErrors,
+		P95:      c.intervalLatencies.stats().P95,
+	}
+	if length := elapsed - c.intervalStart; length > 0 {
+		interval.Throughput = float64(c.intervalRequests) / length.Seconds()
+	}
+	c.intervals = append(c.intervals, interval)
+	c.intervalLatencies, c.intervalRequests, c.intervalErrors, c.intervalStart = latencyHistogram{}, 0, 0, elapsed
+}
+
+// metrics summarizes the run after it lasted elapsed time
+func (c *loadCollector) metrics(elapsed time.Duration) *core.LoadMetrics {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	metrics := &core.LoadMetrics{
+		Requests:          c.requests,
+		Errors:            c.errors,
+		Iterations:        c.iterations,
+		DroppedIterations: c.dropped,
+		Duration:          elapsed,
+		Latency:           c.latencies.stats(),
+		StatusCodes:       c.statusCodes,
+		ErrorMessages:     c.errorMessages,
+		Intervals:         c.intervals,
+	}
+	if c.requests > 0 {
+		metrics.ErrorRate = float64(c.errors) / float64(c.requests)
+	}
+	if elapsed > 0 {
+		metrics.Throughput = float64(c.requests) / elapsed.Seconds()
+	}
+	return metrics
+}
+
+// latencyHistogram counts latencies in buckets 1% wider than the previous one, so the percentiles
+// of a soak run are accurate to within 1% in constant memory; min, max and mean are exact
+type latencyHistogram struct {
+	counts []int // allocated by the first sample
+	count  int
+	total  time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+// add counts a latency
+func (h *latencyHistogram) add(latency time.Duration) {
+	if h.counts == nil {
+		h.counts = make([]int, histogramBuckets)
+	}
+	index := 0
+	if latency > time.Microsecond {
+		index = min(int(math.Log(float64(latency)/float64(time.Microsecond))/math.Log(histogramGrowth))+1, histogramBuckets-1)
+	}
+	h.counts[index]++
+	if h.count == 0 || latency < h.min {
+		h.min = latency
+	}
+	h.max = max(h.max, latency)
+	h.count++
+	h.total += latency
+}
+
+// stats returns the distribution of the latencies, using nearest-rank percentiles at the geometric
+// middle of their bucket
+func (h *latencyHistogram) stats() core.LatencyStats {
+	if h.count == 0 {
+		return core.LatencyStats{}
+	}
+	percentile := func(p float64) time.Duration {
+		rank := int(math.Ceil(p * float64(h.count)))
+		seen := 0
+		for index, count := range h.counts {
+			if seen += count; seen >= rank && index < histogramBuckets-1 {
+				middle := time.Duration(float64(time.Microsecond) * math.Pow(histogramGrowth, float64(index)-0.5))
+				return min(max(middle, h.min), h.max)
+			}
+		}
+		return h.max
+	}
+	return core.LatencyStats{
+		Min:  h.min,
+		Mean: h.total / time.Duration(h.count),
+		P50:  percentile(0.50),
+		P90:  percentile(0.90),
+		P95:  percentile(0.95),
+		P99:  percentile(0.99),
+		Max:  h.max,
+	}
+}
+
+// checkLoadThresholds records an assertion step for each threshold of a load test
+func checkLoadThresholds(asserter *assertions.Asserter, metrics *core.LoadMetrics, thresholds *core.LoadThresholds) {
+	if thresholds == nil {
+		return
+	}
+
+	percentiles := []struct {
+		name      string
+		actual    time.Duration
+		threshold time.Duration
+	}{
+		{"p50", metrics.Latency.P50, thresholds.P50},
+		{"p90", metrics.Latency.P90, thresholds.P90},
+		{"p95", metrics.Latency.P95, thresholds.P95},
+		{"p99", metrics.Latency.P99, thresholds.P99},
+	}
+	for _, percentile := range percentiles {
+		if percentile.threshold > 0 {
+			addThresholdStep(asserter, percentile.actual <= percentile.threshold,
+				fmt.Sprintf("%s latency %s within %s", percentile.name, percentile.actual, percentile.threshold),
+				fmt.Sprintf("%s latency %s exceeds %s", percentile.name, percentile.actual, percentile.threshold),
+				percentile.threshold, percentile.actual)
+		}
+	}
+	if thresholds.MaxErrorRate != nil {
+		limit := *thresholds.MaxErrorRate
+		addThresholdStep(asserter, metrics.ErrorRate <= limit,
+			fmt.Sprintf("error rate %.2f%% within %.2f%%", metrics.ErrorRate*100, limit*100),
+			fmt.Sprintf("error rate %.2f%% exceeds %.2f%%", metrics.ErrorRate*100, limit*100),
+			limit, metrics.ErrorRate)
+	}
+	if thresholds.MinThroughput > 0 {
+		addThresholdStep(asserter, metrics.Throughput >= thresholds.MinThroughput,
+			fmt.Sprintf("throughput %.1f/s reaches %.1f/s", metrics.Throughput, thresholds.MinThroughput),
+			fmt.Sprintf("throughput %.1f/s is below %.1f/s", metrics.Throughput, thresholds.MinThroughput),
+			thresholds.MinThroughput, metrics.Throughput)
+	}
+}
+
+// addThresholdStep records a passed or failed threshold step
+func addThresholdStep(asserter *assertions.Asserter, passed bool, passMessage, failMessage string, expected, actual interface{}) {
+	now := time.Now()
+	step := assertions.AssertionStep{
+		Name:        "Threshold",
+		Description: passMessage,
+		Status:      assertions.TestStatusPassed,
+		StartTime:   now,
+		EndTime:     now,
+		Expected:    expected,
+		Actual:      actual,
+	}
+	if !passed {
+		step.Description, step.Status = failMessage, assertions.TestStatusFailed
+		step.Error = core.NewGowrightError(core.AssertionError, failMessage, nil)
+	}
+	asserter.AddStep(step)
+}
+
+// loadLogs describes the metrics of a load test run
+func loadLogs(metrics *core.LoadMetrics, users int, rps float64) []string {
+	target := fmt.Sprintf("%d virtual users", users)
+	if rps > 0 {
+		target = fmt.Sprintf("%g iterations/s on up to %d virtual users", rps, users)
+	}
+	logs := []string{
+		fmt.Sprintf("Load: %d requests in %d iterations over %s with %s, %.1f requests/s, %.2f%% errors",
+			metrics.Requests, metrics.Iterations, metrics.Duration.Round(time.Millisecond), target, metrics.Throughput, metrics.ErrorRate*100),
+		fmt.Sprintf("Latency: min %s, mean %s, p50 %s, p90 %s, p95 %s, p99 %s, max %s",
+			metrics.Latency.Min, metrics.Latency.Mean, metrics.Latency.P50, metrics.Latency.P90,
+			metrics.Latency.P95, metrics.Latency.P99, metrics.Latency.Max),
+	}
+	if metrics.DroppedIterations > 0 {
+		logs = append(logs, fmt.Sprintf("Dropped %d iterations because every virtual user was busy", metrics.DroppedIterations))
+	}
+
+	if len(metrics.StatusCodes) > 0 {
+		codes := make([]int, 0, len(metrics.StatusCodes))
+		for code := range metrics.StatusCodes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		counts := make([]string, len(codes))
+		for i, code := range codes {
+			counts[i] = fmt.Sprintf("%d: %d", code, metrics.StatusCodes[code])
+		}
+		logs = append(logs, "Status codes: "+strings.Join(counts, ", "))
+	}
+	for _, message := range sortedKeys(metrics.ErrorMessages) {
+		logs = append(logs, fmt.Sprintf("Error (%d times): %s", metrics.ErrorMessages[message], message))
+	}
+	for _, interval := range metrics.Intervals {
+		errorRate := 0.0
+		if interval.Requests > 0 {
+			errorRate = float64(interval.Errors) / float64(interval.Requests) * 100
+		}
+		logs = append(logs, fmt.Sprintf("[%s] %d requests, %.1f/s, p95 %s, %.2f%% errors",
+			interval.Elapsed.Round(time.Second), interval.Requests, interval.Throughput, interval.P95, errorRate))
+	}
+	return logs
+}
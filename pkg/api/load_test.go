@@ -0,0 +1,268 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gwconfig "github.com/gowright/framework/pkg/config"
+	"github.com/gowright/framework/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	var histogram latencyHistogram
+	for i := 100; i >= 1; i-- {
+		histogram.add(time.Duration(i) * time.Millisecond)
+	}
+	stats := histogram.stats()
+	assert.Equal(t, time.Millisecond, stats.Min)
+	assert.Equal(t, 50500*time.Microsecond, stats.Mean)
+	assert.Equal(t, 100*time.Millisecond, stats.Max)
+	for expected, actual := range map[time.Duration]time.Duration{
+		50 * time.Millisecond: stats.P50,
+		90 * time.Millisecond: stats.P90,
+		95 * time.Millisecond: stats.P95,
+		99 * time.Millisecond: stats.P99,
+	} {
+		assert.InEpsilon(t, expected, actual, 0.01)
+	}
+
+	// Percentiles stay within the observed range, and memory does not grow with the samples
+	var single latencyHistogram
+	for i := 0; i < 100000; i++ {
+		single.add(3 * time.Millisecond)
+	}
+	assert.Equal(t, core.LatencyStats{
+		Min: 3 * time.Millisecond, Mean: 3 * time.Millisecond, P50: 3 * time.Millisecond, P90: 3 * time.Millisecond,
+		P95: 3 * time.Millisecond, P99: 3 * time.Millisecond, Max: 3 * time.Millisecond,
+	}, single.stats())
+	assert.Len(t, single.counts, histogramBuckets)
+
+	var slow latencyHistogram
+	slow.add(500 * time.Nanosecond)
+	slow.add(24 * time.Hour)
+	assert.Equal(t, 24*time.Hour, slow.stats().P99)
+	assert.Equal(t, core.LatencyStats{}, (&latencyHistogram{}).stats())
+}
+
+func TestAPITester_LoadTestVirtualUsers(t *testing.T) {
+	var sessions, mismatches atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			session := fmt.Sprint(sessions.Add(1))
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: session, Path: "/"})
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"session": %q}`, session)
+		case "/orders":
+			// Each user sends the session it captured along with its own cookie
+			cookie, err := r.Cookie("session")
+			if err != nil || cookie.Value != r.Header.Get("X-Session") || r.Header.Get("X-API-Key") != "key" {
+				mismatches.Add(1)
+				w.WriteHeader(http.StatusForbidden)
+			}
+		}
+	}))
+	defer server.Close()
+
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{
+		BaseURL:    server.URL,
+		Timeout:    2 * time.Second,
+		RetryCount: 3,
+		Auth:       &gwconfig.AuthConfig{Type: "api_key", APIKey: "key"},
+	}))
+
+	noErrors := 0.0
+	result := tester.ExecuteLoadTest(&core.LoadTest{
+		Name: "checkout",
+		Scenario: []*core.APITest{
+			{
+				Name: "login", Method: "POST", Endpoint: "/login",
+				Expected: &core.APIExpectation{StatusCode: http.StatusOK},
+				Captures: []core.APICapture{{Variable: "session", JSONPath: "$.session"}},
+			},
+			{
+				Name: "orders", Method: "GET", Endpoint: "/orders",
+				Headers:  map[string]string{"X-Session": "{{session}}"},
+				Expected: &core.APIExpectation{StatusCode: http.StatusOK},
+			},
+		},
+		VirtualUsers: 4,
+		Duration:     400 * time.Millisecond,
+		RampUp:       100 * time.Millisecond,
+		Interval:     100 * time.Millisecond,
+		Thresholds:   &core.LoadThresholds{P95: time.Second, MaxErrorRate: &noErrors, MinThroughput: 1},
+	})
+	require.Equal(t, core.TestStatusPassed, result.Status, result.Logs)
+	assert.Zero(t, mismatches.Load())
+
+	metrics, ok := result.Metadata["load"].(*core.LoadMetrics)
+	require.True(t, ok)
+	assert.Greater(t, metrics.Requests, 8)
+	assert.Zero(t, metrics.Errors)
+	assert.Equal(t, metrics.Requests, metrics.StatusCodes[http.StatusOK])
+	assert.GreaterOrEqual(t, metrics.Iterations, metrics.Requests/2)
+	assert.Positive(t, metrics.Latency.Max)
+	assert.LessOrEqual(t, metrics.Latency.P50, metrics.Latency.P99)
+	assert.GreaterOrEqual(t, len(metrics.Intervals), 3)
+	assert.GreaterOrEqual(t, metrics.Duration, 400*time.Millisecond)
+	require.Len(t, result.Steps, 3)
+	assert.Equal(t, "error rate 0.00% within 0.00%", result.Steps[1].Description)
+	assert.True(t, strings.HasPrefix(result.Logs[0], fmt.Sprintf("Load: %d requests", metrics.Requests)), result.Logs[0])
+
+	// Captures stay with the virtual user that made them
+	_, captured := tester.Variables().Get("session")
+	assert.False(t, captured)
+}
+
+func TestAPITester_LoadTestRate(t *testing.T) {
+	var delay atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Duration(delay.Load()))
+	}))
+	defer server.Close()
+
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, Timeout: 2 * time.Second}))
+	scenario := []*core.APITest{{Name: "ping", Method: "GET", Endpoint: "/ping"}}
+
+	result := tester.ExecuteLoadTest(&core.LoadTest{Name: "rate", Scenario: scenario, RPS: 100, Duration: 500 * time.Millisecond, VirtualUsers: 5})
+	require.Equal(t, core.TestStatusPassed, result.Status)
+	metrics := result.Metadata["load"].(*core.LoadMetrics)
+	assert.InDelta(t, 50, metrics.Iterations, 12)
+	assert.Equal(t, metrics.Iterations, metrics.Requests)
+	assert.Zero(t, metrics.DroppedIterations)
+
+	// A ramp-up halves the arrivals while the rate grows
+	result = tester.ExecuteLoadTest(&core.LoadTest{Name: "ramp", Scenario: scenario, RPS: 100, Duration: 500 * time.Millisecond, RampUp: 500 * time.Millisecond, VirtualUsers: 5})
+	assert.InDelta(t, 25, result.Metadata["load"].(*core.LoadMetrics).Iterations, 8)
+
+	// A slow server keeps the only user busy, so most arrivals are dropped
+	delay.Store(int64(100 * time.Millisecond))
+	result = tester.ExecuteLoadTest(&core.LoadTest{Name: "saturated", Scenario: scenario, RPS: 50, Duration: 300 * time.Millisecond, VirtualUsers: 1})
+	metrics = result.Metadata["load"].(*core.LoadMetrics)
+	assert.LessOrEqual(t, metrics.Iterations, 4)
+	assert.Positive(t, metrics.DroppedIterations)
+	assert.Contains(t, result.Logs, fmt.Sprintf("Dropped %d iterations because every virtual user was busy", metrics.DroppedIterations))
+}
+
+func TestAPITester_LoadTestThresholds(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1)%2 == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, Timeout: 2 * time.Second}))
+
+	maxErrors := 0.1
+	result := tester.ExecuteLoadTest(&core.LoadTest{
+		Name:         "flaky",
+		Scenario:     []*core.APITest{{Name: "status", Method: "GET", Endpoint: "/status", Expected: &core.APIExpectation{StatusCode: http.StatusOK}}},
+		VirtualUsers: 2,
+		Duration:     200 * time.Millisecond,
+		Thresholds:   &core.LoadThresholds{P99: time.Nanosecond, MaxErrorRate: &maxErrors},
+	})
+	require.Equal(t, core.TestStatusFailed, result.Status)
+	assert.Equal(t, core.AssertionError, core.GetErrorType(result.Error))
+
+	metrics := result.Metadata["load"].(*core.LoadMetrics)
+	assert.InDelta(t, 0.5, metrics.ErrorRate, 0.05)
+	assert.Equal(t, metrics.Errors, metrics.ErrorMessages["status: Status code validation"])
+	assert.Equal(t, metrics.Errors, metrics.StatusCodes[http.StatusInternalServerError])
+
+	require.Len(t, result.Steps, 2)
+	assert.True(t, strings.HasPrefix(result.Steps[0].Description, "p99 latency "), result.Steps[0].Description)
+	assert.True(t, strings.HasSuffix(result.Steps[0].Description, " exceeds 1ns"), result.Steps[0].Description)
+	assert.Equal(t, core.TestStatusFailed, result.Steps[0].Status)
+	assert.Contains(t, result.Steps[1].Description, "exceeds 10.00%")
+	assert.Contains(t, result.Logs, fmt.Sprintf("Error (%d times): status: Status code validation", metrics.Errors))
+}
+
+func TestAPITester_LoadTestConnections(t *testing.T) {
+	var inFlight, peak atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			highest := peak.Load()
+			if current <= highest || peak.CompareAndSwap(highest, current) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	// MaxConnections limits the tester's own requests, not the virtual users
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, Timeout: 2 * time.Second, MaxConnections: 1}))
+	result := tester.ExecuteLoadTest(&core.LoadTest{
+		Name:         "connections",
+		Scenario:     []*core.APITest{{Name: "slow", Method: "GET", Endpoint: "/slow"}},
+		VirtualUsers: 4,
+		Duration:     300 * time.Millisecond,
+	})
+	require.Equal(t, core.TestStatusPassed, result.Status)
+	assert.EqualValues(t, 4, peak.Load())
+	assert.Less(t, result.Metadata["load"].(*core.LoadMetrics).Latency.Max, 150*time.Millisecond)
+	assert.Equal(t, 1, tester.client.GetClient().Transport.(*http.Transport).MaxConnsPerHost)
+}
+
+func TestAPITester_LoadTestBypassesRecording(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "cassette.yaml")
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{
+		BaseURL:   server.URL,
+		Timeout:   2 * time.Second,
+		Recording: &gwconfig.RecordingConfig{Mode: RecordingModeRecord, File: file},
+	}))
+
+	result := tester.ExecuteLoadTest(&core.LoadTest{
+		Name:         "recorded",
+		Scenario:     []*core.APITest{{Name: "ping", Method: "GET", Endpoint: "/ping"}},
+		VirtualUsers: 2,
+		Duration:     100 * time.Millisecond,
+	})
+	require.Equal(t, core.TestStatusPassed, result.Status)
+	assert.Positive(t, result.Metadata["load"].(*core.LoadMetrics).Requests)
+	require.NoError(t, tester.Cleanup())
+	assert.NoFileExists(t, file)
+}
+
+func TestAPITester_LoadTestValidation(t *testing.T) {
+	scenario := []*core.APITest{{Name: "ping", Method: "GET", Endpoint: "/ping"}}
+	result := NewAPITester().ExecuteLoadTest(&core.LoadTest{Name: "uninitialized", Scenario: scenario, Duration: time.Second})
+	assert.Equal(t, core.TestStatusError, result.Status)
+	assert.Equal(t, core.ConfigurationError, core.GetErrorType(result.Error))
+
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: "http://localhost", Timeout: time.Second}))
+	for name, test := range map[string]*core.LoadTest{
+		"no scenario":  {Duration: time.Second},
+		"no duration":  {Scenario: scenario},
+		"negative rps": {Scenario: scenario, Duration: time.Second, RPS: -1},
+		"long ramp-up": {Scenario: scenario, Duration: time.Second, RampUp: 2 * time.Second},
+		"nil test":     {Scenario: []*core.APITest{nil}, Duration: time.Second},
+		"reader body":  {Scenario: []*core.APITest{{Name: "upload", Method: "POST", RequestBody: &core.RequestBody{Reader: strings.NewReader("x")}}}, Duration: time.Second},
+	} {
+		result := tester.ExecuteLoadTest(test)
+		assert.Equal(t, core.TestStatusError, result.Status, name)
+		assert.Equal(t, core.ValidationError, core.GetErrorType(result.Error), name)
+		assert.Nil(t, result.Metadata, name)
+	}
+}
@@ -0,0 +1,599 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gowright/framework/pkg/assertions"
+	"github.com/gowright/framework/pkg/core"
+)
+
+// MockFault is a network failure a stub can simulate instead of responding
+type MockFault string
+
+// Supported faults
+const (
+	// MockFaultConnectionReset resets the connection without a response
+	MockFaultConnectionReset MockFault = "connection_reset"
+	// MockFaultEmptyResponse closes the connection without a response
+	MockFaultEmptyResponse MockFault = "empty_response"
+	// MockFaultMalformedResponse writes bytes that are not valid HTTP, then closes the connection
+	MockFaultMalformedResponse MockFault = "malformed_response"
+)
+
+// MockServer is a programmable HTTP server for testing API clients. Stubs decide how requests
+// are answered, every request is recorded, and verifications report what was received as
+// assertion steps.
+type MockServer struct {
+	mutex    sync.RWMutex
+	server   *http.Server
+	listener net.Listener
+	stubs    []*MockStub
+	requests []MockRequest
+	steps    []core.AssertionStep
+}
+
+// MockRequest is a request received by a MockServer
+type MockRequest struct {
+	Method   string
+	Path     string
+	Query    url.Values
+	Headers  http.Header
+	Body     []byte
+	Received time.Time
+	Matched  bool // whether a stub answered the request
+}
+
+// NewMockServer creates a mock server; call Start to begin listening
+func NewMockServer() *MockServer {
+	return &MockServer{}
+}
+
+// Start listens on a free local port
+func (ms *MockServer) Start() error {
+	return ms.StartOn("127.0.0.1:0")
+}
+
+// StartOn listens on the given address
+func (ms *MockServer) StartOn(address string) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	if ms.server != nil {
+		return core.NewGowrightError(core.APIError, "mock server already started", nil)
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return core.NewGowrightError(core.APIError, "failed to start mock server", err).
+			WithContext("address", address)
+	}
+
+	ms.listener = listener
+	ms.server = &http.Server{Handler: http.HandlerFunc(ms.handle), ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = ms.server.Serve(listener) }()
+	return nil
+}
+
+// URL returns the base URL of the running server
+func (ms *MockServer) URL() string {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	if ms.listener == nil {
+		return ""
+	}
+	return "http://" + ms.listener.Addr().String()
+}
+
+// Close stops the server
+func (ms *MockServer) Close() error {
+	ms.mutex.Lock()
+	server := ms.server
+	ms.server, ms.listener = nil, nil
+	ms.mutex.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		return server.Close()
+	}
+	return nil
+}
+
+// Stub adds a stub for a method and path pattern; see MockStub for the pattern syntax. The most
+// recently added matching stub answers a request.
+func (ms *MockServer) Stub(method, pathPattern string) *MockStub {
+	stub := newMockStub(method, pathPattern)
+
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.stubs = append(ms.stubs, stub)
+	return stub
+}
+
+// Reset removes all stubs, recorded requests and verification steps
+func (ms *MockServer) Reset() {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.stubs = nil
+	ms.requests = nil
+	ms.steps = nil
+}
+
+// Requests returns the requests received so far
+func (ms *MockServer) Requests() []MockRequest {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	return append([]MockRequest(nil), ms.requests...)
+}
+
+// UnmatchedRequests returns the received requests no stub answered
+func (ms *MockServer) UnmatchedRequests() []MockRequest {
+	var unmatched []MockRequest
+	for _, request := range ms.Requests() {
+		if !request.Matched {
+			unmatched = append(unmatched, request)
+		}
+	}
+	return unmatched
+}
+
+// handle answers a request with the matching stub, or 404 when no stub matches
+func (ms *MockServer) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	request := MockRequest{
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		Query:    r.URL.Query(),
+		Headers:  r.Header.Clone(),
+		Body:     body,
+		Received: time.Now(),
+	}
+
+	ms.mutex.Lock()
+	var stub *MockStub
+	for i := len(ms.stubs) - 1; i >= 0; i-- {
+		if ms.stubs[i].matches(&request) {
+			stub = ms.stubs[i]
+			stub.calls++
+			break
+		}
+	}
+	request.Matched = stub != nil
+	ms.requests = append(ms.requests, request)
+	ms.mutex.Unlock()
+
+	if stub == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("no stub matches %s %s", r.Method, r.URL.Path)})
+		return
+	}
+
+	if stub.delay > 0 {
+		select {
+		case <-time.After(stub.delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if stub.fault != "" {
+		injectFault(w, stub.fault)
+		return
+	}
+
+	for name, values := range stub.responseHeaders {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	if stub.responseBody != nil && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", stub.contentType)
+	}
+	w.WriteHeader(stub.status)
+	_, _ = w.Write(stub.responseBody)
+}
+
+// injectFault breaks the connection of a request as the fault describes
+func injectFault(w http.ResponseWriter, fault MockFault) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	conn, buffer, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	switch fault {
+	case MockFaultConnectionReset:
+		if tcp, ok := conn.(*net.TCPConn); ok {
+			_ = tcp.SetLinger(0)
+		}
+	case MockFaultMalformedResponse:
+		_, _ = buffer.WriteString("NOT HTTP\r\n\r\n")
+		_ = buffer.Flush()
+	}
+}
+
+// MockStub describes which requests a stub answers and how. Path patterns match the request path
+// exactly, except that a {name} or * segment matches any single segment and a trailing /** matches
+// the rest of the path. Patterns starting with ~ are regular expressions.
+type MockStub struct {
+	method    string
+	path      *regexp.Regexp
+	pathError error
+	headers   map[string]interface{}
+	query     map[string]interface{}
+	body      interface{}
+	jsonPaths map[string]interface{}
+	maxCalls  int
+
+	status          int
+	responseHeaders http.Header
+	responseBody    []byte
+	contentType     string
+	delay           time.Duration
+	fault           MockFault
+	calls           int
+}
+
+// newMockStub creates a stub answering 200 with no body
+func newMockStub(method, pathPattern string) *MockStub {
+	path, err := compilePathPattern(pathPattern)
+	return &MockStub{
+		method:          strings.ToUpper(method),
+		path:            path,
+		pathError:       err,
+		headers:         make(map[string]interface{}),
+		query:           make(map[string]interface{}),
+		jsonPaths:       make(map[string]interface{}),
+		status:          http.StatusOK,
+		responseHeaders: http.Header{},
+	}
+}
+
+// Err returns the error of an invalid path pattern; such a stub never matches
+func (s *MockStub) Err() error {
+	return s.pathError
+}
+
+// WithHeader requires a request header to match a literal or ValueMatcher
+func (s *MockStub) WithHeader(name string, expected interface{}) *MockStub {
+	s.headers[name] = expected
+	return s
+}
+
+// WithQuery requires a query parameter to match a literal or ValueMatcher
+func (s *MockStub) WithQuery(name string, expected interface{}) *MockStub {
+	s.query[name] = expected
+	return s
+}
+
+// WithBody requires the request body to match a literal or matcher, compared as JSON when the body is JSON
+func (s *MockStub) WithBody(expected interface{}) *MockStub {
+	s.body = expected
+	return s
+}
+
+// WithJSONPath requires a value in the JSON request body to match a literal or ValueMatcher
+func (s *MockStub) WithJSONPath(path string, expected interface{}) *MockStub {
+	s.jsonPaths[path] = expected
+	return s
+}
+
+// Times limits how many requests the stub answers; later requests fall through to older stubs
+func (s *MockStub) Times(n int) *MockStub {
+	s.maxCalls = n
+	return s
+}
+
+// Respond sets the response status and body. Strings and byte slices are sent as they are,
+// other values as JSON.
+func (s *MockStub) Respond(status int, body interface{}) *MockStub {
+	s.status = status
+	switch typed := body.(type) {
+	case nil:
+		s.responseBody = nil
+	case string:
+		s.responseBody, s.contentType = []byte(typed), "text/plain; charset=utf-8"
+	case []byte:
+		s.responseBody, s.contentType = typed, "application/octet-stream"
+	default:
+		data, err := json.Marshal(typed)
+		if err != nil {
+			s.status, s.responseBody, s.contentType = http.StatusInternalServerError, []byte(err.Error()), "text/plain; charset=utf-8"
+			return s
+		}
+		s.responseBody, s.contentType = data, "application/json"
+	}
+	return s
+}
+
+// WithResponseHeader adds a response header
+func (s *MockStub) WithResponseHeader(name, value string) *MockStub {
+	s.responseHeaders.Add(name, value)
+	return s
+}
+
+// WithDelay delays the response or fault
+func (s *MockStub) WithDelay(delay time.Duration) *MockStub {
+	s.delay = delay
+	return s
+}
+
+// WithFault breaks the connection instead of responding
+func (s *MockStub) WithFault(fault MockFault) *MockStub {
+	s.fault = fault
+	return s
+}
+
+// matches reports whether the stub answers a request; the caller holds the server lock
+func (s *MockStub) matches(request *MockRequest) bool {
+	if s.maxCalls > 0 && s.calls >= s.maxCalls {
+		return false
+	}
+	return requestMatches(request, s.method, s.path, s.pathError, s.headers, s.query, s.body, s.jsonPaths) == nil
+}
+
+// requestMatches returns why a request does not match the given criteria, or nil when it matches
+func requestMatches(request *MockRequest, method string, path *regexp.Regexp, pathError error,
+	headers, query map[string]interface{}, body interface{}, jsonPaths map[string]interface{}) error {
+	if pathError != nil {
+		return pathError
+	}
+	if method != "" && method != "*" && method != request.Method {
+		return fmt.Errorf("method %s", request.Method)
+	}
+	if !path.MatchString(request.Path) {
+		return fmt.Errorf("path %s", request.Path)
+	}
+	for _, name := range sortedKeys(headers) {
+		if err := assertions.MatchValue(headers[name], request.Headers.Get(name)); err != nil {
+			return fmt.Errorf("header %s: %v", name, err)
+		}
+	}
+	for _, name := range sortedKeys(query) {
+		if err := assertions.MatchValue(query[name], request.Query.Get(name)); err != nil {
+			return fmt.Errorf("query parameter %s: %v", name, err)
+		}
+	}
+	if body == nil && len(jsonPaths) == 0 {
+		return nil
+	}
+
+	document := assertions.ParseJSONBody(request.Body)
+	if body != nil {
+		if err := assertions.MatchValue(body, document); err != nil {
+			return fmt.Errorf("body: %v", err)
+		}
+	}
+	for _, path := range sortedKeys(jsonPaths) {
+		if _, err := assertions.MatchJSONPath(document, path, jsonPaths[path]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compilePathPattern converts a stub path pattern to a regular expression
+func compilePathPattern(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "~") {
+		re, err := regexp.Compile(pattern[1:])
+		if err != nil {
+			return regexp.MustCompile(`^\b$`), core.NewGowrightError(core.ValidationError, "invalid path pattern", err).
+				WithContext("pattern", pattern)
+		}
+		return re, nil
+	}
+
+	var expression strings.Builder
+	expression.WriteString("^")
+	segments := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	for i, segment := range segments {
+		expression.WriteString("/")
+		switch {
+		case segment == "**" && i == len(segments)-1:
+			expression.WriteString(".*")
+		case segment == "*" || (strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")):
+			expression.WriteString("[^/]+")
+		default:
+			expression.WriteString(regexp.QuoteMeta(segment))
+		}
+	}
+	expression.WriteString("$")
+	return regexp.MustCompile(expression.String()), nil
+}
+
+// MockVerification selects received requests by method, path and content, and checks how many arrived
+type MockVerification struct {
+	server    *MockServer
+	method    string
+	pattern   string
+	path      *regexp.Regexp
+	pathError error
+	headers   map[string]interface{}
+	query     map[string]interface{}
+	body      interface{}
+	jsonPaths map[string]interface{}
+}
+
+// Verify starts a verification of the requests received for a method and path pattern
+func (ms *MockServer) Verify(method, pathPattern string) *MockVerification {
+	path, err := compilePathPattern(pathPattern)
+	return &MockVerification{
+		server:    ms,
+		method:    strings.ToUpper(method),
+		pattern:   pathPattern,
+		path:      path,
+		pathError: err,
+		headers:   make(map[string]interface{}),
+		query:     make(map[string]interface{}),
+		jsonPaths: make(map[string]interface{}),
+	}
+}
+
+// WithHeader only counts requests with a header matching a literal or ValueMatcher
+func (v *MockVerification) WithHeader(name string, expected interface{}) *MockVerification {
+	v.headers[name] = expected
+	return v
+}
+
+// WithQuery only counts requests with a query parameter matching a literal or ValueMatcher
+func (v *MockVerification) WithQuery(name string, expected interface{}) *MockVerification {
+	v.query[name] = expected
+	return v
+}
+
+// WithBody only counts requests whose body matches a literal or matcher
+func (v *MockVerification) WithBody(expected interface{}) *MockVerification {
+	v.body = expected
+	return v
+}
+
+// WithJSONPath only counts requests with a JSON body value matching a literal or ValueMatcher
+func (v *MockVerification) WithJSONPath(path string, expected interface{}) *MockVerification {
+	v.jsonPaths[path] = expected
+	return v
+}
+
+// Count returns the number of matching requests received
+func (v *MockVerification) Count() int {
+	count := 0
+	for _, request := range v.server.Requests() {
+		if requestMatches(&request, v.method, v.path, v.pathError, v.headers, v.query, v.body, v.jsonPaths) == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Times asserts that exactly n matching requests were received
+func (v *MockVerification) Times(n int) core.AssertionStep {
+	return v.check(fmt.Sprintf("exactly %d", n), n, func(count int) bool { return count == n })
+}
+
+// AtLeast asserts that at least n matching requests were received
+func (v *MockVerification) AtLeast(n int) core.AssertionStep {
+	return v.check(fmt.Sprintf("at least %d", n), n, func(count int) bool { return count >= n })
+}
+
+// Never asserts that no matching request was received
+func (v *MockVerification) Never() core.AssertionStep {
+	return v.check("none", 0, func(count int) bool { return count == 0 })
+}
+
+// check records an assertion step comparing the number of matching requests with an expectation
+func (v *MockVerification) check(expectation string, expected int, ok func(count int) bool) core.AssertionStep {
+	step := core.AssertionStep{
+		Name:        "Verify",
+		Description: fmt.Sprintf("%s requests: %s", v.describe(), expectation),
+		Expected:    expected,
+		StartTime:   time.Now(),
+		Status:      core.TestStatusPassed,
+	}
+
+	count := v.Count()
+	step.Actual = count
+	if v.pathError != nil {
+		step.Status, step.Error = core.TestStatusFailed, v.pathError
+	} else if !ok(count) {
+		step.Status = core.TestStatusFailed
+		step.Error = fmt.Errorf("%s requests: expected %s, got %d", v.describe(), expectation, count)
+	}
+	step.EndTime = time.Now()
+	step.Duration = step.EndTime.Sub(step.StartTime)
+
+	v.server.mutex.Lock()
+	v.server.steps = append(v.server.steps, step)
+	v.server.mutex.Unlock()
+	return step
+}
+
+// describe names the verified requests, such as "POST /orders with body matching {...}"
+func (v *MockVerification) describe() string {
+	var description strings.Builder
+	description.WriteString(v.method + " " + v.pattern)
+	for _, name := range sortedKeys(v.headers) {
+		fmt.Fprintf(&description, " with header %s %s", name, describeExpected(v.headers[name]))
+	}
+	for _, name := range sortedKeys(v.query) {
+		fmt.Fprintf(&description, " with query %s %s", name, describeExpected(v.query[name]))
+	}
+	if v.body != nil {
+		fmt.Fprintf(&description, " with body %s", describeExpected(v.body))
+	}
+	for _, path := range sortedKeys(v.jsonPaths) {
+		fmt.Fprintf(&description, " with %s %s", path, describeExpected(v.jsonPaths[path]))
+	}
+	return description.String()
+}
+
+// describeExpected formats an expected value or matcher for step descriptions
+func describeExpected(expected interface{}) string {
+	if matcher, ok := expected.(assertions.ValueMatcher); ok {
+		return matcher.String()
+	}
+	data, err := json.Marshal(expected)
+	if err != nil {
+		return fmt.Sprintf("%v", expected)
+	}
+	return "matching " + string(data)
+}
+
+// VerifyNoUnmatchedRequests asserts that every received request was answered by a stub
+func (ms *MockServer) VerifyNoUnmatchedRequests() core.AssertionStep {
+	step := core.AssertionStep{
+		Name:        "Verify",
+		Description: "No unmatched requests",
+		Expected:    0,
+		StartTime:   time.Now(),
+		Status:      core.TestStatusPassed,
+	}
+
+	unmatched := ms.UnmatchedRequests()
+	step.Actual = len(unmatched)
+	if len(unmatched) > 0 {
+		step.Status = core.TestStatusFailed
+		step.Error = fmt.Errorf("%d requests matched no stub, first %s %s", len(unmatched), unmatched[0].Method, unmatched[0].Path)
+	}
+	step.EndTime = time.Now()
+	step.Duration = step.EndTime.Sub(step.StartTime)
+
+	ms.mutex.Lock()
+	ms.steps = append(ms.steps, step)
+	ms.mutex.Unlock()
+	return step
+}
+
+// Steps returns the assertion steps of all verifications so far
+func (ms *MockServer) Steps() []core.AssertionStep {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	return append([]core.AssertionStep(nil), ms.steps...)
+}
+
+// HasFailures reports whether any verification failed
+func (ms *MockServer) HasFailures() bool {
+	for _, step := range ms.Steps() {
+		if step.Status == core.TestStatusFailed {
+			return true
+		}
+	}
+	return false
+}
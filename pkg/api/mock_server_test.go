@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gowright/framework/pkg/assertions"
+	gwconfig "github.com/gowright/framework/pkg/config"
+	"github.com/gowright/framework/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMockServer starts a mock server that is closed when the test ends
+func newMockServer(t *testing.T) (*MockServer, *APITester) {
+	server := NewMockServer()
+	require.NoError(t, server.Start())
+	t.Cleanup(func() { _ = server.Close() })
+
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: server.URL(), Timeout: 2 * time.Second}))
+	return server, tester
+}
+
+func TestMockServer_Stubs(t *testing.T) {
+	server, tester := newMockServer(t)
+
+	server.Stub("GET", "/orders/{id}").Respond(http.StatusOK, map[string]interface{}{"id": 1, "status": "open"})
+	server.Stub("POST", "/orders").
+		WithHeader("X-Tenant", "acme").
+		WithJSONPath("$.items[0].sku", assertions.MatchRegex(`^SKU-\d+$`)).
+		Respond(http.StatusCreated, `created`).
+		WithResponseHeader("Location", "/orders/7")
+	server.Stub("GET", "/flaky").Respond(http.StatusOK, "ok")
+	server.Stub("GET", "/flaky").Respond(http.StatusServiceUnavailable, nil).Times(1)
+	server.Stub("*", "~^/files/.+\\.txt$").Respond(http.StatusNoContent, nil)
+
+	response, err := tester.Get("/orders/42", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "application/json", response.Headers["Content-Type"])
+	assert.JSONEq(t, `{"id": 1, "status": "open"}`, string(response.Body))
+
+	response, err = tester.Post("/orders", map[string]interface{}{"items": []map[string]string{{"sku": "SKU-1"}}}, map[string]string{"X-Tenant": "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.Equal(t, "/orders/7", response.Headers["Location"])
+	assert.Equal(t, "created", string(response.Body))
+
+	// A stub limited with Times falls through to older stubs once used up
+	response, err = tester.Get("/flaky", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	response, err = tester.Get("/flaky", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	response, err = tester.Delete("/files/a/b.txt", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+
+	// Requests no stub matches get a 404 and are recorded as unmatched
+	response, err = tester.Post("/orders", map[string]interface{}{"items": []map[string]string{{"sku": "bad"}}}, map[string]string{"X-Tenant": "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	assert.Contains(t, string(response.Body), "no stub matches POST /orders")
+
+	unmatched := server.UnmatchedRequests()
+	require.Len(t, unmatched, 1)
+	assert.JSONEq(t, `{"items": [{"sku": "bad"}]}`, string(unmatched[0].Body))
+	assert.Len(t, server.Requests(), 6)
+	assert.Equal(t, core.TestStatusFailed, server.VerifyNoUnmatchedRequests().Status)
+
+	assert.Error(t, server.Stub("GET", "~[").Err())
+}
+
+func TestMockServer_DelaysAndFaults(t *testing.T) {
+	server, tester := newMockServer(t)
+
+	server.Stub("GET", "/slow").Respond(http.StatusOK, "late").WithDelay(100 * time.Millisecond)
+	server.Stub("GET", "/reset").WithFault(MockFaultConnectionReset)
+	server.Stub("GET", "/empty").WithFault(MockFaultEmptyResponse)
+	server.Stub("GET", "/garbage").WithFault(MockFaultMalformedResponse)
+
+	response, err := tester.Get("/slow", nil)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, response.Duration, 100*time.Millisecond)
+
+	for _, path := range []string{"/reset", "/empty", "/garbage"} {
+		_, err := tester.Get(path, nil)
+		assert.Error(t, err, path)
+	}
+	assert.Len(t, server.Requests(), 4)
+}
+
+func TestMockServer_Verify(t *testing.T) {
+	server, tester := newMockServer(t)
+	server.Stub("POST", "/orders").Respond(http.StatusCreated, nil)
+
+	for _, quantity := range []int{1, 2, 2} {
+		_, err := tester.Post("/orders?source=web", map[string]interface{}{"sku": "A", "quantity": quantity}, nil)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 3, server.Verify("POST", "/orders").Count())
+	assert.Equal(t, core.TestStatusPassed, server.Verify("POST", "/orders").WithJSONPath("$.quantity", 2).Times(2).Status)
+	assert.Equal(t, core.TestStatusPassed, server.Verify("POST", "/orders").WithBody(map[string]interface{}{"sku": "A", "quantity": 1}).Times(1).Status)
+	assert.Equal(t, core.TestStatusPassed, server.Verify("POST", "/orders").WithQuery("source", "web").AtLeast(3).Status)
+	assert.Equal(t, core.TestStatusPassed, server.Verify("GET", "/orders").Never().Status)
+	assert.Equal(t, core.TestStatusPassed, server.VerifyNoUnmatchedRequests().Status)
+	assert.False(t, server.HasFailures())
+
+	step := server.Verify("POST", "/orders").WithJSONPath("$.quantity", 2).Times(1)
+	assert.Equal(t, core.TestStatusFailed, step.Status)
+	assert.Equal(t, 2, step.Actual)
+	require.Error(t, step.Error)
+	assert.Equal(t, "POST /orders with $.quantity matching 2 requests: expected exactly 1, got 2", step.Error.Error())
+	assert.True(t, server.HasFailures())
+	assert.Len(t, server.Steps(), 6)
+
+	server.Reset()
+	assert.Empty(t, server.Requests())
+	assert.Empty(t, server.Steps())
+	response, err := tester.Post("/orders", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
@@ -0,0 +1,595 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gowright/framework/pkg/config"
+	"github.com/gowright/framework/pkg/core"
+	"gopkg.in/yaml.v3"
+)
+
+// Recording modes
+const (
+	RecordingModeRecord = "record"
+	RecordingModeReplay = "replay"
+)
+
+// defaultMatchOn is how replayed requests are matched when RecordingConfig.MatchOn is empty
+var defaultMatchOn = []string{"method", "url"}
+
+// defaultIgnoreHeaders are request headers carrying credentials, which are never recorded
+var defaultIgnoreHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// recordedExchange is a request and its response as kept in a recording
+type recordedExchange struct {
+	StartedAt time.Time        `yaml:"started_at"`
+	Duration  time.Duration    `yaml:"duration"`
+	Request   recordedRequest  `yaml:"request"`
+	Response  recordedResponse `yaml:"response"`
+}
+
+// recordedRequest is the recorded part of a request
+type recordedRequest struct {
+	Method       string              `yaml:"method"`
+	URL          string              `yaml:"url"`
+	Headers      map[string][]string `yaml:"headers,omitempty"`
+	Body         string              `yaml:"body,omitempty"`
+	BodyEncoding string              `yaml:"body_encoding,omitempty"` // base64 for bodies that are not UTF-8 text
+}
+
+// recordedResponse is the recorded part of a response
+type recordedResponse struct {
+	StatusCode   int                 `yaml:"status_code"`
+	Headers      map[string][]string `yaml:"headers,omitempty"`
+	Body         string              `yaml:"body,omitempty"`
+	BodyEncoding string              `yaml:"body_encoding,omitempty"`
+}
+
+// cassette is the YAML recording format
+type cassette struct {
+	Version      int                `yaml:"version"`
+	Interactions []recordedExchange `yaml:"interactions"`
+}
+
+// recorder is an http.RoundTripper that records exchanges to a file or replays them from it
+type recorder struct {
+	config        *config.RecordingConfig
+	base          http.RoundTripper
+	matchOn       []string
+	ignoreHeaders map[string]bool
+
+	// secrets returns a replacer masking credentials before exchanges are saved or matched
+	secrets func() *strings.Replacer
+
+	mutex     sync.Mutex
+	exchanges []recordedExchange
+	replayed  []bool
+	unsaved   bool
+}
+
+// newRecorder creates a recorder around base. Replay mode loads the recording file.
+func newRecorder(cfg *config.RecordingConfig, base http.RoundTripper) (*recorder, error) {
+	if cfg.Mode != RecordingModeRecord && cfg.Mode != RecordingModeReplay {
+		return nil, core.NewGowrightError(core.ConfigurationError, fmt.Sprintf("unsupported recording mode: %s", cfg.Mode), nil)
+	}
+	if cfg.File == "" {
+		return nil, core.NewGowrightError(core.ConfigurationError, "recording file is required", nil)
+	}
+
+	r := &recorder{
+		config:        cfg,
+		base:          base,
+		matchOn:       cfg.MatchOn,
+		ignoreHeaders: make(map[string]bool),
+	}
+	if len(r.matchOn) == 0 {
+		r.matchOn = defaultMatchOn
+	}
+	for _, criterion := range r.matchOn {
+		switch criterion {
+		case "method", "url", "body", "headers":
+		default:
+			return nil, core.NewGowrightError(core.ConfigurationError, fmt.Sprintf("unsupported recording match criterion: %s", criterion), nil)
+		}
+	}
+	for _, header := range append(defaultIgnoreHeaders, cfg.IgnoreHeaders...) {
+		r.ignoreHeaders[http.CanonicalHeaderKey(header)] = true
+	}
+
+	if cfg.Mode == RecordingModeReplay {
+		exchanges, err := loadRecording(cfg.File)
+		if err != nil {
+			return nil, core.NewGowrightError(core.ConfigurationError, "failed to load recording", err).
+				WithContext("file", cfg.File)
+		}
+		r.exchanges = exchanges
+		r.replayed = make([]bool, len(exchanges))
+	}
+
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+	}
+
+	replacer := r.replacer()
+	if r.config.Mode == RecordingModeReplay {
+		if resp, ok := r.replay(req, r.recordRequest(req, body, replacer)); ok {
+			return resp, nil
+		}
+		if r.config.Strict {
+			return nil, core.NewGowrightError(core.APIError, fmt.Sprintf("no recorded response matches %s %s", req.Method, req.URL), nil).
+				WithContext("file", r.config.File)
+		}
+	}
+
+	outgoing := req.Clone(req.Context())
+	outgoing.Body = io.NopCloser(bytes.NewReader(body))
+	started := time.Now()
+	resp, err := r.base.RoundTrip(outgoing)
+	if err != nil || r.config.Mode != RecordingModeRecord {
+		return resp, err
+	}
+
+	responseBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	exchange := recordedExchange{
+		StartedAt: started,
+		Duration:  time.Since(started),
+		Request:   r.recordRequest(req, body, replacer),
+		Response: recordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    maskHeader(replacer, resp.Header),
+		},
+	}
+	exchange.Response.Body, exchange.Response.BodyEncoding = encodeBody(responseBody)
+	if exchange.Response.BodyEncoding == "" {
+		exchange.Response.Body = replacer.Replace(exchange.Response.Body)
+	}
+
+	// Exchanges are buffered and written once by flush, as rewriting the file per exchange is quadratic
+	r.mutex.Lock()
+	r.exchanges = append(r.exchanges, exchange)
+	r.unsaved = true
+	r.mutex.Unlock()
+	return resp, nil
+}
+
+// flush writes the recorded exchanges to the recording file if any were added since the last flush
+func (r *recorder) flush() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.unsaved {
+		return nil
+	}
+	if err := saveRecording(r.config.File, r.exchanges); err != nil {
+		return core.NewGowrightError(core.APIError, "failed to save recording", err).
+			WithContext("file", r.config.File)
+	}
+	r.unsaved = false
+	return nil
+}
+
+// baseTransport returns the transport a recorder wraps, or transport itself when it is not a recorder
+func baseTransport(transport http.RoundTripper) http.RoundTripper {
+	if recording, ok := transport.(*recorder); ok {
+		return recording.base
+	}
+	return transport
+}
+
+// CloseIdleConnections closes idle connections of the underlying transport
+func (r *recorder) CloseIdleConnections() {
+	if closer, ok := r.base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// replacer returns the replacer masking secrets, which masks nothing when no secrets are set
+func (r *recorder) replacer() *strings.Replacer {
+	if r.secrets == nil {
+		return strings.NewReplacer()
+	}
+	return r.secrets()
+}
+
+// recordRequest returns a request as it is written to the recording, without ignored headers and
+// with secrets masked in its URL, headers and text body
+func (r *recorder) recordRequest(req *http.Request, body []byte, replacer *strings.Replacer) recordedRequest {
+	recorded := recordedRequest{
+		Method:  req.Method,
+		URL:     replacer.Replace(req.URL.String()),
+		Headers: maskHeader(replacer, r.recordedHeaders(req.Header)),
+	}
+	recorded.Body, recorded.BodyEncoding = encodeBody(body)
+	if recorded.BodyEncoding == "" {
+		recorded.Body = replacer.Replace(recorded.Body)
+	}
+	return recorded
+}
+
+// replay returns the response of the first matching exchange not replayed yet, or of the last
+// matching exchange when all were replayed, so repeated requests replay in recorded order
+func (r *recorder) replay(req *http.Request, actual recordedRequest) (*http.Response, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	found := -1
+	for i, exchange := range r.exchanges {
+		if !r.matches(actual, exchange.Request) {
+			continue
+		}
+		found = i
+		if !r.replayed[i] {
+			break
+		}
+	}
+	if found < 0 {
+		return nil, false
+	}
+	r.replayed[found] = true
+
+	recorded := r.exchanges[found].Response
+	responseBody, err := decodeBody(recorded.Body, recorded.BodyEncoding)
+	if err != nil {
+		return nil, false
+	}
+	header := http.Header(recorded.Headers).Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(responseBody)),
+		ContentLength: int64(len(responseBody)),
+		Request:       req,
+	}, true
+}
+
+// matches reports whether a request, as it would be recorded, matches a recorded request on the
+// configured criteria
+func (r *recorder) matches(actual, recorded recordedRequest) bool {
+	for _, criterion := range r.matchOn {
+		switch criterion {
+		case "method":
+			if !strings.EqualFold(actual.Method, recorded.Method) {
+				return false
+			}
+		case "url":
+			if normalizeURL(actual.URL) != normalizeURL(recorded.URL) {
+				return false
+			}
+		case "body":
+			actualBody, err := decodeBody(actual.Body, actual.BodyEncoding)
+			if err != nil {
+				return false
+			}
+			recordedBody, err := decodeBody(recorded.Body, recorded.BodyEncoding)
+			if err != nil || sha256.Sum256(actualBody) != sha256.Sum256(recordedBody) {
+				return false
+			}
+		case "headers":
+			expected := r.recordedHeaders(recorded.Headers)
+			if len(actual.Headers) != len(expected) {
+				return false
+			}
+			for name, values := range actual.Headers {
+				if strings.Join(values, "\n") != strings.Join(expected[name], "\n") {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// recordedHeaders returns the request headers that are recorded and matched
+func (r *recorder) recordedHeaders(header http.Header) map[string][]string {
+	recorded := make(map[string][]string)
+	for name, values := range header {
+		if !r.ignoreHeaders[http.CanonicalHeaderKey(name)] {
+			recorded[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+	}
+	return recorded
+}
+
+// normalizeURL sorts query parameters so their order does not affect matching
+func normalizeURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	parsed.RawQuery = parsed.Query().Encode()
+	return parsed.String()
+}
+
+// encodeBody returns a body as text, base64 encoding bodies that are not valid UTF-8
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// decodeBody reverses encodeBody
+func decodeBody(text, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(text)
+	}
+	return []byte(text), nil
+}
+
+// isHARFile reports whether a recording file uses the HAR format
+func isHARFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".har")
+}
+
+// loadRecording reads the exchanges of a HAR file or YAML cassette
+func loadRecording(path string) ([]recordedExchange, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if isHARFile(path) {
+		var document harDocument
+		if err := json.Unmarshal(data, &document); err != nil {
+			return nil, err
+		}
+		return document.exchanges()
+	}
+
+	var recording cassette
+	if err := yaml.Unmarshal(data, &recording); err != nil {
+		return nil, err
+	}
+	return recording.Interactions, nil
+}
+
+// saveRecording writes exchanges to a HAR file or YAML cassette, replacing the file atomically
+func saveRecording(path string, exchanges []recordedExchange) error {
+	var data []byte
+	var err error
+	if isHARFile(path) {
+		data, err = json.MarshalIndent(newHARDocument(exchanges), "", "  ")
+	} else {
+		data, err = yaml.Marshal(&cassette{Version: 1, Interactions: exchanges})
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(temporary, path)
+}
+
+// harDocument is the top level of a HAR 1.2 file
+type harDocument struct {
+	Log struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+// harCreator names the application that wrote a HAR file
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// harEntry is one request and response in a HAR file
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+// harRequest is a HAR request
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// harPostData is a HAR request body; _encoding is a custom field for binary bodies
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"_encoding,omitempty"`
+}
+
+// harResponse is a HAR response
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// harContent is a HAR response body
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// harNameValue is a HAR header, cookie or query parameter
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// harTimings holds HAR timings in milliseconds
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// newHARDocument converts exchanges to a HAR document
+func newHARDocument(exchanges []recordedExchange) *harDocument {
+	document := &harDocument{}
+	document.Log.Version = "1.2"
+	document.Log.Creator = harCreator{Name: "gowright", Version: core.Version}
+	document.Log.Entries = make([]harEntry, len(exchanges))
+
+	for i, exchange := range exchanges {
+		milliseconds := float64(exchange.Duration) / float64(time.Millisecond)
+		entry := harEntry{
+			StartedDateTime: exchange.StartedAt,
+			Time:            milliseconds,
+			Request: harRequest{
+				Method:      exchange.Request.Method,
+				URL:         exchange.Request.URL,
+				HTTPVersion: "HTTP/1.1",
+				Cookies:     []harNameValue{},
+				Headers:     harHeaders(exchange.Request.Headers),
+				QueryString: []harNameValue{},
+				HeadersSize: -1,
+				BodySize:    len(exchange.Request.Body),
+			},
+			Response: harResponse{
+				Status:      exchange.Response.StatusCode,
+				StatusText:  http.StatusText(exchange.Response.StatusCode),
+				HTTPVersion: "HTTP/1.1",
+				Cookies:     []harNameValue{},
+				Headers:     harHeaders(exchange.Response.Headers),
+				Content: harContent{
+					Size:     len(exchange.Response.Body),
+					MimeType: http.Header(exchange.Response.Headers).Get("Content-Type"),
+					Text:     exchange.Response.Body,
+					Encoding: exchange.Response.BodyEncoding,
+				},
+				RedirectURL: http.Header(exchange.Response.Headers).Get("Location"),
+				HeadersSize: -1,
+				BodySize:    len(exchange.Response.Body),
+			},
+			Timings: harTimings{Wait: milliseconds},
+		}
+
+		if parsed, err := url.Parse(exchange.Request.URL); err == nil {
+			query := parsed.Query()
+			for _, name := range sortedKeys(query) {
+				for _, value := range query[name] {
+					entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: name, Value: value})
+				}
+			}
+		}
+		if exchange.Request.Body != "" {
+			entry.Request.PostData = &harPostData{
+				MimeType: http.Header(exchange.Request.Headers).Get("Content-Type"),
+				Text:     exchange.Request.Body,
+				Encoding: exchange.Request.BodyEncoding,
+			}
+		}
+
+		document.Log.Entries[i] = entry
+	}
+	return document
+}
+
+// exchanges converts the entries of a HAR document, such as one exported by a browser
+func (document *harDocument) exchanges() ([]recordedExchange, error) {
+	exchanges := make([]recordedExchange, len(document.Log.Entries))
+	for i, entry := range document.Log.Entries {
+		exchange := recordedExchange{
+			StartedAt: entry.StartedDateTime,
+			Duration:  time.Duration(entry.Time * float64(time.Millisecond)),
+			Request: recordedRequest{
+				Method:  entry.Request.Method,
+				URL:     entry.Request.URL,
+				Headers: fromHARHeaders(entry.Request.Headers),
+			},
+			Response: recordedResponse{
+				StatusCode:   entry.Response.Status,
+				Headers:      fromHARHeaders(entry.Response.Headers),
+				Body:         entry.Response.Content.Text,
+				BodyEncoding: entry.Response.Content.Encoding,
+			},
+		}
+		if entry.Request.PostData != nil {
+			exchange.Request.Body = entry.Request.PostData.Text
+			exchange.Request.BodyEncoding = entry.Request.PostData.Encoding
+		}
+		if exchange.Response.BodyEncoding != "" && exchange.Response.BodyEncoding != "base64" {
+			return nil, fmt.Errorf("entry %d: unsupported content encoding %q", i, exchange.Response.BodyEncoding)
+		}
+		exchanges[i] = exchange
+	}
+	return exchanges, nil
+}
+
+// harHeaders converts headers to sorted HAR name/value pairs
+func harHeaders(headers map[string][]string) []harNameValue {
+	pairs := []harNameValue{}
+	for _, name := range sortedKeys(headers) {
+		for _, value := range headers[name] {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	return pairs
+}
+
+// fromHARHeaders converts HAR name/value pairs to canonical headers
+func fromHARHeaders(pairs []harNameValue) map[string][]string {
+	headers := http.Header{}
+	for _, pair := range pairs {
+		headers.Add(pair.Name, pair.Value)
+	}
+	return headers
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gwconfig "github.com/gowright/framework/pkg/config"
+	"github.com/gowright/framework/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRecordingServer starts a server that echoes request bodies and counts requests
+func newRecordingServer(t *testing.T) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count := atomic.AddInt32(&requests, 1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Count", string(rune('0'+count)))
+		switch r.URL.Path {
+		case "/binary":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write([]byte{0xff, 0x00, 0xfe})
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			if r.Method == http.MethodPost {
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: "live-session", Path: "/", HttpOnly: true})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"path": r.URL.RequestURI(), "body": string(body)})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// newRecordingTester creates an initialized tester with a recording configuration
+func newRecordingTester(t *testing.T, baseURL string, recording *gwconfig.RecordingConfig) *APITester {
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: baseURL, Timeout: 5 * time.Second, Recording: recording}))
+	return tester
+}
+
+func TestAPITester_RecordAndReplay(t *testing.T) {
+	for _, name := range []string{"session.har", "session.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			server, requests := newRecordingServer(t)
+			file := filepath.Join(t.TempDir(), "recordings", name)
+
+			recording := newRecordingTester(t, server.URL, &gwconfig.RecordingConfig{
+				Mode:          RecordingModeRecord,
+				File:          file,
+				IgnoreHeaders: []string{"Authorization"},
+			})
+			live := make([]*core.APIResponse, 0, 4)
+			for _, request := range []func() (*core.APIResponse, error){
+				func() (*core.APIResponse, error) { return recording.Get("/items?b=2&a=1", nil) },
+				func() (*core.APIResponse, error) { return recording.Get("/items?b=2&a=1", nil) },
+				func() (*core.APIResponse, error) {
+					return recording.Post("/items", map[string]string{"name": "x"}, map[string]string{"Authorization": "Bearer secret"})
+				},
+				func() (*core.APIResponse, error) { return recording.Get("/binary", nil) },
+			} {
+				response, err := request()
+				require.NoError(t, err)
+				live = append(live, response)
+			}
+			assert.EqualValues(t, 4, atomic.LoadInt32(requests))
+
+			// Exchanges are written when the tester is cleaned up
+			_, err := os.Stat(file)
+			assert.True(t, os.IsNotExist(err))
+			require.NoError(t, recording.Cleanup())
+
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+			assert.NotContains(t, string(data), "Bearer secret")
+			assert.NotContains(t, string(data), "live-session")
+			assert.Contains(t, string(data), "session=****; Path=/; HttpOnly")
+
+			// Replay needs no network: the server is gone
+			server.Close()
+			replaying := newRecordingTester(t, server.URL, &gwconfig.RecordingConfig{Mode: RecordingModeReplay, File: file, Strict: true})
+
+			// Query parameter order does not matter, and repeated requests replay in order
+			first, err := replaying.Get("/items?a=1&b=2", nil)
+			require.NoError(t, err)
+			assert.Equal(t, live[0].Body, first.Body)
+			assert.Equal(t, "1", first.Headers["X-Count"])
+			second, err := replaying.Get("/items?a=1&b=2", nil)
+			require.NoError(t, err)
+			assert.Equal(t, "2", second.Headers["X-Count"])
+			third, err := replaying.Get("/items?a=1&b=2", nil)
+			require.NoError(t, err)
+			assert.Equal(t, "2", third.Headers["X-Count"])
+
+			posted, err := replaying.Post("/items", map[string]string{"name": "x"}, nil)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(live[2].Body), string(posted.Body))
+
+			binary, err := replaying.Get("/binary", nil)
+			require.NoError(t, err)
+			assert.Equal(t, []byte{0xff, 0x00, 0xfe}, binary.Body)
+
+			_, err = replaying.Get("/unknown", nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "no recorded response matches GET")
+		})
+	}
+}
+
+func TestAPITester_ReplayMatching(t *testing.T) {
+	server, requests := newRecordingServer(t)
+	file := filepath.Join(t.TempDir(), "cassette.yaml")
+
+	recording := newRecordingTester(t, server.URL, &gwconfig.RecordingConfig{Mode: RecordingModeRecord, File: file})
+	_, err := recording.Post("/orders", `{"id": 1}`, map[string]string{"X-Tenant": "a"})
+	require.NoError(t, err)
+	_, err = recording.Post("/orders", `{"id": 2}`, map[string]string{"X-Tenant": "a"})
+	require.NoError(t, err)
+	require.NoError(t, recording.Cleanup())
+
+	t.Run("body", func(t *testing.T) {
+		tester := newRecordingTester(t, server.URL, &gwconfig.RecordingConfig{
+			Mode: RecordingModeReplay, File: file, MatchOn: []string{"method", "url", "body"}, Strict: true,
+		})
+		response, err := tester.Post("/orders", `{"id": 2}`, map[string]string{"X-Tenant": "a"})
+		require.NoError(t, err)
+		assert.Contains(t, string(response.Body), `\"id\": 2`)
+
+		_, err = tester.Post("/orders", `{"id": 3}`, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		tester := newRecordingTester(t, server.URL, &gwconfig.RecordingConfig{
+			Mode: RecordingModeReplay, File: file, MatchOn: []string{"headers"}, IgnoreHeaders: []string{"X-Tenant"}, Strict: true,
+		})
+		_, err := tester.Post("/orders", `{"id": 1}`, map[string]string{"X-Tenant": "b"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("unmatched requests reach the network when not strict", func(t *testing.T) {
+		before := atomic.LoadInt32(requests)
+		tester := newRecordingTester(t, server.URL, &gwconfig.RecordingConfig{Mode: RecordingModeReplay, File: file})
+		response, err := tester.Get("/missing", nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+		assert.Equal(t, before+1, atomic.LoadInt32(requests))
+	})
+
+	t.Run("configuration errors", func(t *testing.T) {
+		tester := NewAPITester()
+		for _, recording := range []*gwconfig.RecordingConfig{
+			{Mode: "rewind", File: file},
+			{Mode: RecordingModeRecord},
+			{Mode: RecordingModeReplay, File: filepath.Join(t.TempDir(), "missing.har")},
+			{Mode: RecordingModeReplay, File: file, MatchOn: []string{"cookies"}},
+		} {
+			err := tester.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, Recording: recording})
+			require.Error(t, err, "%+v", recording)
+			assert.Equal(t, core.ConfigurationError, core.GetErrorType(err))
+		}
+	})
+}
+
+func TestAPITester_RecordingMasksSecrets(t *testing.T) {
+	server := newTokenServer(t, 3600)
+	file := filepath.Join(t.TempDir(), "session.har")
+	auth := &gwconfig.AuthConfig{
+		Type:     "oauth2",
+		Username: "alice",
+		Password: "wonderland",
+		OAuth2: &gwconfig.OAuth2Config{
+			TokenURL:     server.URL + "/token",
+			GrantType:    "password",
+			ClientID:     "gowright",
+			ClientSecret: "s3cret",
+			AuthStyle:    "params",
+		},
+	}
+	newTester := func(recording *gwconfig.RecordingConfig) *APITester {
+		tester := NewAPITester()
+		require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, Timeout: 5 * time.Second, Auth: auth, Recording: recording}))
+		return tester
+	}
+
+	recording := newTester(&gwconfig.RecordingConfig{Mode: RecordingModeRecord, File: file})
+	assert.Equal(t, "Bearer token-1", authorization(t, recording))
+	_, err := recording.Post("/me?password=wonderland", map[string]string{"password": "wonderland"}, map[string]string{"Cookie": "session=abc"})
+	require.NoError(t, err)
+	require.NoError(t, recording.Cleanup())
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	for _, secret := range []string{"/token", "s3cret", "wonderland", "token-1", "refresh-1", "session=abc"} {
+		assert.NotContains(t, string(data), secret)
+	}
+	assert.Contains(t, string(data), "password=****")
+
+	// Requests are masked the same way before matching, and token requests still reach the network
+	replaying := newTester(&gwconfig.RecordingConfig{Mode: RecordingModeReplay, File: file, MatchOn: []string{"method", "url", "body"}, Strict: true})
+	response, err := replaying.Post("/me?password=wonderland", map[string]string{"password": "wonderland"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Len(t, server.tokenRequests(), 2)
+}
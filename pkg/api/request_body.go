@@ -0,0 +1,211 @@
+package api
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gowright/framework/pkg/core"
+)
+
+// quoteEscaper escapes quotes and backslashes in Content-Disposition parameters
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// requestPayload returns the body to send for a test, which may set a JSON body or a request body but not both
+func requestPayload(body interface{}, requestBody *core.RequestBody) (interface{}, error) {
+	if requestBody == nil {
+		return body, nil
+	}
+	if body != nil {
+		return nil, core.NewGowrightError(core.ValidationError, "a test cannot set both a body and a request body", nil)
+	}
+	return requestBody, nil
+}
+
+// encodeRequestBody returns the reader and content type of a request body. Files given by path are
+// opened; the reader is then an *os.File the caller closes.
+func encodeRequestBody(spec *core.RequestBody) (io.Reader, string, error) {
+	set := 0
+	for _, isSet := range []bool{spec.Form != nil, spec.Multipart != nil, spec.Raw != "", spec.File != "", spec.Reader != nil, spec.XML != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, "", core.NewGowrightError(core.ValidationError, "request body must set exactly one of form, multipart, raw, file, reader or xml", nil)
+	}
+
+	var reader io.Reader
+	var contentType string
+	switch {
+	case spec.Form != nil:
+		reader, contentType = strings.NewReader(encodeForm(spec.Form)), "application/x-www-form-urlencoded"
+	case spec.Multipart != nil:
+		return encodeMultipart(spec.Multipart)
+	case spec.Raw != "":
+		reader, contentType = strings.NewReader(spec.Raw), "application/octet-stream"
+	case spec.File != "":
+		file, err := os.Open(spec.File)
+		if err != nil {
+			return nil, "", core.NewGowrightError(core.ConfigurationError, "failed to open request body file", err).
+				WithContext("file", spec.File)
+		}
+		reader, contentType = file, contentTypeOf(spec.File)
+	case spec.Reader != nil:
+		reader, contentType = spec.Reader, "application/octet-stream"
+	default:
+		body, err := encodeXML(spec.XML)
+		if err != nil {
+			return nil, "", err
+		}
+		reader, contentType = bytes.NewReader(body), "application/xml"
+	}
+
+	if spec.ContentType != "" {
+		contentType = spec.ContentType
+	}
+	return reader, contentType, nil
+}
+
+// encodeForm encodes form fields; a list value repeats the field once per item
+func encodeForm(form map[string]interface{}) string {
+	values := url.Values{}
+	for name, value := range form {
+		switch typed := value.(type) {
+		case []interface{}:
+			for _, item := range typed {
+				values.Add(name, fmt.Sprint(item))
+			}
+		case []string:
+			values[name] = append(values[name], typed...)
+		default:
+			values.Add(name, fmt.Sprint(value))
+		}
+	}
+	return values.Encode()
+}
+
+// encodeMultipart writes the parts of a multipart/form-data body in order
+func encodeMultipart(parts []core.MultipartPart) (io.Reader, string, error) {
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+	for i, part := range parts {
+		if part.Name == "" {
+			return nil, "", core.NewGowrightError(core.ValidationError, "multipart part has no name", nil).
+				WithContext("part", i)
+		}
+
+		content, fileName := []byte(part.Value), part.FileName
+		isFile := part.File != "" || part.Content != nil
+		if part.File != "" {
+			data, err := os.ReadFile(part.File)
+			if err != nil {
+				return nil, "", core.NewGowrightError(core.ConfigurationError, "failed to read multipart file", err).
+					WithContext("part", part.Name).
+					WithContext("file", part.File)
+			}
+			content = data
+			if fileName == "" {
+				fileName = filepath.Base(part.File)
+			}
+		} else if part.Content != nil {
+			content = part.Content
+		}
+
+		header := textproto.MIMEHeader{}
+		disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(part.Name))
+		if isFile {
+			disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(fileName))
+		}
+		header.Set("Content-Disposition", disposition)
+		switch {
+		case part.ContentType != "":
+			header.Set("Content-Type", part.ContentType)
+		case isFile:
+			header.Set("Content-Type", contentTypeOf(fileName))
+		}
+
+		partWriter, err := writer.CreatePart(header)
+		if err == nil {
+			_, err = partWriter.Write(content)
+		}
+		if err != nil {
+			return nil, "", core.NewGowrightError(core.APIError, "failed to encode multipart body", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", core.NewGowrightError(core.APIError, "failed to encode multipart body", err)
+	}
+	return &buffer, writer.FormDataContentType(), nil
+}
+
+// encodeXML sends strings and byte slices as they are and marshals other values with an XML declaration
+func encodeXML(value interface{}) ([]byte, error) {
+	switch typed := value.(type) {
+	case string:
+		return []byte(typed), nil
+	case []byte:
+		return typed, nil
+	}
+
+	body, err := xml.Marshal(value)
+	if err != nil {
+		return nil, core.NewGowrightError(core.ValidationError, "failed to encode XML body", err)
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// contentTypeOf returns the content type registered for the extension of a file name
+func contentTypeOf(fileName string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(fileName)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// interpolateRequestBody returns a copy of a request body with placeholders replaced in its form
+// values, raw text, XML strings, file paths and multipart values. File contents are sent as they are.
+func interpolateRequestBody(scope *core.Variables, spec *core.RequestBody) (*core.RequestBody, error) {
+	interpolated := *spec
+	var err error
+
+	if spec.Form != nil {
+		form, err := scope.InterpolateValue(spec.Form)
+		if err != nil {
+			return nil, err
+		}
+		interpolated.Form = form.(map[string]interface{})
+	}
+	if interpolated.Raw, err = scope.Interpolate(spec.Raw); err != nil {
+		return nil, err
+	}
+	if interpolated.File, err = scope.Interpolate(spec.File); err != nil {
+		return nil, err
+	}
+	if text, isText := spec.XML.(string); isText {
+		if interpolated.XML, err = scope.Interpolate(text); err != nil {
+			return nil, err
+		}
+	}
+
+	if spec.Multipart != nil {
+		interpolated.Multipart = make([]core.MultipartPart, len(spec.Multipart))
+		for i, part := range spec.Multipart {
+			for _, field := range []*string{&part.Value, &part.File, &part.FileName} {
+				if *field, err = scope.Interpolate(*field); err != nil {
+					return nil, err
+				}
+			}
+			interpolated.Multipart[i] = part
+		}
+	}
+	return &interpolated, nil
+}
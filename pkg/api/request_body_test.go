@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gwconfig "github.com/gowright/framework/pkg/config"
+	"github.com/gowright/framework/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBodyEchoServer starts a server that describes the body it received as JSON: its media type,
+// the raw text, form fields and multipart parts
+func newBodyEchoServer(t *testing.T) *APITester {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		description := map[string]interface{}{"type": mediaType}
+		switch mediaType {
+		case "application/x-www-form-urlencoded":
+			require.NoError(t, r.ParseForm())
+			description["form"] = r.PostForm
+		case "multipart/form-data":
+			reader, err := r.MultipartReader()
+			require.NoError(t, err)
+			var parts []map[string]string
+			for {
+				part, err := reader.NextPart()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				content, _ := io.ReadAll(part)
+				parts = append(parts, map[string]string{
+					"name": part.FormName(), "file": part.FileName(), "type": part.Header.Get("Content-Type"), "content": string(content),
+				})
+			}
+			description["parts"] = parts
+		default:
+			body, _ := io.ReadAll(r.Body)
+			description["raw"] = string(body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(description)
+	}))
+	t.Cleanup(server.Close)
+
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, Timeout: 2 * time.Second}))
+	return tester
+}
+
+type xmlOrder struct {
+	XMLName xml.Name `xml:"order"`
+	ID      int      `xml:"id,attr"`
+	Item    string   `xml:"item"`
+}
+
+func TestAPITester_RequestBodies(t *testing.T) {
+	tester := newBodyEchoServer(t)
+	dir := t.TempDir()
+	report := filepath.Join(dir, "report.json")
+	require.NoError(t, os.WriteFile(report, []byte("{\"total\": 9.5}"), 0600))
+	tester.Variables().Set("user", "ada")
+
+	tests := []struct {
+		name     string
+		body     *core.RequestBody
+		expected map[string]interface{}
+	}{
+		{
+			name: "form",
+			body: &core.RequestBody{Form: map[string]interface{}{"user": "{{user}}", "tag": []interface{}{"a", "b"}}},
+			expected: map[string]interface{}{
+				"$.type": "application/x-www-form-urlencoded", "$.form.user[0]": "ada", "$.form.tag": []interface{}{"a", "b"},
+			},
+		},
+		{
+			name: "multipart",
+			body: &core.RequestBody{Multipart: []core.MultipartPart{
+				{Name: "user", Value: "{{user}}"},
+				{Name: "report", File: report},
+				{Name: "avatar", Content: []byte("PNG"), FileName: "me.png", ContentType: "image/x-custom"},
+			}},
+			expected: map[string]interface{}{
+				"$.type":             "multipart/form-data",
+				"$.parts[0].content": "ada",
+				"$.parts[0].file":    "",
+				"$.parts[1].file":    "report.json",
+				"$.parts[1].type":    "application/json",
+				"$.parts[1].content": "{\"total\": 9.5}",
+				"$.parts[2].file":    "me.png",
+				"$.parts[2].type":    "image/x-custom",
+			},
+		},
+		{
+			name:     "raw",
+			body:     &core.RequestBody{Raw: "user={{user}}", ContentType: "text/plain"},
+			expected: map[string]interface{}{"$.type": "text/plain", "$.raw": "user=ada"},
+		},
+		{
+			name:     "file",
+			body:     &core.RequestBody{File: report},
+			expected: map[string]interface{}{"$.type": "application/json", "$.raw": "{\"total\": 9.5}"},
+		},
+		{
+			name:     "reader",
+			body:     &core.RequestBody{Reader: strings.NewReader("\x00\x01")},
+			expected: map[string]interface{}{"$.type": "application/octet-stream", "$.raw": "\x00\x01"},
+		},
+		{
+			name:     "xml struct",
+			body:     &core.RequestBody{XML: xmlOrder{ID: 7, Item: "book"}},
+			expected: map[string]interface{}{"$.type": "application/xml", "$.raw": xml.Header + `<order id="7"><item>book</item></order>`},
+		},
+		{
+			name:     "xml text",
+			body:     &core.RequestBody{XML: "<user>{{user}}</user>", ContentType: "text/xml"},
+			expected: map[string]interface{}{"$.type": "text/xml", "$.raw": "<user>ada</user>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tester.ExecuteTest(&core.APITest{
+				Name:        tt.name,
+				Method:      "POST",
+				Endpoint:    "/upload",
+				Headers:     map[string]string{"Content-Type": "application/json"},
+				RequestBody: tt.body,
+				Expected:    &core.APIExpectation{StatusCode: http.StatusOK, JSONPath: tt.expected},
+			})
+			assert.Equal(t, core.TestStatusPassed, result.Status, "%v %v", result.Error, result.Steps)
+		})
+	}
+
+	t.Run("builder and JSON definitions", func(t *testing.T) {
+		result := NewAPITest("upload", "PUT", "/upload", tester).
+			AddMultipartField("user", "{{user}}").
+			AddMultipartFile("report", report).
+			SetExpectedJSONPath("$.parts[1].file", "report.json").
+			Clone().
+			Execute()
+		assert.Equal(t, core.TestStatusPassed, result.Status, "%v", result.Error)
+
+		var test core.APITest
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"name": "avatar", "method": "POST", "endpoint": "/upload",
+			"request_body": {"multipart": [{"name": "avatar", "content": "UE5H", "file_name": "a.png"}]},
+			"expected": {"status_code": 200, "json_path": {"$.parts[0].content": "PNG", "$.parts[0].type": "image/png"}}
+		}`), &test))
+		result = tester.ExecuteTest(&test)
+		assert.Equal(t, core.TestStatusPassed, result.Status, "%v", result.Error)
+	})
+
+	t.Run("invalid bodies", func(t *testing.T) {
+		result := tester.ExecuteTest(&core.APITest{Name: "both", Method: "POST", Endpoint: "/upload", Body: "x", RequestBody: &core.RequestBody{Raw: "y"}})
+		assert.Equal(t, core.TestStatusError, result.Status)
+		assert.Equal(t, core.ValidationError, core.GetErrorType(result.Error))
+
+		_, err := tester.Post("/upload", core.RequestBody{Raw: "a", XML: "<a/>"}, nil)
+		assert.EqualError(t, err, "request body must set exactly one of form, multipart, raw, file, reader or xml")
+
+		_, err = tester.Post("/upload", &core.RequestBody{File: filepath.Join(dir, "missing.bin")}, nil)
+		assert.Equal(t, core.ConfigurationError, core.GetErrorType(err))
+	})
+}
@@ -0,0 +1,385 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/gowright/framework/pkg/core"
+)
+
+// Snippet formats for reproducing a request
+const (
+	ReproduceCurl   = "curl"
+	ReproduceHTTPie = "httpie"
+	ReproduceGo     = "go"
+)
+
+// defaultRequestHistory is the number of requests kept when APIConfig.RequestHistory is zero
+const defaultRequestHistory = 10
+
+// maxRecordedBody bounds the request body kept for a snippet
+const maxRecordedBody = 64 << 10
+
+// secretMask replaces secrets in request snippets
+const secretMask = "****"
+
+// RequestRecord is a request sent by an APITester
+type RequestRecord struct {
+	Method        string      `json:"method"`
+	URL           string      `json:"url"`
+	Header        http.Header `json:"header,omitempty"`
+	Body          []byte      `json:"body,omitempty"`
+	BodySize      int64       `json:"body_size"`      // -1 when unknown
+	BodyTruncated bool        `json:"body_truncated"` // only the first 64 KiB of the body were kept
+	Time          time.Time   `json:"time"`
+}
+
+// requestHistory keeps the most recent requests
+type requestHistory struct {
+	mutex   sync.Mutex
+	records []*RequestRecord
+	limit   int
+	total   int // requests recorded since the history was created
+}
+
+// add records a request, dropping the oldest one when the history is full
+func (h *requestHistory) add(record *RequestRecord) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.total++
+	if h.limit <= 0 {
+		return
+	}
+	if len(h.records) == h.limit {
+		h.records = h.records[1:]
+	}
+	h.records = append(h.records, record)
+}
+
+// count returns the number of requests recorded so far, to pass to since
+func (h *requestHistory) count() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.total
+}
+
+// since returns the kept requests recorded after count requests
+func (h *requestHistory) since(count int) []*RequestRecord {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	newer := h.total - count
+	if newer <= 0 {
+		return nil
+	}
+	return append([]*RequestRecord(nil), h.records[max(len(h.records)-newer, 0):]...)
+}
+
+// record is a resty pre-request hook that records each request attempt as it is sent
+func (h *requestHistory) record(_ *resty.Client, req *http.Request) error {
+	record := &RequestRecord{
+		Method:   req.Method,
+		URL:      req.URL.String(),
+		Header:   req.Header.Clone(),
+		BodySize: req.ContentLength,
+		Time:     time.Now(),
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		// Keep the start of the body and send the rest unread, so large uploads are not buffered
+		body := req.Body
+		prefix, err := io.ReadAll(io.LimitReader(body, maxRecordedBody+1))
+		if err != nil {
+			return err
+		}
+		if len(prefix) > maxRecordedBody {
+			record.Body, record.BodyTruncated = prefix[:maxRecordedBody], true
+			req.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(prefix), body), body}
+		} else {
+			record.Body, record.BodySize = prefix, int64(len(prefix))
+			_ = body.Close()
+			req.Body = io.NopCloser(bytes.NewReader(prefix))
+		}
+	} else if req.ContentLength <= 0 {
+		record.BodySize = 0
+	}
+
+	h.add(record)
+	return nil
+}
+
+// RecentRequests returns the last requests the tester sent, oldest first, with secrets masked
+func (at *APITester) RecentRequests() []*RequestRecord {
+	if at.history == nil {
+		return nil
+	}
+	return at.maskRecords(at.history.since(0))
+}
+
+// maskRecords returns copies of records with the credentials of the auth config masked
+func (at *APITester) maskRecords(records []*RequestRecord) []*RequestRecord {
+	replacer := at.secretReplacer()
+	masked := make([]*RequestRecord, len(records))
+	for i, record := range records {
+		copied := *record
+		copied.URL = replacer.Replace(record.URL)
+		copied.Header = maskHeader(replacer, record.Header)
+		copied.Body = []byte(replacer.Replace(string(record.Body)))
+		masked[i] = &copied
+	}
+	return masked
+}
+
+// secretReplacer returns a replacer masking the credentials of the auth config and the cached tokens
+func (at *APITester) secretReplacer() *strings.Replacer {
+	var secrets []string
+	if at.config != nil && at.config.Auth != nil {
+		auth := at.config.Auth
+		secrets = append(secrets, auth.Password, auth.Token, auth.APIKey)
+		for _, value := range auth.Headers {
+			secrets = append(secrets, value)
+		}
+		if auth.OAuth2 != nil {
+			secrets = append(secrets, auth.OAuth2.ClientSecret)
+		}
+		if auth.JWT != nil {
+			secrets = append(secrets, auth.JWT.Secret)
+		}
+	}
+	if at.tokenSource != nil {
+		secrets = append(secrets, at.tokenSource.secrets()...)
+	}
+
+	// Longer secrets first, so a secret containing another is masked whole
+	pairs := []string{}
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	for _, secret := range secrets {
+		if secret != "" {
+			pairs = append(pairs, secret, secretMask)
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// maskHeader returns a copy of header with secrets, credentials and cookie values masked
+func maskHeader(replacer *strings.Replacer, header map[string][]string) http.Header {
+	masked := make(http.Header, len(header))
+	for name, values := range header {
+		for _, value := range values {
+			switch http.CanonicalHeaderKey(name) {
+			case "Authorization", "Proxy-Authorization":
+				// Basic credentials are encoded, so the whole credential is masked
+				if scheme, _, found := strings.Cut(value, " "); found {
+					value = scheme + " " + secretMask
+				} else {
+					value = secretMask
+				}
+			case "Cookie":
+				value = maskCookieValues(value)
+			case "Set-Cookie":
+				value = maskSetCookieValue(value)
+			}
+			masked[name] = append(masked[name], replacer.Replace(value))
+		}
+	}
+	return masked
+}
+
+// maskCookieValues masks the values of a Cookie header and keeps the cookie names
+func maskCookieValues(value string) string {
+	pairs := strings.Split(value, ";")
+	for i, pair := range pairs {
+		if name, _, found := strings.Cut(pair, "="); found {
+			pairs[i] = name + "=" + secretMask
+		}
+	}
+	return strings.Join(pairs, ";")
+}
+
+// maskSetCookieValue masks the cookie value of a Set-Cookie header and keeps its name and attributes
+func maskSetCookieValue(value string) string {
+	pair, attributes, found := strings.Cut(value, ";")
+	pair = maskCookieValues(pair)
+	if found {
+		return pair + ";" + attributes
+	}
+	return pair
+}
+
+// requestCount returns the number of requests recorded so far, to pass to attachReproduction
+func (at *APITester) requestCount() int {
+	if at.history == nil {
+		return 0
+	}
+	return at.history.count()
+}
+
+// attachReproduction adds snippets of the requests a failed test sent, after count requests, to its
+// logs and to its metadata under "reproduction"
+func (at *APITester) attachReproduction(result *core.TestCaseResult, count int) {
+	if at.history == nil || result.Status == core.TestStatusPassed || result.Status == core.TestStatusSkipped {
+		return
+	}
+	records := at.maskRecords(at.history.since(count))
+	if len(records) == 0 {
+		return
+	}
+
+	formats := at.config.ReproduceFormats
+	if len(formats) == 0 {
+		formats = []string{ReproduceCurl}
+	}
+	var snippets []string
+	for i, record := range records {
+		for _, format := range formats {
+			snippet, _ := record.Snippet(format)
+			label := fmt.Sprintf("Reproduce %s %s with %s", record.Method, record.URL, format)
+			if len(records) > 1 {
+				label = fmt.Sprintf("Reproduce request %d of %d (%s %s) with %s", i+1, len(records), record.Method, record.URL, format)
+			}
+			snippets = append(snippets, label+":\n"+snippet)
+		}
+	}
+
+	result.Logs = append(result.Logs, snippets...)
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata["reproduction"] = snippets
+}
+
+// validateReproduceFormats checks the snippet formats of an API config
+func validateReproduceFormats(formats []string) error {
+	for _, format := range formats {
+		switch format {
+		case ReproduceCurl, ReproduceHTTPie, ReproduceGo:
+		default:
+			return core.NewGowrightError(core.ConfigurationError, "unsupported reproduce format: "+format, nil).
+				WithContext("supported", "curl, httpie, go")
+		}
+	}
+	return nil
+}
+
+// Snippet returns the request as a curl command, an HTTPie command or a Go program fragment
+func (r *RequestRecord) Snippet(format string) (string, error) {
+	switch format {
+	case ReproduceCurl:
+		return r.Curl(), nil
+	case ReproduceHTTPie:
+		return r.HTTPie(), nil
+	case ReproduceGo:
+		return r.GoSnippet(), nil
+	}
+	return "", validateReproduceFormats([]string{format})
+}
+
+// Curl returns the request as a curl command
+func (r *RequestRecord) Curl() string {
+	lines := []string{"curl"}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodHead:
+		// -X HEAD makes curl wait for a body that never comes
+		lines[0] += " --head"
+	default:
+		lines[0] += " -X " + r.Method
+	}
+	lines[0] += " " + shellQuote(r.URL)
+	for _, name := range sortedKeys(r.Header) {
+		for _, value := range r.Header[name] {
+			lines = append(lines, "-H "+shellQuote(name+": "+value))
+		}
+	}
+	if body, ok := r.textBody(); ok {
+		lines = append(lines, "--data-raw "+shellQuote(body))
+	} else if body != "" {
+		lines = append(lines, "--data-binary @body.bin")
+		return r.bodyNote() + "\n" + strings.Join(lines, " \\\n  ")
+	}
+	return strings.Join(lines, " \\\n  ")
+}
+
+// HTTPie returns the request as an HTTPie command
+func (r *RequestRecord) HTTPie() string {
+	lines := []string{"http"}
+	body, isText := r.textBody()
+	switch {
+	case isText:
+		lines[0] += " --raw " + shellQuote(body)
+	case body != "":
+		lines[0] += " --raw \"$(cat body.bin)\""
+	}
+	lines[0] += " " + r.Method + " " + shellQuote(r.URL)
+	for _, name := range sortedKeys(r.Header) {
+		for _, value := range r.Header[name] {
+			lines = append(lines, shellQuote(name+":"+value))
+		}
+	}
+	command := strings.Join(lines, " \\\n  ")
+	if !isText && body != "" {
+		command = r.bodyNote() + "\n" + command
+	}
+	return command
+}
+
+// GoSnippet returns the request as a Go program fragment using net/http
+func (r *RequestRecord) GoSnippet() string {
+	var snippet strings.Builder
+	body, isText := r.textBody()
+	switch {
+	case isText:
+		fmt.Fprintf(&snippet, "body := strings.NewReader(%s)\n", goQuote(body))
+	case body != "":
+		snippet.WriteString(strings.Replace(r.bodyNote(), "#", "//", 1) + "\n")
+		snippet.WriteString("body, err := os.Open(\"body.bin\")\nif err != nil {\n\tpanic(err)\n}\n")
+	default:
+		snippet.WriteString("var body io.Reader\n")
+	}
+	fmt.Fprintf(&snippet, "req, err := http.NewRequest(%q, %q, body)\nif err != nil {\n\tpanic(err)\n}\n", r.Method, r.URL)
+	for _, name := range sortedKeys(r.Header) {
+		for _, value := range r.Header[name] {
+			fmt.Fprintf(&snippet, "req.Header.Add(%q, %q)\n", name, value)
+		}
+	}
+	snippet.WriteString("resp, err := http.DefaultClient.Do(req)\nif err != nil {\n\tpanic(err)\n}\ndefer resp.Body.Close()")
+	return snippet.String()
+}
+
+// textBody returns the body and whether it is complete UTF-8 text that can be pasted into a command
+func (r *RequestRecord) textBody() (string, bool) {
+	body := string(r.Body)
+	return body, body != "" && !r.BodyTruncated && utf8.ValidString(body)
+}
+
+// bodyNote explains that a binary or truncated body has to be supplied as body.bin
+func (r *RequestRecord) bodyNote() string {
+	size := fmt.Sprintf("%d byte", r.BodySize)
+	if r.BodySize < 0 {
+		size = "streamed"
+	}
+	return fmt.Sprintf("# the %s body is not included; save it as body.bin", size)
+}
+
+// shellQuote quotes a string for POSIX shells
+func shellQuote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+}
+
+// goQuote quotes a string as a Go literal, preferring a raw string for multi-line text
+func goQuote(text string) string {
+	if strings.Contains(text, "\n") && !strings.ContainsAny(text, "`\r") {
+		return "`" + text + "`"
+	}
+	return fmt.Sprintf("%q", text)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gwconfig "github.com/gowright/framework/pkg/config"
+	"github.com/gowright/framework/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestRecord_Snippets(t *testing.T) {
+	record := &RequestRecord{
+		Method: "POST",
+		URL:    "https://api.example.com/orders?dry=1",
+		Header: http.Header{"Content-Type": {"application/json"}, "X-Note": {"it's"}},
+		Body:   []byte(`{"sku": "A"}`),
+	}
+
+	assert.Equal(t, `curl -X POST 'https://api.example.com/orders?dry=1' \
+  -H 'Content-Type: application/json' \
+  -H 'X-Note: it'\''s' \
+  --data-raw '{"sku": "A"}'`, record.Curl())
+
+	assert.Equal(t, `http --raw '{"sku": "A"}' POST 'https://api.example.com/orders?dry=1' \
+  'Content-Type:application/json' \
+  'X-Note:it'\''s'`, record.HTTPie())
+
+	assert.Equal(t, `body := strings.NewReader("{\"sku\": \"A\"}")
+req, err := http.NewRequest("POST", "https://api.example.com/orders?dry=1", body)
+if err != nil {
+	panic(err)
+}
+req.Header.Add("Content-Type", "application/json")
+req.Header.Add("X-Note", "it's")
+resp, err := http.DefaultClient.Do(req)
+if err != nil {
+	panic(err)
+}
+defer resp.Body.Close()`, record.GoSnippet())
+
+	get := &RequestRecord{Method: "GET", URL: "https://api.example.com/"}
+	assert.Equal(t, `curl 'https://api.example.com/'`, get.Curl())
+	head := &RequestRecord{Method: "HEAD", URL: "https://api.example.com/"}
+	assert.Equal(t, `curl --head 'https://api.example.com/'`, head.Curl())
+	snippet, err := get.Snippet(ReproduceGo)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(snippet, "var body io.Reader\n"))
+
+	binary := &RequestRecord{Method: "PUT", URL: "https://api.example.com/blob", Body: []byte{0xff, 0x00}, BodySize: 2}
+	assert.Equal(t, "# the 2 byte body is not included; save it as body.bin\ncurl -X PUT 'https://api.example.com/blob' \\\n  --data-binary @body.bin", binary.Curl())
+
+	_, err = get.Snippet("wget")
+	assert.Equal(t, core.ConfigurationError, core.GetErrorType(err))
+}
+
+func TestAPITester_RequestHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{
+		BaseURL:          server.URL,
+		Timeout:          2 * time.Second,
+		Auth:             &gwconfig.AuthConfig{Type: "bearer", Token: "secret-token", Headers: map[string]string{"X-Client-Secret": "hush-hush"}},
+		RequestHistory:   3,
+		ReproduceFormats: []string{ReproduceCurl, ReproduceHTTPie, ReproduceGo},
+	}))
+
+	result := tester.ExecuteTest(&core.APITest{
+		Name:     "create",
+		Method:   "POST",
+		Endpoint: "/fail?key=secret-token",
+		Body:     map[string]interface{}{"note": "hush-hush"},
+		Expected: &core.APIExpectation{StatusCode: http.StatusCreated},
+	})
+	require.Equal(t, core.TestStatusFailed, result.Status)
+	snippets, ok := result.Metadata["reproduction"].([]string)
+	require.True(t, ok)
+	require.Len(t, snippets, 3)
+	assert.Equal(t, snippets, result.Logs[len(result.Logs)-3:])
+	assert.True(t, strings.HasPrefix(snippets[0], "Reproduce POST "+server.URL+"/fail?key=**** with curl:\ncurl -X POST"), snippets[0])
+	assert.Contains(t, snippets[0], `-H 'Authorization: Bearer ****'`)
+	assert.Contains(t, snippets[0], `--data-raw '{"note":"****"}'`)
+	assert.Contains(t, snippets[1], "with httpie:\nhttp --raw")
+	assert.Contains(t, snippets[2], `req.Header.Add("X-Client-Secret", "****")`)
+	for _, snippet := range snippets {
+		assert.NotContains(t, snippet, "secret-token")
+		assert.NotContains(t, snippet, "hush-hush")
+	}
+
+	// Passing tests have no snippets, and only the last requests are kept
+	result = tester.ExecuteTest(&core.APITest{Name: "ok", Method: "GET", Endpoint: "/ok", Expected: &core.APIExpectation{StatusCode: http.StatusOK}})
+	assert.Equal(t, core.TestStatusPassed, result.Status)
+	assert.Nil(t, result.Metadata["reproduction"])
+	for _, path := range []string{"/a", "/b", "/c"} {
+		_, err := tester.Get(path, nil)
+		require.NoError(t, err)
+	}
+	recent := tester.RecentRequests()
+	require.Len(t, recent, 3)
+	assert.Equal(t, server.URL+"/a", recent[0].URL)
+	assert.Equal(t, "Bearer ****", recent[2].Header.Get("Authorization"))
+
+	t.Run("cookies", func(t *testing.T) {
+		result := tester.ExecuteTest(&core.APITest{
+			Name:     "cookie",
+			Method:   "GET",
+			Endpoint: "/fail",
+			Headers:  map[string]string{"Cookie": "session=cookie-token; theme=dark"},
+			Expected: &core.APIExpectation{StatusCode: http.StatusOK},
+		})
+		require.Equal(t, core.TestStatusFailed, result.Status)
+		snippets, ok := result.Metadata["reproduction"].([]string)
+		require.True(t, ok)
+		assert.Contains(t, snippets[0], `-H 'Cookie: session=****; theme=****'`)
+		assert.NotContains(t, snippets[0], "cookie-token")
+	})
+
+	t.Run("configuration", func(t *testing.T) {
+		err := NewAPITester().Initialize(&gwconfig.APIConfig{BaseURL: server.URL, ReproduceFormats: []string{"wget"}})
+		assert.Equal(t, core.ConfigurationError, core.GetErrorType(err))
+
+		disabled := NewAPITester()
+		require.NoError(t, disabled.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, RequestHistory: -1}))
+		result := NewAPITest("fail", "GET", "/fail", disabled).SetExpectedStatusCode(http.StatusOK).Execute()
+		assert.Equal(t, core.TestStatusFailed, result.Status)
+		assert.Nil(t, result.Metadata["reproduction"])
+		assert.Empty(t, disabled.RecentRequests())
+	})
+}
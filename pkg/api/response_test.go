@@ -0,0 +1,121 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gwconfig "github.com/gowright/framework/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPITester_ResponseDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Add("Link", `</users?page=2>; rel="next"`)
+			w.Header().Add("Link", `</users?page=9>; rel="last"`)
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", HttpOnly: true})
+			http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+			_, _ = w.Write([]byte(`[{"id": 1}, {"id": 2}]`))
+		case "/count":
+			w.Header().Set("Content-Type", "application/problem+json")
+			_, _ = w.Write([]byte(`42`))
+		case "/order":
+			w.Header().Set("Content-Type", "text/xml; charset=ISO-8859-1")
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="ISO-8859-1"?>
+<order xmlns="urn:orders" id="7"><items><item sku="A">Book</item><item sku="B">Stylo ` + "\xe0" + ` bille</item></items></order>`))
+		case "/broken":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":`))
+		}
+	}))
+	defer server.Close()
+
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{BaseURL: server.URL, Timeout: 2 * time.Second}))
+
+	response, err := tester.Get("/users", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", response.ContentType)
+	assert.Equal(t, "utf-8", response.Charset)
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": float64(1)}, map[string]interface{}{"id": float64(2)}}, response.JSONValue)
+	assert.Nil(t, response.JSON)
+	assert.Len(t, response.HeaderValues.Values("Link"), 2)
+	assert.Equal(t, `</users?page=2>; rel="next"`, response.Header("link"))
+	require.Len(t, response.Cookies, 2)
+	require.NotNil(t, response.Cookie("session"))
+	assert.True(t, response.Cookie("session").HttpOnly)
+	assert.Nil(t, response.Cookie("missing"))
+
+	response, err = tester.Get("/count", nil)
+	require.NoError(t, err)
+	assert.Equal(t, float64(42), response.JSONValue)
+
+	response, err = tester.Get("/order", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ISO-8859-1", response.Charset)
+	require.NotNil(t, response.XML)
+	assert.Equal(t, "order", response.XML.Name)
+	assert.Equal(t, "urn:orders", response.XML.Namespace)
+	assert.Equal(t, "7", response.XML.Attr("id"))
+	items := response.XML.Find("items/item")
+	require.Len(t, items, 2)
+	assert.Equal(t, "Stylo à bille", items[1].Text)
+	assert.Equal(t, "A", response.XML.First("*/item").Attr("sku"))
+	assert.Nil(t, response.XML.First("customer"))
+
+	response, err = tester.Get("/broken", nil)
+	require.NoError(t, err)
+	assert.Nil(t, response.JSONValue)
+	assert.Equal(t, `{"id":`, string(response.Body))
+}
+
+func TestAPITester_CookieJar(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", Path: "/"})
+			return
+		}
+		var cookies []string
+		for _, cookie := range r.Cookies() {
+			cookies = append(cookies, cookie.Name+"="+cookie.Value)
+		}
+		_, _ = fmt.Fprint(w, cookies)
+	}))
+	defer server.Close()
+
+	config := &gwconfig.APIConfig{BaseURL: server.URL, Timeout: 2 * time.Second}
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(config))
+
+	_, err := tester.Post("/login", nil, nil)
+	require.NoError(t, err)
+	response, err := tester.Get("/me", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[session=s1]", string(response.Body))
+
+	cookies, err := tester.Cookies("/me")
+	require.NoError(t, err)
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "s1", cookies[0].Value)
+
+	// Cookies survive initializing the tester again
+	require.NoError(t, tester.Initialize(config))
+	require.NoError(t, tester.SetCookies("/", &http.Cookie{Name: "locale", Value: "en"}))
+	response, err = tester.Get("/me", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[session=s1 locale=en]", string(response.Body))
+
+	tester.ClearCookies()
+	response, err = tester.Get("/me", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(response.Body))
+
+	_, err = NewAPITester().Cookies("/relative")
+	assert.Error(t, err)
+}
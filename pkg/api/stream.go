@@ -0,0 +1,536 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gowright/framework/pkg/assertions"
+	"github.com/gowright/framework/pkg/core"
+)
+
+// Stream protocols
+const (
+	StreamProtocolWebSocket = "websocket"
+	StreamProtocolSSE       = "sse"
+)
+
+// defaultStreamTimeout is how long an Expect step waits when neither the step nor the test sets a timeout
+const defaultStreamTimeout = 5 * time.Second
+
+// streamMessage is a message received over a stream
+type streamMessage struct {
+	event    string // SSE event type
+	data     []byte
+	received time.Time
+}
+
+// streamConnection is an open WebSocket or SSE connection
+type streamConnection interface {
+	// Send sends a message; SSE connections cannot send
+	Send(payload []byte, isBinary bool) error
+	// Messages returns the channel of received messages; it is closed when the connection ends
+	Messages() <-chan streamMessage
+	// Err returns why the connection ended, once Messages is closed
+	Err() error
+	Close() error
+}
+
+// streamReceiver delivers received messages until the connection ends or is closed
+type streamReceiver struct {
+	messages  chan streamMessage
+	done      chan struct{}
+	closeOnce sync.Once
+	err       error // why the connection ended; read after messages is closed
+}
+
+// newStreamReceiver creates a receiver with a small buffer
+func newStreamReceiver() streamReceiver {
+	return streamReceiver{messages: make(chan streamMessage, 64), done: make(chan struct{})}
+}
+
+// deliver passes a message on, reporting false once the connection was closed
+func (r *streamReceiver) deliver(message streamMessage) bool {
+	select {
+	case r.messages <- message:
+		return true
+	case <-r.done:
+		return false
+	}
+}
+
+// stop marks the connection closed so the reader stops delivering
+func (r *streamReceiver) stop() {
+	r.closeOnce.Do(func() { close(r.done) })
+}
+
+// Messages returns the channel of received messages
+func (r *streamReceiver) Messages() <-chan streamMessage {
+	return r.messages
+}
+
+// Err returns why the connection ended
+func (r *streamReceiver) Err() error {
+	return r.err
+}
+
+// ExecuteStreamTest connects to a WebSocket or SSE endpoint, runs the test's steps in order and
+// returns the result. Each step becomes an assertion step, the run stops at the first failed
+// step, and the transcript of sent and received messages is added to the logs.
+func (at *APITester) ExecuteStreamTest(test *core.StreamTest) *core.TestCaseResult {
+	startTime := time.Now()
+	result := &core.TestCaseResult{
+		Name:      test.Name,
+		StartTime: startTime,
+		Status:    core.TestStatusPassed,
+		Logs:      []string{},
+	}
+
+	scope := at.variables.NewScope()
+	conn, err := at.openStream(test, scope)
+	if err != nil {
+		result.Status = core.TestStatusError
+		result.Error = err
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(startTime)
+		return result
+	}
+
+	runner := &streamRunner{conn: conn, started: time.Now(), asserter: assertions.NewAsserter(), timeout: test.Timeout}
+	for i, step := range test.Steps {
+		if err := runner.run(i, step, scope); err != nil {
+			result.Status = core.TestStatusError
+			result.Error = err
+			break
+		}
+		if runner.asserter.HasFailures() {
+			result.Status = core.TestStatusFailed
+			result.Error = core.NewGowrightError(core.AssertionError, "one or more assertions failed", nil)
+			break
+		}
+	}
+	_ = conn.Close()
+
+	result.Steps = runner.asserter.GetSteps()
+	result.Logs = append(result.Logs, runner.transcript...)
+	result.Logs = append(result.Logs, fmt.Sprintf("%s stream to %s: %d message(s) sent, %d received",
+		streamProtocol(test), test.Endpoint, runner.sent, len(runner.received)))
+	result.EndTime = time.Now()
+	result.Duration = result.EndTime.Sub(startTime)
+	return result
+}
+
+// streamProtocol returns the protocol of a test, defaulting to WebSocket
+func streamProtocol(test *core.StreamTest) string {
+	if test.Protocol == "" {
+		return StreamProtocolWebSocket
+	}
+	return strings.ToLower(test.Protocol)
+}
+
+// openStream resolves the endpoint and connects with the tester's default and auth headers
+func (at *APITester) openStream(test *core.StreamTest, scope *core.Variables) (streamConnection, error) {
+	if at.client == nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "API tester is not initialized", nil)
+	}
+
+	protocol := streamProtocol(test)
+	if protocol != StreamProtocolWebSocket && protocol != StreamProtocolSSE {
+		return nil, core.NewGowrightError(core.ConfigurationError, fmt.Sprintf("unsupported stream protocol: %s", test.Protocol), nil)
+	}
+
+	endpoint, err := scope.Interpolate(test.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	target, err := at.streamURL(endpoint, protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := at.connectionHeaders()
+	if err != nil {
+		return nil, err
+	}
+	headers, err := scope.InterpolateHeaders(test.Headers)
+	if err != nil {
+		return nil, err
+	}
+	if cookies := at.cookieHeader(target); cookies != "" {
+		header.Set("Cookie", cookies)
+	}
+	for name, value := range headers {
+		header.Set(name, value)
+	}
+
+	timeout := at.config.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	// Streams bypass a recording transport, which would wait for the whole body
+	transport := baseTransport(at.client.GetClient().Transport)
+
+	var conn streamConnection
+	if protocol == StreamProtocolSSE {
+		conn, err = openEventStream(target, header, transport, timeout)
+	} else {
+		var tlsConfig *tls.Config
+		var proxyURL *url.URL
+		if httpTransport, ok := transport.(*http.Transport); ok {
+			tlsConfig = httpTransport.TLSClientConfig
+			proxyURL, err = webSocketProxy(httpTransport, target)
+		}
+		if err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			conn, err = dialWebSocket(ctx, target, header, tlsConfig, proxyURL)
+		}
+	}
+	if err != nil {
+		return nil, core.NewGowrightError(core.APIError, fmt.Sprintf("failed to open %s stream", protocol), err).
+			WithContext("url", target.String())
+	}
+	return conn, nil
+}
+
+// streamURL resolves an endpoint against the base URL and picks the scheme for the protocol
+func (at *APITester) streamURL(endpoint, protocol string) (*url.URL, error) {
+	target, err := at.resolveURL(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	secure := target.Scheme == "https" || target.Scheme == "wss"
+	switch {
+	case protocol == StreamProtocolWebSocket && secure:
+		target.Scheme = "wss"
+	case protocol == StreamProtocolWebSocket:
+		target.Scheme = "ws"
+	case secure:
+		target.Scheme = "https"
+	default:
+		target.Scheme = "http"
+	}
+	return target, nil
+}
+
+// connectionHeaders returns the default headers and credentials the tester adds to requests
+func (at *APITester) connectionHeaders() (http.Header, error) {
+	header := at.client.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	switch {
+	case at.tokenSource != nil:
+		token, err := at.tokenSource.Token()
+		if err != nil {
+			return nil, err
+		}
+		header.Set("Authorization", "Bearer "+token)
+	case at.client.Token != "":
+		header.Set("Authorization", at.client.AuthScheme+" "+at.client.Token)
+	case at.client.UserInfo != nil:
+		credentials := at.client.UserInfo.Username + ":" + at.client.UserInfo.Password
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	}
+	return header, nil
+}
+
+// eventStreamConnection is a Server-Sent Events connection
+type eventStreamConnection struct {
+	streamReceiver
+	body   interface{ Close() error }
+	cancel context.CancelFunc
+}
+
+// openEventStream sends the GET request of an event stream and starts reading events
+func openEventStream(target *url.URL, header http.Header, transport http.RoundTripper, timeout time.Duration) (*eventStreamConnection, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	request.Header = header.Clone()
+	request.Header.Set("Accept", "text/event-stream")
+	request.Header.Set("Cache-Control", "no-cache")
+
+	// The timeout applies to the response headers only; the stream stays open until closed
+	timer := time.AfterFunc(timeout, cancel)
+	response, err := (&http.Client{Transport: transport}).Do(request)
+	if err != nil || !timer.Stop() {
+		cancel()
+		if err == nil {
+			_ = response.Body.Close()
+			err = context.DeadlineExceeded
+		}
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK || !strings.HasPrefix(response.Header.Get("Content-Type"), "text/event-stream") {
+		_ = response.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("expected a text/event-stream response, got status %d with content type %q",
+			response.StatusCode, response.Header.Get("Content-Type"))
+	}
+
+	conn := &eventStreamConnection{streamReceiver: newStreamReceiver(), body: response.Body, cancel: cancel}
+	go conn.readLoop(bufio.NewReader(response.Body))
+	return conn, nil
+}
+
+// readLoop parses events: lines of "field: value", dispatched at each blank line
+func (c *eventStreamConnection) readLoop(reader *bufio.Reader) {
+	defer close(c.messages)
+
+	var event string
+	var data bytes.Buffer
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			c.err = err
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if data.Len() > 0 {
+				payload := bytes.TrimSuffix(data.Bytes(), []byte("\n"))
+				if !c.deliver(streamMessage{event: event, data: append([]byte(nil), payload...), received: time.Now()}) {
+					return
+				}
+			}
+			event = ""
+			data.Reset()
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		}
+	}
+}
+
+// Send is not supported: events only flow from the server
+func (c *eventStreamConnection) Send([]byte, bool) error {
+	return errors.New("cannot send messages over Server-Sent Events")
+}
+
+// Close stops reading and closes the response body
+func (c *eventStreamConnection) Close() error {
+	c.stop()
+	c.cancel()
+	return c.body.Close()
+}
+
+// streamRunner runs the steps of a stream test against an open connection
+type streamRunner struct {
+	conn       streamConnection
+	started    time.Time
+	asserter   *assertions.Asserter
+	timeout    time.Duration
+	received   []streamMessage
+	cursor     int // index of the first received message not consumed by an expectation
+	sent       int
+	closed     bool
+	transcript []string
+}
+
+// record adds a message to the transcript
+func (r *streamRunner) record(direction string, message streamMessage) {
+	text := string(message.data)
+	if !utf8.Valid(message.data) {
+		text = "base64:" + base64.StdEncoding.EncodeToString(message.data)
+	}
+	if message.event != "" {
+		text = "event " + message.event + ": " + text
+	}
+	r.transcript = append(r.transcript, fmt.Sprintf("[+%dms] %s %s", message.received.Sub(r.started).Milliseconds(), direction, text))
+}
+
+// run performs one step; the returned error means the step could not be carried out
+func (r *streamRunner) run(index int, step core.StreamStep, scope *core.Variables) error {
+	switch {
+	case step.Send != nil && step.Expect == nil:
+		return r.send(step.Send, scope)
+	case step.Expect != nil && step.Send == nil:
+		r.expect(step.Expect)
+		return nil
+	default:
+		return core.NewGowrightError(core.ValidationError, fmt.Sprintf("stream step %d must set exactly one of send and expect", index+1), nil)
+	}
+}
+
+// send interpolates and sends a message
+func (r *streamRunner) send(message interface{}, scope *core.Variables) error {
+	value, err := scope.InterpolateValue(message)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	isBinary := false
+	switch typed := value.(type) {
+	case string:
+		payload = []byte(typed)
+	case []byte:
+		payload, isBinary = typed, true
+	default:
+		if payload, err = json.Marshal(typed); err != nil {
+			return core.NewGowrightError(core.ValidationError, "failed to encode stream message", err)
+		}
+	}
+
+	if err := r.conn.Send(payload, isBinary); err != nil {
+		return core.NewGowrightError(core.APIError, "failed to send stream message", err)
+	}
+	r.sent++
+	r.record("sent", streamMessage{data: payload, received: time.Now()})
+	return nil
+}
+
+// expect waits for the messages an expectation describes and records an assertion step
+func (r *streamRunner) expect(expected *core.StreamExpectation) {
+	count := max(expected.Count, 1)
+	timeout := expected.Timeout
+	if timeout <= 0 {
+		timeout = r.timeout
+	}
+	if timeout <= 0 {
+		timeout = defaultStreamTimeout
+	}
+
+	step := core.AssertionStep{
+		Name:        "Stream",
+		Description: describeStreamExpectation(expected, count),
+		Expected:    count,
+		StartTime:   time.Now(),
+		Status:      core.TestStatusPassed,
+	}
+	if expected.Absent {
+		step.Expected = 0
+	}
+
+	matched := 0
+	var failure error
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+wait:
+	for expected.Absent || matched < count {
+		// Consume the messages received so far, in order
+		for ; r.cursor < len(r.received) && (expected.Absent || matched < count); r.cursor++ {
+			message := r.received[r.cursor]
+			if err := matchStreamMessage(expected, message); err == nil {
+				matched++
+				if expected.Absent {
+					failure = fmt.Errorf("unexpected message received: %s", message.data)
+					r.cursor++
+					break wait
+				}
+			} else if expected.Next {
+				failure = fmt.Errorf("message %d did not match: %v", r.cursor+1, err)
+				r.cursor++
+				break wait
+			}
+		}
+		if !expected.Absent && matched == count {
+			break
+		}
+
+		if r.closed {
+			if !expected.Absent {
+				failure = fmt.Errorf("connection ended after %d of %d matching message(s): %v", matched, count, r.conn.Err())
+			}
+			break
+		}
+		select {
+		case message, ok := <-r.conn.Messages():
+			if !ok {
+				r.closed = true
+				continue
+			}
+			r.received = append(r.received, message)
+			r.record("received", message)
+		case <-deadline.C:
+			if !expected.Absent {
+				failure = fmt.Errorf("timed out after %s with %d of %d matching message(s)", timeout, matched, count)
+			}
+			break wait
+		}
+	}
+
+	step.Actual = matched
+	if failure != nil {
+		step.Status = core.TestStatusFailed
+		step.Error = failure
+	}
+	step.EndTime = time.Now()
+	step.Duration = step.EndTime.Sub(step.StartTime)
+	r.asserter.AddStep(step)
+}
+
+// matchStreamMessage checks a message against the predicates of an expectation
+func matchStreamMessage(expected *core.StreamExpectation, message streamMessage) error {
+	if expected.Event != "" && message.event != expected.Event {
+		return fmt.Errorf("expected event %q, got %q", expected.Event, message.event)
+	}
+	if expected.Regex != "" {
+		if err := assertions.MatchRegex(expected.Regex).Match(string(message.data)); err != nil {
+			return err
+		}
+	}
+	if len(expected.JSONPath) > 0 {
+		document := assertions.ParseJSONBody(message.data)
+		for _, path := range sortedKeys(expected.JSONPath) {
+			if _, err := assertions.MatchJSONPath(document, path, expected.JSONPath[path]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// describeStreamExpectation describes an expectation for its assertion step
+func describeStreamExpectation(expected *core.StreamExpectation, count int) string {
+	var conditions []string
+	if expected.Event != "" {
+		conditions = append(conditions, "event "+expected.Event)
+	}
+	if expected.Regex != "" {
+		conditions = append(conditions, "text matching /"+expected.Regex+"/")
+	}
+	for _, path := range sortedKeys(expected.JSONPath) {
+		conditions = append(conditions, fmt.Sprintf("%s %s", path, describeExpected(expected.JSONPath[path])))
+	}
+
+	description := fmt.Sprintf("Receive %d message(s)", count)
+	switch {
+	case expected.Absent:
+		description = "Receive no message"
+	case expected.Next:
+		description = fmt.Sprintf("Receive next %d message(s)", count)
+	}
+	if len(conditions) > 0 {
+		description += " with " + strings.Join(conditions, ", ")
+	}
+	return description
+}
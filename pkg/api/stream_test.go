@@ -0,0 +1,288 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gowright/framework/pkg/assertions"
+	gwconfig "github.com/gowright/framework/pkg/config"
+	"github.com/gowright/framework/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newWebSocketServer starts a server that greets clients, pings them, answers a subscribe message
+// with three ticks and echoes anything else. It returns the Authorization header of the last client.
+func newWebSocketServer(t *testing.T) (*httptest.Server, func() string) {
+	var mutex sync.Mutex
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		authorization = r.Header.Get("Authorization")
+		mutex.Unlock()
+
+		conn, buffer, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+		_, _ = fmt.Fprintf(buffer, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+			webSocketAccept(r.Header.Get("Sec-WebSocket-Key")))
+		_ = buffer.Flush()
+
+		send := func(opcode byte, payload string) { _ = writeWebSocketFrame(conn, opcode, []byte(payload), false) }
+		send(webSocketText, `{"type": "welcome"}`)
+		send(webSocketPing, "keepalive")
+		for {
+			_, opcode, payload, err := readWebSocketFrame(buffer)
+			if err != nil || opcode == webSocketClose {
+				return
+			}
+			if opcode == webSocketPong {
+				continue
+			}
+			var message map[string]interface{}
+			if json.Unmarshal(payload, &message) == nil && message["action"] == "subscribe" {
+				for n := 1; n <= 3; n++ {
+					send(webSocketText, fmt.Sprintf(`{"type": "tick", "channel": %q, "n": %d}`, message["channel"], n))
+				}
+				continue
+			}
+			send(opcode, string(payload))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return authorization
+	}
+}
+
+// newStreamTester creates an initialized tester with bearer auth
+func newStreamTester(t *testing.T, baseURL string) *APITester {
+	tester := NewAPITester()
+	require.NoError(t, tester.Initialize(&gwconfig.APIConfig{
+		BaseURL: baseURL,
+		Timeout: 2 * time.Second,
+		Auth:    &gwconfig.AuthConfig{Type: "bearer", Token: "stream-token"},
+	}))
+	return tester
+}
+
+func TestAPITester_WebSocketStream(t *testing.T) {
+	server, authorization := newWebSocketServer(t)
+	tester := newStreamTester(t, server.URL)
+	tester.Variables().Set("channel", "prices")
+
+	result := tester.ExecuteStreamTest(&core.StreamTest{
+		Name:     "subscribe",
+		Endpoint: "/socket",
+		Timeout:  time.Second,
+		Steps: []core.StreamStep{
+			{Expect: &core.StreamExpectation{Next: true, JSONPath: map[string]interface{}{"$.type": "welcome"}}},
+			{Send: map[string]interface{}{"action": "subscribe", "channel": "{{channel}}"}},
+			{Expect: &core.StreamExpectation{Count: 2, JSONPath: map[string]interface{}{"$.type": "tick", "$.channel": "prices"}}},
+			{Expect: &core.StreamExpectation{Next: true, JSONPath: map[string]interface{}{"$.n": assertions.GreaterThan(2)}}},
+			{Send: "hello"},
+			{Expect: &core.StreamExpectation{Regex: "^hello$"}},
+			{Expect: &core.StreamExpectation{Absent: true, Regex: "error", Timeout: 50 * time.Millisecond}},
+		},
+	})
+	require.NoError(t, result.Error)
+	assert.Equal(t, core.TestStatusPassed, result.Status)
+	assert.Equal(t, "Bearer stream-token", authorization())
+
+	require.Len(t, result.Steps, 5)
+	assert.Equal(t, "Receive 2 message(s) with $.channel matching \"prices\", $.type matching \"tick\"", result.Steps[1].Description)
+	assert.Equal(t, 2, result.Steps[1].Actual)
+
+	require.Len(t, result.Logs, 8)
+	assert.Regexp(t, `^\[\+\d+ms\] received \{"type": "welcome"\}$`, result.Logs[0])
+	assert.Contains(t, result.Logs[1], `sent {"action":"subscribe","channel":"prices"}`)
+	assert.Contains(t, result.Logs[5], "sent hello")
+	assert.Equal(t, "websocket stream to /socket: 2 message(s) sent, 5 received", result.Logs[7])
+
+	t.Run("ordering and timeouts fail the test", func(t *testing.T) {
+		result := tester.ExecuteStreamTest(&core.StreamTest{
+			Name:     "order",
+			Endpoint: "/socket",
+			Steps: []core.StreamStep{
+				{Expect: &core.StreamExpectation{Next: true, JSONPath: map[string]interface{}{"$.type": "tick"}}},
+				{Send: "never sent"},
+			},
+		})
+		assert.Equal(t, core.TestStatusFailed, result.Status)
+		require.Len(t, result.Steps, 1)
+		assert.Contains(t, result.Steps[0].Error.Error(), `message 1 did not match: JSON path '$.type': expected "tick", got "welcome"`)
+
+		result = tester.ExecuteStreamTest(&core.StreamTest{
+			Name:     "timeout",
+			Endpoint: "/socket",
+			Steps: []core.StreamStep{
+				{Expect: &core.StreamExpectation{Regex: "tick", Timeout: 50 * time.Millisecond}},
+			},
+		})
+		assert.Equal(t, core.TestStatusFailed, result.Status)
+		assert.EqualError(t, result.Steps[0].Error, "timed out after 50ms with 0 of 1 matching message(s)")
+		assert.Len(t, result.Logs, 2)
+	})
+
+	t.Run("connection errors", func(t *testing.T) {
+		result := tester.ExecuteStreamTest(&core.StreamTest{Name: "missing", Endpoint: "ws://127.0.0.1:1/socket"})
+		assert.Equal(t, core.TestStatusError, result.Status)
+		assert.Equal(t, core.APIError, core.GetErrorType(result.Error))
+
+		result = tester.ExecuteStreamTest(&core.StreamTest{Name: "empty step", Endpoint: "/socket", Steps: []core.StreamStep{{}}})
+		assert.Equal(t, core.TestStatusError, result.Status)
+		assert.Equal(t, core.ValidationError, core.GetErrorType(result.Error))
+	})
+}
+
+func TestAPITester_WebSocketProxy(t *testing.T) {
+	server, _ := newWebSocketServer(t)
+
+	// A CONNECT proxy that requires credentials and counts its tunnels
+	var tunnels atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect || r.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("user:pass")) {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		require.NoError(t, err)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		tunnels.Add(1)
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go func() {
+			_, _ = io.Copy(upstream, conn)
+			_ = upstream.Close()
+		}()
+		_, _ = io.Copy(conn, upstream)
+		_ = conn.Close()
+	}))
+	defer proxy.Close()
+
+	proxyHost := strings.TrimPrefix(proxy.URL, "http://")
+	steps := []core.StreamStep{{Expect: &core.StreamExpectation{Next: true, JSONPath: map[string]interface{}{"$.type": "welcome"}}}}
+	for _, credentials := range []struct {
+		password string
+		status   core.TestStatus
+	}{{"pass", core.TestStatusPassed}, {"wrong", core.TestStatusError}} {
+		tester := NewAPITester()
+		require.NoError(t, tester.Initialize(&gwconfig.APIConfig{
+			BaseURL: server.URL,
+			Timeout: 2 * time.Second,
+			Proxy:   &gwconfig.ProxyConfig{Host: proxyHost, Username: "user", Password: credentials.password},
+		}))
+		result := tester.ExecuteStreamTest(&core.StreamTest{Name: "proxied", Endpoint: "/socket", Steps: steps})
+		assert.Equal(t, credentials.status, result.Status, "%v", result.Error)
+	}
+	assert.EqualValues(t, 1, tunnels.Load())
+}
+
+func TestWebSocket_MessageLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buffer, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+		_, _ = fmt.Fprintf(buffer, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+			webSocketAccept(r.Header.Get("Sec-WebSocket-Key")))
+		_ = buffer.Flush()
+
+		// Frames within the limit that add up to a message beyond it
+		chunk := make([]byte, maxWebSocketMessage/4)
+		for i := 0; i < 5; i++ {
+			var frame bytes.Buffer
+			opcode := webSocketContinuation
+			if i == 0 {
+				opcode = webSocketText
+			}
+			_ = writeWebSocketFrame(&frame, opcode, chunk, false)
+			frame.Bytes()[0] &^= 0x80 // not final
+			if _, err := conn.Write(frame.Bytes()); err != nil {
+				return
+			}
+		}
+		_, _ = io.Copy(io.Discard, conn)
+	}))
+	defer server.Close()
+
+	target, err := url.Parse("ws" + strings.TrimPrefix(server.URL, "http"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ws, err := dialWebSocket(ctx, target, nil, nil, nil)
+	require.NoError(t, err)
+	defer func() { _ = ws.Close() }()
+
+	for range ws.Messages() {
+		t.Fatal("an oversized message was delivered")
+	}
+	assert.EqualError(t, ws.err, fmt.Sprintf("websocket message exceeds the %d byte limit", maxWebSocketMessage))
+}
+
+func TestAPITester_ServerSentEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		writer := bufio.NewWriter(w)
+		_, _ = writer.WriteString(": comment\n\nevent: greeting\ndata: hello\n\n")
+		_, _ = writer.WriteString("id: 2\ndata: {\"n\": 1,\ndata: \"user\": " + fmt.Sprintf("%q", r.Header.Get("Authorization")) + "}\n\n")
+		_ = writer.Flush()
+		w.(http.Flusher).Flush()
+		if r.URL.Query().Get("hold") != "" {
+			<-r.Context().Done()
+		}
+	}))
+	t.Cleanup(server.Close)
+	tester := newStreamTester(t, server.URL)
+
+	result := tester.ExecuteStreamTest(&core.StreamTest{
+		Name:     "events",
+		Protocol: "sse",
+		Endpoint: "/events?hold=1",
+		Steps: []core.StreamStep{
+			{Expect: &core.StreamExpectation{Event: "greeting", Regex: "^hello$"}},
+			{Expect: &core.StreamExpectation{Next: true, JSONPath: map[string]interface{}{"$.n": 1, "$.user": "Bearer stream-token"}}},
+		},
+	})
+	require.NoError(t, result.Error)
+	assert.Equal(t, core.TestStatusPassed, result.Status)
+	assert.True(t, strings.HasSuffix(result.Logs[0], "received event greeting: hello"), result.Logs[0])
+
+	// The stream ending before the expected messages arrive fails the expectation
+	result = tester.ExecuteStreamTest(&core.StreamTest{
+		Name:     "ended",
+		Protocol: "sse",
+		Endpoint: "/events",
+		Steps:    []core.StreamStep{{Expect: &core.StreamExpectation{Count: 3}}},
+	})
+	assert.Equal(t, core.TestStatusFailed, result.Status)
+	assert.Contains(t, result.Steps[0].Error.Error(), "connection ended after 2 of 3 matching message(s)")
+
+	result = tester.ExecuteStreamTest(&core.StreamTest{
+		Name:     "send",
+		Protocol: "sse",
+		Endpoint: "/events",
+		Steps:    []core.StreamStep{{Send: "hello"}},
+	})
+	assert.Equal(t, core.TestStatusError, result.Status)
+	assert.Contains(t, result.Error.Error(), "cannot send messages over Server-Sent Events")
+}
@@ -0,0 +1,372 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1" // #nosec G505 -- SHA-1 is mandated by the WebSocket handshake (RFC 6455)
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// WebSocket opcodes (RFC 6455, section 5.2)
+const (
+	webSocketContinuation byte = 0x0
+	webSocketText         byte = 0x1
+	webSocketBinary       byte = 0x2
+	webSocketClose        byte = 0x8
+	webSocketPing         byte = 0x9
+	webSocketPong         byte = 0xA
+)
+
+// webSocketGUID is appended to the handshake key to compute Sec-WebSocket-Accept
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxWebSocketMessage bounds a received message, whether sent in one frame or reassembled from several
+const maxWebSocketMessage = 16 << 20
+
+// webSocketAccept returns the Sec-WebSocket-Accept value for a handshake key
+func webSocketAccept(key string) string {
+	hash := sha1.Sum([]byte(key + webSocketGUID)) // #nosec G401 -- required by RFC 6455
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// writeWebSocketFrame writes a single final frame. Clients must mask their frames; servers must not.
+func writeWebSocketFrame(w io.Writer, opcode byte, payload []byte, masked bool) error {
+	header := []byte{0x80 | opcode, 0}
+	switch length := len(payload); {
+	case length < 126:
+		header[1] = byte(length)
+	case length <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	if masked {
+		header[1] |= 0x80
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		header = append(header, key[:]...)
+		maskedPayload := make([]byte, len(payload))
+		for i, b := range payload {
+			maskedPayload[i] = b ^ key[i%4]
+		}
+		payload = maskedPayload
+	}
+
+	if _, err := w.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readWebSocketFrame reads one frame and unmasks its payload
+func readWebSocketFrame(r io.Reader) (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, opcode = header[0]&0x80 != 0, header[0]&0x0F
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err = io.ReadFull(r, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err = io.ReadFull(r, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if length > maxWebSocketMessage {
+		return false, 0, nil, fmt.Errorf("websocket frame of %d bytes exceeds the %d byte limit", length, maxWebSocketMessage)
+	}
+
+	var key [4]byte
+	masked := header[1]&0x80 != 0
+	if masked {
+		if _, err = io.ReadFull(r, key[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= key[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// webSocketConnection is a client WebSocket connection that delivers received messages on a channel
+type webSocketConnection struct {
+	streamReceiver
+	conn    net.Conn
+	reader  *bufio.Reader
+	writeMu sync.Mutex
+}
+
+// webSocketProxy returns the proxy a transport uses for the HTTP form of a ws:// or wss:// URL
+func webSocketProxy(transport *http.Transport, target *url.URL) (*url.URL, error) {
+	if transport.Proxy == nil {
+		return nil, nil
+	}
+	probe := *target
+	probe.Scheme = "http"
+	if target.Scheme == "wss" {
+		probe.Scheme = "https"
+	}
+	return transport.Proxy(&http.Request{Method: http.MethodGet, URL: &probe, Host: probe.Host, Header: http.Header{}})
+}
+
+// hostAddress returns the host and port of a URL, using the default port of secure or plain schemes
+func hostAddress(target *url.URL, secure bool) string {
+	if target.Port() != "" {
+		return target.Host
+	}
+	if secure {
+		return net.JoinHostPort(target.Hostname(), "443")
+	}
+	return net.JoinHostPort(target.Hostname(), "80")
+}
+
+// dialProxy opens a connection to address, tunnelling through an HTTP CONNECT or SOCKS5 proxy when
+// proxyURL is set
+func dialProxy(ctx context.Context, address string, proxyURL *url.URL) (net.Conn, error) {
+	var dialer net.Dialer
+	if proxyURL == nil {
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if proxyURL.User != nil {
+			password, _ := proxyURL.User.Password()
+			auth = &proxy.Auth{User: proxyURL.User.Username(), Password: password}
+		}
+		socks, err := proxy.SOCKS5("tcp", proxyURL.Host, auth, &dialer)
+		if err != nil {
+			return nil, err
+		}
+		return socks.(proxy.ContextDialer).DialContext(ctx, "tcp", address)
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", hostAddress(proxyURL, proxyURL.Scheme == "https"))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname(), MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	connect := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		connect.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := connect.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	// The proxy sends nothing after its response until the tunnel is used, so no bytes are lost
+	// with the reader
+	response, err := http.ReadResponse(bufio.NewReader(conn), connect)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, fmt.Errorf("proxy refused the tunnel to %s with status %d", address, response.StatusCode)
+	}
+	return conn, nil
+}
+
+// dialWebSocket performs the opening handshake with a ws:// or wss:// URL, through proxyURL when set
+func dialWebSocket(ctx context.Context, target *url.URL, header http.Header, tlsConfig *tls.Config, proxyURL *url.URL) (*webSocketConnection, error) {
+	conn, err := dialProxy(ctx, hostAddress(target, target.Scheme == "wss"), proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if target.Scheme == "wss" {
+		config := &tls.Config{MinVersion: tls.VersionTLS12}
+		if tlsConfig != nil {
+			config = tlsConfig.Clone()
+		}
+		if config.ServerName == "" {
+			config.ServerName = target.Hostname()
+		}
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	request := &http.Request{
+		Method:     http.MethodGet,
+		URL:        &url.URL{Path: target.Path, RawPath: target.RawPath, RawQuery: target.RawQuery},
+		Host:       target.Host,
+		Header:     header.Clone(),
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+	}
+	if request.Header == nil {
+		request.Header = http.Header{}
+	}
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Sec-WebSocket-Key", key)
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	if err := request.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		_ = conn.Close()
+		return nil, fmt.Errorf("websocket handshake failed with status %d", response.StatusCode)
+	}
+	if response.Header.Get("Sec-WebSocket-Accept") != webSocketAccept(key) {
+		_ = conn.Close()
+		return nil, errors.New("websocket handshake failed: invalid Sec-WebSocket-Accept")
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	ws := &webSocketConnection{streamReceiver: newStreamReceiver(), conn: conn, reader: reader}
+	go ws.readLoop()
+	return ws, nil
+}
+
+// readLoop delivers data messages, answers pings and stops when the connection closes
+func (ws *webSocketConnection) readLoop() {
+	defer close(ws.messages)
+
+	var message []byte
+	for {
+		fin, opcode, payload, err := readWebSocketFrame(ws.reader)
+		if err != nil {
+			ws.err = err
+			return
+		}
+
+		switch opcode {
+		case webSocketPing:
+			_ = ws.write(webSocketPong, payload)
+			continue
+		case webSocketPong:
+			continue
+		case webSocketClose:
+			code := 1005 // no status received
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			ws.err = fmt.Errorf("connection closed by the server with code %d", code)
+			_ = ws.write(webSocketClose, payload[:min(len(payload), 2)])
+			return
+		case webSocketText, webSocketBinary:
+			message = payload
+		case webSocketContinuation:
+			if len(message)+len(payload) > maxWebSocketMessage {
+				ws.err = fmt.Errorf("websocket message exceeds the %d byte limit", maxWebSocketMessage)
+				_ = ws.write(webSocketClose, []byte{0x03, 0xF1}) // 1009: message too big
+				return
+			}
+			message = append(message, payload...)
+		}
+
+		if fin {
+			if !ws.deliver(streamMessage{data: message, received: time.Now()}) {
+				return
+			}
+			message = nil
+		}
+	}
+}
+
+// write sends a frame; writes from the reader and the test are serialized
+func (ws *webSocketConnection) write(opcode byte, payload []byte) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	return writeWebSocketFrame(ws.conn, opcode, payload, true)
+}
+
+// Send sends a text or binary message
+func (ws *webSocketConnection) Send(payload []byte, isBinary bool) error {
+	opcode := webSocketText
+	if isBinary {
+		opcode = webSocketBinary
+	}
+	return ws.write(opcode, payload)
+}
+
+// Close sends a normal closure frame and closes the connection
+func (ws *webSocketConnection) Close() error {
+	ws.stop()
+	_ = ws.write(webSocketClose, []byte{0x03, 0xE8})
+	return ws.conn.Close()
+}
@@ -260,6 +260,11 @@ func (a *Asserter) Less(actual, expected interface{}, message string) bool {
 	return success
 }
 
+// AddStep records a step produced outside the built-in assertions
+func (a *Asserter) AddStep(step AssertionStep) {
+	a.steps = append(a.steps, step)
+}
+
 // GetSteps returns all assertion steps
 func (a *Asserter) GetSteps() []AssertionStep {
 	return a.steps
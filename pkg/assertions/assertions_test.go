@@ -286,6 +286,17 @@ func TestAsserter_Reset(t *testing.T) {
 	assert.Empty(t, steps)
 }
 
+func TestAsserter_AddStep(t *testing.T) {
+	asserter := NewAsserter()
+
+	asserter.AddStep(AssertionStep{Name: "Eventually", Status: TestStatusSkipped})
+	assert.False(t, asserter.HasFailures())
+
+	asserter.AddStep(AssertionStep{Name: "Eventually", Status: TestStatusFailed})
+	assert.True(t, asserter.HasFailures())
+	assert.Len(t, asserter.GetSteps(), 2)
+}
+
 func TestAsserter_HasFailures(t *testing.T) {
 	asserter := NewAsserter()
 
@@ -0,0 +1,372 @@
+package assertions
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ColumnChange describes a column whose value changed between two snapshots
+type ColumnChange struct {
+	Column string      `json:"column"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// RowUpdate describes a row present in both snapshots whose column values changed
+type RowUpdate struct {
+	Key     map[string]interface{} `json:"key"`
+	Before  map[string]interface{} `json:"before"`
+	After   map[string]interface{} `json:"after"`
+	Columns []ColumnChange         `json:"columns"`
+}
+
+// TableChanges lists the rows inserted, updated and deleted in a table between two snapshots
+type TableChanges struct {
+	Table    string                   `json:"table"`
+	Inserted []map[string]interface{} `json:"inserted,omitempty"`
+	Updated  []RowUpdate              `json:"updated,omitempty"`
+	Deleted  []map[string]interface{} `json:"deleted,omitempty"`
+}
+
+// IsEmpty reports whether the table did not change
+func (tc TableChanges) IsEmpty() bool {
+	return len(tc.Inserted) == 0 && len(tc.Updated) == 0 && len(tc.Deleted) == 0
+}
+
+// String renders the table changes as a readable diff, one line per changed row
+func (tc TableChanges) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %d inserted, %d updated, %d deleted", tc.Table, len(tc.Inserted), len(tc.Updated), len(tc.Deleted))
+
+	for _, row := range tc.Inserted {
+		fmt.Fprintf(&sb, "\n  + %s", formatRow(row))
+	}
+	for _, update := range tc.Updated {
+		fmt.Fprintf(&sb, "\n  ~ %s %s", formatRow(update.Key), formatColumnChanges(update.Columns))
+	}
+	for _, row := range tc.Deleted {
+		fmt.Fprintf(&sb, "\n  - %s", formatRow(row))
+	}
+
+	return sb.String()
+}
+
+// DataDiff holds the changes found in each table of a snapshot
+type DataDiff struct {
+	Tables []TableChanges `json:"tables"`
+}
+
+// Table returns the changes for the named table; tables that were not compared have no changes
+func (dd *DataDiff) Table(name string) TableChanges {
+	for _, table := range dd.Tables {
+		if table.Table == name {
+			return table
+		}
+	}
+	return TableChanges{Table: name}
+}
+
+// IsEmpty reports whether no table changed
+func (dd *DataDiff) IsEmpty() bool {
+	for _, table := range dd.Tables {
+		if !table.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the changes of every table
+func (dd *DataDiff) String() string {
+	tables := make([]string, len(dd.Tables))
+	for i, table := range dd.Tables {
+		tables[i] = table.String()
+	}
+	return strings.Join(tables, "\n")
+}
+
+// ExpectedRowUpdate describes an expected update. Key identifies the row and Changes holds the new value of every column expected to change.
+type ExpectedRowUpdate struct {
+	Key     map[string]interface{} `json:"key"`
+	Changes map[string]interface{} `json:"changes"`
+}
+
+// ExpectedTableChanges is the change set expected for one table.
+// Inserted and deleted rows only need to list the columns that should be compared.
+type ExpectedTableChanges struct {
+	Inserted []map[string]interface{} `json:"inserted,omitempty"`
+	Updated  []ExpectedRowUpdate      `json:"updated,omitempty"`
+	Deleted  []map[string]interface{} `json:"deleted,omitempty"`
+}
+
+// ExpectedChanges maps table names to their expected changes; compared tables that are not listed must be unchanged
+type ExpectedChanges map[string]*ExpectedTableChanges
+
+// DiffRows compares two snapshots of a table. Rows are matched on the key columns; without key columns
+// rows are matched on their full contents, so a changed row shows up as a deletion plus an insertion.
+func DiffRows(table string, before, after []map[string]interface{}, key []string) TableChanges {
+	changes := TableChanges{Table: table}
+
+	remaining := make(map[string][]int, len(before))
+	for i, row := range before {
+		identity := rowIdentity(row, key)
+		remaining[identity] = append(remaining[identity], i)
+	}
+
+	matched := make([]bool, len(before))
+	for _, afterRow := range after {
+		identity := rowIdentity(afterRow, key)
+		candidates := remaining[identity]
+		if len(candidates) == 0 {
+			changes.Inserted = append(changes.Inserted, afterRow)
+			continue
+		}
+
+		beforeIndex := candidates[0]
+		remaining[identity] = candidates[1:]
+		matched[beforeIndex] = true
+
+		beforeRow := before[beforeIndex]
+		if columns := changedColumns(beforeRow, afterRow); len(columns) > 0 {
+			keyValues := make(map[string]interface{}, len(key))
+			for _, column := range key {
+				keyValues[column] = afterRow[column]
+			}
+			changes.Updated = append(changes.Updated, RowUpdate{
+				Key:     keyValues,
+				Before:  beforeRow,
+				After:   afterRow,
+				Columns: columns,
+			})
+		}
+	}
+
+	for i, row := range before {
+		if !matched[i] {
+			changes.Deleted = append(changes.Deleted, row)
+		}
+	}
+
+	return changes
+}
+
+// CompareTableChanges compares actual table changes with the expected change set and describes every mismatch.
+// A nil expected change set means the table must not have changed.
+func CompareTableChanges(expected *ExpectedTableChanges, actual TableChanges) []string {
+	if expected == nil {
+		expected = &ExpectedTableChanges{}
+	}
+
+	problems := make([]string, 0)
+	rowOptions := &RowMatchOptions{Unordered: true, Subset: true}
+
+	for _, difference := range CompareRows(expected.Inserted, actual.Inserted, rowOptions) {
+		problems = append(problems, "inserted "+difference.String())
+	}
+	for _, difference := range CompareRows(expected.Deleted, actual.Deleted, rowOptions) {
+		problems = append(problems, "deleted "+difference.String())
+	}
+
+	used := make([]bool, len(actual.Updated))
+	for _, expectedUpdate := range expected.Updated {
+		index := -1
+		for i, update := range actual.Updated {
+			if !used[i] && len(compareRow(expectedUpdate.Key, update.After, RowMatchOptions{Subset: true})) == 0 {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			problems = append(problems, fmt.Sprintf("expected update of %s not found", formatRow(expectedUpdate.Key)))
+			continue
+		}
+		used[index] = true
+		problems = append(problems, compareUpdate(expectedUpdate, actual.Updated[index])...)
+	}
+
+	for i, update := range actual.Updated {
+		if !used[i] {
+			problems = append(problems, fmt.Sprintf("unexpected update of %s: %s",
+				formatRow(update.Key), formatColumnChanges(update.Columns)))
+		}
+	}
+
+	return problems
+}
+
+// DataChanges asserts that a data diff holds exactly the expected changes, recording one step per table
+func (a *Asserter) DataChanges(expected ExpectedChanges, actual *DataDiff, message string) bool {
+	tables := make([]TableChanges, 0, len(actual.Tables)+len(expected))
+	tables = append(tables, actual.Tables...)
+
+	// Tables that were expected to change but not compared are reported as failures
+	missing := make(map[string]bool)
+	for _, name := range sortedTableNames(expected) {
+		found := false
+		for _, table := range actual.Tables {
+			if table.Table == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing[name] = true
+			tables = append(tables, TableChanges{Table: name})
+		}
+	}
+
+	success := true
+	for _, table := range tables {
+		step := AssertionStep{
+			Name:        "DataChanges",
+			Description: fmt.Sprintf("%s: %s", message, table.Table),
+			Expected:    expected[table.Table],
+			Actual:      table,
+			StartTime:   time.Now(),
+			Status:      TestStatusPassed,
+		}
+
+		var problems []string
+		if missing[table.Table] {
+			problems = []string{fmt.Sprintf("table %s was not included in the snapshot", table.Table)}
+		} else {
+			problems = CompareTableChanges(expected[table.Table], table)
+		}
+
+		if len(problems) > 0 {
+			step.Status = TestStatusFailed
+			step.Error = errors.New(strings.Join(problems, "; ") + "\n" + table.String())
+			success = false
+		}
+		step.EndTime = time.Now()
+		step.Duration = step.EndTime.Sub(step.StartTime)
+		a.steps = append(a.steps, step)
+	}
+
+	return success
+}
+
+// compareUpdate compares an actual row update with the expected column changes
+func compareUpdate(expected ExpectedRowUpdate, actual RowUpdate) []string {
+	problems := make([]string, 0)
+	changed := make(map[string]ColumnChange, len(actual.Columns))
+	for _, column := range actual.Columns {
+		changed[column.Column] = column
+	}
+
+	for _, column := range sortedColumns(expected.Changes) {
+		change, exists := changed[column]
+		if !exists {
+			problems = append(problems, fmt.Sprintf("update of %s: %s expected to change to %s, was unchanged",
+				formatRow(expected.Key), column, formatValue(expected.Changes[column])))
+			continue
+		}
+		if !ValuesMatch(expected.Changes[column], change.After, nil) {
+			problems = append(problems, fmt.Sprintf("update of %s: %s expected to change to %s, got %s",
+				formatRow(expected.Key), column, formatValue(expected.Changes[column]), formatValue(change.After)))
+		}
+	}
+
+	for _, column := range actual.Columns {
+		if _, exists := expected.Changes[column.Column]; !exists {
+			problems = append(problems, fmt.Sprintf("update of %s: unexpected change %s",
+				formatRow(expected.Key), formatColumnChanges([]ColumnChange{column})))
+		}
+	}
+
+	return problems
+}
+
+// changedColumns returns the columns whose values differ between two versions of a row
+func changedColumns(before, after map[string]interface{}) []ColumnChange {
+	columns := make(map[string]bool, len(before)+len(after))
+	for column := range before {
+		columns[column] = true
+	}
+	for column := range after {
+		columns[column] = true
+	}
+
+	changes := make([]ColumnChange, 0)
+	for _, column := range sortedColumns(toColumnSet(columns)) {
+		if !valuesMatch(before[column], after[column], RowMatchOptions{}) {
+			changes = append(changes, ColumnChange{Column: column, Before: before[column], After: after[column]})
+		}
+	}
+	return changes
+}
+
+// rowIdentity builds a comparable identity from the key columns of a row, or from every column when key is empty
+func rowIdentity(row map[string]interface{}, key []string) string {
+	columns := key
+	if len(columns) == 0 {
+		columns = sortedColumns(row)
+	}
+
+	parts := make([]string, len(columns))
+	for i, column := range columns {
+		value := row[column]
+		if t, ok := value.(time.Time); ok {
+			value = t.UTC().Format(time.RFC3339Nano)
+		}
+		parts[i] = fmt.Sprintf("%s=%T:%v", column, value, value)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// toColumnSet converts a set of column names into a row-shaped map for sortedColumns
+func toColumnSet(columns map[string]bool) map[string]interface{} {
+	set := make(map[string]interface{}, len(columns))
+	for column := range columns {
+		set[column] = nil
+	}
+	return set
+}
+
+// sortedTableNames returns the table names of an expected change set in sorted order
+func sortedTableNames(expected ExpectedChanges) []string {
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatRow formats a row as {column: value, ...} in sorted column order
+func formatRow(row map[string]interface{}) string {
+	columns := sortedColumns(row)
+	parts := make([]string, len(columns))
+	for i, column := range columns {
+		parts[i] = fmt.Sprintf("%s: %s", column, formatValue(row[column]))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// formatColumnChanges formats column changes as column: before -> after
+func formatColumnChanges(changes []ColumnChange) string {
+	parts := make([]string, len(changes))
+	for i, change := range changes {
+		parts[i] = fmt.Sprintf("%s: %s -> %s", change.Column, formatValue(change.Before), formatValue(change.After))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatValue formats a single column value, quoting strings
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []byte:
+		return fmt.Sprintf("%q", v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
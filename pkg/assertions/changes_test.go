@@ -0,0 +1,161 @@
+package assertions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffRows_Keyed(t *testing.T) {
+	before := []map[string]interface{}{
+		{"id": int64(1), "name": "alice", "active": true},
+		{"id": int64(2), "name": "bob", "active": true},
+		{"id": int64(3), "name": "carol", "active": true},
+	}
+	after := []map[string]interface{}{
+		{"id": int64(1), "name": "alice", "active": true},
+		{"id": int64(2), "name": "robert", "active": false},
+		{"id": int64(4), "name": "dave", "active": true},
+	}
+
+	changes := DiffRows("users", before, after, []string{"id"})
+
+	assert.Equal(t, "users", changes.Table)
+	assert.Equal(t, []map[string]interface{}{after[2]}, changes.Inserted)
+	assert.Equal(t, []map[string]interface{}{before[2]}, changes.Deleted)
+	require.Len(t, changes.Updated, 1)
+	assert.Equal(t, map[string]interface{}{"id": int64(2)}, changes.Updated[0].Key)
+	assert.Equal(t, []ColumnChange{
+		{Column: "active", Before: true, After: false},
+		{Column: "name", Before: "bob", After: "robert"},
+	}, changes.Updated[0].Columns)
+
+	assert.Equal(t, `users: 1 inserted, 1 updated, 1 deleted
+  + {active: true, id: 4, name: "dave"}
+  ~ {id: 2} active: true -> false, name: "bob" -> "robert"
+  - {active: true, id: 3, name: "carol"}`, changes.String())
+}
+
+func TestDiffRows_Unkeyed(t *testing.T) {
+	before := []map[string]interface{}{
+		{"tag": "a"},
+		{"tag": "a"},
+		{"tag": "b"},
+	}
+	after := []map[string]interface{}{
+		{"tag": "a"},
+		{"tag": "b"},
+		{"tag": "c"},
+	}
+
+	changes := DiffRows("tags", before, after, nil)
+
+	assert.Empty(t, changes.Updated)
+	assert.Equal(t, []map[string]interface{}{{"tag": "c"}}, changes.Inserted)
+	assert.Equal(t, []map[string]interface{}{{"tag": "a"}}, changes.Deleted)
+
+	assert.True(t, DiffRows("tags", before, before, nil).IsEmpty())
+}
+
+func TestCompareTableChanges(t *testing.T) {
+	actual := DiffRows("users",
+		[]map[string]interface{}{
+			{"id": int64(1), "name": "alice", "active": true},
+			{"id": int64(2), "name": "bob", "active": true},
+		},
+		[]map[string]interface{}{
+			{"id": int64(1), "name": "alice", "active": false},
+			{"id": int64(3), "name": "carol", "active": true},
+		},
+		[]string{"id"})
+
+	tests := []struct {
+		name     string
+		expected *ExpectedTableChanges
+		problems []string
+	}{
+		{
+			name: "exact change set",
+			expected: &ExpectedTableChanges{
+				Inserted: []map[string]interface{}{{"name": "carol"}},
+				Updated:  []ExpectedRowUpdate{{Key: map[string]interface{}{"id": 1}, Changes: map[string]interface{}{"active": false}}},
+				Deleted:  []map[string]interface{}{{"id": 2}},
+			},
+		},
+		{
+			name: "wrong new value and missing deletion",
+			expected: &ExpectedTableChanges{
+				Inserted: []map[string]interface{}{{"name": "carol"}},
+				Updated:  []ExpectedRowUpdate{{Key: map[string]interface{}{"id": 1}, Changes: map[string]interface{}{"active": true}}},
+			},
+			problems: []string{
+				"deleted unexpected row 0: map[active:true id:2 name:bob]",
+				"update of {id: 1}: active expected to change to true, got false",
+			},
+		},
+		{
+			name: "unexpected column change and update",
+			expected: &ExpectedTableChanges{
+				Inserted: []map[string]interface{}{{"name": "carol"}},
+				Updated:  []ExpectedRowUpdate{{Key: map[string]interface{}{"id": 1}, Changes: map[string]interface{}{"name": "alicia"}}},
+				Deleted:  []map[string]interface{}{{"id": 2}},
+			},
+			problems: []string{
+				`update of {id: 1}: name expected to change to "alicia", was unchanged`,
+				"update of {id: 1}: unexpected change active: true -> false",
+			},
+		},
+		{
+			name: "no changes expected",
+			problems: []string{
+				"inserted unexpected row 0: map[active:true id:3 name:carol]",
+				"deleted unexpected row 0: map[active:true id:2 name:bob]",
+				"unexpected update of {id: 1}: active: true -> false",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := CompareTableChanges(tt.expected, actual)
+			if tt.problems == nil {
+				assert.Empty(t, problems)
+			} else {
+				assert.Equal(t, tt.problems, problems)
+			}
+		})
+	}
+}
+
+func TestAsserter_DataChanges(t *testing.T) {
+	diff := &DataDiff{Tables: []TableChanges{
+		DiffRows("users", nil, []map[string]interface{}{{"id": int64(1), "name": "alice"}}, []string{"id"}),
+		DiffRows("orders", nil, nil, []string{"id"}),
+	}}
+
+	asserter := NewAsserter()
+	success := asserter.DataChanges(ExpectedChanges{
+		"users": {Inserted: []map[string]interface{}{{"name": "alice"}}},
+	}, diff, "Data changes")
+
+	assert.True(t, success)
+	require.Len(t, asserter.GetSteps(), 2)
+	assert.Equal(t, "Data changes: users", asserter.GetSteps()[0].Description)
+	assert.Equal(t, TestStatusPassed, asserter.GetSteps()[1].Status)
+
+	asserter = NewAsserter()
+	success = asserter.DataChanges(ExpectedChanges{
+		"audit": {Inserted: []map[string]interface{}{{"action": "create"}}},
+	}, diff, "Data changes")
+
+	assert.False(t, success)
+	steps := asserter.GetSteps()
+	require.Len(t, steps, 3)
+	assert.Equal(t, TestStatusFailed, steps[0].Status)
+	assert.Contains(t, steps[0].Error.Error(), "inserted unexpected row 0")
+	assert.Contains(t, steps[0].Error.Error(), `+ {id: 1, name: "alice"}`)
+	assert.Equal(t, TestStatusPassed, steps[1].Status)
+	assert.Equal(t, TestStatusFailed, steps[2].Status)
+	assert.Contains(t, steps[2].Error.Error(), "table audit was not included in the snapshot")
+}
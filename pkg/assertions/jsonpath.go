@@ -0,0 +1,808 @@
+package assertions
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONPath is a compiled JSONPath expression. It supports member names in dot and bracket
+// notation, wildcards, recursive descent (..), array indices (negative indices count from the end),
+// slices ([start:end:step]), unions ([0,2] or ['a','b']) and filters such as
+// [?(@.price < 10 && @.tags[0] =~ /^new/i)]. Expressions without a leading "$", like
+// "data.users[0].name", are evaluated from the document root.
+type JSONPath struct {
+	expr     string
+	segments []pathSegment
+}
+
+// pathSegment applies its selectors to the current nodes, or to all their descendants when recursive
+type pathSegment struct {
+	recursive bool
+	selectors []pathSelector
+}
+
+// selectorKind identifies what a selector matches
+type selectorKind int
+
+const (
+	selectName selectorKind = iota
+	selectWildcard
+	selectIndex
+	selectSlice
+	selectFilter
+)
+
+// pathSelector selects children of a node
+type pathSelector struct {
+	kind   selectorKind
+	name   string
+	index  int
+	start  *int
+	end    *int
+	step   int
+	filter filterExpr
+}
+
+// CompileJSONPath parses a JSONPath expression
+func CompileJSONPath(expr string) (*JSONPath, error) {
+	input := strings.TrimSpace(expr)
+	switch {
+	case input == "":
+		return nil, fmt.Errorf("empty JSONPath expression")
+	case strings.HasPrefix(input, "["):
+		input = "$" + input
+	case !strings.HasPrefix(input, "$"):
+		input = "$." + input
+	}
+
+	p := &pathParser{input: input, pos: 1}
+	segments, err := p.parseSegments(false)
+	if err == nil && p.pos < len(p.input) {
+		err = p.errorf("unexpected %q", p.input[p.pos])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath %q: %w", expr, err)
+	}
+
+	return &JSONPath{expr: expr, segments: segments}, nil
+}
+
+// String returns the expression the path was compiled from
+func (jp *JSONPath) String() string {
+	return jp.expr
+}
+
+// IsDefinite reports whether the path selects at most one value, i.e. it only uses member names
+// and indices without wildcards, slices, unions, filters or recursive descent
+func (jp *JSONPath) IsDefinite() bool {
+	for _, segment := range jp.segments {
+		if segment.recursive || len(segment.selectors) != 1 {
+			return false
+		}
+		if kind := segment.selectors[0].kind; kind != selectName && kind != selectIndex {
+			return false
+		}
+	}
+	return true
+}
+
+// Find returns the values the path selects from a decoded JSON document, in document order
+func (jp *JSONPath) Find(document interface{}) []interface{} {
+	return applySegments(jp.segments, document, document)
+}
+
+// QueryJSONPath compiles a JSONPath expression and returns the values it selects from a document
+func QueryJSONPath(document interface{}, expr string) ([]interface{}, error) {
+	path, err := CompileJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	return path.Find(document), nil
+}
+
+// MatchJSONPath compares the value selected by a JSONPath expression with an expected value, which
+// may be a literal or a matcher (see MatchValue). A definite path must select exactly one value.
+// Other paths select a list: matchers must hold for every value in it and literals are compared
+// with the whole list. The selected value or list is returned for reporting.
+func MatchJSONPath(document interface{}, expr string, expected interface{}) (interface{}, error) {
+	path, err := CompileJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	results := path.Find(document)
+	if path.IsDefinite() {
+		if len(results) == 0 {
+			return nil, fmt.Errorf("JSON path '%s' not found", expr)
+		}
+		if err := MatchValue(expected, results[0]); err != nil {
+			return results[0], fmt.Errorf("JSON path '%s': %w", expr, err)
+		}
+		return results[0], nil
+	}
+
+	if matcher, ok := matcherFor(expected); ok {
+		if len(results) == 0 {
+			return results, fmt.Errorf("JSON path '%s' selected no values", expr)
+		}
+		for i, result := range results {
+			if err := matcher.Match(result); err != nil {
+				return results, fmt.Errorf("JSON path '%s' value %d: %w", expr, i, err)
+			}
+		}
+		return results, nil
+	}
+
+	if err := MatchValue(expected, results); err != nil {
+		return results, fmt.Errorf("JSON path '%s': %w", expr, err)
+	}
+	return results, nil
+}
+
+// JSONPath asserts that the value selected by a JSONPath expression matches expected
+func (a *Asserter) JSONPath(document interface{}, expr string, expected interface{}, message string) bool {
+	step := AssertionStep{
+		Name:        "JSONPath",
+		Description: message,
+		Expected:    expected,
+		StartTime:   time.Now(),
+	}
+
+	actual, err := MatchJSONPath(document, expr, expected)
+	step.Actual = actual
+	step.Status = TestStatusPassed
+	if err != nil {
+		step.Status = TestStatusFailed
+		step.Error = err
+	}
+
+	step.EndTime = time.Now()
+	step.Duration = step.EndTime.Sub(step.StartTime)
+	a.steps = append(a.steps, step)
+
+	return err == nil
+}
+
+// JSONExpectations validates the body and JSON path expressions against literals or matchers. It
+// records a step for body, when set, and one per path in sorted order so reports are deterministic,
+// described by bodyMessage and by pathMessage followed by the path.
+func (a *Asserter) JSONExpectations(document, body interface{}, paths map[string]interface{}, bodyMessage, pathMessage string) bool {
+	passed := true
+	if body != nil {
+		passed = a.Match(body, document, bodyMessage)
+	}
+
+	exprs := make([]string, 0, len(paths))
+	for expr := range paths {
+		exprs = append(exprs, expr)
+	}
+	sort.Strings(exprs)
+	for _, expr := range exprs {
+		if !a.JSONPath(document, expr, paths[expr], pathMessage+expr) {
+			passed = false
+		}
+	}
+	return passed
+}
+
+// applySegments applies path segments starting from a node
+func applySegments(segments []pathSegment, node, root interface{}) []interface{} {
+	nodes := []interface{}{node}
+	for _, segment := range segments {
+		var next []interface{}
+		for _, current := range nodes {
+			targets := []interface{}{current}
+			if segment.recursive {
+				targets = descendants(current, targets)
+			}
+			for _, target := range targets {
+				for _, selector := range segment.selectors {
+					next = append(next, selector.apply(target, root)...)
+				}
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// apply returns the children of a node that the selector matches
+func (s pathSelector) apply(node, root interface{}) []interface{} {
+	switch s.kind {
+	case selectName:
+		if object, ok := node.(map[string]interface{}); ok {
+			if value, exists := object[s.name]; exists {
+				return []interface{}{value}
+			}
+		}
+	case selectWildcard:
+		return children(node)
+	case selectIndex:
+		if array, ok := node.([]interface{}); ok {
+			index := s.index
+			if index < 0 {
+				index += len(array)
+			}
+			if index >= 0 && index < len(array) {
+				return []interface{}{array[index]}
+			}
+		}
+	case selectSlice:
+		if array, ok := node.([]interface{}); ok {
+			return sliceArray(array, s.start, s.end, s.step)
+		}
+	case selectFilter:
+		var matched []interface{}
+		for _, child := range children(node) {
+			if s.filter.test(child, root) {
+				matched = append(matched, child)
+			}
+		}
+		return matched
+	}
+	return nil
+}
+
+// sliceArray applies [start:end:step] slice semantics, where negative bounds count from the end
+func sliceArray(array []interface{}, start, end *int, step int) []interface{} {
+	length := len(array)
+	if step == 0 {
+		return nil
+	}
+	normalize := func(i int) int {
+		if i < 0 {
+			return length + i
+		}
+		return i
+	}
+	clamp := func(i, lower, upper int) int {
+		return max(lower, min(i, upper))
+	}
+
+	var selected []interface{}
+	if step > 0 {
+		lower, upper := 0, length
+		if start != nil {
+			lower = clamp(normalize(*start), 0, length)
+		}
+		if end != nil {
+			upper = clamp(normalize(*end), 0, length)
+		}
+		for i := lower; i < upper; i += step {
+			selected = append(selected, array[i])
+		}
+		return selected
+	}
+
+	upper, lower := length-1, -1
+	if start != nil {
+		upper = clamp(normalize(*start), -1, length-1)
+	}
+	if end != nil {
+		lower = clamp(normalize(*end), -1, length-1)
+	}
+	for i := upper; i > lower; i += step {
+		selected = append(selected, array[i])
+	}
+	return selected
+}
+
+// children returns the member values of an object in key order, or the items of an array
+func children(node interface{}) []interface{} {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		result := make([]interface{}, len(keys))
+		for i, key := range keys {
+			result[i] = value[key]
+		}
+		return result
+	case []interface{}:
+		return value
+	}
+	return nil
+}
+
+// descendants appends every descendant of a node in document order
+func descendants(node interface{}, result []interface{}) []interface{} {
+	for _, child := range children(node) {
+		result = append(result, child)
+		result = descendants(child, result)
+	}
+	return result
+}
+
+// filterExpr is a node of a filter expression
+type filterExpr interface {
+	// test evaluates the expression in a logical context
+	test(current, root interface{}) bool
+	// value evaluates the expression as a comparison operand; false means nothing was selected
+	value(current, root interface{}) (interface{}, bool)
+}
+
+// literalExpr is a string, number, boolean or null literal
+type literalExpr struct {
+	literal interface{}
+}
+
+func (e literalExpr) test(current, root interface{}) bool {
+	return e.literal == true
+}
+
+func (e literalExpr) value(current, root interface{}) (interface{}, bool) {
+	return e.literal, true
+}
+
+// queryExpr is a path relative to the current node (@) or the root ($)
+type queryExpr struct {
+	relative bool
+	segments []pathSegment
+}
+
+func (e queryExpr) find(current, root interface{}) []interface{} {
+	if e.relative {
+		return applySegments(e.segments, current, root)
+	}
+	return applySegments(e.segments, root, root)
+}
+
+func (e queryExpr) test(current, root interface{}) bool {
+	return len(e.find(current, root)) > 0
+}
+
+func (e queryExpr) value(current, root interface{}) (interface{}, bool) {
+	results := e.find(current, root)
+	if len(results) != 1 {
+		return nil, false
+	}
+	return results[0], true
+}
+
+// logicalExpr combines expressions with &&, || or negates one with !
+type logicalExpr struct {
+	op          string
+	left, right filterExpr
+}
+
+func (e logicalExpr) test(current, root interface{}) bool {
+	switch e.op {
+	case "&&":
+		return e.left.test(current, root) && e.right.test(current, root)
+	case "||":
+		return e.left.test(current, root) || e.right.test(current, root)
+	default:
+		return !e.left.test(current, root)
+	}
+}
+
+func (e logicalExpr) value(current, root interface{}) (interface{}, bool) {
+	return e.test(current, root), true
+}
+
+// comparisonExpr compares two operands, or matches the left operand against a regex with =~
+type comparisonExpr struct {
+	op          string
+	left, right filterExpr
+	pattern     *regexp.Regexp
+}
+
+func (e comparisonExpr) test(current, root interface{}) bool {
+	left, leftOK := e.left.value(current, root)
+	if e.op == "=~" {
+		text, ok := left.(string)
+		return leftOK && ok && e.pattern.MatchString(text)
+	}
+
+	right, rightOK := e.right.value(current, root)
+	if !leftOK || !rightOK {
+		// Nothing only equals nothing
+		switch e.op {
+		case "==", "<=", ">=":
+			return !leftOK && !rightOK
+		case "!=":
+			return leftOK != rightOK
+		}
+		return false
+	}
+
+	switch e.op {
+	case "==":
+		return MatchValue(right, left) == nil
+	case "!=":
+		return MatchValue(right, left) != nil
+	}
+
+	if l, ok := toFloat64(left); ok {
+		if r, ok := toFloat64(right); ok {
+			return compareOrdered(e.op, l, r)
+		}
+	}
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok {
+			return compareOrdered(e.op, l, r)
+		}
+	}
+	if e.op == "<=" || e.op == ">=" {
+		return MatchValue(right, left) == nil
+	}
+	return false
+}
+
+func (e comparisonExpr) value(current, root interface{}) (interface{}, bool) {
+	return e.test(current, root), true
+}
+
+// compareOrdered applies an ordering operator
+func compareOrdered[T float64 | string](op string, left, right T) bool {
+	switch op {
+	case "<":
+		return left < right
+	case "<=":
+		return left <= right
+	case ">":
+		return left > right
+	default:
+		return left >= right
+	}
+}
+
+// pathParser is a recursive descent parser for JSONPath expressions
+type pathParser struct {
+	input string
+	pos   int
+}
+
+// errorf reports a parse error at the current position
+func (p *pathParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(format, args...), p.pos)
+}
+
+// peek returns the byte at offset from the current position, or 0 past the end
+func (p *pathParser) peek(offset int) byte {
+	if p.pos+offset < len(p.input) {
+		return p.input[p.pos+offset]
+	}
+	return 0
+}
+
+// skipSpace skips whitespace
+func (p *pathParser) skipSpace() {
+	for p.pos < len(p.input) && strings.IndexByte(" \t\r\n", p.input[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// consume skips whitespace and consumes token when it comes next
+func (p *pathParser) consume(token string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.input[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+// parseSegments parses segments until the end of input or, inside filters, the first byte that
+// cannot continue a path
+func (p *pathParser) parseSegments(inFilter bool) ([]pathSegment, error) {
+	var segments []pathSegment
+	for p.pos < len(p.input) {
+		switch p.input[p.pos] {
+		case '.':
+			recursive := p.peek(1) == '.'
+			p.pos++
+			if recursive {
+				p.pos++
+			}
+
+			switch {
+			case p.peek(0) == '[' && recursive:
+				selectors, err := p.parseBracket()
+				if err != nil {
+					return nil, err
+				}
+				segments = append(segments, pathSegment{recursive: true, selectors: selectors})
+			case p.peek(0) == '*':
+				p.pos++
+				segments = append(segments, pathSegment{recursive: recursive, selectors: []pathSelector{{kind: selectWildcard}}})
+			default:
+				name := p.parseName(inFilter)
+				if name == "" {
+					return nil, p.errorf("expected a member name")
+				}
+				if p.peek(0) == '(' {
+					return nil, p.errorf("unsupported function %s()", name)
+				}
+				segments = append(segments, pathSegment{recursive: recursive, selectors: []pathSelector{{kind: selectName, name: name}}})
+			}
+		case '[':
+			selectors, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, pathSegment{selectors: selectors})
+		default:
+			if inFilter {
+				return segments, nil
+			}
+			return nil, p.errorf("unexpected %q", p.input[p.pos])
+		}
+	}
+	return segments, nil
+}
+
+// parseName parses a member name in dot notation. Outside filters, names may contain any
+// character but '.', brackets, parentheses and whitespace, so keys like "user-id" work as before.
+func (p *pathParser) parseName(inFilter bool) string {
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if c == '.' || c == '[' || c == ']' || c == '(' || c == ')' || c == ' ' || c == '\t' {
+			break
+		}
+		if inFilter && !(c == '_' || c >= 0x80 || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			break
+		}
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// parseBracket parses a bracketed list of selectors
+func (p *pathParser) parseBracket() ([]pathSelector, error) {
+	p.pos++ // [
+	var selectors []pathSelector
+	for {
+		p.skipSpace()
+		selector, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+
+		switch {
+		case p.consume(","):
+		case p.consume("]"):
+			return selectors, nil
+		default:
+			return nil, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+// parseSelector parses a single selector inside brackets
+func (p *pathParser) parseSelector() (pathSelector, error) {
+	switch c := p.peek(0); {
+	case c == '*':
+		p.pos++
+		return pathSelector{kind: selectWildcard}, nil
+	case c == '\'' || c == '"':
+		name, err := p.parseString()
+		return pathSelector{kind: selectName, name: name}, err
+	case c == '?':
+		p.pos++
+		filter, err := p.parseOr()
+		return pathSelector{kind: selectFilter, filter: filter}, err
+	}
+
+	start, hasStart := p.parseInt()
+	if !p.consume(":") {
+		if !hasStart {
+			return pathSelector{}, p.errorf("expected a selector")
+		}
+		return pathSelector{kind: selectIndex, index: start}, nil
+	}
+
+	selector := pathSelector{kind: selectSlice, step: 1}
+	if hasStart {
+		selector.start = &start
+	}
+	if end, ok := p.parseInt(); ok {
+		selector.end = &end
+	}
+	if p.consume(":") {
+		if step, ok := p.parseInt(); ok {
+			selector.step = step
+		}
+	}
+	return selector, nil
+}
+
+// parseInt parses an optionally signed integer
+func (p *pathParser) parseInt() (int, bool) {
+	p.skipSpace()
+	start := p.pos
+	if p.peek(0) == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		p.pos++
+	}
+	value, err := strconv.Atoi(p.input[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return 0, false
+	}
+	return value, true
+}
+
+// parseString parses a single- or double-quoted string with backslash escapes
+func (p *pathParser) parseString() (string, error) {
+	quote := p.input[p.pos]
+	p.pos++
+
+	var b strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return b.String(), nil
+		case c == '\\' && p.pos < len(p.input):
+			escaped := p.input[p.pos]
+			p.pos++
+			switch escaped {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(escaped)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// parseOr parses a filter expression: or := and ('||' and)*
+func (p *pathParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.consume("||") {
+		var right filterExpr
+		right, err = p.parseAnd()
+		left = logicalExpr{op: "||", left: left, right: right}
+	}
+	return left, err
+}
+
+// parseAnd parses and := unary ('&&' unary)*
+func (p *pathParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	for err == nil && p.consume("&&") {
+		var right filterExpr
+		right, err = p.parseUnary()
+		left = logicalExpr{op: "&&", left: left, right: right}
+	}
+	return left, err
+}
+
+// parseUnary parses unary := '!' unary | comparison
+func (p *pathParser) parseUnary() (filterExpr, error) {
+	if p.consume("!") {
+		operand, err := p.parseUnary()
+		return logicalExpr{op: "!", left: operand}, err
+	}
+	return p.parseComparison()
+}
+
+// comparisonOperators lists the comparison operators, longest first
+var comparisonOperators = []string{"==", "!=", "<=", ">=", "=~", "<", ">"}
+
+// parseComparison parses comparison := operand (operator operand)?
+func (p *pathParser) parseComparison() (filterExpr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range comparisonOperators {
+		if !p.consume(op) {
+			continue
+		}
+		if op == "=~" {
+			pattern, err := p.parseRegex()
+			return comparisonExpr{op: op, left: left, pattern: pattern}, err
+		}
+		right, err := p.parseOperand()
+		return comparisonExpr{op: op, left: left, right: right}, err
+	}
+	return left, nil
+}
+
+// parseOperand parses a parenthesized expression, a query or a literal
+func (p *pathParser) parseOperand() (filterExpr, error) {
+	p.skipSpace()
+	switch c := p.peek(0); {
+	case c == '(':
+		p.pos++
+		expr, err := p.parseOr()
+		if err == nil && !p.consume(")") {
+			err = p.errorf("expected ')'")
+		}
+		return expr, err
+	case c == '@' || c == '$':
+		p.pos++
+		segments, err := p.parseSegments(true)
+		return queryExpr{relative: c == '@', segments: segments}, err
+	case c == '\'' || c == '"':
+		text, err := p.parseString()
+		return literalExpr{literal: text}, err
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.input) && strings.IndexByte("0123456789.eE+-", p.input[p.pos]) >= 0 {
+			p.pos++
+		}
+		number, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", p.input[start:p.pos])
+		}
+		return literalExpr{literal: number}, nil
+	}
+
+	for keyword, literal := range map[string]interface{}{"true": true, "false": false, "null": nil} {
+		if strings.HasPrefix(p.input[p.pos:], keyword) {
+			p.pos += len(keyword)
+			return literalExpr{literal: literal}, nil
+		}
+	}
+	return nil, p.errorf("expected a filter operand")
+}
+
+// parseRegex parses the right side of =~, either /pattern/flags or a quoted string
+func (p *pathParser) parseRegex() (*regexp.Regexp, error) {
+	p.skipSpace()
+
+	var pattern string
+	switch p.peek(0) {
+	case '\'', '"':
+		text, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		pattern = text
+	case '/':
+		p.pos++
+		var b strings.Builder
+		for {
+			if p.pos >= len(p.input) {
+				return nil, p.errorf("unterminated regular expression")
+			}
+			c := p.input[p.pos]
+			p.pos++
+			if c == '/' {
+				break
+			}
+			if c == '\\' && p.peek(0) == '/' {
+				c = '/'
+				p.pos++
+			}
+			b.WriteByte(c)
+		}
+		pattern = b.String()
+		if p.peek(0) == 'i' {
+			p.pos++
+			pattern = "(?i)" + pattern
+		}
+	default:
+		return nil, p.errorf("expected a regular expression")
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, p.errorf("invalid regular expression: %v", err)
+	}
+	return re, nil
+}
@@ -0,0 +1,154 @@
+package assertions
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const storeJSON = `{
+	"store": {
+		"book": [
+			{"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+			{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+			{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+			{"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+		],
+		"bicycle": {"color": "red", "price": 19.95},
+		"user-id": 7
+	}
+}`
+
+// decodeStore returns the decoded store document
+func decodeStore(t *testing.T) interface{} {
+	t.Helper()
+
+	var document interface{}
+	require.NoError(t, json.Unmarshal([]byte(storeJSON), &document))
+	return document
+}
+
+func TestJSONPath_Find(t *testing.T) {
+	document := decodeStore(t)
+
+	tests := []struct {
+		path     string
+		expected []interface{}
+	}{
+		{"$.store.bicycle.color", []interface{}{"red"}},
+		{"store.book[0].author", []interface{}{"Nigel Rees"}},
+		{"$['store']['bicycle']['price']", []interface{}{19.95}},
+		{"store.user-id", []interface{}{7.0}},
+		{"$.store.book[-1].title", []interface{}{"The Lord of the Rings"}},
+		{"$.store.book[*].author", []interface{}{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"}},
+		{"$..isbn", []interface{}{"0-553-21311-3", "0-395-19395-8"}},
+		{"$.store.*.color", []interface{}{"red"}},
+		{"$.store.book[1:3].price", []interface{}{12.99, 8.99}},
+		{"$.store.book[::-2].price", []interface{}{22.99, 12.99}},
+		{"$.store.book[-2:].title", []interface{}{"Moby Dick", "The Lord of the Rings"}},
+		{"$.store.book[0,2].price", []interface{}{8.95, 8.99}},
+		{"$.store.book[?(@.price < 10)].title", []interface{}{"Sayings of the Century", "Moby Dick"}},
+		{"$.store.book[?@.isbn && @.price > 10].title", []interface{}{"The Lord of the Rings"}},
+		{"$.store.book[?(@.author =~ /^j\\. r/i || @.category == 'reference')].price", []interface{}{8.95, 22.99}},
+		{"$.store.book[?(!@.isbn)].price", []interface{}{8.95, 12.99}},
+		{"$.store.book[?(@.price > $.store.bicycle.price)].title", []interface{}{"The Lord of the Rings"}},
+		{"$..[?(@.color == \"red\")].price", []interface{}{19.95}},
+		{"$.store.missing", nil},
+		{"$.store.book[10]", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			results, err := QueryJSONPath(document, tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, results)
+		})
+	}
+}
+
+func TestCompileJSONPath(t *testing.T) {
+	definite, err := CompileJSONPath("$.store.book[0]['title']")
+	require.NoError(t, err)
+	assert.True(t, definite.IsDefinite())
+
+	indefinite, err := CompileJSONPath("$..title")
+	require.NoError(t, err)
+	assert.False(t, indefinite.IsDefinite())
+
+	for _, invalid := range []string{"", "$.", "$[", "$.book[?(@.price <)]", "$[?(@.a =~ /[/)]", "$['unterminated]", "$.book]", "$.a)"} {
+		_, err := CompileJSONPath(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	// Functions are not supported and must not silently select nothing
+	for _, function := range []string{"$.store.book.length()", "$.store.book[?(@.tags.length() > 1)]"} {
+		_, err := CompileJSONPath(function)
+		require.Error(t, err, function)
+		assert.Contains(t, err.Error(), "unsupported function length()")
+	}
+	_, err = MatchJSONPath(decodeStore(t), "$.store.book.length()", 4)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid JSONPath "$.store.book.length()": unsupported function length() at position 19`)
+}
+
+func TestMatchJSONPath(t *testing.T) {
+	document := decodeStore(t)
+
+	_, err := MatchJSONPath(document, "$.store.bicycle.price", 19.95)
+	assert.NoError(t, err)
+	_, err = MatchJSONPath(document, "store.user-id", 7)
+	assert.NoError(t, err)
+	_, err = MatchJSONPath(document, "$.store.book[*].price", GreaterThan(8))
+	assert.NoError(t, err)
+	_, err = MatchJSONPath(document, "$..category", []interface{}{"reference", "fiction", "fiction", "fiction"})
+	assert.NoError(t, err)
+
+	actual, err := MatchJSONPath(document, "$.store.book[*].price", GreaterThan(9))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "value 0: expected a number greater than 9, got 8.95")
+	assert.Len(t, actual, 4)
+
+	_, err = MatchJSONPath(document, "$.store.missing", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+
+	_, err = MatchJSONPath(document, "$..missing", MatchType("string"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "selected no values")
+}
+
+func TestAsserter_JSONPath(t *testing.T) {
+	asserter := NewAsserter()
+	document := decodeStore(t)
+
+	assert.True(t, asserter.JSONPath(document, "$.store.bicycle.color", "red", "bicycle color"))
+	assert.False(t, asserter.JSONPath(document, "$.store.bicycle.color", "blue", "bicycle color"))
+
+	steps := asserter.GetSteps()
+	require.Len(t, steps, 2)
+	assert.Equal(t, TestStatusPassed, steps[0].Status)
+	assert.Equal(t, TestStatusFailed, steps[1].Status)
+	assert.Equal(t, "red", steps[1].Actual)
+	assert.EqualError(t, steps[1].Error, `JSON path '$.store.bicycle.color': expected "blue", got "red"`)
+}
+
+func TestAsserter_JSONExpectations(t *testing.T) {
+	asserter := NewAsserter()
+	document := decodeStore(t)
+
+	assert.True(t, asserter.JSONExpectations(document, nil, map[string]interface{}{
+		"$.store.bicycle.price": GreaterThan(10),
+		"$.store.bicycle.color": "red",
+	}, "body", "path "))
+	assert.False(t, asserter.JSONExpectations(document, MatchType("array"), map[string]interface{}{"$.store.bicycle.color": "red"}, "body", "path "))
+
+	steps := asserter.GetSteps()
+	require.Len(t, steps, 4)
+	assert.Equal(t, "path $.store.bicycle.color", steps[0].Description)
+	assert.Equal(t, "path $.store.bicycle.price", steps[1].Description)
+	assert.Equal(t, "body", steps[2].Description)
+	assert.Equal(t, TestStatusFailed, steps[2].Status)
+	assert.Equal(t, TestStatusPassed, steps[3].Status)
+}
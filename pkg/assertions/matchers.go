@@ -0,0 +1,362 @@
+package assertions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ValueMatcher checks an actual value against a condition other than equality. Matchers are
+// accepted wherever MatchValue takes an expected value, including inside expected objects and arrays.
+type ValueMatcher interface {
+	// Match returns nil when the actual value satisfies the matcher
+	Match(actual interface{}) error
+	// String describes the matcher for reports
+	String() string
+}
+
+// regexMatcher matches strings against a regular expression
+type regexMatcher struct {
+	pattern string
+	re      *regexp.Regexp
+	err     error
+}
+
+// MatchRegex matches strings against a regular expression
+func MatchRegex(pattern string) ValueMatcher {
+	re, err := regexp.Compile(pattern)
+	return &regexMatcher{pattern: pattern, re: re, err: err}
+}
+
+// Match implements ValueMatcher
+func (m *regexMatcher) Match(actual interface{}) error {
+	if m.err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", m.pattern, m.err)
+	}
+	text, ok := actual.(string)
+	if !ok {
+		return fmt.Errorf("expected a string matching /%s/, got %s", m.pattern, describeJSON(actual))
+	}
+	if !m.re.MatchString(text) {
+		return fmt.Errorf("expected a string matching /%s/, got %q", m.pattern, text)
+	}
+	return nil
+}
+
+// String implements ValueMatcher
+func (m *regexMatcher) String() string {
+	return fmt.Sprintf("matches /%s/", m.pattern)
+}
+
+// typeMatcher matches values by JSON type
+type typeMatcher struct {
+	name string
+}
+
+// MatchType matches values of a JSON type: "string", "number", "integer", "boolean", "object",
+// "array" or "null". Integers also match "number".
+func MatchType(name string) ValueMatcher {
+	return &typeMatcher{name: name}
+}
+
+// Match implements ValueMatcher
+func (m *typeMatcher) Match(actual interface{}) error {
+	actualType := jsonTypeOf(actual)
+	if actualType == m.name || (m.name == "number" && actualType == "integer") {
+		return nil
+	}
+	return fmt.Errorf("expected a value of type %s, got %s %s", m.name, actualType, describeJSON(actual))
+}
+
+// String implements ValueMatcher
+func (m *typeMatcher) String() string {
+	return "of type " + m.name
+}
+
+// greaterThanMatcher matches numbers above a limit
+type greaterThanMatcher struct {
+	limit float64
+}
+
+// GreaterThan matches numbers strictly greater than limit
+func GreaterThan(limit float64) ValueMatcher {
+	return &greaterThanMatcher{limit: limit}
+}
+
+// Match implements ValueMatcher
+func (m *greaterThanMatcher) Match(actual interface{}) error {
+	if number, ok := toFloat64(actual); ok && number > m.limit {
+		return nil
+	}
+	return fmt.Errorf("expected a number greater than %v, got %s", m.limit, describeJSON(actual))
+}
+
+// String implements ValueMatcher
+func (m *greaterThanMatcher) String() string {
+	return fmt.Sprintf("greater than %v", m.limit)
+}
+
+// anyOfMatcher matches values that match one of several expected values
+type anyOfMatcher struct {
+	values []interface{}
+}
+
+// AnyOf matches values equal to, or matched by, any of the given values
+func AnyOf(values ...interface{}) ValueMatcher {
+	return &anyOfMatcher{values: values}
+}
+
+// Match implements ValueMatcher
+func (m *anyOfMatcher) Match(actual interface{}) error {
+	for _, value := range m.values {
+		if MatchValue(value, actual) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("expected %s, got %s", m.String(), describeJSON(actual))
+}
+
+// String implements ValueMatcher
+func (m *anyOfMatcher) String() string {
+	descriptions := make([]string, len(m.values))
+	for i, value := range m.values {
+		if matcher, ok := matcherFor(value); ok {
+			descriptions[i] = matcher.String()
+		} else {
+			descriptions[i] = describeJSON(value)
+		}
+	}
+	return "any of [" + strings.Join(descriptions, ", ") + "]"
+}
+
+// invalidMatcher reports a malformed operator object
+type invalidMatcher struct {
+	err error
+}
+
+// Match implements ValueMatcher
+func (m *invalidMatcher) Match(actual interface{}) error {
+	return m.err
+}
+
+// String implements ValueMatcher
+func (m *invalidMatcher) String() string {
+	return "invalid matcher: " + m.err.Error()
+}
+
+// matcherFor returns the matcher an expected value stands for: a ValueMatcher, or an object with a
+// single operator key ($regex, $type, $gt or $anyOf) as written in JSON or YAML test definitions
+func matcherFor(expected interface{}) (ValueMatcher, bool) {
+	if matcher, ok := expected.(ValueMatcher); ok {
+		return matcher, true
+	}
+
+	object, ok := expected.(map[string]interface{})
+	if !ok || len(object) != 1 {
+		return nil, false
+	}
+
+	for key, operand := range object {
+		switch key {
+		case "$regex":
+			if pattern, ok := operand.(string); ok {
+				return MatchRegex(pattern), true
+			}
+		case "$type":
+			if name, ok := operand.(string); ok {
+				return MatchType(name), true
+			}
+		case "$gt":
+			if limit, ok := toFloat64(operand); ok {
+				return GreaterThan(limit), true
+			}
+		case "$anyOf":
+			if values, ok := operand.([]interface{}); ok {
+				return AnyOf(values...), true
+			}
+		default:
+			return nil, false
+		}
+		return &invalidMatcher{err: fmt.Errorf("invalid operand for %s: %s", key, describeJSON(operand))}, true
+	}
+	return nil, false
+}
+
+// MatchValue compares an actual decoded JSON value with an expected value. The expected value may
+// be a literal, a ValueMatcher or an operator object such as {"$regex": "^a"}, {"$type": "string"},
+// {"$gt": 3} or {"$anyOf": [1, 2]}. Objects and arrays are compared member by member, so matchers
+// can be nested, and numbers compare by value regardless of their Go type.
+func MatchValue(expected, actual interface{}) error {
+	return matchValue(expected, actual, "$")
+}
+
+// matchValue compares values, naming the location of a mismatch within the actual value
+func matchValue(expected, actual interface{}, location string) error {
+	mismatch := func(format string, args ...interface{}) error {
+		message := fmt.Sprintf(format, args...)
+		if location != "$" {
+			message = "at " + location + ": " + message
+		}
+		return errors.New(message)
+	}
+
+	if matcher, ok := matcherFor(expected); ok {
+		if err := matcher.Match(actual); err != nil {
+			return mismatch("%v", err)
+		}
+		return nil
+	}
+
+	expectedValue := reflect.ValueOf(expected)
+	switch expectedValue.Kind() {
+	case reflect.Map:
+		if expectedValue.Type().Key().Kind() != reflect.String {
+			break
+		}
+		object, ok := actual.(map[string]interface{})
+		if !ok {
+			return mismatch("expected an object, got %s", describeJSON(actual))
+		}
+
+		members := make(map[string]interface{}, expectedValue.Len())
+		for iter := expectedValue.MapRange(); iter.Next(); {
+			members[iter.Key().String()] = iter.Value().Interface()
+		}
+		for _, key := range sortedColumns(members) {
+			value, exists := object[key]
+			if !exists {
+				return mismatch("missing member %q", key)
+			}
+			if err := matchValue(members[key], value, location+"."+key); err != nil {
+				return err
+			}
+		}
+		for _, key := range sortedColumns(object) {
+			if _, exists := members[key]; !exists {
+				return mismatch("unexpected member %q", key)
+			}
+		}
+		return nil
+
+	case reflect.Slice, reflect.Array:
+		if expectedValue.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		items, ok := actual.([]interface{})
+		if !ok {
+			return mismatch("expected an array, got %s", describeJSON(actual))
+		}
+		if len(items) != expectedValue.Len() {
+			return mismatch("expected %d items, got %d: %s", expectedValue.Len(), len(items), describeJSON(actual))
+		}
+		for i := range items {
+			if err := matchValue(expectedValue.Index(i).Interface(), items[i], fmt.Sprintf("%s[%d]", location, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Struct, reflect.Ptr:
+		if _, isTime := expected.(time.Time); isTime || (expectedValue.Kind() == reflect.Ptr && expectedValue.IsNil()) {
+			break
+		}
+		// Structs are compared through their JSON form
+		data, err := json.Marshal(expected)
+		if err != nil {
+			return mismatch("cannot convert expected value to JSON: %v", err)
+		}
+		var normalized interface{}
+		if err := json.Unmarshal(data, &normalized); err != nil {
+			return mismatch("cannot convert expected value to JSON: %v", err)
+		}
+		return matchValue(normalized, actual, location)
+	}
+
+	if expectedNumber, ok := toFloat64(expected); ok {
+		if actualNumber, ok := toFloat64(actual); ok && expectedNumber == actualNumber {
+			return nil
+		}
+		return mismatch("expected %s, got %s", describeJSON(expected), describeJSON(actual))
+	}
+
+	if !valuesMatch(expected, actual, RowMatchOptions{}) {
+		return mismatch("expected %s, got %s", describeJSON(expected), describeJSON(actual))
+	}
+	return nil
+}
+
+// Match asserts that an actual value matches an expected literal or matcher (see MatchValue)
+func (a *Asserter) Match(expected, actual interface{}, message string) bool {
+	step := AssertionStep{
+		Name:        "Match",
+		Description: message,
+		Expected:    expected,
+		Actual:      actual,
+		StartTime:   time.Now(),
+	}
+
+	err := MatchValue(expected, actual)
+	step.Status = TestStatusPassed
+	if err != nil {
+		step.Status = TestStatusFailed
+		step.Error = err
+	}
+
+	step.EndTime = time.Now()
+	step.Duration = step.EndTime.Sub(step.StartTime)
+	a.steps = append(a.steps, step)
+
+	return err == nil
+}
+
+// ParseJSONBody decodes a response body for matching; bodies that are not JSON are returned as a string
+func ParseJSONBody(body []byte) interface{} {
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return string(body)
+	}
+	return document
+}
+
+// jsonTypeOf returns the JSON type name of a value
+func jsonTypeOf(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	if number, ok := toFloat64(value); ok {
+		if number == math.Trunc(number) && !math.IsInf(number, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// describeJSON formats a value as JSON for messages
+func describeJSON(value interface{}) string {
+	if matcher, ok := value.(ValueMatcher); ok {
+		return matcher.String()
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
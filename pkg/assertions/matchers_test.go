@@ -0,0 +1,84 @@
+package assertions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchers(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher ValueMatcher
+		matches []interface{}
+		rejects []interface{}
+	}{
+		{"regex", MatchRegex(`^\d{3}-\d{4}$`), []interface{}{"555-1234"}, []interface{}{"5551234", 5551234}},
+		{"type string", MatchType("string"), []interface{}{"a"}, []interface{}{1.0, nil}},
+		{"type number", MatchType("number"), []interface{}{1.5, 2.0, int64(3)}, []interface{}{"1"}},
+		{"type integer", MatchType("integer"), []interface{}{2.0, 3}, []interface{}{2.5}},
+		{"type object", MatchType("object"), []interface{}{map[string]interface{}{}}, []interface{}{[]interface{}{}}},
+		{"greater than", GreaterThan(10), []interface{}{10.5, int64(11)}, []interface{}{10.0, "11"}},
+		{"any of", AnyOf("active", "pending", GreaterThan(100)), []interface{}{"pending", 101.0}, []interface{}{"closed", 99.0}},
+		{"invalid regex", MatchRegex("("), nil, []interface{}{"("}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, value := range tt.matches {
+				assert.NoError(t, tt.matcher.Match(value), "%v", value)
+			}
+			for _, value := range tt.rejects {
+				assert.Error(t, tt.matcher.Match(value), "%v", value)
+			}
+		})
+	}
+
+	assert.Equal(t, `any of ["a", greater than 1]`, AnyOf("a", GreaterThan(1)).String())
+}
+
+func TestMatchValue(t *testing.T) {
+	actual := map[string]interface{}{
+		"id":    42.0,
+		"email": "alice@example.com",
+		"tags":  []interface{}{"admin", "beta"},
+		"meta":  map[string]interface{}{"created": "2024-01-01"},
+	}
+
+	assert.NoError(t, MatchValue(map[string]interface{}{
+		"id":    42,
+		"email": MatchRegex("@example\\.com$"),
+		"tags":  []string{"admin", "beta"},
+		"meta":  map[string]interface{}{"created": MatchType("string")},
+	}, actual))
+
+	// Operator objects are the JSON/YAML spelling of matchers
+	assert.NoError(t, MatchValue(map[string]interface{}{
+		"id":    map[string]interface{}{"$gt": 40},
+		"email": map[string]interface{}{"$regex": "^alice"},
+		"tags":  map[string]interface{}{"$type": "array"},
+		"meta":  map[string]interface{}{"created": map[string]interface{}{"$anyOf": []interface{}{"2024-01-01", "2024-01-02"}}},
+	}, actual))
+
+	err := MatchValue(map[string]interface{}{"id": 42, "email": "bob@example.com", "tags": []string{"admin", "beta"}, "meta": map[string]interface{}{"created": "2024-01-01"}}, actual)
+	require.Error(t, err)
+	assert.Equal(t, `at $.email: expected "bob@example.com", got "alice@example.com"`, err.Error())
+
+	err = MatchValue(map[string]interface{}{"id": 42}, actual)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unexpected member "email"`)
+
+	err = MatchValue([]interface{}{1, 2}, []interface{}{1.0})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected 2 items, got 1")
+
+	err = MatchValue(map[string]interface{}{"$gt": "ten"}, 11.0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid operand for $gt")
+
+	assert.NoError(t, MatchValue(struct {
+		ID int `json:"id"`
+	}{ID: 1}, map[string]interface{}{"id": 1.0}))
+	assert.NoError(t, MatchValue("plain text", ParseJSONBody([]byte("plain text"))))
+}
@@ -0,0 +1,408 @@
+package assertions
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RowMatchOptions controls how expected database rows are compared with actual rows
+type RowMatchOptions struct {
+	// Unordered matches expected rows against actual rows regardless of position
+	Unordered bool `json:"unordered,omitempty"`
+
+	// Subset compares only the columns listed in each expected row
+	Subset bool `json:"subset,omitempty"`
+
+	// StrictTypes disables type-tolerant comparison, so int64(1) no longer equals float64(1)
+	StrictTypes bool `json:"strict_types,omitempty"`
+
+	// Tolerance is the maximum absolute difference allowed between numeric values
+	Tolerance float64 `json:"tolerance,omitempty"`
+}
+
+// ColumnDifference describes a single column mismatch within a row
+type ColumnDifference struct {
+	Column     string      `json:"column"`
+	Expected   interface{} `json:"expected,omitempty"`
+	Actual     interface{} `json:"actual,omitempty"`
+	Missing    bool        `json:"missing,omitempty"`
+	Unexpected bool        `json:"unexpected,omitempty"`
+}
+
+// String returns a readable description of the column difference
+func (cd ColumnDifference) String() string {
+	switch {
+	case cd.Missing:
+		return fmt.Sprintf("%s: expected %s, column missing from result", cd.Column, describeValue(cd.Expected))
+	case cd.Unexpected:
+		return fmt.Sprintf("%s: unexpected column with value %s", cd.Column, describeValue(cd.Actual))
+	default:
+		return fmt.Sprintf("%s: expected %s, got %s", cd.Column, describeValue(cd.Expected), describeValue(cd.Actual))
+	}
+}
+
+// RowDifference describes a mismatch between an expected row and the actual row it was compared with.
+// ExpectedIndex is -1 for rows that only exist in the result; ActualIndex is -1 when no actual row was left to compare.
+type RowDifference struct {
+	ExpectedIndex int                    `json:"expected_index"`
+	ActualIndex   int                    `json:"actual_index"`
+	Expected      map[string]interface{} `json:"expected,omitempty"`
+	Actual        map[string]interface{} `json:"actual,omitempty"`
+	Columns       []ColumnDifference     `json:"columns,omitempty"`
+}
+
+// String returns a readable per-column description of the row difference
+func (rd RowDifference) String() string {
+	switch {
+	case rd.ExpectedIndex < 0:
+		return fmt.Sprintf("unexpected row %d: %v", rd.ActualIndex, rd.Actual)
+	case rd.ActualIndex < 0:
+		return fmt.Sprintf("expected row %d not found: %v", rd.ExpectedIndex, rd.Expected)
+	}
+
+	columns := make([]string, len(rd.Columns))
+	for i, column := range rd.Columns {
+		columns[i] = column.String()
+	}
+
+	label := fmt.Sprintf("row %d", rd.ExpectedIndex)
+	if rd.ActualIndex != rd.ExpectedIndex {
+		label = fmt.Sprintf("row %d (compared with actual row %d)", rd.ExpectedIndex, rd.ActualIndex)
+	}
+	return label + ": " + strings.Join(columns, "; ")
+}
+
+// rowPairing records which actual row an expected row was compared with
+type rowPairing struct {
+	expectedIndex int
+	actualIndex   int
+	columns       []ColumnDifference
+}
+
+// CompareRows compares expected rows with actual rows and returns every difference found.
+// An empty result means the rows match under the given options; nil options use the defaults.
+func CompareRows(expected, actual []map[string]interface{}, opts *RowMatchOptions) []RowDifference {
+	pairings, unexpected := pairRows(expected, actual, resolveRowOptions(opts))
+
+	differences := make([]RowDifference, 0)
+	for _, pairing := range pairings {
+		if pairing.actualIndex >= 0 && len(pairing.columns) == 0 {
+			continue
+		}
+		differences = append(differences, newRowDifference(expected, actual, pairing))
+	}
+	for _, actualIndex := range unexpected {
+		differences = append(differences, RowDifference{
+			ExpectedIndex: -1,
+			ActualIndex:   actualIndex,
+			Actual:        actual[actualIndex],
+		})
+	}
+
+	return differences
+}
+
+// ValuesMatch compares a single expected column value with an actual one
+func ValuesMatch(expected, actual interface{}, opts *RowMatchOptions) bool {
+	return valuesMatch(expected, actual, resolveRowOptions(opts))
+}
+
+// RowsMatch asserts that actual rows match expected rows, recording one step per expected row
+// and one per unexpected actual row so that reports show a per-row, per-column diff
+func (a *Asserter) RowsMatch(expected, actual []map[string]interface{}, opts *RowMatchOptions, message string) bool {
+	pairings, unexpected := pairRows(expected, actual, resolveRowOptions(opts))
+	success := true
+
+	for _, pairing := range pairings {
+		step := AssertionStep{
+			Name:        "RowsMatch",
+			Description: fmt.Sprintf("%s: row %d", message, pairing.expectedIndex),
+			Expected:    expected[pairing.expectedIndex],
+			StartTime:   time.Now(),
+			Status:      TestStatusPassed,
+		}
+		if pairing.actualIndex >= 0 {
+			step.Actual = actual[pairing.actualIndex]
+		}
+		if pairing.actualIndex < 0 || len(pairing.columns) > 0 {
+			step.Status = TestStatusFailed
+			step.Error = errors.New(newRowDifference(expected, actual, pairing).String())
+			success = false
+		}
+		step.EndTime = time.Now()
+		step.Duration = step.EndTime.Sub(step.StartTime)
+		a.steps = append(a.steps, step)
+	}
+
+	for _, actualIndex := range unexpected {
+		step := AssertionStep{
+			Name:        "RowsMatch",
+			Description: fmt.Sprintf("%s: unexpected row %d", message, actualIndex),
+			Actual:      actual[actualIndex],
+			StartTime:   time.Now(),
+			Status:      TestStatusFailed,
+		}
+		step.Error = errors.New(RowDifference{ExpectedIndex: -1, ActualIndex: actualIndex, Actual: actual[actualIndex]}.String())
+		step.EndTime = time.Now()
+		step.Duration = step.EndTime.Sub(step.StartTime)
+		a.steps = append(a.steps, step)
+		success = false
+	}
+
+	return success
+}
+
+// resolveRowOptions returns the options to use, falling back to defaults for nil
+func resolveRowOptions(opts *RowMatchOptions) RowMatchOptions {
+	if opts == nil {
+		return RowMatchOptions{}
+	}
+	return *opts
+}
+
+// newRowDifference builds the RowDifference for a pairing
+func newRowDifference(expected, actual []map[string]interface{}, pairing rowPairing) RowDifference {
+	difference := RowDifference{
+		ExpectedIndex: pairing.expectedIndex,
+		ActualIndex:   pairing.actualIndex,
+		Expected:      expected[pairing.expectedIndex],
+		Columns:       pairing.columns,
+	}
+	if pairing.actualIndex >= 0 {
+		difference.Actual = actual[pairing.actualIndex]
+	}
+	return difference
+}
+
+// pairRows pairs every expected row with an actual row and returns the actual rows left over
+func pairRows(expected, actual []map[string]interface{}, opts RowMatchOptions) ([]rowPairing, []int) {
+	pairings := make([]rowPairing, len(expected))
+	used := make([]bool, len(actual))
+
+	if !opts.Unordered {
+		for i := range expected {
+			pairings[i] = rowPairing{expectedIndex: i, actualIndex: -1}
+			if i < len(actual) {
+				pairings[i].actualIndex = i
+				pairings[i].columns = compareRow(expected[i], actual[i], opts)
+				used[i] = true
+			}
+		}
+		return pairings, unusedIndexes(used)
+	}
+
+	// First pass pairs as many exact matches as possible so that near misses cannot steal them.
+	// With subset matching one actual row can match several expected rows, so a first fit is not enough.
+	candidates := make([][]int, len(expected))
+	for i := range expected {
+		for j := range actual {
+			if len(compareRow(expected[i], actual[j], opts)) == 0 {
+				candidates[i] = append(candidates[i], j)
+			}
+		}
+	}
+
+	matched := make([]bool, len(expected))
+	for i, j := range maximumMatching(candidates, len(actual)) {
+		pairings[i] = rowPairing{expectedIndex: i, actualIndex: j}
+		if j >= 0 {
+			used[j] = true
+			matched[i] = true
+		}
+	}
+
+	// Second pass pairs the remaining rows with their closest candidate for a useful diff
+	for i := range expected {
+		if matched[i] {
+			continue
+		}
+		best := -1
+		var bestColumns []ColumnDifference
+		for j := range actual {
+			if used[j] {
+				continue
+			}
+			columns := compareRow(expected[i], actual[j], opts)
+			if best < 0 || len(columns) < len(bestColumns) {
+				best = j
+				bestColumns = columns
+			}
+		}
+		if best >= 0 {
+			pairings[i].actualIndex = best
+			pairings[i].columns = bestColumns
+			used[best] = true
+		}
+	}
+
+	return pairings, unusedIndexes(used)
+}
+
+// maximumMatching pairs each expected row with one of its candidate actual rows so that as many
+// expected rows as possible are paired, using augmenting paths. Unpaired rows map to -1.
+func maximumMatching(candidates [][]int, actualCount int) []int {
+	owner := make([]int, actualCount)
+	for j := range owner {
+		owner[j] = -1
+	}
+
+	var augment func(i int, visited []bool) bool
+	augment = func(i int, visited []bool) bool {
+		for _, j := range candidates[i] {
+			if visited[j] {
+				continue
+			}
+			visited[j] = true
+			if owner[j] < 0 || augment(owner[j], visited) {
+				owner[j] = i
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := range candidates {
+		augment(i, make([]bool, actualCount))
+	}
+
+	assignment := make([]int, len(candidates))
+	for i := range assignment {
+		assignment[i] = -1
+	}
+	for j, i := range owner {
+		if i >= 0 {
+			assignment[i] = j
+		}
+	}
+	return assignment
+}
+
+// unusedIndexes returns the indexes that were never paired
+func unusedIndexes(used []bool) []int {
+	indexes := make([]int, 0)
+	for i, u := range used {
+		if !u {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// compareRow compares the columns of two rows in sorted column order
+func compareRow(expected, actual map[string]interface{}, opts RowMatchOptions) []ColumnDifference {
+	differences := make([]ColumnDifference, 0)
+
+	for _, column := range sortedColumns(expected) {
+		expectedValue := expected[column]
+		actualValue, exists := actual[column]
+		if !exists {
+			differences = append(differences, ColumnDifference{Column: column, Expected: expectedValue, Missing: true})
+			continue
+		}
+		if !valuesMatch(expectedValue, actualValue, opts) {
+			differences = append(differences, ColumnDifference{Column: column, Expected: expectedValue, Actual: actualValue})
+		}
+	}
+
+	if !opts.Subset {
+		for _, column := range sortedColumns(actual) {
+			if _, exists := expected[column]; !exists {
+				differences = append(differences, ColumnDifference{Column: column, Actual: actual[column], Unexpected: true})
+			}
+		}
+	}
+
+	return differences
+}
+
+// valuesMatch compares two column values under the given options
+func valuesMatch(expected, actual interface{}, opts RowMatchOptions) bool {
+	if opts.StrictTypes {
+		return reflect.DeepEqual(expected, actual)
+	}
+
+	if expectedNum, ok := toFloat64(expected); ok {
+		if actualNum, ok := toFloat64(actual); ok {
+			return math.Abs(expectedNum-actualNum) <= opts.Tolerance
+		}
+	}
+
+	switch actualValue := actual.(type) {
+	case []byte:
+		if expectedStr, ok := expected.(string); ok {
+			return expectedStr == string(actualValue)
+		}
+	case time.Time:
+		switch expectedValue := expected.(type) {
+		case time.Time:
+			return expectedValue.Equal(actualValue)
+		case string:
+			if parsed, err := time.Parse(time.RFC3339Nano, expectedValue); err == nil {
+				return parsed.Equal(actualValue)
+			}
+		}
+	}
+
+	return reflect.DeepEqual(expected, actual)
+}
+
+// toFloat64 converts any Go numeric value to float64
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// sortedColumns returns the column names of a row in sorted order
+func sortedColumns(row map[string]interface{}) []string {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// describeValue formats a value together with its Go type
+func describeValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return fmt.Sprintf("%q (string)", v)
+	case []byte:
+		return fmt.Sprintf("%q ([]byte)", v)
+	default:
+		return fmt.Sprintf("%v (%T)", v, v)
+	}
+}
@@ -0,0 +1,184 @@
+package assertions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareRows_Ordered(t *testing.T) {
+	expected := []map[string]interface{}{
+		{"id": 1, "name": "alice"},
+		{"id": 2, "name": "bob"},
+	}
+
+	t.Run("matching rows", func(t *testing.T) {
+		actual := []map[string]interface{}{
+			{"id": int64(1), "name": "alice"},
+			{"id": 2.0, "name": []byte("bob")},
+		}
+		assert.Empty(t, CompareRows(expected, actual, nil))
+	})
+
+	t.Run("column mismatch", func(t *testing.T) {
+		actual := []map[string]interface{}{
+			{"id": int64(1), "name": "alice"},
+			{"id": int64(2), "name": "carol"},
+		}
+		differences := CompareRows(expected, actual, nil)
+		require.Len(t, differences, 1)
+		assert.Equal(t, 1, differences[0].ExpectedIndex)
+		assert.Equal(t, 1, differences[0].ActualIndex)
+		require.Len(t, differences[0].Columns, 1)
+		assert.Equal(t, "name", differences[0].Columns[0].Column)
+		assert.Equal(t, `row 1: name: expected "bob" (string), got "carol" (string)`, differences[0].String())
+	})
+
+	t.Run("order matters", func(t *testing.T) {
+		actual := []map[string]interface{}{
+			{"id": int64(2), "name": "bob"},
+			{"id": int64(1), "name": "alice"},
+		}
+		assert.Len(t, CompareRows(expected, actual, nil), 2)
+	})
+
+	t.Run("missing and unexpected rows", func(t *testing.T) {
+		actual := []map[string]interface{}{
+			{"id": int64(1), "name": "alice"},
+		}
+		differences := CompareRows(expected, actual, nil)
+		require.Len(t, differences, 1)
+		assert.Equal(t, -1, differences[0].ActualIndex)
+		assert.Contains(t, differences[0].String(), "expected row 1 not found")
+
+		actual = append(actual, map[string]interface{}{"id": 2, "name": "bob"}, map[string]interface{}{"id": 3, "name": "dave"})
+		differences = CompareRows(expected, actual, nil)
+		require.Len(t, differences, 1)
+		assert.Equal(t, -1, differences[0].ExpectedIndex)
+		assert.Equal(t, 2, differences[0].ActualIndex)
+		assert.Contains(t, differences[0].String(), "unexpected row 2")
+	})
+}
+
+func TestCompareRows_Unordered(t *testing.T) {
+	expected := []map[string]interface{}{
+		{"id": 1, "name": "alice"},
+		{"id": 2, "name": "bob"},
+	}
+	opts := &RowMatchOptions{Unordered: true}
+
+	actual := []map[string]interface{}{
+		{"id": int64(2), "name": "bob"},
+		{"id": int64(1), "name": "alice"},
+	}
+	assert.Empty(t, CompareRows(expected, actual, opts))
+
+	actual = []map[string]interface{}{
+		{"id": int64(2), "name": "bobby"},
+		{"id": int64(1), "name": "alice"},
+	}
+	differences := CompareRows(expected, actual, opts)
+	require.Len(t, differences, 1)
+	assert.Equal(t, 1, differences[0].ExpectedIndex)
+	assert.Equal(t, 0, differences[0].ActualIndex)
+	assert.Equal(t, `row 1 (compared with actual row 0): name: expected "bob" (string), got "bobby" (string)`, differences[0].String())
+}
+
+func TestCompareRows_Subset(t *testing.T) {
+	expected := []map[string]interface{}{{"name": "alice"}}
+	actual := []map[string]interface{}{{"id": int64(1), "name": "alice"}}
+
+	differences := CompareRows(expected, actual, nil)
+	require.Len(t, differences, 1)
+	require.Len(t, differences[0].Columns, 1)
+	assert.True(t, differences[0].Columns[0].Unexpected)
+	assert.Equal(t, "id", differences[0].Columns[0].Column)
+
+	assert.Empty(t, CompareRows(expected, actual, &RowMatchOptions{Subset: true}))
+
+	expected = []map[string]interface{}{{"email": "a@example.com"}}
+	differences = CompareRows(expected, actual, &RowMatchOptions{Subset: true})
+	require.Len(t, differences, 1)
+	assert.True(t, differences[0].Columns[0].Missing)
+	assert.Contains(t, differences[0].String(), "column missing from result")
+}
+
+func TestCompareRows_UnorderedSubset(t *testing.T) {
+	opts := &RowMatchOptions{Unordered: true, Subset: true}
+
+	// {x:1} also matches the only actual row {x:1,y:2} can pair with, so it must give way
+	expected := []map[string]interface{}{
+		{"x": 1},
+		{"x": 1, "y": 2},
+	}
+	actual := []map[string]interface{}{
+		{"x": int64(1), "y": int64(2)},
+		{"x": int64(1), "y": int64(3)},
+	}
+	assert.Empty(t, CompareRows(expected, actual, opts))
+
+	actual = []map[string]interface{}{
+		{"x": int64(1), "y": int64(2)},
+		{"x": int64(2), "y": int64(2)},
+	}
+	differences := CompareRows(expected, actual, opts)
+	require.Len(t, differences, 1)
+	assert.Equal(t, 1, differences[0].ExpectedIndex)
+	assert.Equal(t, 1, differences[0].ActualIndex)
+}
+
+func TestValuesMatch(t *testing.T) {
+	createdAt := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	assert.True(t, ValuesMatch(1, int64(1), nil))
+	assert.True(t, ValuesMatch(1.5, float32(1.5), nil))
+	assert.False(t, ValuesMatch(1, int64(2), nil))
+	assert.True(t, ValuesMatch(1.0, 1.05, &RowMatchOptions{Tolerance: 0.1}))
+	assert.False(t, ValuesMatch(1, int64(1), &RowMatchOptions{StrictTypes: true}))
+	assert.True(t, ValuesMatch(int64(1), int64(1), &RowMatchOptions{StrictTypes: true}))
+	assert.True(t, ValuesMatch("abc", []byte("abc"), nil))
+	assert.True(t, ValuesMatch("2024-05-06T07:08:09Z", createdAt, nil))
+	assert.True(t, ValuesMatch(nil, nil, nil))
+	assert.False(t, ValuesMatch(nil, int64(0), nil))
+}
+
+func TestAsserter_RowsMatch(t *testing.T) {
+	asserter := NewAsserter()
+
+	expected := []map[string]interface{}{
+		{"id": 1, "name": "alice"},
+		{"id": 2, "name": "bob"},
+	}
+	actual := []map[string]interface{}{
+		{"id": int64(1), "name": "alice"},
+		{"id": int64(2), "name": "robert"},
+		{"id": int64(3), "name": "carol"},
+	}
+
+	success := asserter.RowsMatch(expected, actual, nil, "users")
+	assert.False(t, success)
+	assert.True(t, asserter.HasFailures())
+
+	steps := asserter.GetSteps()
+	require.Len(t, steps, 3)
+
+	assert.Equal(t, "RowsMatch", steps[0].Name)
+	assert.Equal(t, "users: row 0", steps[0].Description)
+	assert.Equal(t, TestStatusPassed, steps[0].Status)
+
+	assert.Equal(t, TestStatusFailed, steps[1].Status)
+	assert.Equal(t, actual[1], steps[1].Actual)
+	assert.EqualError(t, steps[1].Error, `row 1: name: expected "bob" (string), got "robert" (string)`)
+
+	assert.Equal(t, "users: unexpected row 2", steps[2].Description)
+	assert.Equal(t, TestStatusFailed, steps[2].Status)
+
+	asserter.Reset()
+	assert.False(t, asserter.RowsMatch(expected, actual[:1], &RowMatchOptions{Unordered: true}, "users"))
+	steps = asserter.GetSteps()
+	require.Len(t, steps, 2)
+	assert.Nil(t, steps[1].Actual)
+	assert.Contains(t, steps[1].Error.Error(), "expected row 1 not found")
+}
@@ -50,19 +50,64 @@ type APIConfig struct {
 	DefaultHeaders  map[string]string `json:"default_headers"`
 	Auth            *AuthConfig       `json:"auth,omitempty"`
 	TLSConfig       *TLSConfig        `json:"tls_config,omitempty"`
-	MaxConnections  int               `json:"max_connections"`
+	Proxy           *ProxyConfig      `json:"proxy,omitempty"`
+	MaxConnections  int               `json:"max_connections"` // per host, 0 means no limit
 	KeepAlive       bool              `json:"keep_alive"`
 	FollowRedirects bool              `json:"follow_redirects"`
+	Recording       *RecordingConfig  `json:"recording,omitempty"`
+
+	RequestHistory   int      `json:"request_history,omitempty"`   // recent requests kept for reproducing failures; 10 when zero, none when negative
+	ReproduceFormats []string `json:"reproduce_formats,omitempty"` // snippets attached to failed tests: curl (default), httpie and go
+}
+
+// RecordingConfig controls recording API traffic to a file and replaying it without network access
+type RecordingConfig struct {
+	Mode          string   `json:"mode"`                     // record or replay
+	File          string   `json:"file"`                     // .har files use HAR 1.2, other files a YAML cassette
+	MatchOn       []string `json:"match_on,omitempty"`       // method, url, body and headers; method and url by default
+	IgnoreHeaders []string `json:"ignore_headers,omitempty"` // request headers neither matched nor written to the file, besides Authorization, Proxy-Authorization and Cookie
+	Strict        bool     `json:"strict"`                   // in replay mode, fail unmatched requests instead of sending them
 }
 
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
-	Type     string            `json:"type"` // basic, bearer, oauth2, api_key
+	Type     string            `json:"type"` // basic, bearer, oauth2, jwt, api_key
 	Username string            `json:"username,omitempty"`
 	Password string            `json:"password,omitempty"`
 	Token    string            `json:"token,omitempty"`
 	APIKey   string            `json:"api_key,omitempty"`
 	Headers  map[string]string `json:"headers,omitempty"`
+	OAuth2   *OAuth2Config     `json:"oauth2,omitempty"` // required for the oauth2 type
+	JWT      *JWTConfig        `json:"jwt,omitempty"`    // required for the jwt type
+}
+
+// OAuth2Config holds the token endpoint settings of the oauth2 auth type
+type OAuth2Config struct {
+	TokenURL     string            `json:"token_url"`
+	GrantType    string            `json:"grant_type,omitempty"` // client_credentials (default) or password, which uses AuthConfig.Username and Password
+	ClientID     string            `json:"client_id"`
+	ClientSecret string            `json:"client_secret,omitempty"`
+	Scopes       []string          `json:"scopes,omitempty"`
+	Params       map[string]string `json:"params,omitempty"`       // extra token request parameters, such as audience
+	AuthStyle    string            `json:"auth_style,omitempty"`   // header (HTTP basic, default) or params
+	RefreshSkew  time.Duration     `json:"refresh_skew,omitempty"` // how long before expiry tokens are renewed, 30s by default
+}
+
+// JWTConfig holds the signing settings of the jwt auth type. Tokens are signed locally and sent as
+// bearer tokens; when TokenURL is set they are exchanged for an access token first (RFC 7523).
+type JWTConfig struct {
+	Algorithm      string                 `json:"algorithm,omitempty"`        // HS256 (default), HS384, HS512, RS256 or ES256
+	Secret         string                 `json:"secret,omitempty"`           // HMAC key
+	PrivateKeyFile string                 `json:"private_key_file,omitempty"` // PEM key for RS256 and ES256
+	KeyID          string                 `json:"key_id,omitempty"`
+	Issuer         string                 `json:"issuer,omitempty"`
+	Subject        string                 `json:"subject,omitempty"`
+	Audience       string                 `json:"audience,omitempty"`
+	Claims         map[string]interface{} `json:"claims,omitempty"`
+	TTL            time.Duration          `json:"ttl,omitempty"` // token lifetime, 5m by default
+	TokenURL       string                 `json:"token_url,omitempty"`
+	Scopes         []string               `json:"scopes,omitempty"`
+	RefreshSkew    time.Duration          `json:"refresh_skew,omitempty"`
 }
 
 // TLSConfig holds TLS configuration
@@ -82,8 +127,18 @@ type DatabaseConfig struct {
 	ConnMaxLife        time.Duration                  `json:"connection_max_lifetime"`
 	QueryTimeout       time.Duration                  `json:"query_timeout"`
 	TransactionTimeout time.Duration                  `json:"transaction_timeout"`
+	TestIsolation      string                         `json:"test_isolation,omitempty"` // none, transaction
 }
 
+// Database test isolation modes
+const (
+	// DatabaseIsolationNone runs setup, query and teardown directly against the connection
+	DatabaseIsolationNone = "none"
+
+	// DatabaseIsolationTransaction runs the whole test inside one transaction that is always rolled back
+	DatabaseIsolationTransaction = "transaction"
+)
+
 // DatabaseConnection represents a single database connection configuration
 type DatabaseConnection struct {
 	Driver   string            `json:"driver"` // mysql, postgres, sqlite3
@@ -0,0 +1,33 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/gowright/framework/pkg/config"
+)
+
+// DatabaseIsolationDefaulter is implemented by database testers that carry a suite-level isolation default
+type DatabaseIsolationDefaulter interface {
+	DefaultIsolation() string
+}
+
+// ResolveDatabaseIsolation returns the isolation mode for a database test. Tests that leave
+// Isolation empty inherit the tester's suite default when the tester provides one.
+func ResolveDatabaseIsolation(test *DatabaseTest, tester DatabaseTester) (string, error) {
+	isolation := test.Isolation
+	if isolation == "" {
+		if defaulter, ok := tester.(DatabaseIsolationDefaulter); ok {
+			isolation = defaulter.DefaultIsolation()
+		}
+	}
+
+	switch isolation {
+	case "", config.DatabaseIsolationNone:
+		return config.DatabaseIsolationNone, nil
+	case config.DatabaseIsolationTransaction:
+		return isolation, nil
+	default:
+		return "", NewGowrightError(ConfigurationError, fmt.Sprintf("unsupported database isolation mode: %s", isolation), nil).
+			WithContext("test", test.Name)
+	}
+}
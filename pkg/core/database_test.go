@@ -3,6 +3,9 @@ package core
 import (
 	"fmt"
 	"time"
+
+	"github.com/gowright/framework/pkg/assertions"
+	"github.com/gowright/framework/pkg/config"
 )
 
 // DatabaseTestImpl implements the Test interface for database testing
@@ -34,11 +37,51 @@ func (dt *DatabaseTestImpl) Execute() *TestCaseResult {
 		Logs:      []string{},
 	}
 
+	execute := func(query string, args ...interface{}) (*DatabaseResult, error) {
+		return dt.tester.Execute(dt.testCase.Connection, query, args...)
+	}
+
+	isolation, err := ResolveDatabaseIsolation(dt.testCase, dt.tester)
+	if err != nil {
+		result.Status = TestStatusError
+		result.Error = err
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(startTime)
+		return result
+	}
+
+	var tx Transaction
+	if isolation == config.DatabaseIsolationTransaction {
+		tx, err = dt.tester.BeginTransaction(dt.testCase.Connection)
+		if err != nil {
+			result.Status = TestStatusError
+			result.Error = NewGowrightError(DatabaseError, "failed to begin isolation transaction", err).
+				WithContext("connection", dt.testCase.Connection)
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(startTime)
+			return result
+		}
+		result.Logs = append(result.Logs, "Running test inside isolation transaction")
+
+		// The transaction is always rolled back so the test leaves no data behind
+		defer func() {
+			if err := tx.Rollback(); err != nil {
+				result.Logs = append(result.Logs, fmt.Sprintf("Warning: isolation rollback failed: %v", err))
+			} else {
+				result.Logs = append(result.Logs, "Isolation transaction rolled back")
+			}
+		}()
+
+		execute = func(query string, args ...interface{}) (*DatabaseResult, error) {
+			return tx.Execute(query, args...)
+		}
+	}
+
 	// Execute setup queries if any
 	if len(dt.testCase.Setup) > 0 {
 		for i, setupQuery := range dt.testCase.Setup {
 			result.Logs = append(result.Logs, fmt.Sprintf("Executing setup query %d: %s", i+1, setupQuery))
-			if _, err := dt.tester.Execute(dt.testCase.Connection, setupQuery); err != nil {
+			if _, err := execute(setupQuery); err != nil {
 				result.Status = TestStatusError
 				result.Error = NewGowrightError(DatabaseError,
 					fmt.Sprintf("setup query %d failed", i+1), err).
@@ -53,25 +96,99 @@ func (dt *DatabaseTestImpl) Execute() *TestCaseResult {
 		}
 	}
 
+	// Load fixtures once setup has created the schema; the tables are restored before teardown
+	var fixtures Fixtures
+	restoreFixtures := func() {
+		if fixtures == nil {
+			return
+		}
+		if err := fixtures.Restore(); err != nil {
+			result.Logs = append(result.Logs, fmt.Sprintf("Warning: restoring fixtures failed: %v", err))
+		} else {
+			result.Logs = append(result.Logs, "Fixture tables restored")
+		}
+		fixtures = nil
+	}
+	if len(dt.testCase.Fixtures) > 0 {
+		loader, ok := dt.tester.(FixtureLoader)
+		switch {
+		case !ok:
+			err = NewGowrightError(ConfigurationError, "database tester does not support fixtures", nil)
+		case tx != nil:
+			fixtures, err = loader.LoadFixturesInTransaction(tx, dt.testCase.Connection, dt.testCase.Fixtures...)
+		default:
+			fixtures, err = loader.LoadFixtures(dt.testCase.Connection, dt.testCase.Fixtures...)
+		}
+		if err != nil {
+			result.Status = TestStatusError
+			result.Error = err
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(startTime)
+			return result
+		}
+		result.Logs = append(result.Logs, fmt.Sprintf("Loaded %d fixture files", len(dt.testCase.Fixtures)))
+		defer restoreFixtures()
+	}
+
+	// Capture the tables whose changes the test asserts on, inside the isolation transaction when
+	// there is one so its uncommitted changes are seen
+	var snapshot DataSnapshot
+	if len(dt.testCase.Snapshot) > 0 {
+		snapshotter, ok := dt.tester.(DataSnapshotter)
+		switch {
+		case !ok:
+			err = NewGowrightError(ConfigurationError, "database tester does not support snapshots", nil)
+		case tx != nil:
+			snapshot, err = snapshotter.SnapshotDataInTransaction(tx, dt.testCase.Connection, dt.testCase.Snapshot...)
+		default:
+			snapshot, err = snapshotter.SnapshotData(dt.testCase.Connection, dt.testCase.Snapshot...)
+		}
+		if err != nil {
+			result.Status = TestStatusError
+			result.Error = err
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(startTime)
+			return result
+		}
+	}
+
 	// Execute main query
-	result.Logs = append(result.Logs, fmt.Sprintf("Executing main query: %s", dt.testCase.Query))
-	queryResult, err := dt.tester.Execute(dt.testCase.Connection, dt.testCase.Query)
+	query, args, err := dt.resolveQuery()
 	if err != nil {
 		result.Status = TestStatusError
-		result.Error = NewGowrightError(DatabaseError, "main query execution failed", err).
-			WithContext("query", dt.testCase.Query).
-			WithContext("connection", dt.testCase.Connection)
-		result.Logs = append(result.Logs, fmt.Sprintf("Main query failed: %v", err))
+		result.Error = err
 		result.EndTime = time.Now()
 		result.Duration = result.EndTime.Sub(startTime)
 		return result
 	}
+	if dt.testCase.Eventually != nil && dt.testCase.Expected != nil {
+		// Poll until asynchronous writers produce the expected data; each attempt becomes a step
+		poller, ok := dt.tester.(DataPoller)
+		if !ok {
+			result.Status = TestStatusError
+			result.Error = NewGowrightError(ConfigurationError, "database tester does not support polling", nil)
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(startTime)
+			return result
+		}
 
-	result.Logs = append(result.Logs, fmt.Sprintf("Main query executed successfully, %d rows affected", queryResult.RowsAffected))
-
-	// Validate results if expected results are provided
-	if dt.testCase.Expected != nil {
-		if err := dt.validateResults(queryResult, dt.testCase.Expected); err != nil {
+		result.Logs = append(result.Logs, fmt.Sprintf("Polling main query: %s", query))
+		var poll *PollResult
+		if tx != nil {
+			poll, err = poller.WaitForDataInTransaction(tx, query, dt.testCase.Expected, dt.testCase.Eventually, args...)
+		} else {
+			poll, err = poller.WaitForData(dt.testCase.Connection, query, dt.testCase.Expected, dt.testCase.Eventually, args...)
+		}
+		if poll == nil {
+			result.Status = TestStatusError
+			result.Error = err
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(startTime)
+			return result
+		}
+		result.Steps = append(result.Steps, poll.Steps...)
+		result.Logs = append(result.Logs, fmt.Sprintf("Main query polled %d times over %v", poll.Attempts, poll.Elapsed))
+		if err != nil {
 			result.Status = TestStatusFailed
 			result.Error = err
 			result.EndTime = time.Now()
@@ -79,12 +196,71 @@ func (dt *DatabaseTestImpl) Execute() *TestCaseResult {
 			return result
 		}
 		result.Logs = append(result.Logs, "Result validation passed")
+	} else {
+		result.Logs = append(result.Logs, fmt.Sprintf("Executing main query: %s", query))
+		queryResult, err := execute(query, args...)
+		if err != nil {
+			result.Status = TestStatusError
+			result.Error = NewGowrightError(DatabaseError, "main query execution failed", err).
+				WithContext("query", query).
+				WithContext("connection", dt.testCase.Connection)
+			result.Logs = append(result.Logs, fmt.Sprintf("Main query failed: %v", err))
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(startTime)
+			return result
+		}
+
+		result.Logs = append(result.Logs, fmt.Sprintf("Main query executed successfully, %d rows affected", queryResult.RowsAffected))
+
+		// Validate results if expected results are provided
+		if dt.testCase.Expected != nil {
+			if err := dt.validateResults(queryResult, dt.testCase.Expected); err != nil {
+				result.Status = TestStatusFailed
+				result.Error = err
+				result.EndTime = time.Now()
+				result.Duration = result.EndTime.Sub(startTime)
+				return result
+			}
+			result.Logs = append(result.Logs, "Result validation passed")
+		}
 	}
 
+	// Validate the data changes made by the query
+	if snapshot != nil {
+		changes, err := snapshot.Changes()
+		if err != nil {
+			result.Status = TestStatusError
+			result.Error = err
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(startTime)
+			return result
+		}
+
+		var expected ExpectedChanges
+		if dt.testCase.Expected != nil {
+			expected = dt.testCase.Expected.Changes
+		}
+		asserter := assertions.NewAsserter()
+		passed := asserter.DataChanges(expected, changes, "Data changes")
+		result.Steps = append(result.Steps, asserter.GetSteps()...)
+		if !passed {
+			result.Status = TestStatusFailed
+			result.Error = NewGowrightError(ValidationError, "unexpected data changes:\n"+changes.String(), nil).
+				WithContext("tables", dt.testCase.Snapshot)
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(startTime)
+			return result
+		}
+		result.Logs = append(result.Logs, "Data change validation passed")
+	}
+
+	// Restore fixture tables before teardown, which may drop them
+	restoreFixtures()
+
 	// Execute teardown queries if any
 	if len(dt.testCase.Teardown) > 0 {
 		for i, teardownQuery := range dt.testCase.Teardown {
-			if _, err := dt.tester.Execute(dt.testCase.Connection, teardownQuery); err != nil {
+			if _, err := execute(teardownQuery); err != nil {
 				// Teardown failures are logged but don't fail the test
 				result.Logs = append(result.Logs, fmt.Sprintf("Warning: teardown query %d failed: %v", i+1, err))
 			} else {
@@ -98,6 +274,21 @@ func (dt *DatabaseTestImpl) Execute() *TestCaseResult {
 	return result
 }
 
+// resolveQuery returns the main query and its arguments, reading query files and binding named
+// parameters and template variables through the tester
+func (dt *DatabaseTestImpl) resolveQuery() (string, []interface{}, error) {
+	source := dt.testCase.QuerySource()
+	if source.IsPlain() {
+		return source.Query, nil, nil
+	}
+
+	resolver, ok := dt.tester.(QueryResolver)
+	if !ok {
+		return "", nil, NewGowrightError(ConfigurationError, "database tester does not support query files or named parameters", nil)
+	}
+	return resolver.ResolveQuery(dt.testCase.Connection, source)
+}
+
 // validateResults validates the query results against expected results
 func (dt *DatabaseTestImpl) validateResults(actual *DatabaseResult, expected *DatabaseExpectation) error {
 	// Validate rows affected if specified
@@ -113,31 +304,10 @@ func (dt *DatabaseTestImpl) validateResults(actual *DatabaseResult, expected *Da
 			return NewGowrightError(ValidationError, "expected result data but got nil", nil)
 		}
 
-		// Compare result data (simplified comparison)
-		if len(expected.Rows) != len(actual.Rows) {
-			return NewGowrightError(ValidationError,
-				fmt.Sprintf("expected %d result rows, got %d", len(expected.Rows), len(actual.Rows)),
-				nil)
-		}
-
-		// Validate each row
-		for i, expectedRow := range expected.Rows {
-			actualRow := actual.Rows[i]
-			if len(expectedRow) != len(actualRow) {
-				return NewGowrightError(ValidationError,
-					fmt.Sprintf("row %d: expected %d columns, got %d", i, len(expectedRow), len(actualRow)),
-					nil)
-			}
-
-			// Validate each column (basic comparison)
-			for j, expectedValue := range expectedRow {
-				actualValue := actualRow[j]
-				if expectedValue != actualValue {
-					return NewGowrightError(ValidationError,
-						fmt.Sprintf("row %d, column %s: expected %v, got %v", i, j, expectedValue, actualValue),
-						nil)
-				}
-			}
+		differences := assertions.CompareRows(expected.Rows, actual.Rows, expected.Match)
+		if len(differences) > 0 {
+			return NewGowrightError(ValidationError, differences[0].String(), nil).
+				WithContext("differences", differences)
 		}
 	}
 
@@ -184,6 +354,12 @@ func (dtb *DatabaseTestBuilder) WithTeardown(query string) *DatabaseTestBuilder
 	return dtb
 }
 
+// WithIsolation sets the isolation mode for the test
+func (dtb *DatabaseTestBuilder) WithIsolation(isolation string) *DatabaseTestBuilder {
+	dtb.testCase.Isolation = isolation
+	return dtb
+}
+
 // WithExpected sets the expected results
 func (dtb *DatabaseTestBuilder) WithExpected(expected *DatabaseExpectation) *DatabaseTestBuilder {
 	dtb.testCase.Expected = expected
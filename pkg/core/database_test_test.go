@@ -1,9 +1,14 @@
 package core
 
 import (
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/gowright/framework/pkg/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewDatabaseTestImpl(t *testing.T) {
@@ -223,3 +228,357 @@ func TestDatabaseTestBuilder_WithExpectedData(t *testing.T) {
 	assert.NotNil(t, dbTest.testCase.Expected)
 	assert.Equal(t, expectedRows, dbTest.testCase.Expected.Rows)
 }
+
+// recordingTransaction is a Transaction stub that records executed queries
+type recordingTransaction struct {
+	queries    []string
+	rolledBack bool
+	committed  bool
+}
+
+func (rt *recordingTransaction) Commit() error {
+	rt.committed = true
+	return nil
+}
+
+func (rt *recordingTransaction) Rollback() error {
+	rt.rolledBack = true
+	return nil
+}
+
+func (rt *recordingTransaction) Execute(query string, args ...interface{}) (*DatabaseResult, error) {
+	rt.queries = append(rt.queries, query)
+	return &DatabaseResult{Rows: []map[string]interface{}{{"id": 1}}, RowCount: 1}, nil
+}
+
+func (rt *recordingTransaction) BeginTransaction() (Transaction, error) {
+	return rt, nil
+}
+
+func TestDatabaseTestImpl_Execute_TransactionIsolation(t *testing.T) {
+	testCase := &DatabaseTest{
+		Name:       "isolated_test",
+		Connection: "test_conn",
+		Setup:      []string{"INSERT INTO users (id) VALUES (1)"},
+		Query:      "SELECT id FROM users",
+		Isolation:  config.DatabaseIsolationTransaction,
+		Expected: &DatabaseExpectation{
+			Rows: []map[string]interface{}{{"id": 1}},
+		},
+	}
+
+	tx := &recordingTransaction{}
+	tester := &MockDatabaseTester{}
+	tester.On("BeginTransaction", "test_conn").Return(tx, nil)
+
+	result := NewDatabaseTest(testCase, tester).Execute()
+
+	assert.Equal(t, TestStatusPassed, result.Status)
+	assert.Equal(t, []string{"INSERT INTO users (id) VALUES (1)", "SELECT id FROM users"}, tx.queries)
+	assert.True(t, tx.rolledBack)
+	assert.False(t, tx.committed)
+	assert.Contains(t, result.Logs, "Isolation transaction rolled back")
+	tester.AssertNotCalled(t, "Execute")
+}
+
+func TestResolveDatabaseIsolation(t *testing.T) {
+	tester := &MockDatabaseTester{}
+
+	isolation, err := ResolveDatabaseIsolation(&DatabaseTest{}, tester)
+	assert.NoError(t, err)
+	assert.Equal(t, config.DatabaseIsolationNone, isolation)
+
+	isolation, err = ResolveDatabaseIsolation(&DatabaseTest{Isolation: config.DatabaseIsolationTransaction}, tester)
+	assert.NoError(t, err)
+	assert.Equal(t, config.DatabaseIsolationTransaction, isolation)
+
+	_, err = ResolveDatabaseIsolation(&DatabaseTest{Name: "bad", Isolation: "serializable"}, tester)
+	assert.Error(t, err)
+	assert.Equal(t, ConfigurationError, GetErrorType(err))
+}
+
+// resolvingDatabaseTester is a mock tester that also resolves query files
+type resolvingDatabaseTester struct {
+	MockDatabaseTester
+}
+
+func (rt *resolvingDatabaseTester) ResolveQuery(connectionName string, source QuerySource) (string, []interface{}, error) {
+	args := rt.Called(connectionName, source)
+	return args.String(0), args.Get(1).([]interface{}), args.Error(2)
+}
+
+func TestDatabaseTestImpl_Execute_QueryFile(t *testing.T) {
+	testCase := &DatabaseTest{
+		Name:       "query_file_test",
+		Connection: "test_conn",
+		QueryFile:  "queries/users.sql",
+		Expected:   &DatabaseExpectation{RowsAffected: -1, Rows: []map[string]interface{}{{"total": 2}}},
+	}
+
+	tester := &resolvingDatabaseTester{}
+	tester.On("ResolveQuery", "test_conn", QuerySource{File: "queries/users.sql"}).
+		Return("SELECT COUNT(*) AS total FROM users WHERE status = ?", []interface{}{"active"}, nil)
+	tester.On("Execute", "test_conn", "SELECT COUNT(*) AS total FROM users WHERE status = ?", []interface{}{"active"}).
+		Return(&DatabaseResult{Rows: []map[string]interface{}{{"total": 2}}, RowCount: 1}, nil)
+
+	result := NewDatabaseTest(testCase, tester).Execute()
+
+	assert.Equal(t, TestStatusPassed, result.Status, "%v", result.Error)
+	assert.Contains(t, result.Logs, "Executing main query: SELECT COUNT(*) AS total FROM users WHERE status = ?")
+	tester.AssertExpectations(t)
+
+	// Testers that cannot resolve query files fail the test before running anything
+	plain := &MockDatabaseTester{}
+	result = NewDatabaseTest(testCase, plain).Execute()
+	assert.Equal(t, TestStatusError, result.Status)
+	assert.Equal(t, ConfigurationError, GetErrorType(result.Error))
+	plain.AssertNotCalled(t, "Execute")
+}
+
+// fixtureDatabaseTester is a mock tester that also loads fixtures
+type fixtureDatabaseTester struct {
+	MockDatabaseTester
+}
+
+func (ft *fixtureDatabaseTester) LoadFixtures(connectionName string, paths ...string) (Fixtures, error) {
+	args := ft.Called(connectionName, paths)
+	fixtures, _ := args.Get(0).(Fixtures)
+	return fixtures, args.Error(1)
+}
+
+func (ft *fixtureDatabaseTester) LoadFixturesInTransaction(tx Transaction, connectionName string, paths ...string) (Fixtures, error) {
+	args := ft.Called(tx, connectionName, paths)
+	fixtures, _ := args.Get(0).(Fixtures)
+	return fixtures, args.Error(1)
+}
+
+// recordingFixtures is a Fixtures stub that records restores
+type recordingFixtures struct {
+	restored int
+}
+
+func (rf *recordingFixtures) Restore() error {
+	rf.restored++
+	return nil
+}
+
+func TestDatabaseTestImpl_Execute_Fixtures(t *testing.T) {
+	testCase := &DatabaseTest{
+		Name:       "fixture_test",
+		Connection: "test_conn",
+		Fixtures:   []string{"fixtures/users.yaml"},
+		Query:      "SELECT id FROM users",
+		Teardown:   []string{"DROP TABLE users"},
+	}
+
+	fixtures := &recordingFixtures{}
+	tester := &fixtureDatabaseTester{}
+	tester.On("LoadFixtures", "test_conn", []string{"fixtures/users.yaml"}).Return(fixtures, nil)
+	tester.On("Execute", "test_conn", "SELECT id FROM users", []interface{}(nil)).Return(&DatabaseResult{RowCount: 1}, nil)
+	tester.On("Execute", "test_conn", "DROP TABLE users", []interface{}(nil)).Return(&DatabaseResult{}, nil).
+		Run(func(mock.Arguments) {
+			// Fixture tables are restored before teardown may drop them
+			assert.Equal(t, 1, fixtures.restored)
+		})
+
+	result := NewDatabaseTest(testCase, tester).Execute()
+	assert.Equal(t, TestStatusPassed, result.Status, "%v", result.Error)
+	assert.Equal(t, 1, fixtures.restored)
+	assert.Contains(t, result.Logs, "Fixture tables restored")
+	tester.AssertExpectations(t)
+
+	// Fixtures are restored when the main query fails too
+	failing := &fixtureDatabaseTester{}
+	fixtures = &recordingFixtures{}
+	failing.On("LoadFixtures", "test_conn", []string{"fixtures/users.yaml"}).Return(fixtures, nil)
+	failing.On("Execute", "test_conn", "SELECT id FROM users", []interface{}(nil)).Return((*DatabaseResult)(nil), errors.New("no such table"))
+	result = NewDatabaseTest(testCase, failing).Execute()
+	assert.Equal(t, TestStatusError, result.Status)
+	assert.Equal(t, 1, fixtures.restored)
+
+	result = NewDatabaseTest(testCase, &MockDatabaseTester{}).Execute()
+	assert.Equal(t, TestStatusError, result.Status)
+	assert.Equal(t, ConfigurationError, GetErrorType(result.Error))
+}
+
+func TestDatabaseTestImpl_Execute_FixturesInIsolation(t *testing.T) {
+	testCase := &DatabaseTest{
+		Name:       "isolated_fixture_test",
+		Connection: "test_conn",
+		Isolation:  config.DatabaseIsolationTransaction,
+		Fixtures:   []string{"fixtures/users.yaml"},
+		Query:      "SELECT id FROM users",
+	}
+
+	// Fixtures are loaded through the isolation transaction, never committed on another connection
+	tx := &recordingTransaction{}
+	fixtures := &recordingFixtures{}
+	tester := &fixtureDatabaseTester{}
+	tester.On("BeginTransaction", "test_conn").Return(tx, nil)
+	tester.On("LoadFixturesInTransaction", tx, "test_conn", []string{"fixtures/users.yaml"}).Return(fixtures, nil)
+
+	result := NewDatabaseTest(testCase, tester).Execute()
+	assert.Equal(t, TestStatusPassed, result.Status, "%v", result.Error)
+	assert.Equal(t, 1, fixtures.restored)
+	assert.True(t, tx.rolledBack)
+	tester.AssertNotCalled(t, "LoadFixtures", mock.Anything, mock.Anything)
+}
+
+// snapshotDatabaseTester is a mock tester that also captures table snapshots
+type snapshotDatabaseTester struct {
+	MockDatabaseTester
+}
+
+func (st *snapshotDatabaseTester) SnapshotData(connectionName string, tables ...string) (DataSnapshot, error) {
+	args := st.Called(connectionName, tables)
+	snapshot, _ := args.Get(0).(DataSnapshot)
+	return snapshot, args.Error(1)
+}
+
+func (st *snapshotDatabaseTester) SnapshotDataInTransaction(tx Transaction, connectionName string, tables ...string) (DataSnapshot, error) {
+	args := st.Called(tx, connectionName, tables)
+	snapshot, _ := args.Get(0).(DataSnapshot)
+	return snapshot, args.Error(1)
+}
+
+// fixedSnapshot is a DataSnapshot stub reporting a fixed diff
+type fixedSnapshot struct {
+	diff *DataDiff
+}
+
+func (fs *fixedSnapshot) Changes() (*DataDiff, error) {
+	return fs.diff, nil
+}
+
+func TestDatabaseTestImpl_Execute_Snapshot(t *testing.T) {
+	inserted := &DataDiff{Tables: []TableChanges{{Table: "users", Inserted: []map[string]interface{}{{"id": 1, "name": "Alice"}}}}}
+	testCase := &DatabaseTest{
+		Name:       "snapshot_test",
+		Connection: "test_conn",
+		Snapshot:   []string{"users"},
+		Query:      "INSERT INTO users (name) VALUES ('Alice')",
+		Expected: &DatabaseExpectation{
+			RowsAffected: 1,
+			Changes:      ExpectedChanges{"users": {Inserted: []map[string]interface{}{{"name": "Alice"}}}},
+		},
+	}
+
+	tester := &snapshotDatabaseTester{}
+	tester.On("SnapshotData", "test_conn", []string{"users"}).Return(&fixedSnapshot{diff: inserted}, nil)
+	tester.On("Execute", "test_conn", testCase.Query, []interface{}(nil)).Return(&DatabaseResult{RowsAffected: 1}, nil)
+
+	result := NewDatabaseTest(testCase, tester).Execute()
+	assert.Equal(t, TestStatusPassed, result.Status, "%v", result.Error)
+	require.Len(t, result.Steps, 1)
+	assert.Equal(t, "Data changes: users", result.Steps[0].Description)
+
+	// Changes that were not expected fail the test with the rendered diff
+	testCase.Expected.Changes = nil
+	result = NewDatabaseTest(testCase, tester).Execute()
+	assert.Equal(t, TestStatusFailed, result.Status)
+	assert.Contains(t, result.Error.Error(), "users: 1 inserted, 0 updated, 0 deleted")
+
+	result = NewDatabaseTest(testCase, &MockDatabaseTester{}).Execute()
+	assert.Equal(t, TestStatusError, result.Status)
+	assert.Equal(t, ConfigurationError, GetErrorType(result.Error))
+}
+
+func TestDatabaseTestImpl_Execute_SnapshotInIsolation(t *testing.T) {
+	testCase := &DatabaseTest{
+		Name:       "isolated_snapshot_test",
+		Connection: "test_conn",
+		Isolation:  config.DatabaseIsolationTransaction,
+		Snapshot:   []string{"users"},
+		Query:      "INSERT INTO users (name) VALUES ('Alice')",
+		Expected: &DatabaseExpectation{
+			Changes: ExpectedChanges{"users": {Inserted: []map[string]interface{}{{"name": "Alice"}}}},
+		},
+	}
+
+	// The snapshot is taken through the isolation transaction, never on a separate connection
+	tx := &recordingTransaction{}
+	inserted := &DataDiff{Tables: []TableChanges{{Table: "users", Inserted: []map[string]interface{}{{"id": 1, "name": "Alice"}}}}}
+	tester := &snapshotDatabaseTester{}
+	tester.On("BeginTransaction", "test_conn").Return(tx, nil)
+	tester.On("SnapshotDataInTransaction", tx, "test_conn", []string{"users"}).Return(&fixedSnapshot{diff: inserted}, nil)
+
+	result := NewDatabaseTest(testCase, tester).Execute()
+	assert.Equal(t, TestStatusPassed, result.Status, "%v", result.Error)
+	assert.Equal(t, []string{testCase.Query}, tx.queries)
+	assert.True(t, tx.rolledBack)
+	tester.AssertNotCalled(t, "SnapshotData", mock.Anything, mock.Anything)
+	tester.AssertNotCalled(t, "Execute")
+}
+
+// pollingDatabaseTester is a mock tester that also polls for data
+type pollingDatabaseTester struct {
+	MockDatabaseTester
+}
+
+func (pt *pollingDatabaseTester) WaitForData(connectionName, query string, expected *DatabaseExpectation, poll *PollConfig, args ...interface{}) (*PollResult, error) {
+	mockArgs := pt.Called(connectionName, query, expected, poll, args)
+	result, _ := mockArgs.Get(0).(*PollResult)
+	return result, mockArgs.Error(1)
+}
+
+func (pt *pollingDatabaseTester) WaitForDataInTransaction(tx Transaction, query string, expected *DatabaseExpectation, poll *PollConfig, args ...interface{}) (*PollResult, error) {
+	mockArgs := pt.Called(tx, query, expected, poll, args)
+	result, _ := mockArgs.Get(0).(*PollResult)
+	return result, mockArgs.Error(1)
+}
+
+func TestDatabaseTestImpl_Execute_Eventually(t *testing.T) {
+	testCase := &DatabaseTest{
+		Name:       "eventually_test",
+		Connection: "test_conn",
+		Query:      "SELECT state FROM jobs",
+		Eventually: &PollConfig{Timeout: time.Second},
+		Expected:   &DatabaseExpectation{Rows: []map[string]interface{}{{"state": "done"}}},
+	}
+	steps := []AssertionStep{{Name: "Eventually", Status: TestStatusSkipped}, {Name: "Eventually", Status: TestStatusPassed}}
+
+	tester := &pollingDatabaseTester{}
+	tester.On("WaitForData", "test_conn", "SELECT state FROM jobs", testCase.Expected, testCase.Eventually, []interface{}(nil)).
+		Return(&PollResult{Attempts: 2, Steps: steps}, nil).Once()
+
+	result := NewDatabaseTest(testCase, tester).Execute()
+	assert.Equal(t, TestStatusPassed, result.Status, "%v", result.Error)
+	assert.Equal(t, steps, result.Steps)
+	tester.AssertNotCalled(t, "Execute")
+
+	// A timeout fails the test with the poller's error
+	tester.On("WaitForData", "test_conn", "SELECT state FROM jobs", testCase.Expected, testCase.Eventually, []interface{}(nil)).
+		Return(&PollResult{Attempts: 5, Steps: steps[:1]}, NewGowrightError(AssertionError, "data did not match expectation within 1s", nil))
+	result = NewDatabaseTest(testCase, tester).Execute()
+	assert.Equal(t, TestStatusFailed, result.Status)
+	assert.Contains(t, result.Error.Error(), "within 1s")
+
+	result = NewDatabaseTest(testCase, &MockDatabaseTester{}).Execute()
+	assert.Equal(t, TestStatusError, result.Status)
+	assert.Equal(t, ConfigurationError, GetErrorType(result.Error))
+}
+
+func TestDatabaseTestImpl_Execute_EventuallyInIsolation(t *testing.T) {
+	testCase := &DatabaseTest{
+		Name:       "isolated_eventually_test",
+		Connection: "test_conn",
+		Isolation:  config.DatabaseIsolationTransaction,
+		Setup:      []string{"INSERT INTO jobs (state) VALUES ('done')"},
+		Query:      "SELECT state FROM jobs",
+		Eventually: &PollConfig{Timeout: time.Second},
+		Expected:   &DatabaseExpectation{Rows: []map[string]interface{}{{"state": "done"}}},
+	}
+
+	// Polling goes through the isolation transaction, which holds the setup rows
+	tx := &recordingTransaction{}
+	tester := &pollingDatabaseTester{}
+	tester.On("BeginTransaction", "test_conn").Return(tx, nil)
+	tester.On("WaitForDataInTransaction", tx, "SELECT state FROM jobs", testCase.Expected, testCase.Eventually, []interface{}(nil)).
+		Return(&PollResult{Attempts: 1, Steps: []AssertionStep{{Name: "Eventually", Status: TestStatusPassed}}}, nil)
+
+	result := NewDatabaseTest(testCase, tester).Execute()
+	assert.Equal(t, TestStatusPassed, result.Status, "%v", result.Error)
+	assert.Equal(t, []string{"INSERT INTO jobs (state) VALUES ('done')"}, tx.queries)
+	assert.True(t, tx.rolledBack)
+	tester.AssertNotCalled(t, "WaitForData", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -19,6 +19,10 @@ type Gowright struct {
 	databaseTester    DatabaseTester
 	integrationTester IntegrationTester
 
+	// Resources opened by the testers, such as connection pools, for resource reports
+	resourceManager     *ResourceManager
+	ownsResourceManager bool
+
 	// Internal state management
 	initialized bool
 	mutex       sync.RWMutex
@@ -31,6 +35,7 @@ type GowrightOptions struct {
 	APITester         APITester
 	DatabaseTester    DatabaseTester
 	IntegrationTester IntegrationTester
+	ResourceManager   *ResourceManager // created by Initialize when nil
 }
 
 // New creates a new Gowright instance with the provided configuration
@@ -62,6 +67,7 @@ func NewWithOptions(options *GowrightOptions) *Gowright {
 		apiTester:         options.APITester,
 		databaseTester:    options.DatabaseTester,
 		integrationTester: options.IntegrationTester,
+		resourceManager:   options.ResourceManager,
 		initialized:       false,
 	}
 }
@@ -105,6 +111,19 @@ func (g *Gowright) Initialize() error {
 		}
 	}
 
+	// Testers register the resources they open, such as connection pools, with the resource manager
+	if g.resourceManager == nil {
+		g.resourceManager = NewResourceManager(nil)
+		g.ownsResourceManager = true
+	}
+	for _, tester := range []interface{}{g.uiTester, g.apiTester, g.databaseTester, g.integrationTester} {
+		if registrar, ok := tester.(ResourceRegistrar); ok {
+			if err := registrar.SetResourceManager(g.resourceManager); err != nil {
+				return NewGowrightError(ConfigurationError, "failed to register tester resources", err)
+			}
+		}
+	}
+
 	g.initialized = true
 	return nil
 }
@@ -141,6 +160,14 @@ func (g *Gowright) Cleanup() error {
 		}
 	}
 
+	// A resource manager created by Initialize is shut down with the framework
+	if g.ownsResourceManager {
+		if err := g.resourceManager.Shutdown(); err != nil {
+			errors = append(errors, fmt.Errorf("resource manager shutdown failed: %w", err))
+		}
+		g.resourceManager, g.ownsResourceManager = nil, false
+	}
+
 	g.initialized = false
 
 	if len(errors) > 0 {
@@ -178,6 +205,14 @@ func (g *Gowright) GetConfig() *config.Config {
 	return g.config
 }
 
+// GetResourceManager returns the resource manager that tracks the testers' resources, or nil
+// before Initialize when none was provided
+func (g *Gowright) GetResourceManager() *ResourceManager {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	return g.resourceManager
+}
+
 // GetUITester returns the UI tester instance
 func (g *Gowright) GetUITester() UITester {
 	g.mutex.RLock()
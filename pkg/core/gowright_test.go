@@ -110,6 +110,26 @@ func (m *TestMockAPITester) Delete(endpoint string, headers map[string]string) (
 	return args.Get(0).(*APIResponse), args.Error(1)
 }
 
+func (m *TestMockAPITester) Patch(endpoint string, body interface{}, headers map[string]string) (*APIResponse, error) {
+	args := m.Called(endpoint, body, headers)
+	return args.Get(0).(*APIResponse), args.Error(1)
+}
+
+func (m *TestMockAPITester) Head(endpoint string, headers map[string]string) (*APIResponse, error) {
+	args := m.Called(endpoint, headers)
+	return args.Get(0).(*APIResponse), args.Error(1)
+}
+
+func (m *TestMockAPITester) Options(endpoint string, headers map[string]string) (*APIResponse, error) {
+	args := m.Called(endpoint, headers)
+	return args.Get(0).(*APIResponse), args.Error(1)
+}
+
+func (m *TestMockAPITester) Do(method, endpoint string, body interface{}, headers map[string]string) (*APIResponse, error) {
+	args := m.Called(method, endpoint, body, headers)
+	return args.Get(0).(*APIResponse), args.Error(1)
+}
+
 func (m *TestMockAPITester) SetAuth(auth *config.AuthConfig) error {
 	args := m.Called(auth)
 	return args.Error(0)
@@ -215,6 +235,43 @@ func TestGowrightClose(t *testing.T) {
 	assert.False(t, gw.IsInitialized())
 }
 
+// registrarDatabaseTester is a mock database tester that accepts a resource manager
+type registrarDatabaseTester struct {
+	MockDatabaseTester
+}
+
+func (m *registrarDatabaseTester) SetResourceManager(rm *ResourceManager) error {
+	return m.Called(rm).Error(0)
+}
+
+func TestGowrightResourceManager(t *testing.T) {
+	tester := &registrarDatabaseTester{}
+	tester.On("Initialize", mock.Anything).Return(nil)
+	tester.On("SetResourceManager", mock.AnythingOfType("*core.ResourceManager")).Return(nil).Once()
+	tester.On("Cleanup").Return(nil)
+
+	gw := NewWithOptions(&GowrightOptions{DatabaseTester: tester})
+	assert.Nil(t, gw.GetResourceManager())
+	require.NoError(t, gw.Initialize())
+	rm := gw.GetResourceManager()
+	require.NotNil(t, rm)
+	tester.AssertCalled(t, "SetResourceManager", rm)
+
+	// The manager created by Initialize is shut down with the framework
+	require.NoError(t, gw.Cleanup())
+	assert.Nil(t, gw.GetResourceManager())
+
+	// A provided manager is used as is and outlives the framework
+	provided := NewResourceManager(nil)
+	defer func() { _ = provided.Shutdown() }()
+	tester.On("SetResourceManager", provided).Return(nil).Once()
+	gw = NewWithOptions(&GowrightOptions{DatabaseTester: tester, ResourceManager: provided})
+	require.NoError(t, gw.Initialize())
+	require.NoError(t, gw.Cleanup())
+	assert.Same(t, provided, gw.GetResourceManager())
+	tester.AssertExpectations(t)
+}
+
 func TestGowrightExecuteUITest(t *testing.T) {
 	config := config.DefaultConfig()
 	gw := New(config)
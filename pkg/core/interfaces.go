@@ -60,9 +60,21 @@ type APITester interface {
 	// Put performs a PUT request to the specified endpoint
 	Put(endpoint string, body interface{}, headers map[string]string) (*APIResponse, error)
 
+	// Patch performs a PATCH request to the specified endpoint
+	Patch(endpoint string, body interface{}, headers map[string]string) (*APIResponse, error)
+
 	// Delete performs a DELETE request to the specified endpoint
 	Delete(endpoint string, headers map[string]string) (*APIResponse, error)
 
+	// Head performs a HEAD request to the specified endpoint
+	Head(endpoint string, headers map[string]string) (*APIResponse, error)
+
+	// Options performs an OPTIONS request to the specified endpoint
+	Options(endpoint string, headers map[string]string) (*APIResponse, error)
+
+	// Do performs a request with any supported HTTP method
+	Do(method, endpoint string, body interface{}, headers map[string]string) (*APIResponse, error)
+
 	// SetAuth sets authentication for API requests
 	SetAuth(auth *config.AuthConfig) error
 
@@ -129,4 +141,60 @@ type Transaction interface {
 
 	// Execute executes a query within the transaction
 	Execute(query string, args ...interface{}) (*DatabaseResult, error)
+
+	// BeginTransaction starts a nested transaction backed by a savepoint
+	BeginTransaction() (Transaction, error)
+}
+
+// FixtureLoader is implemented by database testers that can seed tables from fixture files
+type FixtureLoader interface {
+	// LoadFixtures loads the given fixture files into the named connection
+	LoadFixtures(connectionName string, paths ...string) (Fixtures, error)
+
+	// LoadFixturesInTransaction loads the given fixture files through a transaction on the named
+	// connection, so the rows and their restore stay inside it
+	LoadFixturesInTransaction(tx Transaction, connectionName string, paths ...string) (Fixtures, error)
+}
+
+// Fixtures is a handle to fixture data that has been loaded into a database
+type Fixtures interface {
+	// Restore puts the fixture tables back into the state they were in before loading
+	Restore() error
+}
+
+// DataSnapshotter is implemented by database testers that capture table contents to diff them later
+type DataSnapshotter interface {
+	// SnapshotData captures the full contents of the given tables on the named connection
+	SnapshotData(connectionName string, tables ...string) (DataSnapshot, error)
+
+	// SnapshotDataInTransaction captures the tables through a transaction on the named connection,
+	// so the snapshot and its changes include the transaction's uncommitted writes
+	SnapshotDataInTransaction(tx Transaction, connectionName string, tables ...string) (DataSnapshot, error)
+}
+
+// DataSnapshot is the captured contents of a set of tables
+type DataSnapshot interface {
+	// Changes captures the tables again and returns how they differ from the snapshot
+	Changes() (*DataDiff, error)
+}
+
+// DataPoller is implemented by database testers that wait for eventually-consistent data
+type DataPoller interface {
+	// WaitForData runs a query until its result matches the expectation or the poll times out
+	WaitForData(connectionName, query string, expected *DatabaseExpectation, poll *PollConfig, args ...interface{}) (*PollResult, error)
+
+	// WaitForDataInTransaction polls the query through a transaction, so it sees the transaction's own writes
+	WaitForDataInTransaction(tx Transaction, query string, expected *DatabaseExpectation, poll *PollConfig, args ...interface{}) (*PollResult, error)
+}
+
+// QueryResolver is implemented by database testers that resolve query files, named parameters and templates
+type QueryResolver interface {
+	// ResolveQuery returns the SQL and positional arguments to execute on the named connection
+	ResolveQuery(connectionName string, source QuerySource) (string, []interface{}, error)
+}
+
+// ResourceRegistrar is implemented by testers that register the resources they open, such as
+// database connection pools, with a resource manager
+type ResourceRegistrar interface {
+	SetResourceManager(rm *ResourceManager) error
 }
@@ -141,6 +141,30 @@ func (m *MockAPITester) Delete(endpoint string, headers map[string]string) (*API
 	return args.Get(0).(*APIResponse), args.Error(1)
 }
 
+// Patch performs a PATCH request
+func (m *MockAPITester) Patch(endpoint string, body interface{}, headers map[string]string) (*APIResponse, error) {
+	args := m.Called(endpoint, body, headers)
+	return args.Get(0).(*APIResponse), args.Error(1)
+}
+
+// Head performs a HEAD request
+func (m *MockAPITester) Head(endpoint string, headers map[string]string) (*APIResponse, error) {
+	args := m.Called(endpoint, headers)
+	return args.Get(0).(*APIResponse), args.Error(1)
+}
+
+// Options performs an OPTIONS request
+func (m *MockAPITester) Options(endpoint string, headers map[string]string) (*APIResponse, error) {
+	args := m.Called(endpoint, headers)
+	return args.Get(0).(*APIResponse), args.Error(1)
+}
+
+// Do performs a request with any HTTP method
+func (m *MockAPITester) Do(method, endpoint string, body interface{}, headers map[string]string) (*APIResponse, error) {
+	args := m.Called(method, endpoint, body, headers)
+	return args.Get(0).(*APIResponse), args.Error(1)
+}
+
 // SetAuth sets authentication
 func (m *MockAPITester) SetAuth(auth *config.AuthConfig) error {
 	args := m.Called(auth)
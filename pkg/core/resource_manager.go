@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
@@ -29,6 +30,17 @@ type Resource interface {
 	Cleanup() error
 }
 
+// ResourceMetadataProvider is implemented by resources that add details to resource reports
+type ResourceMetadataProvider interface {
+	GetMetadata() map[string]interface{}
+}
+
+// LeakDetector is implemented by resources that hand out leases and can report
+// leases held longer than maxAge. DetectLeaks returns the number of newly detected leaks.
+type LeakDetector interface {
+	DetectLeaks(maxAge time.Duration) int
+}
+
 // ResourceInfo holds information about a resource
 type ResourceInfo struct {
 	ID        string                 `json:"id"`
@@ -46,6 +58,7 @@ type ResourceManager struct {
 	mutex         sync.RWMutex
 	config        *ResourceManagerConfig
 	cleanupTicker *time.Ticker
+	leakTicker    *time.Ticker
 	ctx           context.Context
 	cancel        context.CancelFunc
 }
@@ -112,6 +125,10 @@ func NewResourceManager(config *ResourceManagerConfig) *ResourceManager {
 	// Start cleanup routine
 	rm.startCleanupRoutine()
 
+	if config.EnableLeakDetection && config.LeakDetectionInterval > 0 {
+		rm.startLeakDetectionRoutine()
+	}
+
 	return rm
 }
 
@@ -219,6 +236,70 @@ func (rm *ResourceManager) GetStats() map[ResourceType]*ResourceTypeStats {
 	return stats
 }
 
+// GetResourceInfo returns a report of all registered resources ordered by ID,
+// including the metadata of resources that implement ResourceMetadataProvider
+func (rm *ResourceManager) GetResourceInfo() []ResourceInfo {
+	rm.mutex.RLock()
+	resources := make([]Resource, 0, len(rm.resources))
+	for _, resource := range rm.resources {
+		resources = append(resources, resource)
+	}
+	rm.mutex.RUnlock()
+
+	infos := make([]ResourceInfo, 0, len(resources))
+	for _, resource := range resources {
+		info := ResourceInfo{
+			ID:        resource.GetID(),
+			Type:      resource.GetType(),
+			CreatedAt: resource.GetCreatedAt(),
+			LastUsed:  resource.GetLastUsed(),
+			Active:    resource.IsActive(),
+		}
+		if provider, ok := resource.(ResourceMetadataProvider); ok {
+			info.Metadata = provider.GetMetadata()
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+
+	return infos
+}
+
+// DetectLeaks asks every resource implementing LeakDetector for leases held longer than
+// the configured resource timeout, records them in the type statistics and returns the count
+func (rm *ResourceManager) DetectLeaks() int {
+	rm.mutex.RLock()
+	var detectors []Resource
+	for _, resource := range rm.resources {
+		if _, ok := resource.(LeakDetector); ok {
+			detectors = append(detectors, resource)
+		}
+	}
+	rm.mutex.RUnlock()
+
+	total := 0
+	for _, resource := range detectors {
+		leaked := resource.(LeakDetector).DetectLeaks(rm.config.ResourceTimeout)
+		if leaked == 0 {
+			continue
+		}
+
+		rm.mutex.Lock()
+		if stats, exists := rm.resourceStats[resource.GetType()]; exists {
+			stats.Leaked += leaked
+			stats.LastActivity = time.Now()
+		}
+		rm.mutex.Unlock()
+
+		total += leaked
+	}
+
+	return total
+}
+
 // CleanupAll cleans up all resources
 func (rm *ResourceManager) CleanupAll() error {
 	rm.mutex.Lock()
@@ -253,6 +334,9 @@ func (rm *ResourceManager) Shutdown() error {
 	if rm.cleanupTicker != nil {
 		rm.cleanupTicker.Stop()
 	}
+	if rm.leakTicker != nil {
+		rm.leakTicker.Stop()
+	}
 
 	// Cancel context
 	if rm.cancel != nil {
@@ -278,3 +362,19 @@ func (rm *ResourceManager) startCleanupRoutine() {
 		}
 	}()
 }
+
+// startLeakDetectionRoutine starts the periodic leak detection routine
+func (rm *ResourceManager) startLeakDetectionRoutine() {
+	rm.leakTicker = time.NewTicker(rm.config.LeakDetectionInterval)
+
+	go func() {
+		for {
+			select {
+			case <-rm.leakTicker.C:
+				rm.DetectLeaks()
+			case <-rm.ctx.Done():
+				return
+			}
+		}
+	}()
+}
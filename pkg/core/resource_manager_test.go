@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -271,6 +272,46 @@ func TestDefaultResourceManagerConfig(t *testing.T) {
 	assert.Greater(t, config.LeakDetectionInterval, time.Duration(0))
 }
 
+func TestResourceManager_GetResourceInfo(t *testing.T) {
+	rm := NewResourceManager(DefaultResourceManagerConfig())
+	defer func() { _ = rm.Shutdown() }()
+
+	now := time.Now()
+	require.NoError(t, rm.RegisterResource(&MockResource{id: "b_browser", resType: ResourceTypeBrowser, createdAt: now, active: true}))
+	require.NoError(t, rm.RegisterResource(&MockLeasingResource{
+		MockResource: MockResource{id: "a_pool", resType: ResourceTypeDatabase, createdAt: now, active: true},
+		metadata:     map[string]interface{}{"in_use": 2},
+	}))
+
+	infos := rm.GetResourceInfo()
+	require.Len(t, infos, 2)
+	assert.Equal(t, "a_pool", infos[0].ID)
+	assert.Equal(t, map[string]interface{}{"in_use": 2}, infos[0].Metadata)
+	assert.Equal(t, "b_browser", infos[1].ID)
+	assert.Nil(t, infos[1].Metadata)
+}
+
+func TestResourceManager_DetectLeaks(t *testing.T) {
+	config := DefaultResourceManagerConfig()
+	config.ResourceTimeout = time.Second
+	config.LeakDetectionInterval = 10 * time.Millisecond
+	rm := NewResourceManager(config)
+	defer func() { _ = rm.Shutdown() }()
+
+	resource := &MockLeasingResource{
+		MockResource: MockResource{id: "pool", resType: ResourceTypeDatabase, active: true},
+		leaks:        2,
+	}
+	require.NoError(t, rm.RegisterResource(resource))
+
+	// The background routine picks the leaks up and reports each only once
+	assert.Eventually(t, func() bool {
+		return rm.GetStats()[ResourceTypeDatabase].Leaked == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, rm.DetectLeaks())
+	assert.Equal(t, time.Second, resource.lastMaxAge())
+}
+
 // MockResource implements the Resource interface for testing
 type MockResource struct {
 	id         string
@@ -305,3 +346,32 @@ func (m *MockResource) Cleanup() error {
 	m.active = false
 	return m.cleanupErr
 }
+
+// MockLeasingResource adds metadata and leak detection to MockResource
+type MockLeasingResource struct {
+	MockResource
+	metadata map[string]interface{}
+	leaks    int
+	maxAge   time.Duration
+	mutex    sync.Mutex
+}
+
+func (m *MockLeasingResource) GetMetadata() map[string]interface{} {
+	return m.metadata
+}
+
+func (m *MockLeasingResource) DetectLeaks(maxAge time.Duration) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.maxAge = maxAge
+	leaks := m.leaks
+	m.leaks = 0
+	return leaks
+}
+
+func (m *MockLeasingResource) lastMaxAge() time.Duration {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.maxAge
+}
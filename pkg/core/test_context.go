@@ -12,6 +12,7 @@ type TestContext struct {
 	asserter  *assertions.Asserter
 	startTime time.Time
 	timeout   time.Duration
+	variables *Variables
 }
 
 // NewTestContext creates a new test context
@@ -21,6 +22,7 @@ func NewTestContext(testName string) *TestContext {
 		asserter:  assertions.NewAsserter(),
 		startTime: time.Now(),
 		timeout:   30 * time.Second, // default timeout
+		variables: NewVariables(),
 	}
 }
 
@@ -31,6 +33,7 @@ func NewTestContextWithTimeout(testName string, timeout time.Duration) *TestCont
 		asserter:  assertions.NewAsserter(),
 		startTime: time.Now(),
 		timeout:   timeout,
+		variables: NewVariables(),
 	}
 }
 
@@ -49,6 +52,17 @@ func (tc *TestContext) GetTimeout() time.Duration {
 	return tc.timeout
 }
 
+// Variables returns the variable store of the test
+func (tc *TestContext) Variables() *Variables {
+	return tc.variables
+}
+
+// UseSuiteVariables makes the test's variables a scope of a suite store, so the test sees
+// the suite's variables while its own writes stay local to the test
+func (tc *TestContext) UseSuiteVariables(suite *Variables) {
+	tc.variables.SetParent(suite)
+}
+
 // AssertTrue asserts that a value is true
 func (tc *TestContext) AssertTrue(value bool, message string) bool {
 	return tc.asserter.True(value, message)
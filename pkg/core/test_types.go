@@ -1,6 +1,9 @@
 package core
 
-import "time"
+import (
+	"io"
+	"time"
+)
 
 // UITest represents a UI test case
 type UITest struct {
@@ -35,6 +38,45 @@ type APITest struct {
 	Headers  map[string]string `json:"headers,omitempty"`
 	Body     interface{}       `json:"body,omitempty"`
 	Expected *APIExpectation   `json:"expected"`
+
+	Variables map[string]interface{} `json:"variables,omitempty"` // test-scoped values for {{name}} placeholders
+	Captures  []APICapture           `json:"captures,omitempty"`  // values stored for later tests once the response arrives
+
+	RequestBody *RequestBody `json:"request_body,omitempty"` // form, multipart, raw or XML body; replaces Body
+}
+
+// RequestBody describes a request body that is not JSON. Exactly one of Form, Multipart, Raw, File,
+// Reader or XML is set. The content type of the encoding replaces any Content-Type header;
+// ContentType overrides it, except for multipart bodies, whose content type carries the boundary.
+type RequestBody struct {
+	Form        map[string]interface{} `json:"form,omitempty"` // application/x-www-form-urlencoded; a list value repeats the field
+	Multipart   []MultipartPart        `json:"multipart,omitempty"`
+	Raw         string                 `json:"raw,omitempty"`  // sent as is; application/octet-stream unless ContentType is set
+	File        string                 `json:"file,omitempty"` // file streamed as the body; the content type follows the extension
+	Reader      io.Reader              `json:"-"`              // stream sent as the body
+	XML         interface{}            `json:"xml,omitempty"`  // strings and []byte are sent as they are, other values encoded with encoding/xml
+	ContentType string                 `json:"content_type,omitempty"`
+}
+
+// MultipartPart is a field or file of a multipart/form-data body. Value makes a text field; File or
+// Content make a file part.
+type MultipartPart struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	File        string `json:"file,omitempty"`      // path of the file to upload
+	Content     []byte `json:"content,omitempty"`   // file content, base64 encoded in JSON
+	FileName    string `json:"file_name,omitempty"` // defaults to the base name of File
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// APICapture extracts a value from a response into a variable. Header or JSONPath selects the
+// value; Regex, on its own, applies to the body, otherwise to the selected value, and yields its
+// first group, or the whole match when it has no groups.
+type APICapture struct {
+	Variable string `json:"variable"`
+	JSONPath string `json:"json_path,omitempty"`
+	Header   string `json:"header,omitempty"`
+	Regex    string `json:"regex,omitempty"`
 }
 
 // APIExpectation represents expected API response
@@ -43,16 +85,163 @@ type APIExpectation struct {
 	Headers    map[string]string      `json:"headers,omitempty"`
 	Body       interface{}            `json:"body,omitempty"`
 	JSONPath   map[string]interface{} `json:"json_path,omitempty"`
+	JSONSchema *JSONSchemaSource      `json:"json_schema,omitempty"` // schema the response body must satisfy
+
+	MaxResponseTime time.Duration     `json:"max_response_time,omitempty"` // upper bound on APIResponse.Duration
+	BodyRegex       string            `json:"body_regex,omitempty"`        // pattern the response body must match
+	HeaderRegex     map[string]string `json:"header_regex,omitempty"`      // header name to the pattern its value must match
+}
+
+// GraphQLTest represents a GraphQL query or mutation sent as a POST request
+type GraphQLTest struct {
+	Name           string                 `json:"name"`
+	Endpoint       string                 `json:"endpoint"`
+	Query          string                 `json:"query"`
+	OperationName  string                 `json:"operation_name,omitempty"`
+	Variables      map[string]interface{} `json:"variables,omitempty"` // GraphQL variables; {{name}} placeholders in values are interpolated
+	Headers        map[string]string      `json:"headers,omitempty"`
+	PersistedQuery bool                   `json:"persisted_query,omitempty"` // send the query hash first and the full query only if the server does not know it
+	Schema         string                 `json:"schema,omitempty"`          // SDL file the query is validated against before it is sent
+	Expected       *GraphQLExpectation    `json:"expected,omitempty"`
+	Captures       []APICapture           `json:"captures,omitempty"` // JSON paths start at the response root, e.g. "$.data.user.id"
+}
+
+// GraphQLExpectation defines what a GraphQL response must contain. A response with an errors
+// array fails unless Errors or AllowErrors is set.
+type GraphQLExpectation struct {
+	StatusCode      int                    `json:"status_code,omitempty"`
+	Data            interface{}            `json:"data,omitempty"`      // literal or matchers compared with the data object
+	JSONPath        map[string]interface{} `json:"json_path,omitempty"` // paths evaluated against the data object, e.g. "$.user.name"
+	Errors          []string               `json:"errors,omitempty"`    // patterns that must each match the message of a returned error
+	AllowErrors     bool                   `json:"allow_errors,omitempty"`
+	MaxResponseTime time.Duration          `json:"max_response_time,omitempty"`
+}
+
+// StreamTest represents a WebSocket or Server-Sent Events test: a script of messages to send and
+// messages to wait for over one connection
+type StreamTest struct {
+	Name     string            `json:"name"`
+	Protocol string            `json:"protocol,omitempty"` // "websocket" (default) or "sse"
+	Endpoint string            `json:"endpoint"`           // absolute ws(s):// or http(s):// URL, or a path relative to the base URL
+	Headers  map[string]string `json:"headers,omitempty"`
+	Steps    []StreamStep      `json:"steps"`
+	Timeout  time.Duration     `json:"timeout,omitempty"` // default wait of Expect steps; 5s when zero
+}
+
+// StreamStep sends a message or waits for messages; exactly one of Send and Expect is set
+type StreamStep struct {
+	Send   interface{}        `json:"send,omitempty"` // strings are sent as text, []byte as binary, other values as JSON
+	Expect *StreamExpectation `json:"expect,omitempty"`
+}
+
+// StreamExpectation waits for received messages matching every predicate. Messages are consumed in
+// order, so consecutive expectations assert the order in which messages arrive.
+type StreamExpectation struct {
+	JSONPath map[string]interface{} `json:"json_path,omitempty"` // literals or matchers for values in JSON messages
+	Regex    string                 `json:"regex,omitempty"`
+	Event    string                 `json:"event,omitempty"`   // SSE event type
+	Count    int                    `json:"count,omitempty"`   // matching messages to wait for; 1 when zero
+	Next     bool                   `json:"next,omitempty"`    // the matching messages must be the next ones received
+	Absent   bool                   `json:"absent,omitempty"`  // no matching message may arrive within the timeout
+	Timeout  time.Duration          `json:"timeout,omitempty"` // overrides StreamTest.Timeout
+}
+
+// LoadTest runs a scenario of API tests repeatedly to measure latency and throughput. Each iteration
+// sends the tests of the scenario in order as one virtual user, whose captured variables carry over
+// to its later requests.
+type LoadTest struct {
+	Name         string          `json:"name"`
+	Scenario     []*APITest      `json:"scenario"`
+	VirtualUsers int             `json:"virtual_users,omitempty"` // concurrent users, 10 when zero; with RPS, the most iterations in flight
+	RPS          float64         `json:"rps,omitempty"`           // iterations started per second; when zero each user runs iterations back to back
+	Duration     time.Duration   `json:"duration"`
+	RampUp       time.Duration   `json:"ramp_up,omitempty"`  // users start, or the rate grows, linearly over this period
+	Interval     time.Duration   `json:"interval,omitempty"` // period of the progress snapshots of long soak runs; none when zero
+	Thresholds   *LoadThresholds `json:"thresholds,omitempty"`
+}
+
+// LoadThresholds decide whether a load test passes; unset thresholds are not checked
+type LoadThresholds struct {
+	P50           time.Duration `json:"p50,omitempty"` // maximum latency percentiles
+	P90           time.Duration `json:"p90,omitempty"`
+	P95           time.Duration `json:"p95,omitempty"`
+	P99           time.Duration `json:"p99,omitempty"`
+	MaxErrorRate  *float64      `json:"max_error_rate,omitempty"` // highest fraction of failed requests, from 0 to 1
+	MinThroughput float64       `json:"min_throughput,omitempty"` // requests per second
+}
+
+// JSONSchemaSource identifies the JSON Schema used to validate a response body.
+// Exactly one of Inline, File or Ref is set.
+type JSONSchemaSource struct {
+	Inline interface{} `json:"inline,omitempty"` // schema as a JSON string, []byte or decoded value
+	File   string      `json:"file,omitempty"`   // JSON or YAML schema file
+	Ref    string      `json:"ref,omitempty"`    // document and pointer, e.g. "openapi.yaml#/components/schemas/User"
+	Draft  string      `json:"draft,omitempty"`  // "2020-12" (default) or "draft-07"; a $schema keyword takes precedence
 }
 
 // DatabaseTest represents a database test case
 type DatabaseTest struct {
-	Name       string               `json:"name"`
-	Connection string               `json:"connection"`
-	Setup      []string             `json:"setup,omitempty"`
-	Query      string               `json:"query"`
-	Expected   *DatabaseExpectation `json:"expected"`
-	Teardown   []string             `json:"teardown,omitempty"`
+	Name       string                 `json:"name"`
+	Connection string                 `json:"connection"`
+	Setup      []string               `json:"setup,omitempty"`
+	Fixtures   []string               `json:"fixtures,omitempty"`
+	Snapshot   []string               `json:"snapshot,omitempty"` // tables whose changes are checked against Expected.Changes
+	Query      string                 `json:"query"`
+	QueryFile  string                 `json:"query_file,omitempty"` // .sql file holding the query, used instead of Query
+	QueryName  string                 `json:"query_name,omitempty"` // named query (-- name: ...) within QueryFile
+	Params     map[string]interface{} `json:"params,omitempty"`     // values for :name parameters
+	Variables  map[string]interface{} `json:"variables,omitempty"`  // text/template data expanded into the query
+	Eventually *PollConfig            `json:"eventually,omitempty"` // poll the query until Expected holds
+	Expected   *DatabaseExpectation   `json:"expected"`
+	Teardown   []string               `json:"teardown,omitempty"`
+	Isolation  string                 `json:"isolation,omitempty"` // none, transaction; empty uses the suite default
+}
+
+// QuerySource returns the query of the test together with its parameters and template variables
+func (dt *DatabaseTest) QuerySource() QuerySource {
+	return QuerySource{
+		Query:     dt.Query,
+		File:      dt.QueryFile,
+		Name:      dt.QueryName,
+		Params:    dt.Params,
+		Variables: dt.Variables,
+	}
+}
+
+// PollConfig configures polling until eventually-consistent data matches an expectation
+type PollConfig struct {
+	Timeout  time.Duration `json:"timeout"`            // how long to keep polling; zero uses 10s
+	Interval time.Duration `json:"interval,omitempty"` // fixed delay between attempts when Backoff is nil; zero uses 100ms
+	Backoff  *RetryConfig  `json:"backoff,omitempty"`  // delays grow with Backoff.CalculateDelay; MaxRetries is not used
+}
+
+// PollResult describes the attempts made while waiting for data
+type PollResult struct {
+	Attempts int             `json:"attempts"`
+	Result   *DatabaseResult `json:"result,omitempty"` // last observed result
+	Steps    []AssertionStep `json:"steps"`
+	Elapsed  time.Duration   `json:"elapsed"`
+}
+
+// QuerySource describes a query given inline or by name from a .sql file.
+// Variables are expanded with text/template first, then :name parameters are bound from Params.
+type QuerySource struct {
+	Query     string                 `json:"query,omitempty"`
+	File      string                 `json:"file,omitempty"`
+	Name      string                 `json:"name,omitempty"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	Args      []interface{}          `json:"args,omitempty"`
+}
+
+// IsEmpty reports whether the source names no query at all
+func (qs QuerySource) IsEmpty() bool {
+	return qs.Query == "" && qs.File == ""
+}
+
+// IsPlain reports whether the source is an inline query that needs no resolution
+func (qs QuerySource) IsPlain() bool {
+	return qs.File == "" && qs.Params == nil && qs.Variables == nil
 }
 
 // DatabaseExpectation represents expected database results
@@ -60,6 +249,8 @@ type DatabaseExpectation struct {
 	RowCount     int                      `json:"row_count,omitempty"`
 	Rows         []map[string]interface{} `json:"rows,omitempty"`
 	RowsAffected int64                    `json:"rows_affected,omitempty"`
+	Match        *RowMatchOptions         `json:"match,omitempty"`
+	Changes      ExpectedChanges          `json:"changes,omitempty"`
 }
 
 // IntegrationTest represents a complex integration test
@@ -128,10 +319,11 @@ func (usa *UIStepAction) GetType() string {
 
 // APIStepAction represents an API action in an integration step
 type APIStepAction struct {
-	Method   string            `json:"method"`
-	Endpoint string            `json:"endpoint"`
-	Headers  map[string]string `json:"headers,omitempty"`
-	Body     interface{}       `json:"body,omitempty"`
+	Method      string            `json:"method"`
+	Endpoint    string            `json:"endpoint"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        interface{}       `json:"body,omitempty"`
+	RequestBody *RequestBody      `json:"request_body,omitempty"` // form, multipart, raw or XML body; replaces Body
 }
 
 // GetType returns the action type
@@ -141,9 +333,26 @@ func (asa *APIStepAction) GetType() string {
 
 // DatabaseStepAction represents a database action in an integration step
 type DatabaseStepAction struct {
-	Connection string        `json:"connection"`
-	Query      string        `json:"query"`
-	Args       []interface{} `json:"args,omitempty"`
+	Connection string                 `json:"connection"`
+	Query      string                 `json:"query"`
+	QueryFile  string                 `json:"query_file,omitempty"`
+	QueryName  string                 `json:"query_name,omitempty"`
+	Args       []interface{}          `json:"args,omitempty"`
+	Params     map[string]interface{} `json:"params,omitempty"`
+	Variables  map[string]interface{} `json:"variables,omitempty"`
+	Fixtures   []string               `json:"fixtures,omitempty"`
+}
+
+// QuerySource returns the query of the step together with its arguments, parameters and template variables
+func (dsa *DatabaseStepAction) QuerySource() QuerySource {
+	return QuerySource{
+		Query:     dsa.Query,
+		File:      dsa.QueryFile,
+		Name:      dsa.QueryName,
+		Params:    dsa.Params,
+		Variables: dsa.Variables,
+		Args:      dsa.Args,
+	}
 }
 
 // GetType returns the action type
@@ -169,6 +378,7 @@ type DatabaseStepValidation struct {
 	ExpectedRowCount *int                     `json:"expected_row_count,omitempty"`
 	ExpectedRows     []map[string]interface{} `json:"expected_rows,omitempty"`
 	ExpectedAffected *int64                   `json:"expected_affected,omitempty"`
+	Match            *RowMatchOptions         `json:"match,omitempty"`
 }
 
 // GetType returns the validation type
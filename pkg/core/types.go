@@ -3,6 +3,8 @@ package core
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/gowright/framework/pkg/assertions"
@@ -25,6 +27,15 @@ type Test interface {
 // Re-export types from assertions package
 type TestStatus = assertions.TestStatus
 type AssertionStep = assertions.AssertionStep
+type RowMatchOptions = assertions.RowMatchOptions
+type DataDiff = assertions.DataDiff
+type TableChanges = assertions.TableChanges
+type RowUpdate = assertions.RowUpdate
+type ColumnChange = assertions.ColumnChange
+type ExpectedChanges = assertions.ExpectedChanges
+type ExpectedTableChanges = assertions.ExpectedTableChanges
+type ExpectedRowUpdate = assertions.ExpectedRowUpdate
+type ValueMatcher = assertions.ValueMatcher
 
 // Re-export constants from assertions package
 const (
@@ -45,6 +56,9 @@ type TestCaseResult struct {
 	StartTime   time.Time       `json:"start_time"`
 	EndTime     time.Time       `json:"end_time"`
 	Steps       []AssertionStep `json:"steps,omitempty"`
+
+	// Metadata holds tester-specific measurements, such as API request timings under "timings"
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // TestResults holds all test execution results
@@ -139,10 +153,91 @@ func (ge *GowrightError) WithContext(key string, value interface{}) *GowrightErr
 // APIResponse represents an HTTP response
 type APIResponse struct {
 	StatusCode int                    `json:"status_code"`
-	Headers    map[string]string      `json:"headers"`
+	Headers    map[string]string      `json:"headers"` // first value of each header
 	Body       []byte                 `json:"body"`
 	JSON       map[string]interface{} `json:"json,omitempty"`
 	Duration   time.Duration          `json:"duration,omitempty"`
+	Timings    *ResponseTimings       `json:"timings,omitempty"`
+
+	HeaderValues http.Header    `json:"header_values,omitempty"` // every value of each header
+	Cookies      []*http.Cookie `json:"cookies,omitempty"`       // cookies set by the response
+	ContentType  string         `json:"content_type,omitempty"`  // media type without parameters, e.g. "application/json"
+	Charset      string         `json:"charset,omitempty"`
+	JSONValue    interface{}    `json:"json_value,omitempty"` // decoded JSON body of any type, including arrays and scalars
+	XML          *XMLNode       `json:"xml,omitempty"`        // root element of an XML body
+}
+
+// Header returns the first value of a header; the name is case-insensitive
+func (r *APIResponse) Header(name string) string {
+	if r.HeaderValues != nil {
+		return r.HeaderValues.Get(name)
+	}
+	if value, exists := r.Headers[name]; exists {
+		return value
+	}
+	return r.Headers[http.CanonicalHeaderKey(name)]
+}
+
+// Cookie returns the cookie the response set with the given name, or nil
+func (r *APIResponse) Cookie(name string) *http.Cookie {
+	for _, cookie := range r.Cookies {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// ResponseTimings breaks an HTTP request down into its phases. Phases that did not happen, such as
+// DNS and connect on a reused connection, are zero.
+type ResponseTimings struct {
+	DNSLookup    time.Duration `json:"dns_lookup"`
+	Connect      time.Duration `json:"connect"`
+	TLSHandshake time.Duration `json:"tls_handshake"`
+	TTFB         time.Duration `json:"ttfb"` // from the start of the request to the first response byte
+	Total        time.Duration `json:"total"`
+	ConnReused   bool          `json:"conn_reused"`
+}
+
+// String returns the timings on one line for logs
+func (rt *ResponseTimings) String() string {
+	return fmt.Sprintf("dns=%s connect=%s tls=%s ttfb=%s total=%s reused=%t",
+		rt.DNSLookup, rt.Connect, rt.TLSHandshake, rt.TTFB, rt.Total, rt.ConnReused)
+}
+
+// LoadMetrics summarizes a load test run; the result of a load test holds it in Metadata["load"]
+type LoadMetrics struct {
+	Requests          int            `json:"requests"`
+	Errors            int            `json:"errors"` // failed requests and responses that missed their expectations
+	ErrorRate         float64        `json:"error_rate"`
+	Iterations        int            `json:"iterations"`
+	DroppedIterations int            `json:"dropped_iterations,omitempty"` // iterations not started at the target rate because every user was busy
+	Throughput        float64        `json:"throughput"`                   // requests per second
+	Duration          time.Duration  `json:"duration"`
+	Latency           LatencyStats   `json:"latency"`
+	StatusCodes       map[int]int    `json:"status_codes,omitempty"`
+	ErrorMessages     map[string]int `json:"error_messages,omitempty"` // occurrences of each error
+	Intervals         []LoadInterval `json:"intervals,omitempty"`
+}
+
+// LatencyStats holds the distribution of request latencies
+type LatencyStats struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+// LoadInterval summarizes the requests completed during one interval of a load test
+type LoadInterval struct {
+	Elapsed    time.Duration `json:"elapsed"` // time from the start of the run to the end of the interval
+	Requests   int           `json:"requests"`
+	Errors     int           `json:"errors"`
+	Throughput float64       `json:"throughput"`
+	P95        time.Duration `json:"p95"`
 }
 
 // DatabaseResult represents a database query result
@@ -150,6 +245,7 @@ type DatabaseResult struct {
 	Rows         []map[string]interface{} `json:"rows"`
 	RowCount     int                      `json:"row_count"`
 	RowsAffected int64                    `json:"rows_affected"`
+	LastInsertID int64                    `json:"last_insert_id,omitempty"`
 	Duration     time.Duration            `json:"duration"`
 }
 
@@ -0,0 +1,272 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+
+	"github.com/gowright/framework/pkg/assertions"
+)
+
+// placeholderPattern matches {{name}} placeholders; spaces inside the braces are ignored
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.\-]*)\s*\}\}`)
+
+// Variables is a store of named values that are captured from responses and interpolated into
+// later requests as {{name}}. A scope created with NewScope reads the values of its parent but
+// keeps its own writes, so per-test values never leak into the suite.
+type Variables struct {
+	mutex  sync.RWMutex
+	values map[string]interface{}
+	parent *Variables
+}
+
+// NewVariables creates an empty variable store
+func NewVariables() *Variables {
+	return &Variables{values: make(map[string]interface{})}
+}
+
+// NewScope creates a child scope that falls back to v for names it does not define
+func (v *Variables) NewScope() *Variables {
+	scope := NewVariables()
+	scope.parent = v
+	return scope
+}
+
+// Parent returns the enclosing scope, or nil for a root store
+func (v *Variables) Parent() *Variables {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	return v.parent
+}
+
+// SetParent attaches this scope to an enclosing scope, such as a suite's store
+func (v *Variables) SetParent(parent *Variables) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.parent = parent
+}
+
+// Set stores a value in this scope
+func (v *Variables) Set(name string, value interface{}) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.values[name] = value
+}
+
+// SetAll stores several values in this scope
+func (v *Variables) SetAll(values map[string]interface{}) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	for name, value := range values {
+		v.values[name] = value
+	}
+}
+
+// Get returns the value of a variable from this scope or the nearest enclosing scope
+func (v *Variables) Get(name string) (interface{}, bool) {
+	for scope := v; scope != nil; scope = scope.Parent() {
+		scope.mutex.RLock()
+		value, exists := scope.values[name]
+		scope.mutex.RUnlock()
+		if exists {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// All returns every visible variable, with inner scopes shadowing outer ones
+func (v *Variables) All() map[string]interface{} {
+	var scopes []*Variables
+	for scope := v; scope != nil; scope = scope.Parent() {
+		scopes = append(scopes, scope)
+	}
+
+	all := make(map[string]interface{})
+	for i := len(scopes) - 1; i >= 0; i-- {
+		scopes[i].mutex.RLock()
+		for name, value := range scopes[i].values {
+			all[name] = value
+		}
+		scopes[i].mutex.RUnlock()
+	}
+	return all
+}
+
+// Clear removes the variables of this scope
+func (v *Variables) Clear() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.values = make(map[string]interface{})
+}
+
+// Interpolate replaces {{name}} placeholders in text. Referencing an undefined variable is an error.
+func (v *Variables) Interpolate(text string) (string, error) {
+	var missing []string
+	result := placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		value, exists := v.Get(name)
+		if !exists {
+			missing = append(missing, name)
+			return placeholder
+		}
+		if str, ok := value.(string); ok {
+			return str
+		}
+		return fmt.Sprint(value)
+	})
+
+	if len(missing) > 0 {
+		return "", NewGowrightError(ValidationError, fmt.Sprintf("undefined variable %q", missing[0]), nil).
+			WithContext("text", text)
+	}
+	return result, nil
+}
+
+// InterpolateHeaders returns a copy of headers with placeholders in the values replaced
+func (v *Variables) InterpolateHeaders(headers map[string]string) (map[string]string, error) {
+	if headers == nil {
+		return nil, nil
+	}
+
+	interpolated := make(map[string]string, len(headers))
+	for name, value := range headers {
+		text, err := v.Interpolate(value)
+		if err != nil {
+			return nil, err
+		}
+		interpolated[name] = text
+	}
+	return interpolated, nil
+}
+
+// InterpolateValue replaces placeholders in a request body. Strings, maps and slices of decoded
+// JSON values are copied with their strings interpolated; a string that is exactly one placeholder
+// takes the variable's value with its original type, so "{{id}}" can become a number. Other
+// values, such as structs, are returned unchanged.
+func (v *Variables) InterpolateValue(value interface{}) (interface{}, error) {
+	switch typed := value.(type) {
+	case string:
+		if match := placeholderPattern.FindStringSubmatchIndex(typed); match != nil && match[0] == 0 && match[1] == len(typed) {
+			name := typed[match[2]:match[3]]
+			if variable, exists := v.Get(name); exists {
+				return variable, nil
+			}
+		}
+		return v.Interpolate(typed)
+	case []byte:
+		text, err := v.Interpolate(string(typed))
+		if err != nil {
+			return nil, err
+		}
+		return []byte(text), nil
+	case map[string]interface{}:
+		interpolated := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			value, err := v.InterpolateValue(item)
+			if err != nil {
+				return nil, err
+			}
+			interpolated[key] = value
+		}
+		return interpolated, nil
+	case map[string]string:
+		return v.InterpolateHeaders(typed)
+	case []interface{}:
+		interpolated := make([]interface{}, len(typed))
+		for i, item := range typed {
+			value, err := v.InterpolateValue(item)
+			if err != nil {
+				return nil, err
+			}
+			interpolated[i] = value
+		}
+		return interpolated, nil
+	default:
+		return value, nil
+	}
+}
+
+// Capture extracts the values declared by captures from a response and stores them in this scope
+func (v *Variables) Capture(response *APIResponse, captures []APICapture) error {
+	for _, capture := range captures {
+		value, err := ExtractCapture(response, capture)
+		if err != nil {
+			return err
+		}
+		v.Set(capture.Variable, value)
+	}
+	return nil
+}
+
+// ExtractCapture returns the value a capture selects from a response
+func ExtractCapture(response *APIResponse, capture APICapture) (interface{}, error) {
+	captureError := func(message string, cause error) error {
+		return NewGowrightError(AssertionError, fmt.Sprintf("capture of %q failed: %s", capture.Variable, message), cause)
+	}
+
+	if capture.Variable == "" {
+		return nil, NewGowrightError(ValidationError, "capture has no variable name", nil)
+	}
+
+	var value interface{}
+	switch {
+	case capture.Header != "":
+		text, exists := response.Headers[capture.Header]
+		if !exists {
+			text, exists = response.Headers[http.CanonicalHeaderKey(capture.Header)]
+		}
+		if !exists {
+			return nil, captureError(fmt.Sprintf("header %s not found", capture.Header), nil)
+		}
+		value = text
+
+	case capture.JSONPath != "":
+		var document interface{} = response.JSON
+		if len(response.Body) > 0 {
+			document = assertions.ParseJSONBody(response.Body)
+		}
+		path, err := assertions.CompileJSONPath(capture.JSONPath)
+		if err != nil {
+			return nil, captureError("invalid JSON path "+capture.JSONPath, err)
+		}
+		results := path.Find(document)
+		if path.IsDefinite() {
+			if len(results) == 0 {
+				return nil, captureError(fmt.Sprintf("JSON path %s not found", capture.JSONPath), nil)
+			}
+			value = results[0]
+		} else {
+			value = results
+		}
+
+	case capture.Regex != "":
+		value = string(response.Body)
+
+	default:
+		return nil, captureError("one of header, JSON path or regex is required", nil)
+	}
+
+	if capture.Regex == "" {
+		return value, nil
+	}
+
+	// A regex applies to the selected header or value, or to the body
+	text, ok := value.(string)
+	if !ok {
+		text = fmt.Sprint(value)
+	}
+	re, err := regexp.Compile(capture.Regex)
+	if err != nil {
+		return nil, captureError("invalid regex "+capture.Regex, err)
+	}
+	match := re.FindStringSubmatch(text)
+	if match == nil {
+		return nil, captureError(fmt.Sprintf("regex %s did not match", capture.Regex), nil)
+	}
+	if len(match) > 1 {
+		return match[1], nil
+	}
+	return match[0], nil
+}
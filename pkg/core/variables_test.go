@@ -0,0 +1,110 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariables_Scopes(t *testing.T) {
+	suite := NewVariables()
+	suite.Set("host", "api.local")
+	suite.Set("user", "alice")
+
+	test := suite.NewScope()
+	test.Set("user", "bob")
+
+	value, exists := test.Get("user")
+	require.True(t, exists)
+	assert.Equal(t, "bob", value)
+	value, _ = test.Get("host")
+	assert.Equal(t, "api.local", value)
+	assert.Equal(t, map[string]interface{}{"host": "api.local", "user": "bob"}, test.All())
+
+	// Writes to the test scope do not reach the suite
+	value, _ = suite.Get("user")
+	assert.Equal(t, "alice", value)
+
+	ctx := NewTestContext("chained")
+	ctx.Variables().Set("token", "abc")
+	ctx.UseSuiteVariables(suite)
+	value, _ = ctx.Variables().Get("host")
+	assert.Equal(t, "api.local", value)
+	_, exists = suite.Get("token")
+	assert.False(t, exists)
+}
+
+func TestVariables_Interpolate(t *testing.T) {
+	variables := NewVariables()
+	variables.SetAll(map[string]interface{}{"id": 42.0, "token": "abc", "tags": []interface{}{"a"}})
+
+	text, err := variables.Interpolate("/users/{{id}}?token={{ token }}")
+	require.NoError(t, err)
+	assert.Equal(t, "/users/42?token=abc", text)
+
+	_, err = variables.Interpolate("/users/{{missing}}")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `undefined variable "missing"`)
+
+	body, err := variables.InterpolateValue(map[string]interface{}{
+		"id":    "{{id}}",
+		"label": "user {{id}}",
+		"tags":  "{{tags}}",
+		"items": []interface{}{map[string]interface{}{"auth": "Bearer {{token}}"}, 1.0},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":    42.0,
+		"label": "user 42",
+		"tags":  []interface{}{"a"},
+		"items": []interface{}{map[string]interface{}{"auth": "Bearer abc"}, 1.0},
+	}, body)
+
+	type payload struct{ ID string }
+	unchanged, err := variables.InterpolateValue(payload{ID: "{{id}}"})
+	require.NoError(t, err)
+	assert.Equal(t, payload{ID: "{{id}}"}, unchanged)
+}
+
+func TestExtractCapture(t *testing.T) {
+	response := &APIResponse{
+		Headers: map[string]string{"Location": "/users/42", "X-Request-Id": "req-1"},
+		Body:    []byte(`{"token": "abc", "user": {"id": 42}, "items": [{"id": 1}, {"id": 2}]}`),
+	}
+
+	tests := []struct {
+		capture  APICapture
+		expected interface{}
+	}{
+		{APICapture{Variable: "token", JSONPath: "$.token"}, "abc"},
+		{APICapture{Variable: "id", JSONPath: "user.id"}, 42.0},
+		{APICapture{Variable: "ids", JSONPath: "$.items[*].id"}, []interface{}{1.0, 2.0}},
+		{APICapture{Variable: "request", Header: "x-request-id"}, "req-1"},
+		{APICapture{Variable: "location", Header: "Location", Regex: `/users/(\d+)`}, "42"},
+		{APICapture{Variable: "body", Regex: `"token": "\w+"`}, `"token": "abc"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.capture.Variable, func(t *testing.T) {
+			value, err := ExtractCapture(response, tt.capture)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+
+	for _, invalid := range []APICapture{
+		{JSONPath: "$.token"},
+		{Variable: "missing", JSONPath: "$.missing"},
+		{Variable: "header", Header: "X-Missing"},
+		{Variable: "regex", Regex: `nomatch\d`},
+		{Variable: "empty"},
+	} {
+		_, err := ExtractCapture(response, invalid)
+		assert.Error(t, err, "%+v", invalid)
+	}
+
+	variables := NewVariables()
+	require.NoError(t, variables.Capture(response, []APICapture{{Variable: "token", JSONPath: "$.token"}}))
+	value, _ := variables.Get("token")
+	assert.Equal(t, "abc", value)
+}
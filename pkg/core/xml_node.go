@@ -0,0 +1,127 @@
+package core
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// XMLNode is an element of a parsed XML document
+type XMLNode struct {
+	Name       string            `json:"name"` // local name, without the namespace prefix
+	Namespace  string            `json:"namespace,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"` // keyed by local name
+	Text       string            `json:"text,omitempty"`       // character data directly inside the element, trimmed
+	Children   []*XMLNode        `json:"children,omitempty"`
+}
+
+// ParseXML parses a document into its root element
+func ParseXML(data []byte) (*XMLNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = xmlCharsetReader
+
+	var root *XMLNode
+	var open []*XMLNode
+	var text []*strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch typed := token.(type) {
+		case xml.StartElement:
+			node := &XMLNode{Name: typed.Name.Local, Namespace: typed.Name.Space}
+			for _, attribute := range typed.Attr {
+				if node.Attributes == nil {
+					node.Attributes = make(map[string]string)
+				}
+				node.Attributes[attribute.Name.Local] = attribute.Value
+			}
+			if len(open) > 0 {
+				parent := open[len(open)-1]
+				parent.Children = append(parent.Children, node)
+			} else if root == nil {
+				root = node
+			}
+			open = append(open, node)
+			text = append(text, &strings.Builder{})
+		case xml.CharData:
+			if len(text) > 0 {
+				text[len(text)-1].Write(typed)
+			}
+		case xml.EndElement:
+			if len(open) == 0 {
+				return nil, errors.New("unexpected closing tag " + typed.Name.Local)
+			}
+			open[len(open)-1].Text = strings.TrimSpace(text[len(text)-1].String())
+			open, text = open[:len(open)-1], text[:len(text)-1]
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("document has no root element")
+	}
+	if len(open) > 0 {
+		return nil, errors.New("element " + open[len(open)-1].Name + " is not closed")
+	}
+	return root, nil
+}
+
+// xmlCharsetReader decodes the ISO-8859-1 documents encoding/xml does not support on its own
+func xmlCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "us-ascii":
+		return input, nil
+	case "iso-8859-1", "latin1":
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return strings.NewReader(string(runes)), nil
+	}
+	return nil, errors.New("unsupported XML encoding " + charset)
+}
+
+// Find returns the descendants at a slash-separated path of element names relative to the node,
+// such as "items/item"; "*" matches any name
+func (n *XMLNode) Find(path string) []*XMLNode {
+	nodes := []*XMLNode{n}
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		var next []*XMLNode
+		for _, node := range nodes {
+			for _, child := range node.Children {
+				if name == "*" || child.Name == name {
+					next = append(next, child)
+				}
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// First returns the first descendant at a path, or nil
+func (n *XMLNode) First(path string) *XMLNode {
+	if nodes := n.Find(path); len(nodes) > 0 {
+		return nodes[0]
+	}
+	return nil
+}
+
+// Attr returns the value of an attribute, or an empty string
+func (n *XMLNode) Attr(name string) string {
+	return n.Attributes[name]
+}
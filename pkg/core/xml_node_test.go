@@ -0,0 +1,25 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseXML(t *testing.T) {
+	root, err := ParseXML([]byte(`<feed><entry id="1"> first <b>bold</b> </entry><entry id="2"/></feed>`))
+	require.NoError(t, err)
+	entries := root.Find("/entry/")
+	require.Len(t, entries, 2)
+	assert.Equal(t, "first", entries[0].Text)
+	assert.Equal(t, "bold", entries[0].First("b").Text)
+	assert.Equal(t, "2", entries[1].Attr("id"))
+	assert.Equal(t, "", entries[1].Attr("missing"))
+	assert.Equal(t, []*XMLNode{root}, root.Find(""))
+
+	for _, document := range []string{``, `plain text`, `<a><b></a>`, `<a>`, `<?xml version="1.0" encoding="EBCDIC"?><a/>`} {
+		_, err := ParseXML([]byte(document))
+		assert.Error(t, err, document)
+	}
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gowright/framework/pkg/config"
+)
+
+// defaultPingTimeout bounds the initial connectivity check when no query timeout is configured
+const defaultPingTimeout = 10 * time.Second
+
+// buildDSN builds a driver-specific data source name from a connection configuration
+func buildDSN(cfg *config.DatabaseConnection) (string, error) {
+	switch cfg.Driver {
+	case "mysql":
+		host := cfg.Host
+		if cfg.Port != 0 {
+			host = fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+		}
+		dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s", cfg.Username, cfg.Password, host, cfg.Database)
+		if len(cfg.Options) > 0 {
+			dsn += "?" + encodeOptions(cfg.Options)
+		}
+		return dsn, nil
+
+	case "postgres", "pgx":
+		parts := []string{
+			"host=" + quotePostgresValue(cfg.Host),
+			"user=" + quotePostgresValue(cfg.Username),
+			"password=" + quotePostgresValue(cfg.Password),
+			"dbname=" + quotePostgresValue(cfg.Database),
+		}
+		if cfg.Port != 0 {
+			parts = append(parts, fmt.Sprintf("port=%d", cfg.Port))
+		}
+		if cfg.SSLMode != "" {
+			parts = append(parts, "sslmode="+quotePostgresValue(cfg.SSLMode))
+		}
+		for _, key := range sortedKeys(cfg.Options) {
+			parts = append(parts, key+"="+quotePostgresValue(cfg.Options[key]))
+		}
+		return strings.Join(parts, " "), nil
+
+	case "sqlite3", "sqlite":
+		dsn := cfg.Database
+		if len(cfg.Options) > 0 {
+			separator := "?"
+			if strings.Contains(dsn, "?") {
+				separator = "&"
+			}
+			dsn += separator + encodeOptions(cfg.Options)
+		}
+		return dsn, nil
+
+	default:
+		return "", fmt.Errorf("unsupported database driver: %s", cfg.Driver)
+	}
+}
+
+// openDatabase opens and verifies a *sql.DB for the given connection, applying pool limits from dbConfig
+func openDatabase(cfg *config.DatabaseConnection, dbConfig *config.DatabaseConfig) (*sql.DB, error) {
+	dsn, err := buildDSN(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(cfg.Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	applyPoolSettings(db, cfg, dbConfig)
+
+	pingTimeout := defaultPingTimeout
+	if dbConfig != nil && dbConfig.QueryTimeout > 0 {
+		pingTimeout = dbConfig.QueryTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			fmt.Printf("Error closing failed connection: %v\n", closeErr)
+		}
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// applyPoolSettings applies connection pool limits to a database handle
+func applyPoolSettings(db *sql.DB, cfg *config.DatabaseConnection, dbConfig *config.DatabaseConfig) {
+	// Every connection to an in-memory SQLite database sees its own empty
+	// database, so the pool must be pinned to a single long-lived connection.
+	if isInMemorySQLite(cfg) {
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		db.SetConnMaxLifetime(0)
+		return
+	}
+
+	if dbConfig == nil {
+		return
+	}
+
+	if dbConfig.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(dbConfig.MaxOpenConns)
+	}
+	if dbConfig.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(dbConfig.MaxIdleConns)
+	}
+	if dbConfig.ConnMaxLife > 0 {
+		db.SetConnMaxLifetime(dbConfig.ConnMaxLife)
+	}
+}
+
+// isInMemorySQLite reports whether the connection points at a private in-memory SQLite database
+func isInMemorySQLite(cfg *config.DatabaseConnection) bool {
+	if cfg.Driver != "sqlite3" && cfg.Driver != "sqlite" {
+		return false
+	}
+	return cfg.Database == ":memory:" ||
+		strings.HasPrefix(cfg.Database, "file::memory:") ||
+		strings.Contains(cfg.Database, "mode=memory")
+}
+
+// encodeOptions encodes driver options as a URL query string with stable key order
+func encodeOptions(options map[string]string) string {
+	values := url.Values{}
+	for key, value := range options {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
+
+// quotePostgresValue quotes a value for a key/value style postgres connection string when needed
+func quotePostgresValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " '\\") {
+		return value
+	}
+	escaped := strings.ReplaceAll(value, `\`, `\\`)
+	escaped = strings.ReplaceAll(escaped, `'`, `\'`)
+	return "'" + escaped + "'"
+}
+
+// sortedKeys returns the keys of a string map in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
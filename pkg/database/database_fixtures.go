@@ -0,0 +1,643 @@
+package database
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gowright/framework/pkg/core"
+	"gopkg.in/yaml.v3"
+)
+
+// FixtureSet holds fixture rows keyed by table, in the order they were read
+type FixtureSet struct {
+	tables []*FixtureTable
+}
+
+// FixtureTable holds the fixture rows for a single table
+type FixtureTable struct {
+	Name string
+	Rows []*FixtureRow
+}
+
+// FixtureRow is a single labelled fixture row. Columns keeps the column order from the source file.
+type FixtureRow struct {
+	Label   string
+	Columns []string
+	Values  map[string]interface{}
+}
+
+// fixtureRefPattern finds the table referenced by a {{ ref "table.label.column" }} template call
+var fixtureRefPattern = regexp.MustCompile(`ref\s+"([^"]+)\.[^".]+\.[^".]+"`)
+
+// NewFixtureSet creates an empty fixture set
+func NewFixtureSet() *FixtureSet {
+	return &FixtureSet{
+		tables: make([]*FixtureTable, 0),
+	}
+}
+
+// ParseFixtureFiles reads YAML, JSON and CSV fixture files into a single fixture set.
+// YAML and JSON files map table names to either a list of rows or a map of labelled rows;
+// a CSV file holds the rows of the table named after the file, with a header line of columns.
+func ParseFixtureFiles(paths ...string) (*FixtureSet, error) {
+	set := NewFixtureSet()
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path) // #nosec G304 -- fixture paths are supplied by the test author
+		if err != nil {
+			return nil, core.NewGowrightError(core.ConfigurationError, "failed to read fixture file", err).
+				WithContext("path", path)
+		}
+
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yml", ".yaml", ".json":
+			err = set.parseDocument(data)
+		case ".csv":
+			table := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			err = set.parseCSV(table, data)
+		default:
+			err = fmt.Errorf("unsupported fixture file extension: %s", filepath.Ext(path))
+		}
+
+		if err != nil {
+			return nil, core.NewGowrightError(core.ConfigurationError, "failed to parse fixture file", err).
+				WithContext("path", path)
+		}
+	}
+
+	return set, nil
+}
+
+// AddRow appends a row to the named table, generating a label when none is given
+func (fs *FixtureSet) AddRow(table, label string, values map[string]interface{}) {
+	fixtureTable := fs.table(table)
+	if label == "" {
+		label = fmt.Sprintf("%s_%d", table, len(fixtureTable.Rows))
+	}
+
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	fixtureTable.Rows = append(fixtureTable.Rows, &FixtureRow{
+		Label:   label,
+		Columns: columns,
+		Values:  values,
+	})
+}
+
+// Tables returns the fixture tables in the order they were first read
+func (fs *FixtureSet) Tables() []*FixtureTable {
+	return fs.tables
+}
+
+// table returns the named table, creating it if needed
+func (fs *FixtureSet) table(name string) *FixtureTable {
+	for _, table := range fs.tables {
+		if table.Name == name {
+			return table
+		}
+	}
+	table := &FixtureTable{Name: name, Rows: make([]*FixtureRow, 0)}
+	fs.tables = append(fs.tables, table)
+	return table
+}
+
+// parseDocument parses a YAML or JSON fixture document, preserving table, row and column order
+func (fs *FixtureSet) parseDocument(data []byte) error {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	if len(document.Content) == 0 {
+		return nil
+	}
+
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("fixture document must map table names to rows")
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		tableName := root.Content[i].Value
+		rowsNode := root.Content[i+1]
+		table := fs.table(tableName)
+
+		switch rowsNode.Kind {
+		case yaml.SequenceNode:
+			for _, rowNode := range rowsNode.Content {
+				row, err := parseRowNode(fmt.Sprintf("%s_%d", tableName, len(table.Rows)), rowNode)
+				if err != nil {
+					return fmt.Errorf("table %s: %w", tableName, err)
+				}
+				table.Rows = append(table.Rows, row)
+			}
+		case yaml.MappingNode:
+			for j := 0; j+1 < len(rowsNode.Content); j += 2 {
+				row, err := parseRowNode(rowsNode.Content[j].Value, rowsNode.Content[j+1])
+				if err != nil {
+					return fmt.Errorf("table %s: %w", tableName, err)
+				}
+				table.Rows = append(table.Rows, row)
+			}
+		default:
+			return fmt.Errorf("table %s: rows must be a list or a map of labelled rows", tableName)
+		}
+	}
+
+	return nil
+}
+
+// parseRowNode converts a YAML mapping node into a fixture row
+func parseRowNode(label string, node *yaml.Node) (*FixtureRow, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("row %s must be a map of column values", label)
+	}
+
+	row := &FixtureRow{
+		Label:   label,
+		Columns: make([]string, 0, len(node.Content)/2),
+		Values:  make(map[string]interface{}, len(node.Content)/2),
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		column := node.Content[i].Value
+		var value interface{}
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return nil, fmt.Errorf("row %s, column %s: %w", label, column, err)
+		}
+
+		// Nested structures are stored as JSON, which suits json/jsonb columns
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("row %s, column %s: %w", label, column, err)
+			}
+			value = string(encoded)
+		}
+
+		row.Columns = append(row.Columns, column)
+		row.Values[column] = value
+	}
+
+	return row, nil
+}
+
+// parseCSV parses CSV rows for a table; empty cells are loaded as NULL
+func (fs *FixtureSet) parseCSV(tableName string, data []byte) error {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	table := fs.table(tableName)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		row := &FixtureRow{
+			Label:   fmt.Sprintf("%s_%d", tableName, len(table.Rows)),
+			Columns: header,
+			Values:  make(map[string]interface{}, len(header)),
+		}
+		for i, column := range header {
+			if record[i] == "" {
+				row.Values[column] = nil
+			} else {
+				row.Values[column] = record[i]
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+}
+
+// LoadedFixtures is a fixture set that has been inserted into a database.
+// Restore truncates the fixture tables and reinserts the rows they held before loading.
+type LoadedFixtures struct {
+	executor  statementExecutor
+	driver    string
+	order     []string
+	backups   map[string][]map[string]interface{}
+	rows      map[string]map[string]map[string]interface{}
+	sequences map[string]int64
+	keys      map[string]string // generated primary key column per table; empty when there is none
+	now       time.Time
+	restored  bool
+}
+
+// LoadFixtures parses the given fixture files and loads them into the named connection
+func (dt *DatabaseTester) LoadFixtures(connectionName string, paths ...string) (core.Fixtures, error) {
+	set, err := ParseFixtureFiles(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded, err := dt.LoadFixtureSet(connectionName, set)
+	if err != nil {
+		return nil, err
+	}
+	return loaded, nil
+}
+
+// LoadFixturesInTransaction parses fixture files and loads them through tx, so the rows are only
+// visible inside the transaction and disappear when it rolls back
+func (dt *DatabaseTester) LoadFixturesInTransaction(tx core.Transaction, connectionName string, paths ...string) (core.Fixtures, error) {
+	if _, err := dt.getPool(connectionName); err != nil {
+		return nil, err
+	}
+
+	set, err := ParseFixtureFiles(paths...)
+	if err != nil {
+		return nil, err
+	}
+	loaded, err := loadFixtures(tx, dt.config.Connections[connectionName].Driver, set)
+	if err != nil {
+		return nil, err
+	}
+	return loaded, nil
+}
+
+// LoadFixtureSet loads a parsed fixture set into the named connection
+func (dt *DatabaseTester) LoadFixtureSet(connectionName string, set *FixtureSet) (*LoadedFixtures, error) {
+	if _, err := dt.getPool(connectionName); err != nil {
+		return nil, err
+	}
+
+	executor := &connectionExecutor{tester: dt, connectionName: connectionName}
+	return loadFixtures(executor, dt.config.Connections[connectionName].Driver, set)
+}
+
+// loadTestFixtures loads a database test's fixture files through the executor running the test
+func (dt *DatabaseTester) loadTestFixtures(executor statementExecutor, test *core.DatabaseTest) (*LoadedFixtures, error) {
+	if _, err := dt.getPool(test.Connection); err != nil {
+		return nil, err
+	}
+
+	set, err := ParseFixtureFiles(test.Fixtures...)
+	if err != nil {
+		return nil, err
+	}
+	return loadFixtures(executor, dt.config.Connections[test.Connection].Driver, set)
+}
+
+// loadFixtures backs up, truncates and populates the fixture tables in dependency order
+func loadFixtures(executor statementExecutor, driver string, set *FixtureSet) (*LoadedFixtures, error) {
+	order, err := fixtureOrder(executor, driver, set)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := &LoadedFixtures{
+		executor:  executor,
+		driver:    driver,
+		order:     order,
+		backups:   make(map[string][]map[string]interface{}),
+		rows:      make(map[string]map[string]map[string]interface{}),
+		sequences: make(map[string]int64),
+		keys:      make(map[string]string),
+		now:       time.Now().UTC(),
+	}
+
+	for _, table := range order {
+		result, err := executor.Execute("SELECT * FROM " + quoteIdentifier(driver, table))
+		if err != nil {
+			return nil, core.NewGowrightError(core.DatabaseError, "failed to back up fixture table", err).
+				WithContext("table", table)
+		}
+		loaded.backups[table] = result.Rows
+	}
+
+	if err := loaded.truncate(); err != nil {
+		return nil, err
+	}
+
+	tables := make(map[string]*FixtureTable, len(set.tables))
+	for _, table := range set.tables {
+		tables[table.Name] = table
+	}
+
+	for _, tableName := range order {
+		loaded.rows[tableName] = make(map[string]map[string]interface{})
+		for _, row := range tables[tableName].Rows {
+			if err := loaded.insertRow(tableName, row); err != nil {
+				if restoreErr := loaded.Restore(); restoreErr != nil {
+					fmt.Printf("Error restoring fixture tables: %v\n", restoreErr)
+				}
+				return nil, err
+			}
+		}
+	}
+
+	return loaded, nil
+}
+
+// Row returns the resolved column values of a loaded fixture row
+func (lf *LoadedFixtures) Row(table, label string) (map[string]interface{}, bool) {
+	row, exists := lf.rows[table][label]
+	return row, exists
+}
+
+// Restore truncates the fixture tables and reinserts the rows they held before loading
+func (lf *LoadedFixtures) Restore() error {
+	if lf.restored {
+		return nil
+	}
+	lf.restored = true
+
+	if err := lf.truncate(); err != nil {
+		return err
+	}
+
+	for _, table := range lf.order {
+		for _, row := range lf.backups[table] {
+			columns := make([]string, 0, len(row))
+			for column := range row {
+				columns = append(columns, column)
+			}
+			sort.Strings(columns)
+
+			if _, err := lf.insert(table, columns, row, ""); err != nil {
+				return core.NewGowrightError(core.DatabaseError, "failed to restore fixture table", err).
+					WithContext("table", table)
+			}
+		}
+	}
+
+	return nil
+}
+
+// truncate deletes all rows from the fixture tables, dependents first
+func (lf *LoadedFixtures) truncate() error {
+	for i := len(lf.order) - 1; i >= 0; i-- {
+		table := lf.order[i]
+		if _, err := lf.executor.Execute("DELETE FROM " + quoteIdentifier(lf.driver, table)); err != nil {
+			return core.NewGowrightError(core.DatabaseError, "failed to truncate fixture table", err).
+				WithContext("table", table)
+		}
+	}
+	return nil
+}
+
+// insertRow resolves a fixture row's templates and inserts it
+func (lf *LoadedFixtures) insertRow(table string, row *FixtureRow) error {
+	resolved := make(map[string]interface{}, len(row.Values))
+	for _, column := range row.Columns {
+		value, err := lf.resolveValue(row.Values[column])
+		if err != nil {
+			return core.NewGowrightError(core.ConfigurationError, "failed to evaluate fixture value", err).
+				WithContext("table", table).
+				WithContext("row", row.Label).
+				WithContext("column", column)
+		}
+		resolved[column] = value
+	}
+
+	// Rows that leave a single-column primary key to the database read the generated value back
+	// for ref; postgres drivers do not report it as a last insert id, so it is returned instead
+	key := lf.primaryKey(table)
+	returning := ""
+	if _, set := resolved[key]; key != "" && !set && (lf.driver == "postgres" || lf.driver == "pgx") {
+		returning = key
+	}
+
+	result, err := lf.insert(table, row.Columns, resolved, returning)
+	if err != nil {
+		return core.NewGowrightError(core.DatabaseError, "failed to insert fixture row", err).
+			WithContext("table", table).
+			WithContext("row", row.Label)
+	}
+
+	if _, set := resolved[key]; key != "" && !set {
+		switch {
+		case returning != "" && len(result.Rows) == 1:
+			resolved[key] = result.Rows[0][key]
+		case returning == "" && result.LastInsertID > 0:
+			resolved[key] = result.LastInsertID
+		}
+	}
+
+	lf.rows[table][row.Label] = resolved
+	return nil
+}
+
+// primaryKey returns the single primary key column of a table, or an empty string when the key
+// spans several columns or cannot be introspected
+func (lf *LoadedFixtures) primaryKey(table string) string {
+	key, known := lf.keys[table]
+	if !known {
+		if columns, err := primaryKeyColumns(lf.executor, lf.driver, table); err == nil && len(columns) == 1 {
+			key = columns[0]
+		}
+		lf.keys[table] = key
+	}
+	return key
+}
+
+// insert inserts a single row with the given column order, returning the named column when set
+func (lf *LoadedFixtures) insert(table string, columns []string, values map[string]interface{}, returning string) (*core.DatabaseResult, error) {
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteIdentifier(lf.driver, column)
+		placeholders[i] = bindVar(lf.driver, i+1)
+		args[i] = values[column]
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quoteIdentifier(lf.driver, table), strings.Join(quoted, ", "), strings.Join(placeholders, ", "))
+	if returning != "" {
+		query += " RETURNING " + quoteIdentifier(lf.driver, returning)
+	}
+	return lf.executor.Execute(query, args...)
+}
+
+// resolveValue evaluates template expressions in string fixture values.
+// A value consisting of a single template call keeps the call's type (int64, time.Time, ...).
+func (lf *LoadedFixtures) resolveValue(value interface{}) (interface{}, error) {
+	text, ok := value.(string)
+	if !ok || !strings.Contains(text, "{{") {
+		return value, nil
+	}
+
+	typed := make([]interface{}, 0)
+	token := func(v interface{}) string {
+		typed = append(typed, v)
+		return fmt.Sprintf("\x00%d\x00", len(typed)-1)
+	}
+
+	funcs := template.FuncMap{
+		"seq": func(names ...string) string {
+			name := "default"
+			if len(names) > 0 {
+				name = names[0]
+			}
+			lf.sequences[name]++
+			return token(lf.sequences[name])
+		},
+		"now": func() string {
+			return token(lf.now)
+		},
+		"offset": func(duration string) (string, error) {
+			d, err := time.ParseDuration(duration)
+			if err != nil {
+				return "", err
+			}
+			return token(lf.now.Add(d)), nil
+		},
+		"ref": func(path string) (string, error) {
+			parts := strings.Split(path, ".")
+			if len(parts) < 3 {
+				return "", fmt.Errorf("ref %q must have the form table.label.column", path)
+			}
+			table := strings.Join(parts[:len(parts)-2], ".")
+			label, column := parts[len(parts)-2], parts[len(parts)-1]
+
+			row, exists := lf.rows[table][label]
+			if !exists {
+				return "", fmt.Errorf("ref %q: fixture row %s.%s has not been loaded", path, table, label)
+			}
+			referenced, exists := row[column]
+			if !exists {
+				return "", fmt.Errorf("ref %q: column %s is not set on fixture row and is not its generated primary key", path, column)
+			}
+			return token(referenced), nil
+		},
+	}
+
+	tmpl, err := template.New("fixture").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, nil); err != nil {
+		return nil, err
+	}
+
+	rendered := out.String()
+	for i, v := range typed {
+		placeholder := fmt.Sprintf("\x00%d\x00", i)
+		if rendered == placeholder {
+			return v, nil
+		}
+		rendered = strings.ReplaceAll(rendered, placeholder, formatFixtureValue(v))
+	}
+	return rendered, nil
+}
+
+// formatFixtureValue formats a typed template value for interpolation into a string
+func formatFixtureValue(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// fixtureOrder sorts fixture tables so that referenced tables are loaded before the tables that reference them.
+// Dependencies come from foreign keys where the driver supports introspection and from ref template calls.
+func fixtureOrder(executor statementExecutor, driver string, set *FixtureSet) ([]string, error) {
+	inSet := make(map[string]bool, len(set.tables))
+	for _, table := range set.tables {
+		inSet[table.Name] = true
+	}
+
+	dependencies := make(map[string]map[string]bool, len(set.tables))
+	for _, table := range set.tables {
+		deps := make(map[string]bool)
+
+		targets, err := foreignKeyTargets(executor, driver, table.Name)
+		if err != nil {
+			return nil, core.NewGowrightError(core.DatabaseError, "failed to read foreign keys", err).
+				WithContext("table", table.Name)
+		}
+		for _, target := range targets {
+			deps[target] = true
+		}
+
+		for _, row := range table.Rows {
+			for _, value := range row.Values {
+				if text, ok := value.(string); ok {
+					for _, match := range fixtureRefPattern.FindAllStringSubmatch(text, -1) {
+						deps[match[1]] = true
+					}
+				}
+			}
+		}
+
+		// Self references and tables outside the set do not constrain the order
+		delete(deps, table.Name)
+		for dep := range deps {
+			if !inSet[dep] {
+				delete(deps, dep)
+			}
+		}
+		dependencies[table.Name] = deps
+	}
+
+	order := make([]string, 0, len(set.tables))
+	placed := make(map[string]bool, len(set.tables))
+	for len(order) < len(set.tables) {
+		progressed := false
+		for _, table := range set.tables {
+			if placed[table.Name] {
+				continue
+			}
+			ready := true
+			for dep := range dependencies[table.Name] {
+				if !placed[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				order = append(order, table.Name)
+				placed[table.Name] = true
+				progressed = true
+			}
+		}
+
+		if !progressed {
+			remaining := make([]string, 0)
+			for _, table := range set.tables {
+				if !placed[table.Name] {
+					remaining = append(remaining, table.Name)
+				}
+			}
+			return nil, core.NewGowrightError(core.ConfigurationError,
+				"fixture tables have a circular dependency", nil).
+				WithContext("tables", remaining)
+		}
+	}
+
+	return order, nil
+}
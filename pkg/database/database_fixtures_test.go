@@ -0,0 +1,307 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gowright/framework/pkg/config"
+	"github.com/gowright/framework/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFixture writes a fixture file into dir and returns its path
+func writeFixture(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+// createFixtureSchema creates a users/orders schema with a foreign key from orders to users
+func createFixtureSchema(t *testing.T, tester *DatabaseTester) {
+	t.Helper()
+
+	for _, query := range []string{
+		"PRAGMA foreign_keys = ON",
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, created_at TIMESTAMP)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users(id), total REAL, note TEXT)",
+	} {
+		_, err := tester.Execute("test", query)
+		require.NoError(t, err)
+	}
+}
+
+func TestParseFixtureFiles(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := writeFixture(t, dir, "users.yml", `
+users:
+  alice:
+    id: 1
+    name: Alice
+  bob:
+    name: Bob
+    tags: [admin, ops]
+`)
+	jsonPath := writeFixture(t, dir, "orders.json", `{"orders": [{"id": 10, "total": 9.5}, {"id": 11}]}`)
+	csvPath := writeFixture(t, dir, "products.csv", "id,name,description\n1,Widget,\n2,Gadget,Shiny\n")
+
+	set, err := ParseFixtureFiles(yamlPath, jsonPath, csvPath)
+	require.NoError(t, err)
+
+	tables := set.Tables()
+	require.Len(t, tables, 3)
+	assert.Equal(t, "users", tables[0].Name)
+	assert.Equal(t, "orders", tables[1].Name)
+	assert.Equal(t, "products", tables[2].Name)
+
+	alice := tables[0].Rows[0]
+	assert.Equal(t, "alice", alice.Label)
+	assert.Equal(t, []string{"id", "name"}, alice.Columns)
+	assert.Equal(t, 1, alice.Values["id"])
+	assert.Equal(t, `["admin","ops"]`, tables[0].Rows[1].Values["tags"])
+
+	assert.Equal(t, "orders_0", tables[1].Rows[0].Label)
+	assert.Equal(t, "orders_1", tables[1].Rows[1].Label)
+
+	assert.Equal(t, []string{"id", "name", "description"}, tables[2].Rows[0].Columns)
+	assert.Nil(t, tables[2].Rows[0].Values["description"])
+	assert.Equal(t, "Shiny", tables[2].Rows[1].Values["description"])
+}
+
+func TestParseFixtureFiles_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := ParseFixtureFiles(filepath.Join(dir, "missing.yml"))
+	assert.Error(t, err)
+
+	_, err = ParseFixtureFiles(writeFixture(t, dir, "users.txt", "users: []"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported fixture file extension")
+
+	_, err = ParseFixtureFiles(writeFixture(t, dir, "list.yml", "- id: 1"))
+	assert.Error(t, err)
+
+	_, err = ParseFixtureFiles(writeFixture(t, dir, "scalar.yml", "users: 1"))
+	assert.Error(t, err)
+}
+
+func TestDatabaseTester_LoadFixtures(t *testing.T) {
+	tester := newSQLiteTester(t)
+	createFixtureSchema(t, tester)
+
+	_, err := tester.Execute("test", "INSERT INTO users (id, name) VALUES (100, 'existing')")
+	require.NoError(t, err)
+
+	// Orders are listed first but depend on users through the foreign key and refs
+	dir := t.TempDir()
+	path := writeFixture(t, dir, "fixtures.yml", `
+orders:
+  first:
+    user_id: '{{ ref "users.alice.id" }}'
+    total: 12.5
+    note: 'order for {{ ref "users.alice.name" }} #{{ seq "order" }}'
+users:
+  bob:
+    id: '{{ seq }}'
+    name: Bob
+    created_at: '{{ now }}'
+  alice:
+    name: Alice
+    created_at: '{{ offset "-2h" }}'
+`)
+
+	loaded, err := tester.LoadFixtures("test", path)
+	require.NoError(t, err)
+
+	fixtures := loaded.(*LoadedFixtures)
+	assert.Equal(t, []string{"users", "orders"}, fixtures.order)
+
+	alice, exists := fixtures.Row("users", "alice")
+	require.True(t, exists)
+	assert.Equal(t, int64(2), alice["id"])
+	bob, _ := fixtures.Row("users", "bob")
+	assert.Equal(t, int64(1), bob["id"])
+	assert.WithinDuration(t, bob["created_at"].(time.Time).Add(-2*time.Hour), alice["created_at"].(time.Time), 0)
+
+	result, err := tester.Execute("test", "SELECT user_id, note FROM orders")
+	require.NoError(t, err)
+	require.Len(t, result.Rows, 1)
+	assert.Equal(t, alice["id"], result.Rows[0]["user_id"])
+	assert.Equal(t, "order for Alice #1", result.Rows[0]["note"])
+
+	result, err = tester.Execute("test", "SELECT name FROM users ORDER BY name")
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"name": "Alice"}, {"name": "Bob"}}, result.Rows)
+
+	require.NoError(t, loaded.Restore())
+
+	result, err = tester.Execute("test", "SELECT id, name FROM users")
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": int64(100), "name": "existing"}}, result.Rows)
+
+	result, err = tester.Execute("test", "SELECT COUNT(*) AS count FROM orders")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), result.Rows[0]["count"])
+}
+
+func TestDatabaseTester_LoadFixturesInTransaction(t *testing.T) {
+	tester := newSQLiteTester(t)
+	createFixtureSchema(t, tester)
+	path := writeFixture(t, t.TempDir(), "users.yml", `
+users:
+  alice:
+    name: Alice
+`)
+
+	// The in-memory database has a single connection, which the transaction holds
+	tx, err := tester.BeginTransaction("test")
+	require.NoError(t, err)
+	_, err = tester.LoadFixturesInTransaction(tx, "test", path)
+	require.NoError(t, err)
+	inside, err := tx.Execute("SELECT name FROM users")
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"name": "Alice"}}, inside.Rows)
+	require.NoError(t, tx.Rollback())
+
+	after, err := tester.Execute("test", "SELECT name FROM users")
+	require.NoError(t, err)
+	assert.Zero(t, after.RowCount)
+}
+
+func TestDatabaseTester_LoadFixtures_Errors(t *testing.T) {
+	tester := newSQLiteTester(t)
+	createFixtureSchema(t, tester)
+	dir := t.TempDir()
+
+	t.Run("circular refs", func(t *testing.T) {
+		path := writeFixture(t, dir, "cycle.yml", `
+users:
+  alice:
+    name: '{{ ref "orders.first.note" }}'
+orders:
+  first:
+    user_id: '{{ ref "users.alice.id" }}'
+`)
+		_, err := tester.LoadFixtures("test", path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "circular dependency")
+	})
+
+	t.Run("unknown ref", func(t *testing.T) {
+		path := writeFixture(t, dir, "unknown.yml", `
+users:
+  alice:
+    name: '{{ ref "users.nobody.name" }}'
+`)
+		_, err := tester.LoadFixtures("test", path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to evaluate fixture value")
+	})
+
+	t.Run("missing table", func(t *testing.T) {
+		path := writeFixture(t, dir, "missing.yml", "accounts:\n  - id: 1\n")
+		_, err := tester.LoadFixtures("test", path)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown connection", func(t *testing.T) {
+		path := writeFixture(t, dir, "users.yml", "users:\n  - name: Alice\n")
+		_, err := tester.LoadFixtures("missing", path)
+		assert.Error(t, err)
+	})
+}
+
+func TestDatabaseTester_ExecuteTest_Fixtures(t *testing.T) {
+	tester := newSQLiteTester(t)
+	createFixtureSchema(t, tester)
+
+	dir := t.TempDir()
+	path := writeFixture(t, dir, "users.yml", "users:\n  - name: Alice\n  - name: Bob\n")
+
+	for _, isolation := range []string{config.DatabaseIsolationNone, config.DatabaseIsolationTransaction} {
+		t.Run(isolation, func(t *testing.T) {
+			result := tester.ExecuteTest(&core.DatabaseTest{
+				Name:       "fixture test",
+				Connection: "test",
+				Fixtures:   []string{path},
+				Query:      "SELECT name FROM users ORDER BY name",
+				Expected: &core.DatabaseExpectation{
+					Rows: []map[string]interface{}{{"name": "Alice"}, {"name": "Bob"}},
+				},
+				Isolation: isolation,
+			})
+			assert.Equal(t, core.TestStatusPassed, result.Status, "%v", result.Error)
+
+			count, err := tester.Execute("test", "SELECT COUNT(*) AS count FROM users")
+			require.NoError(t, err)
+			assert.Equal(t, int64(0), count.Rows[0]["count"])
+		})
+	}
+}
+
+// returningExecutor records statements and answers INSERT ... RETURNING with a generated key
+type returningExecutor struct {
+	queries []string
+}
+
+func (re *returningExecutor) Execute(query string, args ...interface{}) (*core.DatabaseResult, error) {
+	re.queries = append(re.queries, query)
+	return &core.DatabaseResult{Rows: []map[string]interface{}{{"user_id": int64(41)}}, RowCount: 1}, nil
+}
+
+func TestLoadedFixtures_GeneratedKeys(t *testing.T) {
+	t.Run("primary key column", func(t *testing.T) {
+		tester := newSQLiteTester(t)
+		_, err := tester.Execute("test", "CREATE TABLE accounts (account_no INTEGER PRIMARY KEY, owner TEXT NOT NULL)")
+		require.NoError(t, err)
+		_, err = tester.Execute("test", "CREATE TABLE cards (id INTEGER PRIMARY KEY, account_no INTEGER NOT NULL REFERENCES accounts(account_no))")
+		require.NoError(t, err)
+
+		path := writeFixture(t, t.TempDir(), "accounts.yml", `
+accounts:
+  main:
+    owner: Alice
+cards:
+  - account_no: '{{ ref "accounts.main.account_no" }}'
+`)
+		loaded, err := tester.LoadFixtures("test", path)
+		require.NoError(t, err)
+		main, _ := loaded.(*LoadedFixtures).Row("accounts", "main")
+		assert.Equal(t, int64(1), main["account_no"])
+
+		// Only the generated key is known, not other columns the database filled in
+		path = writeFixture(t, t.TempDir(), "bad.yml", `
+accounts:
+  main:
+    owner: Alice
+cards:
+  - account_no: '{{ ref "accounts.main.opened_at" }}'
+`)
+		require.NoError(t, loaded.Restore())
+		_, err = tester.LoadFixtures("test", path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is not its generated primary key")
+	})
+
+	t.Run("postgres returning", func(t *testing.T) {
+		executor := &returningExecutor{}
+		fixtures := &LoadedFixtures{
+			executor: executor,
+			driver:   "postgres",
+			rows:     map[string]map[string]map[string]interface{}{"users": {}},
+			keys:     map[string]string{"users": "user_id"},
+		}
+		require.NoError(t, fixtures.insertRow("users", &FixtureRow{Label: "alice", Columns: []string{"name"}, Values: map[string]interface{}{"name": "Alice"}}))
+		assert.Equal(t, []string{`INSERT INTO "users" ("name") VALUES ($1) RETURNING "user_id"`}, executor.queries)
+		alice, _ := fixtures.Row("users", "alice")
+		assert.Equal(t, int64(41), alice["user_id"])
+
+		// Rows that set their key insert it as given
+		require.NoError(t, fixtures.insertRow("users", &FixtureRow{Label: "bob", Columns: []string{"user_id"}, Values: map[string]interface{}{"user_id": 7}}))
+		assert.Equal(t, `INSERT INTO "users" ("user_id") VALUES ($1)`, executor.queries[1])
+	})
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gowright/framework/pkg/core"
+)
+
+// statementExecutor runs statements on a connection or inside a transaction
+type statementExecutor interface {
+	Execute(query string, args ...interface{}) (*core.DatabaseResult, error)
+}
+
+// connectionExecutor adapts a named DatabaseTester connection to statementExecutor
+type connectionExecutor struct {
+	tester         *DatabaseTester
+	connectionName string
+}
+
+// Execute executes a statement on the named connection
+func (ce *connectionExecutor) Execute(query string, args ...interface{}) (*core.DatabaseResult, error) {
+	return ce.tester.Execute(ce.connectionName, query, args...)
+}
+
+// quoteIdentifier quotes a possibly schema-qualified identifier for the given driver
+func quoteIdentifier(driver, identifier string) string {
+	quote := `"`
+	if driver == "mysql" {
+		quote = "`"
+	}
+
+	parts := strings.Split(identifier, ".")
+	for i, part := range parts {
+		parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
+
+// bindVar returns the positional placeholder for the given 1-based argument position
+func bindVar(driver string, position int) string {
+	switch driver {
+	case "postgres", "pgx":
+		return fmt.Sprintf("$%d", position)
+	default:
+		return "?"
+	}
+}
+
+// foreignKeyTargets returns the tables referenced by foreign keys declared on table
+func foreignKeyTargets(executor statementExecutor, driver, table string) ([]string, error) {
+	var query string
+	var args []interface{}
+
+	switch driver {
+	case "sqlite3", "sqlite":
+		query = fmt.Sprintf("PRAGMA foreign_key_list(%s)", quoteIdentifier(driver, table))
+	case "postgres", "pgx":
+		schema, name := splitQualifiedName(table, "public")
+		query = `SELECT DISTINCT ccu.table_name AS "table"
+			FROM information_schema.table_constraints tc
+			JOIN information_schema.constraint_column_usage ccu
+			  ON tc.constraint_name = ccu.constraint_name AND tc.constraint_schema = ccu.constraint_schema
+			WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = $1 AND tc.table_name = $2`
+		args = []interface{}{schema, name}
+	case "mysql":
+		query = "SELECT DISTINCT REFERENCED_TABLE_NAME AS `table` FROM information_schema.KEY_COLUMN_USAGE " +
+			"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND REFERENCED_TABLE_NAME IS NOT NULL"
+		args = []interface{}{table}
+	default:
+		return nil, fmt.Errorf("foreign key introspection not supported for driver: %s", driver)
+	}
+
+	result, err := executor.Execute(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		if target, ok := row["table"].(string); ok && target != "" {
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+// splitQualifiedName splits "schema.table" into its parts, using defaultSchema when unqualified
+func splitQualifiedName(name, defaultSchema string) (string, string) {
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		return name[:idx], name[idx+1:]
+	}
+	return defaultSchema, name
+}
+
+// primaryKeyColumns returns the primary key columns of table in key order
+func primaryKeyColumns(executor statementExecutor, driver, table string) ([]string, error) {
+	switch driver {
+	case "sqlite3", "sqlite":
+		result, err := executor.Execute(fmt.Sprintf("PRAGMA table_info(%s)", quoteIdentifier(driver, table)))
+		if err != nil {
+			return nil, err
+		}
+
+		// table_info reports each column's 1-based position within the primary key, or 0
+		columns := make(map[int64]string)
+		for _, row := range result.Rows {
+			if position, ok := row["pk"].(int64); ok && position > 0 {
+				columns[position] = fmt.Sprint(row["name"])
+			}
+		}
+		key := make([]string, 0, len(columns))
+		for position := int64(1); position <= int64(len(columns)); position++ {
+			key = append(key, columns[position])
+		}
+		return key, nil
+	case "postgres", "pgx":
+		schema, name := splitQualifiedName(table, "public")
+		return columnNames(executor, `SELECT kcu.column_name AS "column"
+			FROM information_schema.table_constraints tc
+			JOIN information_schema.key_column_usage kcu
+			  ON tc.constraint_name = kcu.constraint_name AND tc.constraint_schema = kcu.constraint_schema
+			WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = $1 AND tc.table_name = $2
+			ORDER BY kcu.ordinal_position`, schema, name)
+	case "mysql":
+		return columnNames(executor, "SELECT COLUMN_NAME AS `column` FROM information_schema.KEY_COLUMN_USAGE "+
+			"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY' "+
+			"ORDER BY ORDINAL_POSITION", table)
+	default:
+		return nil, fmt.Errorf("primary key introspection not supported for driver: %s", driver)
+	}
+}
+
+// columnNames runs an introspection query and collects its "column" values
+func columnNames(executor statementExecutor, query string, args ...interface{}) ([]string, error) {
+	result, err := executor.Execute(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		if column, ok := row["column"].(string); ok && column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns, nil
+}
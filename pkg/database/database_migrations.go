@@ -0,0 +1,258 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gowright/framework/pkg/core"
+)
+
+// Migration is a versioned schema change with its up and down scripts
+type Migration struct {
+	Version string `json:"version"`
+	Name    string `json:"name"`
+	Up      string `json:"up"`
+	Down    string `json:"down"`
+}
+
+// MigrationVerification configures VerifyMigrations
+type MigrationVerification struct {
+	// Dir holds the migration files, named <version>_<name>.up.sql and <version>_<name>.down.sql
+	Dir string `json:"dir"`
+
+	// GoldenSchema is the JSON file the migrated schema is compared with; empty skips the comparison
+	GoldenSchema string `json:"golden_schema,omitempty"`
+
+	// UpdateGolden writes the migrated schema to GoldenSchema instead of comparing with it
+	UpdateGolden bool `json:"update_golden,omitempty"`
+}
+
+// migrationFilePattern matches <version>_<name>.up.sql and <version>_<name>.down.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// LoadMigrations reads the migrations in a directory, ordered by version.
+// Every migration needs both an up and a down script; other files are ignored.
+func LoadMigrations(dir string) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "failed to read migrations directory", err).
+			WithContext("dir", dir)
+	}
+
+	byVersion := make(map[string]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, name, direction := match[1], match[2], match[3]
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, core.NewGowrightError(core.ConfigurationError, "duplicate migration version", nil).
+				WithContext("version", version).
+				WithContext("names", []string{migration.Name, name})
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(path) // #nosec G304 -- migration paths come from the configured directory
+		if err != nil {
+			return nil, core.NewGowrightError(core.ConfigurationError, "failed to read migration file", err).
+				WithContext("path", path)
+		}
+
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "" {
+			return nil, core.NewGowrightError(core.ConfigurationError, "migration requires both up and down scripts", nil).
+				WithContext("version", migration.Version).
+				WithContext("name", migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return versionLess(migrations[i].Version, migrations[j].Version)
+	})
+
+	return migrations, nil
+}
+
+// ApplyMigrations runs the up script of every migration in order.
+// Each script is executed as a single statement batch, so MySQL connections need multiStatements=true.
+func (dt *DatabaseTester) ApplyMigrations(connectionName string, migrations []Migration) error {
+	for _, migration := range migrations {
+		if err := dt.runMigration(connectionName, migration, "up", migration.Up); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevertMigrations runs the down script of every migration in reverse order
+func (dt *DatabaseTester) RevertMigrations(connectionName string, migrations []Migration) error {
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if err := dt.runMigration(connectionName, migrations[i], "down", migrations[i].Down); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyMigrations applies a directory of migrations to an empty database and checks that each one
+// is reversible: after up, down must restore the previous schema and a second up must reproduce the
+// same schema. The final schema is then compared with the golden snapshot and returned.
+func (dt *DatabaseTester) VerifyMigrations(connectionName string, verification MigrationVerification) (*DatabaseSchema, error) {
+	migrations, err := LoadMigrations(verification.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := dt.Schema(connectionName)
+	if err != nil {
+		return nil, err
+	}
+	if len(before.Tables) > 0 {
+		return nil, core.NewGowrightError(core.ConfigurationError, "migration verification requires an empty database", nil).
+			WithContext("connection", connectionName).
+			WithContext("tables", len(before.Tables))
+	}
+
+	for _, migration := range migrations {
+		if err := dt.runMigration(connectionName, migration, "up", migration.Up); err != nil {
+			return nil, err
+		}
+		after, err := dt.Schema(connectionName)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := dt.runMigration(connectionName, migration, "down", migration.Down); err != nil {
+			return nil, err
+		}
+		if err := dt.compareMigrationSchema(connectionName, migration, "down did not restore the previous schema", before); err != nil {
+			return nil, err
+		}
+
+		if err := dt.runMigration(connectionName, migration, "up", migration.Up); err != nil {
+			return nil, err
+		}
+		if err := dt.compareMigrationSchema(connectionName, migration, "reapplying up produced a different schema", after); err != nil {
+			return nil, err
+		}
+
+		before = after
+	}
+
+	if verification.GoldenSchema == "" {
+		return before, nil
+	}
+
+	if verification.UpdateGolden {
+		return before, writeGoldenSchema(verification.GoldenSchema, before)
+	}
+
+	golden, err := readGoldenSchema(verification.GoldenSchema)
+	if err != nil {
+		return nil, err
+	}
+	if differences := DiffSchemas(golden, before); len(differences) > 0 {
+		return before, core.NewGowrightError(core.AssertionError, "schema does not match golden snapshot",
+			errors.New(strings.Join(differences, "; "))).
+			WithContext("golden", verification.GoldenSchema).
+			WithContext("differences", differences)
+	}
+
+	return before, nil
+}
+
+// runMigration executes one script of a migration
+func (dt *DatabaseTester) runMigration(connectionName string, migration Migration, direction, script string) error {
+	if _, err := dt.Execute(connectionName, script); err != nil {
+		return core.NewGowrightError(core.DatabaseError, "migration failed", err).
+			WithContext("version", migration.Version).
+			WithContext("name", migration.Name).
+			WithContext("direction", direction)
+	}
+	return nil
+}
+
+// compareMigrationSchema compares the current schema with the schema expected at this point of verification
+func (dt *DatabaseTester) compareMigrationSchema(connectionName string, migration Migration, message string, expected *DatabaseSchema) error {
+	actual, err := dt.Schema(connectionName)
+	if err != nil {
+		return err
+	}
+
+	if differences := DiffSchemas(expected, actual); len(differences) > 0 {
+		return core.NewGowrightError(core.AssertionError, message, errors.New(strings.Join(differences, "; "))).
+			WithContext("version", migration.Version).
+			WithContext("name", migration.Name).
+			WithContext("differences", differences)
+	}
+	return nil
+}
+
+// readGoldenSchema reads a schema snapshot written by writeGoldenSchema
+func readGoldenSchema(path string) (*DatabaseSchema, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- golden paths are supplied by the test author
+	if err != nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "failed to read golden schema", err).
+			WithContext("path", path)
+	}
+
+	var schema DatabaseSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "failed to parse golden schema", err).
+			WithContext("path", path)
+	}
+	return &schema, nil
+}
+
+// writeGoldenSchema writes a schema snapshot as indented JSON
+func writeGoldenSchema(path string, schema *DatabaseSchema) error {
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return core.NewGowrightError(core.ConfigurationError, "failed to encode golden schema", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return core.NewGowrightError(core.ConfigurationError, "failed to create golden schema directory", err).
+			WithContext("path", path)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return core.NewGowrightError(core.ConfigurationError, "failed to write golden schema", err).
+			WithContext("path", path)
+	}
+	return nil
+}
+
+// versionLess orders migration versions numerically, falling back to string order for oversized versions
+func versionLess(a, b string) bool {
+	numA, errA := strconv.ParseUint(a, 10, 64)
+	numB, errB := strconv.ParseUint(b, 10, 64)
+	if errA == nil && errB == nil {
+		return numA < numB
+	}
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
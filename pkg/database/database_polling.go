@@ -0,0 +1,126 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gowright/framework/pkg/core"
+)
+
+const (
+	// defaultPollTimeout bounds polling when PollConfig.Timeout is not set
+	defaultPollTimeout = 10 * time.Second
+
+	// defaultPollInterval separates attempts when neither an interval nor a backoff is configured
+	defaultPollInterval = 100 * time.Millisecond
+)
+
+// PollResult describes the attempts made while waiting for data
+type PollResult = core.PollResult
+
+// WaitForData runs a query repeatedly until its result matches the expectation or the poll timeout
+// expires. Every attempt is recorded as an assertion step; attempts that are retried are marked skipped.
+// On timeout the returned error carries the last observed result.
+func (dt *DatabaseTester) WaitForData(connectionName, query string, expected *core.DatabaseExpectation, poll *core.PollConfig, args ...interface{}) (*PollResult, error) {
+	if _, err := dt.getPool(connectionName); err != nil {
+		return nil, err
+	}
+
+	return pollQuery(&connectionExecutor{tester: dt, connectionName: connectionName}, query, args, expected, poll)
+}
+
+// WaitForDataInTransaction polls a query through tx until its result matches the expectation or
+// the poll times out
+func (dt *DatabaseTester) WaitForDataInTransaction(tx core.Transaction, query string, expected *core.DatabaseExpectation, poll *core.PollConfig, args ...interface{}) (*PollResult, error) {
+	return pollQuery(tx, query, args, expected, poll)
+}
+
+// pollQuery polls a query on an executor until its result matches the expectation
+func pollQuery(executor statementExecutor, query string, args []interface{}, expected *core.DatabaseExpectation, poll *core.PollConfig) (*PollResult, error) {
+	if expected == nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "polling requires an expectation", nil)
+	}
+	if poll == nil {
+		poll = &core.PollConfig{}
+	}
+
+	timeout := poll.Timeout
+	if timeout <= 0 {
+		timeout = defaultPollTimeout
+	}
+
+	start := time.Now()
+	deadline := start.Add(timeout)
+	result := &PollResult{}
+	var lastErr error
+
+	for attempt := 1; ; attempt++ {
+		step := core.AssertionStep{
+			Name:        "Eventually",
+			Description: fmt.Sprintf("Poll attempt %d", attempt),
+			Expected:    expected,
+			StartTime:   time.Now(),
+		}
+
+		observed, err := executor.Execute(query, args...)
+		if err == nil {
+			result.Result = observed
+			step.Actual = observed
+			err = validateExpectation(observed, expected)
+		}
+
+		step.EndTime = time.Now()
+		step.Duration = step.EndTime.Sub(step.StartTime)
+		result.Attempts = attempt
+
+		if err == nil {
+			step.Status = core.TestStatusPassed
+			result.Steps = append(result.Steps, step)
+			result.Elapsed = time.Since(start)
+			return result, nil
+		}
+
+		lastErr = err
+		step.Error = err
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			step.Status = core.TestStatusFailed
+			result.Steps = append(result.Steps, step)
+			break
+		}
+
+		// The mismatch is superseded by the next attempt
+		step.Status = core.TestStatusSkipped
+		result.Steps = append(result.Steps, step)
+		time.Sleep(min(pollDelay(poll, attempt), remaining))
+	}
+
+	result.Elapsed = time.Since(start)
+	return result, core.NewGowrightError(core.AssertionError,
+		fmt.Sprintf("data did not match expectation within %s", timeout),
+		fmt.Errorf("%w; last observed result: %s", lastErr, describeResult(result.Result))).
+		WithContext("attempts", result.Attempts).
+		WithContext("last_result", result.Result)
+}
+
+// pollDelay returns the delay after a failed attempt, growing with the backoff configuration when set
+func pollDelay(poll *core.PollConfig, attempt int) time.Duration {
+	if poll.Backoff != nil {
+		return poll.Backoff.CalculateDelay(attempt)
+	}
+	if poll.Interval > 0 {
+		return poll.Interval
+	}
+	return defaultPollInterval
+}
+
+// describeResult summarizes a query result for error messages
+func describeResult(result *core.DatabaseResult) string {
+	if result == nil {
+		return "none"
+	}
+	if result.Rows == nil {
+		return fmt.Sprintf("%d rows affected", result.RowsAffected)
+	}
+	return fmt.Sprintf("%d rows %v", result.RowCount, result.Rows)
+}
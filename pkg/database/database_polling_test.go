@@ -0,0 +1,115 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gowright/framework/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// insertLater inserts a job row from another goroutine after delay, simulating an asynchronous writer
+func insertLater(t *testing.T, tester *DatabaseTester, delay time.Duration) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		time.Sleep(delay)
+		_, _ = tester.Execute("test", "INSERT INTO jobs (id, state) VALUES (1, 'done')")
+	}()
+	t.Cleanup(func() { <-done })
+}
+
+func TestDatabaseTester_WaitForData(t *testing.T) {
+	tester := newSQLiteTester(t)
+	_, err := tester.Execute("test", "CREATE TABLE jobs (id INTEGER PRIMARY KEY, state TEXT NOT NULL)")
+	require.NoError(t, err)
+
+	insertLater(t, tester, 50*time.Millisecond)
+
+	poll, err := tester.WaitForData("test", "SELECT state FROM jobs WHERE id = ?",
+		&core.DatabaseExpectation{RowCount: 1, Rows: []map[string]interface{}{{"state": "done"}}},
+		&core.PollConfig{Timeout: 2 * time.Second, Backoff: &core.RetryConfig{InitialDelay: 10 * time.Millisecond, MaxDelay: 40 * time.Millisecond, BackoffFactor: 2}},
+		1)
+	require.NoError(t, err)
+	assert.Greater(t, poll.Attempts, 1)
+	require.Len(t, poll.Steps, poll.Attempts)
+	assert.Equal(t, core.TestStatusSkipped, poll.Steps[0].Status)
+	assert.Equal(t, core.TestStatusPassed, poll.Steps[len(poll.Steps)-1].Status)
+	assert.Equal(t, 1, poll.Result.RowCount)
+}
+
+func TestDatabaseTester_WaitForData_Timeout(t *testing.T) {
+	tester := newSQLiteTester(t)
+	_, err := tester.Execute("test", "CREATE TABLE jobs (id INTEGER PRIMARY KEY, state TEXT NOT NULL)")
+	require.NoError(t, err)
+	_, err = tester.Execute("test", "INSERT INTO jobs (id, state) VALUES (1, 'running')")
+	require.NoError(t, err)
+
+	poll, err := tester.WaitForData("test", "SELECT state FROM jobs",
+		&core.DatabaseExpectation{Rows: []map[string]interface{}{{"state": "done"}}},
+		&core.PollConfig{Timeout: 50 * time.Millisecond, Interval: 10 * time.Millisecond})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "data did not match expectation within 50ms")
+	assert.Contains(t, err.Error(), "last observed result: 1 rows [map[state:running]]")
+	assert.Equal(t, core.TestStatusFailed, poll.Steps[len(poll.Steps)-1].Status)
+
+	_, err = tester.WaitForData("test", "SELECT state FROM jobs", nil, nil)
+	assert.Error(t, err)
+}
+
+func TestDatabaseTester_ExecuteTest_Eventually(t *testing.T) {
+	tester := newSQLiteTester(t)
+	_, err := tester.Execute("test", "CREATE TABLE jobs (id INTEGER PRIMARY KEY, state TEXT NOT NULL)")
+	require.NoError(t, err)
+
+	test := &core.DatabaseTest{
+		Name:       "job completes",
+		Connection: "test",
+		Query:      "SELECT state FROM jobs WHERE id = 1",
+		Eventually: &core.PollConfig{Timeout: 2 * time.Second, Interval: 10 * time.Millisecond},
+		Expected:   &core.DatabaseExpectation{RowCount: 1},
+	}
+
+	insertLater(t, tester, 50*time.Millisecond)
+	result := tester.ExecuteTest(test)
+	assert.Equal(t, core.TestStatusPassed, result.Status, "%v", result.Error)
+
+	test.Query = "SELECT state FROM jobs WHERE id = 2"
+	test.Eventually.Timeout = 30 * time.Millisecond
+	result = tester.ExecuteTest(test)
+	assert.Equal(t, core.TestStatusFailed, result.Status)
+	require.Error(t, result.Error)
+	assert.Contains(t, result.Error.Error(), "last observed result: 0 rows []")
+}
+
+func TestDatabaseTester_DataPoller(t *testing.T) {
+	tester := newSQLiteTester(t)
+	_, err := tester.Execute("test", "CREATE TABLE jobs (id INTEGER PRIMARY KEY, state TEXT NOT NULL)")
+	require.NoError(t, err)
+	insertLater(t, tester, 20*time.Millisecond)
+
+	var poller core.DataPoller = tester
+	poll, err := poller.WaitForData("test", "SELECT state FROM jobs", &core.DatabaseExpectation{RowCount: 1}, &core.PollConfig{Timeout: 2 * time.Second, Interval: 10 * time.Millisecond})
+	require.NoError(t, err)
+	assert.Equal(t, core.TestStatusPassed, poll.Steps[len(poll.Steps)-1].Status)
+}
+
+func TestDatabaseTester_WaitForDataInTransaction(t *testing.T) {
+	tester := newSQLiteTester(t)
+	_, err := tester.Execute("test", "CREATE TABLE jobs (id INTEGER PRIMARY KEY, state TEXT NOT NULL)")
+	require.NoError(t, err)
+
+	// The in-memory database has a single connection, which the transaction holds
+	tx, err := tester.BeginTransaction("test")
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+	_, err = tx.Execute("INSERT INTO jobs (state) VALUES ('done')")
+	require.NoError(t, err)
+
+	poll, err := tester.WaitForDataInTransaction(tx, "SELECT state FROM jobs", &core.DatabaseExpectation{RowCount: 1}, &core.PollConfig{Timeout: time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, 1, poll.Attempts)
+}
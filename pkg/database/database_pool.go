@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,46 +14,90 @@ import (
 	"github.com/gowright/framework/pkg/core"
 )
 
-// DatabasePool manages a pool of database connections for concurrent testing
+const (
+	// defaultPoolSize bounds concurrent leases when no MaxOpenConns is configured
+	defaultPoolSize = 10
+
+	// defaultAcquireTimeout bounds how long AcquireConnection waits for a free connection
+	defaultAcquireTimeout = 30 * time.Second
+)
+
+// DatabasePool leases individual connections of one *sql.DB for a named connection.
+// Every lease is tracked so that connections that are never released can be reported as leaked.
 type DatabasePool struct {
 	name        string
 	config      *config.DatabaseConnection
-	connections chan *sql.DB
+	dbConfig    *config.DatabaseConfig
+	db          *sql.DB
 	maxSize     int
 	timeout     time.Duration
+	leases      map[*sql.Conn]*connectionLease
 	mutex       sync.RWMutex
 	stats       *DBPoolStats
+	createdAt   time.Time
+	lastUsed    time.Time
 	initialized bool
 }
 
+// connectionLease records who holds a leased connection and since when
+type connectionLease struct {
+	acquiredAt time.Time
+	acquiredBy string
+	leaked     bool
+	abort      func()
+}
+
 // DBPoolStats holds statistics about database pool usage
 type DBPoolStats struct {
-	Name          string `json:"name"`
-	MaxSize       int    `json:"max_size"`
-	Available     int    `json:"available"`
-	InUse         int    `json:"in_use"`
-	TotalCreated  int    `json:"total_created"`
-	TotalAcquired int    `json:"total_acquired"`
-	TotalReleased int    `json:"total_released"`
-	TotalErrors   int    `json:"total_errors"`
+	Name          string        `json:"name"`
+	MaxSize       int           `json:"max_size"`
+	Open          int           `json:"open"`
+	Available     int           `json:"available"`
+	InUse         int           `json:"in_use"`
+	TotalCreated  int           `json:"total_created"`
+	TotalAcquired int           `json:"total_acquired"`
+	TotalReleased int           `json:"total_released"`
+	TotalErrors   int           `json:"total_errors"`
+	Leaked        int           `json:"leaked"`
+	WaitCount     int64         `json:"wait_count"`
+	WaitDuration  time.Duration `json:"wait_duration"`
 }
 
-// NewDatabasePool creates a new database connection pool
-func NewDatabasePool(name string, cfg *config.DatabaseConnection, maxSize int, timeout time.Duration) (*DatabasePool, error) {
-	if maxSize <= 0 {
-		return nil, core.NewGowrightError(core.ConfigurationError, "database pool max size must be positive", nil)
-	}
+// LeaseInfo describes a connection lease that has not been released
+type LeaseInfo struct {
+	AcquiredAt time.Time     `json:"acquired_at"`
+	Held       time.Duration `json:"held"`
+	AcquiredBy string        `json:"acquired_by"`
+	Leaked     bool          `json:"leaked"`
+}
 
+// NewDatabasePool creates a connection pool for a named connection.
+// The pool size comes from dbConfig.MaxOpenConns, and in-memory SQLite databases are limited to a single connection.
+func NewDatabasePool(name string, cfg *config.DatabaseConnection, dbConfig *config.DatabaseConfig, timeout time.Duration) (*DatabasePool, error) {
 	if cfg == nil {
 		return nil, core.NewGowrightError(core.ConfigurationError, "database connection config cannot be nil", nil)
 	}
 
+	maxSize := defaultPoolSize
+	if dbConfig != nil && dbConfig.MaxOpenConns > 0 {
+		maxSize = dbConfig.MaxOpenConns
+	}
+	if isInMemorySQLite(cfg) {
+		maxSize = 1
+	}
+
+	if timeout <= 0 {
+		timeout = defaultAcquireTimeout
+	}
+
 	pool := &DatabasePool{
-		name:        name,
-		config:      cfg,
-		connections: make(chan *sql.DB, maxSize),
-		maxSize:     maxSize,
-		timeout:     timeout,
+		name:      name,
+		config:    cfg,
+		dbConfig:  dbConfig,
+		maxSize:   maxSize,
+		timeout:   timeout,
+		leases:    make(map[*sql.Conn]*connectionLease),
+		createdAt: time.Now(),
 		stats: &DBPoolStats{
 			Name:    name,
 			MaxSize: maxSize,
@@ -60,7 +107,7 @@ func NewDatabasePool(name string, cfg *config.DatabaseConnection, maxSize int, t
 	return pool, nil
 }
 
-// Initialize initializes the database pool
+// Initialize opens and verifies the underlying database handle
 func (dp *DatabasePool) Initialize() error {
 	dp.mutex.Lock()
 	defer dp.mutex.Unlock()
@@ -69,95 +116,105 @@ func (dp *DatabasePool) Initialize() error {
 		return nil
 	}
 
-	// Pre-create some database connections
-	for i := 0; i < dp.maxSize/2; i++ {
-		conn, err := dp.createConnection()
-		if err != nil {
-			return core.NewGowrightError(core.DatabaseError, "failed to create database connection", err)
-		}
-		dp.connections <- conn
-		dp.stats.TotalCreated++
-		dp.stats.Available++
+	db, err := openDatabase(dp.config, dp.dbConfig)
+	if err != nil {
+		dp.stats.TotalErrors++
+		return core.NewGowrightError(core.DatabaseError, "failed to connect to database", err).
+			WithContext("connection", dp.name).
+			WithContext("driver", dp.config.Driver)
 	}
+	db.SetMaxOpenConns(dp.maxSize)
 
+	dp.db = db
 	dp.initialized = true
+	dp.lastUsed = time.Now()
 	return nil
 }
 
-// AcquireConnection acquires a database connection from the pool
-func (dp *DatabasePool) AcquireConnection(ctx context.Context) (*sql.DB, error) {
-	if !dp.initialized {
-		return nil, core.NewGowrightError(core.DatabaseError, "database pool not initialized", nil)
-	}
-
-	select {
-	case conn := <-dp.connections:
-		// Test the connection
-		if err := conn.Ping(); err != nil {
-			// Connection is stale, create a new one
-			if closeErr := conn.Close(); closeErr != nil {
-				fmt.Printf("Error closing stale connection: %v\n", closeErr)
-			}
-			newConn, createErr := dp.createConnection()
-			if createErr != nil {
-				dp.mutex.Lock()
-				dp.stats.TotalErrors++
-				dp.mutex.Unlock()
-				return nil, core.NewGowrightError(core.DatabaseError, "failed to create replacement connection", createErr)
-			}
-			conn = newConn
-		}
+// AcquireConnection leases a connection, waiting up to the pool timeout for one to become free.
+// Every leased connection must be handed back with ReleaseConnection.
+func (dp *DatabasePool) AcquireConnection(ctx context.Context) (*sql.Conn, error) {
+	dp.mutex.RLock()
+	db, initialized := dp.db, dp.initialized
+	dp.mutex.RUnlock()
 
+	if !initialized {
+		return nil, core.NewGowrightError(core.DatabaseError, "database pool not initialized", nil).
+			WithContext("connection", dp.name)
+	}
+
+	acquireCtx, cancel := context.WithTimeout(ctx, dp.timeout)
+	defer cancel()
+
+	conn, err := db.Conn(acquireCtx)
+	if err != nil {
 		dp.mutex.Lock()
-		dp.stats.TotalAcquired++
-		dp.stats.Available--
-		dp.stats.InUse++
+		dp.stats.TotalErrors++
 		dp.mutex.Unlock()
-		return conn, nil
 
-	case <-time.After(dp.timeout):
-		return nil, core.NewGowrightError(core.DatabaseError, "timeout acquiring connection from pool", nil)
-
-	case <-ctx.Done():
-		return nil, core.NewGowrightError(core.DatabaseError, "context cancelled while acquiring connection", ctx.Err())
+		message := "failed to acquire connection from pool"
+		if ctx.Err() == nil && acquireCtx.Err() != nil {
+			message = "timeout acquiring connection from pool"
+		}
+		return nil, core.NewGowrightError(core.DatabaseError, message, err).
+			WithContext("connection", dp.name).
+			WithContext("max_size", dp.maxSize)
 	}
+
+	now := time.Now()
+	lease := &connectionLease{acquiredAt: now, acquiredBy: leaseCaller()}
+
+	dp.mutex.Lock()
+	dp.leases[conn] = lease
+	dp.stats.TotalAcquired++
+	dp.lastUsed = now
+	dp.mutex.Unlock()
+
+	return conn, nil
 }
 
-// ReleaseConnection returns a database connection to the pool
-func (dp *DatabasePool) ReleaseConnection(conn *sql.DB) error {
+// ReleaseConnection ends a lease and returns the connection to the pool
+func (dp *DatabasePool) ReleaseConnection(conn *sql.Conn) error {
 	if conn == nil {
 		return core.NewGowrightError(core.DatabaseError, "cannot release nil connection", nil)
 	}
 
-	// Test the connection before returning to pool
-	if err := conn.Ping(); err != nil {
-		// Connection is bad, close it
-		if closeErr := conn.Close(); closeErr != nil {
-			fmt.Printf("Error closing bad connection: %v\n", closeErr)
+	dp.mutex.Lock()
+	if _, leased := dp.leases[conn]; !leased {
+		closed := !dp.initialized
+		dp.mutex.Unlock()
+		if closed {
+			// Cleanup already closed the connections that were still leased
+			return nil
 		}
+		return core.NewGowrightError(core.DatabaseError, "connection was not leased from this pool", nil).
+			WithContext("connection", dp.name)
+	}
+	delete(dp.leases, conn)
+	dp.stats.TotalReleased++
+	dp.lastUsed = time.Now()
+	dp.mutex.Unlock()
+
+	// Closing a *sql.Conn hands the driver connection back to the *sql.DB
+	if err := conn.Close(); err != nil {
 		dp.mutex.Lock()
-		dp.stats.InUse--
 		dp.stats.TotalErrors++
 		dp.mutex.Unlock()
-		return nil
+		return core.NewGowrightError(core.DatabaseError, "failed to release connection", err).
+			WithContext("connection", dp.name)
 	}
 
+	return nil
+}
+
+// abortOnCleanup registers a function that Cleanup calls to abandon the work on a leased
+// connection, such as an open transaction, before closing it
+func (dp *DatabasePool) abortOnCleanup(conn *sql.Conn, abort func()) {
 	dp.mutex.Lock()
 	defer dp.mutex.Unlock()
 
-	select {
-	case dp.connections <- conn:
-		dp.stats.TotalReleased++
-		dp.stats.Available++
-		dp.stats.InUse--
-		return nil
-	default:
-		// Pool is full, close the connection
-		if closeErr := conn.Close(); closeErr != nil {
-			fmt.Printf("Error closing excess connection: %v\n", closeErr)
-		}
-		dp.stats.InUse--
-		return nil
+	if lease, leased := dp.leases[conn]; leased {
+		lease.abort = abort
 	}
 }
 
@@ -167,72 +224,175 @@ func (dp *DatabasePool) GetStats() *DBPoolStats {
 	defer dp.mutex.RUnlock()
 
 	// Return a copy to avoid race conditions
-	return &DBPoolStats{
-		Name:          dp.stats.Name,
-		MaxSize:       dp.stats.MaxSize,
-		Available:     dp.stats.Available,
-		InUse:         dp.stats.InUse,
-		TotalCreated:  dp.stats.TotalCreated,
-		TotalAcquired: dp.stats.TotalAcquired,
-		TotalReleased: dp.stats.TotalReleased,
-		TotalErrors:   dp.stats.TotalErrors,
+	stats := *dp.stats
+	stats.InUse = len(dp.leases)
+
+	if dp.db != nil {
+		dbStats := dp.db.Stats()
+		stats.Open = dbStats.OpenConnections
+		stats.Available = dbStats.Idle
+		stats.TotalCreated = dbStats.OpenConnections + int(dbStats.MaxIdleClosed+dbStats.MaxIdleTimeClosed+dbStats.MaxLifetimeClosed)
+		stats.WaitCount = dbStats.WaitCount
+		stats.WaitDuration = dbStats.WaitDuration
 	}
+
+	return &stats
 }
 
-// Cleanup closes all connections and cleans up the pool
-func (dp *DatabasePool) Cleanup() error {
+// ActiveLeases returns the leases that have not been released, oldest first
+func (dp *DatabasePool) ActiveLeases() []LeaseInfo {
+	dp.mutex.RLock()
+	defer dp.mutex.RUnlock()
+
+	now := time.Now()
+	leases := make([]LeaseInfo, 0, len(dp.leases))
+	for _, lease := range dp.leases {
+		leases = append(leases, LeaseInfo{
+			AcquiredAt: lease.acquiredAt,
+			Held:       now.Sub(lease.acquiredAt),
+			AcquiredBy: lease.acquiredBy,
+			Leaked:     lease.leaked,
+		})
+	}
+
+	sort.Slice(leases, func(i, j int) bool {
+		return leases[i].AcquiredAt.Before(leases[j].AcquiredAt)
+	})
+
+	return leases
+}
+
+// DetectLeaks flags leases held longer than maxAge and returns how many were newly flagged
+func (dp *DatabasePool) DetectLeaks(maxAge time.Duration) int {
 	dp.mutex.Lock()
 	defer dp.mutex.Unlock()
 
+	detected := 0
+	now := time.Now()
+	for _, lease := range dp.leases {
+		if !lease.leaked && now.Sub(lease.acquiredAt) > maxAge {
+			lease.leaked = true
+			detected++
+		}
+	}
+
+	dp.stats.Leaked += detected
+	return detected
+}
+
+// GetID returns the resource ID under which the pool is registered with a core.ResourceManager
+func (dp *DatabasePool) GetID() string {
+	return fmt.Sprintf("database_pool:%s:%p", dp.name, dp)
+}
+
+// GetType returns the resource type of the pool
+func (dp *DatabasePool) GetType() core.ResourceType {
+	return core.ResourceTypeDatabase
+}
+
+// GetCreatedAt returns when the pool was created
+func (dp *DatabasePool) GetCreatedAt() time.Time {
+	return dp.createdAt
+}
+
+// GetLastUsed returns when a connection was last acquired or released
+func (dp *DatabasePool) GetLastUsed() time.Time {
+	dp.mutex.RLock()
+	defer dp.mutex.RUnlock()
+	return dp.lastUsed
+}
+
+// IsActive reports whether the pool is open
+func (dp *DatabasePool) IsActive() bool {
+	dp.mutex.RLock()
+	defer dp.mutex.RUnlock()
+	return dp.initialized
+}
+
+// GetMetadata returns the pool statistics and outstanding leases for resource reports
+func (dp *DatabasePool) GetMetadata() map[string]interface{} {
+	return map[string]interface{}{
+		"connection": dp.name,
+		"driver":     dp.config.Driver,
+		"stats":      dp.GetStats(),
+		"leases":     dp.ActiveLeases(),
+	}
+}
+
+// Cleanup closes the pool. Leases that were never released are counted as leaked, their open
+// transactions are rolled back and an error listing their holders is returned.
+func (dp *DatabasePool) Cleanup() error {
+	dp.mutex.Lock()
 	if !dp.initialized {
+		dp.mutex.Unlock()
 		return nil
 	}
 
-	// Close all connections in the pool
-	close(dp.connections)
-	for conn := range dp.connections {
-		if err := conn.Close(); err != nil {
-			fmt.Printf("Error closing database connection: %v\n", err)
+	leases := dp.leases
+	db := dp.db
+	var holders []string
+	for _, lease := range leases {
+		if !lease.leaked {
+			dp.stats.Leaked++
 		}
+		holders = append(holders, lease.acquiredBy)
 	}
+	sort.Strings(holders)
 
-	dp.connections = make(chan *sql.DB, dp.maxSize)
+	dp.leases = make(map[*sql.Conn]*connectionLease)
+	dp.db = nil
 	dp.initialized = false
-	dp.stats.Available = 0
-	dp.stats.InUse = 0
+	dp.mutex.Unlock()
 
-	return nil
-}
+	// Closing a connection waits for its open transaction, so the transaction is cancelled first
+	// and the pool lock is not held while waiting for the rollback
+	for conn, lease := range leases {
+		if lease.abort != nil {
+			lease.abort()
+		}
+		if err := conn.Close(); err != nil {
+			fmt.Printf("Error closing leaked connection: %v\n", err)
+		}
+	}
 
-// createConnection creates a new database connection
-func (dp *DatabasePool) createConnection() (*sql.DB, error) {
-	// Build connection string based on driver
-	var dsn string
-	switch dp.config.Driver {
-	case "mysql":
-		dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s",
-			dp.config.Username, dp.config.Password, dp.config.Host, dp.config.Port, dp.config.Database)
-	case "postgres":
-		dsn = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-			dp.config.Host, dp.config.Port, dp.config.Username, dp.config.Password, dp.config.Database, dp.config.SSLMode)
-	case "sqlite3":
-		dsn = dp.config.Database
-	default:
-		return nil, fmt.Errorf("unsupported database driver: %s", dp.config.Driver)
-	}
-
-	db, err := sql.Open(dp.config.Driver, dsn)
-	if err != nil {
-		return nil, err
+	closeErr := db.Close()
+
+	if len(holders) > 0 {
+		return core.NewGowrightError(core.DatabaseError,
+			fmt.Sprintf("%d connection lease(s) were never released", len(holders)), nil).
+			WithContext("connection", dp.name).
+			WithContext("acquired_by", holders)
+	}
+	if closeErr != nil {
+		return core.NewGowrightError(core.DatabaseError, "failed to close database connection", closeErr).
+			WithContext("connection", dp.name)
 	}
 
-	// Test the connection
-	if err := db.Ping(); err != nil {
-		if closeErr := db.Close(); closeErr != nil {
-			fmt.Printf("Error closing failed connection: %v\n", closeErr)
+	return nil
+}
+
+// leaseCaller returns the first caller outside this package, identifying who acquired a lease
+func leaseCaller() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	fallback := ""
+	for {
+		frame, more := frames.Next()
+		location := fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		if fallback == "" {
+			fallback = location
+		}
+		if !isPackageFrame(frame) {
+			return location
+		}
+		if !more {
+			return fallback
 		}
-		return nil, err
 	}
+}
 
-	return db, nil
+// isPackageFrame reports whether a frame belongs to this package's library code rather than its callers
+func isPackageFrame(frame runtime.Frame) bool {
+	return strings.HasPrefix(frame.Function, "github.com/gowright/framework/pkg/database.") &&
+		!strings.HasSuffix(frame.File, "_test.go")
 }
@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gowright/framework/pkg/config"
+	"github.com/gowright/framework/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFilePool creates an initialized pool backed by a SQLite file allowing maxSize connections
+func newFilePool(t *testing.T, maxSize int, timeout time.Duration) *DatabasePool {
+	t.Helper()
+
+	pool, err := NewDatabasePool("file", &config.DatabaseConnection{
+		Driver:   "sqlite3",
+		Database: filepath.Join(t.TempDir(), "pool.db"),
+	}, &config.DatabaseConfig{MaxOpenConns: maxSize}, timeout)
+	require.NoError(t, err)
+	require.NoError(t, pool.Initialize())
+
+	t.Cleanup(func() {
+		_ = pool.Cleanup()
+	})
+
+	return pool
+}
+
+func TestNewDatabasePool(t *testing.T) {
+	_, err := NewDatabasePool("missing", nil, nil, 0)
+	assert.Error(t, err)
+
+	pool, err := NewDatabasePool("memory", &config.DatabaseConnection{Driver: "sqlite3", Database: ":memory:"},
+		&config.DatabaseConfig{MaxOpenConns: 5}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pool.GetStats().MaxSize)
+	assert.Equal(t, defaultAcquireTimeout, pool.timeout)
+
+	_, err = pool.AcquireConnection(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database pool not initialized")
+}
+
+func TestDatabasePool_AcquireRelease(t *testing.T) {
+	pool := newFilePool(t, 2, 50*time.Millisecond)
+	ctx := context.Background()
+
+	first, err := pool.AcquireConnection(ctx)
+	require.NoError(t, err)
+	second, err := pool.AcquireConnection(ctx)
+	require.NoError(t, err)
+
+	stats := pool.GetStats()
+	assert.Equal(t, 2, stats.InUse)
+	assert.Equal(t, 2, stats.TotalAcquired)
+
+	leases := pool.ActiveLeases()
+	require.Len(t, leases, 2)
+	assert.Contains(t, leases[0].AcquiredBy, "database_pool_test.go")
+
+	// Both connections are leased, so a third caller times out
+	_, err = pool.AcquireConnection(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timeout acquiring connection from pool")
+
+	require.NoError(t, pool.ReleaseConnection(first))
+	assert.Error(t, pool.ReleaseConnection(first))
+
+	third, err := pool.AcquireConnection(ctx)
+	require.NoError(t, err)
+	require.NoError(t, pool.ReleaseConnection(third))
+	require.NoError(t, pool.ReleaseConnection(second))
+
+	stats = pool.GetStats()
+	assert.Equal(t, 0, stats.InUse)
+	assert.Equal(t, 3, stats.TotalReleased)
+	assert.Equal(t, 1, stats.TotalErrors)
+	assert.Equal(t, 2, stats.Available)
+	assert.NoError(t, pool.Cleanup())
+}
+
+func TestDatabasePool_Leaks(t *testing.T) {
+	pool := newFilePool(t, 2, time.Second)
+
+	_, err := pool.AcquireConnection(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, pool.DetectLeaks(time.Minute))
+	assert.Equal(t, 1, pool.DetectLeaks(0))
+	assert.Equal(t, 0, pool.DetectLeaks(0), "leases are only reported once")
+	assert.True(t, pool.ActiveLeases()[0].Leaked)
+	assert.Equal(t, 1, pool.GetStats().Leaked)
+
+	err = pool.Cleanup()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 connection lease(s) were never released")
+	assert.False(t, pool.IsActive())
+	assert.Equal(t, 1, pool.GetStats().Leaked)
+}
+
+func TestDatabaseTester_ResourceManager(t *testing.T) {
+	rmConfig := core.DefaultResourceManagerConfig()
+	rmConfig.EnableLeakDetection = false
+	rmConfig.ResourceTimeout = 0
+	rm := core.NewResourceManager(rmConfig)
+	defer func() { _ = rm.Shutdown() }()
+
+	tester := newSQLiteTester(t)
+	require.NoError(t, tester.SetResourceManager(rm))
+
+	_, err := tester.Execute("test", "CREATE TABLE items (id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+	assert.Equal(t, 1, tester.PoolStats()["test"].TotalReleased)
+
+	infos := rm.GetResourceInfo()
+	require.Len(t, infos, 1)
+	assert.Equal(t, core.ResourceTypeDatabase, infos[0].Type)
+	stats, ok := infos[0].Metadata["stats"].(*DBPoolStats)
+	require.True(t, ok)
+	assert.Equal(t, "test", stats.Name)
+	assert.Equal(t, 0, stats.InUse)
+
+	// An open transaction holds its lease until it finishes
+	tx, err := tester.BeginTransaction("test")
+	require.NoError(t, err)
+	assert.Equal(t, 1, tester.PoolStats()["test"].InUse)
+	assert.Equal(t, 1, rm.DetectLeaks())
+	assert.Equal(t, 1, rm.GetStats()[core.ResourceTypeDatabase].Leaked)
+
+	require.NoError(t, tx.Rollback())
+	assert.Equal(t, 0, tester.PoolStats()["test"].InUse)
+
+	require.NoError(t, tester.Cleanup())
+	assert.Empty(t, rm.GetResourceInfo())
+}
+
+func TestDatabaseTester_FrameworkResourceManager(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.DatabaseConfig = &config.DatabaseConfig{
+		Connections: map[string]*config.DatabaseConnection{"test": {Driver: "sqlite3", Database: ":memory:"}},
+	}
+	gw := core.NewWithOptions(&core.GowrightOptions{Config: cfg, DatabaseTester: NewDatabaseTester()})
+	require.NoError(t, gw.Initialize())
+	defer func() { _ = gw.Cleanup() }()
+
+	_, err := gw.GetDatabaseTester().Execute("test", "SELECT 1")
+	require.NoError(t, err)
+
+	infos := gw.GetResourceManager().GetResourceInfo()
+	require.Len(t, infos, 1)
+	assert.Equal(t, core.ResourceTypeDatabase, infos[0].Type)
+	assert.IsType(t, &DBPoolStats{}, infos[0].Metadata["stats"])
+}
+
+func TestDatabaseTester_CleanupWithOpenTransaction(t *testing.T) {
+	tester := newSQLiteTester(t)
+	_, err := tester.Execute("test", "CREATE TABLE items (id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+
+	// The transaction is never finished and no TransactionTimeout is configured
+	tx, err := tester.BeginTransaction("test")
+	require.NoError(t, err)
+	_, err = tx.Execute("INSERT INTO items (id) VALUES (1)")
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tester.Cleanup()
+	}()
+
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Cleanup blocked on the open transaction")
+	}
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 connection lease(s) were never released")
+
+	// The abandoned transaction was rolled back, so finishing it afterwards is harmless
+	assert.NoError(t, tx.Rollback())
+	_, err = tx.Execute("SELECT 1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,261 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/gowright/framework/pkg/core"
+)
+
+// QueryFile holds the queries of a .sql file. Queries are introduced by a "-- name: <name>"
+// comment and run until the next one; a file without such comments is a single unnamed query.
+type QueryFile struct {
+	Path    string
+	queries map[string]string
+	names   []string
+}
+
+// queryNamePattern matches the comment line that starts a named query
+var queryNamePattern = regexp.MustCompile(`^\s*--\s*name:\s*([A-Za-z0-9_.-]+)\s*$`)
+
+// LoadQueryFile reads and parses a .sql query file
+func LoadQueryFile(path string) (*QueryFile, error) {
+	content, err := os.ReadFile(path) // #nosec G304 -- query file paths are supplied by the test author
+	if err != nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "failed to read query file", err).
+			WithContext("path", path)
+	}
+
+	return ParseQueries(path, string(content))
+}
+
+// ParseQueries parses the content of a query file; path is only used in errors
+func ParseQueries(path, content string) (*QueryFile, error) {
+	file := &QueryFile{Path: path, queries: make(map[string]string)}
+
+	var current string
+	var body []string
+	named := false
+	flush := func() {
+		if named {
+			file.queries[current] = strings.TrimSpace(strings.Join(body, "\n"))
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		match := queryNamePattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			body = append(body, line)
+			continue
+		}
+
+		flush()
+		current, body, named = match[1], nil, true
+		if _, exists := file.queries[current]; exists {
+			return nil, core.NewGowrightError(core.ConfigurationError, "duplicate query name", nil).
+				WithContext("path", path).
+				WithContext("name", current)
+		}
+		file.names = append(file.names, current)
+	}
+	flush()
+
+	if !named {
+		file.queries[""] = strings.TrimSpace(content)
+	}
+
+	return file, nil
+}
+
+// Names returns the query names in file order
+func (qf *QueryFile) Names() []string {
+	return append([]string(nil), qf.names...)
+}
+
+// Query returns a query by name. An empty name selects the only query of a file
+// that contains a single query.
+func (qf *QueryFile) Query(name string) (string, error) {
+	if name == "" && len(qf.names) == 1 {
+		name = qf.names[0]
+	}
+
+	query, exists := qf.queries[name]
+	if !exists {
+		message := "query not found in file"
+		if name == "" {
+			message = "query file contains several queries, a query name is required"
+		}
+		return "", core.NewGowrightError(core.ConfigurationError, message, nil).
+			WithContext("path", qf.Path).
+			WithContext("name", name).
+			WithContext("available", qf.Names())
+	}
+
+	return query, nil
+}
+
+// ExpandQueryTemplate expands a query as a text/template with the given variables.
+// Referencing a variable that is not defined is an error.
+func ExpandQueryTemplate(name, query string, variables map[string]interface{}) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(query)
+	if err != nil {
+		return "", core.NewGowrightError(core.ConfigurationError, "failed to parse query template", err).
+			WithContext("query", name)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, variables); err != nil {
+		return "", core.NewGowrightError(core.ConfigurationError, "failed to expand query template", err).
+			WithContext("query", name)
+	}
+
+	return buf.String(), nil
+}
+
+// BindNamedParams replaces :name parameters with the placeholders of the driver and returns the
+// positional arguments in placeholder order. Postgres placeholders ($1, $2, ...) are reused when a
+// parameter appears more than once; other drivers use ? and repeat the argument.
+// String literals, quoted identifiers, comments and postgres :: casts are left untouched.
+func BindNamedParams(driver, query string, params map[string]interface{}) (string, []interface{}, error) {
+	var out strings.Builder
+	var args []interface{}
+	var missing []string
+	positions := make(map[string]int)
+	numbered := driver == "postgres" || driver == "pgx"
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(query) && query[end] != c {
+				end++
+			}
+			out.WriteString(query[i:min(end+1, len(query))])
+			i = end
+
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			out.WriteString(query[i : i+end])
+			i += end - 1
+
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				out.WriteString(query[i:])
+				i = len(query)
+				break
+			}
+			out.WriteString(query[i : i+end+4])
+			i += end + 3
+
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			out.WriteString("::")
+			i++
+
+		case c == ':' && i+1 < len(query) && isParamStart(query[i+1]):
+			end := i + 1
+			for end < len(query) && isParamChar(query[end]) {
+				end++
+			}
+			name := query[i+1 : end]
+			i = end - 1
+
+			value, exists := params[name]
+			if !exists {
+				if _, seen := positions[name]; !seen {
+					missing = append(missing, name)
+					positions[name] = 0
+				}
+				continue
+			}
+
+			if !numbered {
+				args = append(args, value)
+				out.WriteByte('?')
+				continue
+			}
+			position, seen := positions[name]
+			if !seen {
+				args = append(args, value)
+				position = len(args)
+				positions[name] = position
+			}
+			fmt.Fprintf(&out, "$%d", position)
+
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	if len(missing) > 0 {
+		return "", nil, core.NewGowrightError(core.ConfigurationError, "missing query parameters",
+			errors.New(strings.Join(missing, ", "))).
+			WithContext("missing", missing)
+	}
+
+	return out.String(), args, nil
+}
+
+// ResolveQuery loads, expands and binds a query for the named connection
+func (dt *DatabaseTester) ResolveQuery(connectionName string, source core.QuerySource) (string, []interface{}, error) {
+	query := source.Query
+	name := "query"
+	if source.File != "" {
+		file, err := LoadQueryFile(source.File)
+		if err != nil {
+			return "", nil, err
+		}
+		if query, err = file.Query(source.Name); err != nil {
+			return "", nil, err
+		}
+		name = source.File + ":" + source.Name
+	}
+
+	if source.Variables != nil {
+		expanded, err := ExpandQueryTemplate(name, query, source.Variables)
+		if err != nil {
+			return "", nil, err
+		}
+		query = expanded
+	}
+
+	if source.Params == nil {
+		return query, source.Args, nil
+	}
+
+	if len(source.Args) > 0 {
+		return "", nil, core.NewGowrightError(core.ConfigurationError,
+			"positional arguments cannot be combined with named parameters", nil).
+			WithContext("query", name)
+	}
+
+	if _, err := dt.getPool(connectionName); err != nil {
+		return "", nil, err
+	}
+
+	bound, args, err := BindNamedParams(dt.config.Connections[connectionName].Driver, query, source.Params)
+	if err != nil {
+		return "", nil, err
+	}
+	return bound, args, nil
+}
+
+// isParamStart reports whether c can start a parameter name
+func isParamStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isParamChar reports whether c can continue a parameter name
+func isParamChar(c byte) bool {
+	return isParamStart(c) || (c >= '0' && c <= '9')
+}
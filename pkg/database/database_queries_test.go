@@ -0,0 +1,146 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gowright/framework/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const userQueries = `-- Queries used by the user tests
+
+-- name: find_user
+SELECT id, email FROM users WHERE id = :user_id;
+
+-- name: users_by_status
+SELECT id FROM {{.table}}
+WHERE status = :status -- :ignored in comments
+ORDER BY id;
+`
+
+// writeQueryFile writes a query file into a temporary directory and returns its path
+func writeQueryFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "queries.sql")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestParseQueries(t *testing.T) {
+	file, err := ParseQueries("users.sql", userQueries)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"find_user", "users_by_status"}, file.Names())
+
+	query, err := file.Query("find_user")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT id, email FROM users WHERE id = :user_id;", query)
+
+	_, err = file.Query("missing")
+	assert.Error(t, err)
+	_, err = file.Query("")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a query name is required")
+
+	single, err := ParseQueries("single.sql", "\n  SELECT 1;\n")
+	require.NoError(t, err)
+	query, err = single.Query("")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1;", query)
+
+	_, err = ParseQueries("dup.sql", "-- name: a\nSELECT 1;\n-- name: a\nSELECT 2;")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate query name")
+}
+
+func TestBindNamedParams(t *testing.T) {
+	query := `SELECT * FROM users WHERE id = :id AND name <> ':name' AND created::date = :day OR parent = :id /* :skip */`
+	params := map[string]interface{}{"id": 7, "day": "2024-01-01"}
+
+	tests := []struct {
+		driver   string
+		expected string
+		args     []interface{}
+	}{
+		{"postgres", `SELECT * FROM users WHERE id = $1 AND name <> ':name' AND created::date = $2 OR parent = $1 /* :skip */`, []interface{}{7, "2024-01-01"}},
+		{"mysql", `SELECT * FROM users WHERE id = ? AND name <> ':name' AND created::date = ? OR parent = ? /* :skip */`, []interface{}{7, "2024-01-01", 7}},
+		{"sqlite3", `SELECT * FROM users WHERE id = ? AND name <> ':name' AND created::date = ? OR parent = ? /* :skip */`, []interface{}{7, "2024-01-01", 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			bound, args, err := BindNamedParams(tt.driver, query, params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, bound)
+			assert.Equal(t, tt.args, args)
+		})
+	}
+
+	_, _, err := BindNamedParams("postgres", "SELECT :a, :b, :a", map[string]interface{}{})
+	require.Error(t, err)
+	assert.Equal(t, "missing query parameters: a, b", err.Error())
+}
+
+func TestDatabaseTester_ResolveQuery(t *testing.T) {
+	tester := newSQLiteTester(t)
+	path := writeQueryFile(t, userQueries)
+
+	query, args, err := tester.ResolveQuery("test", core.QuerySource{
+		File:      path,
+		Name:      "users_by_status",
+		Params:    map[string]interface{}{"status": "active"},
+		Variables: map[string]interface{}{"table": "accounts"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT id FROM accounts\nWHERE status = ? -- :ignored in comments\nORDER BY id;", query)
+	assert.Equal(t, []interface{}{"active"}, args)
+
+	_, _, err = tester.ResolveQuery("test", core.QuerySource{File: path, Name: "users_by_status", Variables: map[string]interface{}{}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to expand query template")
+
+	_, _, err = tester.ResolveQuery("test", core.QuerySource{
+		Query:  "SELECT :id",
+		Params: map[string]interface{}{"id": 1},
+		Args:   []interface{}{1},
+	})
+	assert.Error(t, err)
+
+	// Inline queries without parameters or variables pass through unchanged
+	query, args, err = tester.ResolveQuery("test", core.QuerySource{Query: "SELECT ?", Args: []interface{}{1}})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT ?", query)
+	assert.Equal(t, []interface{}{1}, args)
+}
+
+func TestDatabaseTester_ExecuteTest_QueryFile(t *testing.T) {
+	tester := newSQLiteTester(t)
+
+	test := &core.DatabaseTest{
+		Name:       "find user",
+		Connection: "test",
+		Setup: []string{
+			"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL)",
+			"INSERT INTO users (id, email) VALUES (1, 'alice@example.com'), (2, 'bob@example.com')",
+		},
+		QueryFile: writeQueryFile(t, userQueries),
+		QueryName: "find_user",
+		Params:    map[string]interface{}{"user_id": 2},
+		Expected: &core.DatabaseExpectation{
+			RowCount: 1,
+			Rows:     []map[string]interface{}{{"id": int64(2), "email": "bob@example.com"}},
+		},
+		Teardown: []string{"DROP TABLE users"},
+	}
+
+	result := tester.ExecuteTest(test)
+	assert.Equal(t, core.TestStatusPassed, result.Status, "%v", result.Error)
+
+	test.QueryName = "missing"
+	result = tester.ExecuteTest(test)
+	assert.Equal(t, core.TestStatusError, result.Status)
+	assert.Contains(t, result.Error.Error(), "query not found in file")
+}
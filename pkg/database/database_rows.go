@@ -0,0 +1,222 @@
+package database
+
+import (
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// rowReturningKeywords lists the leading statement keywords that produce a result set
+var rowReturningKeywords = map[string]bool{
+	"SELECT":   true,
+	"WITH":     true,
+	"VALUES":   true,
+	"SHOW":     true,
+	"DESCRIBE": true,
+	"DESC":     true,
+	"EXPLAIN":  true,
+	"PRAGMA":   true,
+	"TABLE":    true,
+}
+
+// returningClause matches the RETURNING keyword with any whitespace around it
+var returningClause = regexp.MustCompile(`(?i)\bRETURNING\b`)
+
+// returnsRows reports whether a statement is expected to produce a result set
+func returnsRows(query string) bool {
+	keyword := leadingKeyword(query)
+	if rowReturningKeywords[keyword] {
+		return true
+	}
+
+	// INSERT/UPDATE/DELETE ... RETURNING produces rows on postgres and sqlite
+	return returningClause.MatchString(stripLiterals(query))
+}
+
+// stripLiterals replaces string literals, quoted identifiers and comments with spaces so that
+// keywords inside them are not matched
+func stripLiterals(query string) string {
+	var out strings.Builder
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(query) && query[end] != c {
+				end++
+			}
+			out.WriteByte(' ')
+			i = end
+
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return out.String()
+			}
+			out.WriteByte(' ')
+			i += end - 1
+
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return out.String()
+			}
+			out.WriteByte(' ')
+			i += end + 3
+
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	return out.String()
+}
+
+// leadingKeyword returns the first SQL keyword of a statement, skipping comments and parentheses
+func leadingKeyword(query string) string {
+	rest := query
+	for {
+		rest = strings.TrimLeftFunc(rest, func(r rune) bool {
+			return unicode.IsSpace(r) || r == '('
+		})
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			if idx := strings.IndexByte(rest, '\n'); idx >= 0 {
+				rest = rest[idx+1:]
+				continue
+			}
+			return ""
+		case strings.HasPrefix(rest, "/*"):
+			if idx := strings.Index(rest, "*/"); idx >= 0 {
+				rest = rest[idx+2:]
+				continue
+			}
+			return ""
+		}
+		break
+	}
+
+	end := strings.IndexFunc(rest, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if end < 0 {
+		end = len(rest)
+	}
+	return strings.ToUpper(rest[:end])
+}
+
+// scanRows reads every row of a result set into column-keyed maps
+func scanRows(rows *sql.Rows) ([]map[string]interface{}, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0)
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			row[column] = convertValue(values[i], columnTypes[i].DatabaseTypeName())
+		}
+		result = append(result, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// convertValue converts a raw driver value into a Go value matching the column's database type
+func convertValue(value interface{}, databaseType string) interface{} {
+	typeName := strings.ToUpper(databaseType)
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		if isBinaryType(typeName) {
+			copied := make([]byte, len(v))
+			copy(copied, v)
+			return copied
+		}
+		return convertText(string(v), typeName)
+	case int64:
+		if isBoolType(typeName) {
+			return v != 0
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// convertText parses a textual driver value according to the column's database type
+func convertText(text, typeName string) interface{} {
+	switch {
+	case isBoolType(typeName):
+		if parsed, err := strconv.ParseBool(text); err == nil {
+			return parsed
+		}
+	case isIntegerType(typeName):
+		if parsed, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return parsed
+		}
+	case isFloatType(typeName):
+		if parsed, err := strconv.ParseFloat(text, 64); err == nil {
+			return parsed
+		}
+	}
+	return text
+}
+
+// isBinaryType reports whether a database type holds raw bytes
+func isBinaryType(typeName string) bool {
+	return strings.Contains(typeName, "BLOB") ||
+		strings.Contains(typeName, "BINARY") ||
+		typeName == "BYTEA" ||
+		typeName == "BIT"
+}
+
+// isBoolType reports whether a database type holds booleans
+func isBoolType(typeName string) bool {
+	return typeName == "BOOL" || typeName == "BOOLEAN"
+}
+
+// isIntegerType reports whether a database type holds integers
+func isIntegerType(typeName string) bool {
+	if typeName == "POINT" || typeName == "INTERVAL" {
+		return false
+	}
+	return strings.Contains(typeName, "INT") || strings.HasSuffix(typeName, "SERIAL")
+}
+
+// isFloatType reports whether a database type holds floating point or fixed point numbers
+func isFloatType(typeName string) bool {
+	return strings.Contains(typeName, "DECIMAL") ||
+		strings.Contains(typeName, "NUMERIC") ||
+		strings.Contains(typeName, "FLOAT") ||
+		strings.Contains(typeName, "DOUBLE") ||
+		strings.Contains(typeName, "REAL") ||
+		typeName == "MONEY"
+}
@@ -0,0 +1,533 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gowright/framework/pkg/core"
+)
+
+// DatabaseSchema describes the tables of a database
+type DatabaseSchema struct {
+	Tables []TableSchema `json:"tables"`
+}
+
+// TableSchema describes the columns, keys and indexes of a table
+type TableSchema struct {
+	Name        string             `json:"name"`
+	Columns     []ColumnSchema     `json:"columns"`
+	PrimaryKey  []string           `json:"primary_key,omitempty"`
+	Indexes     []IndexSchema      `json:"indexes,omitempty"`
+	ForeignKeys []ForeignKeySchema `json:"foreign_keys,omitempty"`
+}
+
+// ColumnSchema describes a single column as reported by the database
+type ColumnSchema struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Nullable bool    `json:"nullable"`
+	Default  *string `json:"default,omitempty"`
+}
+
+// IndexSchema describes an index and the columns it covers, in index order
+type IndexSchema struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+}
+
+// ForeignKeySchema describes a foreign key; constraint names are omitted because databases generate them differently
+type ForeignKeySchema struct {
+	Columns           []string `json:"columns"`
+	ReferencedTable   string   `json:"referenced_table"`
+	ReferencedColumns []string `json:"referenced_columns"`
+}
+
+// ColumnExpectation describes the expected definition of a column; unset fields are not checked
+type ColumnExpectation struct {
+	// Type matches the column type case-insensitively; a type without a length such as "varchar" also matches "varchar(255)"
+	Type string `json:"type,omitempty"`
+
+	// Nullable checks whether the column accepts NULL
+	Nullable *bool `json:"nullable,omitempty"`
+}
+
+// schemaQueries holds the per-driver introspection queries. Every query takes the schema and table name
+// (tables only the schema) and aliases its columns to the names read by loadTableSchema.
+type schemaQueries struct {
+	tables      string
+	columns     string
+	indexes     string
+	foreignKeys string
+	args        func(schema, table string) []interface{}
+}
+
+// schemaQueriesFor returns the introspection queries for a driver
+func schemaQueriesFor(driver string) (*schemaQueries, error) {
+	switch driver {
+	case "sqlite3", "sqlite":
+		return &schemaQueries{
+			tables: `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`,
+			columns: `SELECT name, type, CASE WHEN "notnull" = 0 THEN 'YES' ELSE 'NO' END AS nullable, dflt_value AS "default"
+				FROM pragma_table_info(?) ORDER BY cid`,
+			indexes: `SELECT il.name AS name, il."unique" AS "unique", ii.name AS "column"
+				FROM pragma_index_list(?) il JOIN pragma_index_info(il.name) ii ORDER BY il.name, ii.seqno`,
+			foreignKeys: `SELECT id AS name, "from" AS "column", "table" AS referenced_table, "to" AS referenced_column
+				FROM pragma_foreign_key_list(?) ORDER BY id, seq`,
+			args: func(_, table string) []interface{} {
+				return []interface{}{table}
+			},
+		}, nil
+	case "postgres", "pgx":
+		return &schemaQueries{
+			tables: `SELECT table_name AS name FROM information_schema.tables
+				WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name`,
+			columns: `SELECT column_name AS name, data_type AS type, is_nullable AS nullable, column_default AS "default"
+				FROM information_schema.columns WHERE table_schema = $1 AND table_name = $2 ORDER BY ordinal_position`,
+			indexes: `SELECT i.relname AS name, ix.indisunique AS "unique", a.attname AS "column"
+				FROM pg_class t
+				JOIN pg_namespace n ON n.oid = t.relnamespace
+				JOIN pg_index ix ON ix.indrelid = t.oid
+				JOIN pg_class i ON i.oid = ix.indexrelid
+				JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true
+				JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+				WHERE n.nspname = $1 AND t.relname = $2 ORDER BY i.relname, k.ord`,
+			foreignKeys: `SELECT c.conname AS name, a.attname AS "column", rt.relname AS referenced_table, ra.attname AS referenced_column
+				FROM pg_constraint c
+				JOIN pg_class t ON t.oid = c.conrelid
+				JOIN pg_namespace n ON n.oid = t.relnamespace
+				JOIN pg_class rt ON rt.oid = c.confrelid
+				JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, refnum, ord) ON true
+				JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+				JOIN pg_attribute ra ON ra.attrelid = rt.oid AND ra.attnum = k.refnum
+				WHERE c.contype = 'f' AND n.nspname = $1 AND t.relname = $2 ORDER BY c.conname, k.ord`,
+			args: func(schema, table string) []interface{} {
+				return []interface{}{schema, table}
+			},
+		}, nil
+	case "mysql":
+		return &schemaQueries{
+			tables: "SELECT TABLE_NAME AS name FROM information_schema.TABLES " +
+				"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME",
+			columns: "SELECT COLUMN_NAME AS name, COLUMN_TYPE AS type, IS_NULLABLE AS nullable, COLUMN_DEFAULT AS `default` " +
+				"FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION",
+			indexes: "SELECT INDEX_NAME AS name, NON_UNIQUE = 0 AS `unique`, COLUMN_NAME AS `column` " +
+				"FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? " +
+				"ORDER BY INDEX_NAME, SEQ_IN_INDEX",
+			foreignKeys: "SELECT CONSTRAINT_NAME AS name, COLUMN_NAME AS `column`, " +
+				"REFERENCED_TABLE_NAME AS referenced_table, REFERENCED_COLUMN_NAME AS referenced_column " +
+				"FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? " +
+				"AND REFERENCED_TABLE_NAME IS NOT NULL ORDER BY CONSTRAINT_NAME, ORDINAL_POSITION",
+			args: func(_, table string) []interface{} {
+				return []interface{}{table}
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("schema introspection not supported for driver: %s", driver)
+	}
+}
+
+// Schema introspects every table of the named connection
+func (dt *DatabaseTester) Schema(connectionName string) (*DatabaseSchema, error) {
+	executor, driver, err := dt.schemaExecutor(connectionName)
+	if err != nil {
+		return nil, err
+	}
+	return loadSchema(executor, driver)
+}
+
+// TableSchema introspects a single table; it returns nil without error when the table does not exist
+func (dt *DatabaseTester) TableSchema(connectionName, table string) (*TableSchema, error) {
+	executor, driver, err := dt.schemaExecutor(connectionName)
+	if err != nil {
+		return nil, err
+	}
+	return loadTableSchema(executor, driver, table)
+}
+
+// AssertTableExists returns an assertion error unless the table exists
+func (dt *DatabaseTester) AssertTableExists(connectionName, table string) error {
+	_, err := dt.requireTable(connectionName, table)
+	return err
+}
+
+// AssertColumn returns an assertion error unless the column exists and matches the expectation
+func (dt *DatabaseTester) AssertColumn(connectionName, table, column string, expected ColumnExpectation) error {
+	schema, err := dt.requireTable(connectionName, table)
+	if err != nil {
+		return err
+	}
+
+	actual := schema.Column(column)
+	if actual == nil {
+		return schemaAssertionError("column does not exist", table).WithContext("column", column)
+	}
+
+	if expected.Type != "" && !columnTypeMatches(expected.Type, actual.Type) {
+		return schemaAssertionError(
+			fmt.Sprintf("column %s has type %s, expected %s", column, actual.Type, expected.Type), table).
+			WithContext("column", column)
+	}
+
+	if expected.Nullable != nil && *expected.Nullable != actual.Nullable {
+		return schemaAssertionError(
+			fmt.Sprintf("column %s nullable is %t, expected %t", column, actual.Nullable, *expected.Nullable), table).
+			WithContext("column", column)
+	}
+
+	return nil
+}
+
+// AssertIndex returns an assertion error unless an index covers exactly the given columns in order.
+// When unique is true the index must also be unique.
+func (dt *DatabaseTester) AssertIndex(connectionName, table string, columns []string, unique bool) error {
+	schema, err := dt.requireTable(connectionName, table)
+	if err != nil {
+		return err
+	}
+
+	for _, index := range schema.Indexes {
+		if equalColumns(index.Columns, columns) && (!unique || index.Unique) {
+			return nil
+		}
+	}
+
+	message := "index not found"
+	if unique {
+		message = "unique index not found"
+	}
+	return schemaAssertionError(message, table).WithContext("columns", columns)
+}
+
+// AssertForeignKey returns an assertion error unless a foreign key maps the columns to the referenced table's columns
+func (dt *DatabaseTester) AssertForeignKey(connectionName, table string, columns []string, referencedTable string, referencedColumns []string) error {
+	schema, err := dt.requireTable(connectionName, table)
+	if err != nil {
+		return err
+	}
+
+	for _, foreignKey := range schema.ForeignKeys {
+		if equalColumns(foreignKey.Columns, columns) &&
+			strings.EqualFold(foreignKey.ReferencedTable, referencedTable) &&
+			equalColumns(foreignKey.ReferencedColumns, referencedColumns) {
+			return nil
+		}
+	}
+
+	return schemaAssertionError("foreign key not found", table).
+		WithContext("columns", columns).
+		WithContext("referenced_table", referencedTable).
+		WithContext("referenced_columns", referencedColumns)
+}
+
+// Table returns the named table schema, or nil if it is not part of the schema
+func (ds *DatabaseSchema) Table(name string) *TableSchema {
+	for i := range ds.Tables {
+		if ds.Tables[i].Name == name {
+			return &ds.Tables[i]
+		}
+	}
+	return nil
+}
+
+// Column returns the named column schema, or nil if the table has no such column
+func (ts *TableSchema) Column(name string) *ColumnSchema {
+	for i := range ts.Columns {
+		if strings.EqualFold(ts.Columns[i].Name, name) {
+			return &ts.Columns[i]
+		}
+	}
+	return nil
+}
+
+// DiffSchemas describes every difference between an expected and an actual schema
+func DiffSchemas(expected, actual *DatabaseSchema) []string {
+	differences := make([]string, 0)
+
+	for _, expectedTable := range expected.Tables {
+		actualTable := actual.Table(expectedTable.Name)
+		if actualTable == nil {
+			differences = append(differences, fmt.Sprintf("table %s: missing", expectedTable.Name))
+			continue
+		}
+		differences = append(differences, diffTables(&expectedTable, actualTable)...)
+	}
+
+	for _, actualTable := range actual.Tables {
+		if expected.Table(actualTable.Name) == nil {
+			differences = append(differences, fmt.Sprintf("table %s: unexpected", actualTable.Name))
+		}
+	}
+
+	return differences
+}
+
+// diffTables describes the differences between two versions of a table
+func diffTables(expected, actual *TableSchema) []string {
+	differences := make([]string, 0)
+	prefix := "table " + expected.Name
+
+	for _, expectedColumn := range expected.Columns {
+		actualColumn := actual.Column(expectedColumn.Name)
+		switch {
+		case actualColumn == nil:
+			differences = append(differences, fmt.Sprintf("%s: column %s missing", prefix, expectedColumn.Name))
+		case describeColumn(expectedColumn) != describeColumn(*actualColumn):
+			differences = append(differences, fmt.Sprintf("%s: column %s is %s, expected %s",
+				prefix, expectedColumn.Name, describeColumn(*actualColumn), describeColumn(expectedColumn)))
+		}
+	}
+	for _, actualColumn := range actual.Columns {
+		if expected.Column(actualColumn.Name) == nil {
+			differences = append(differences, fmt.Sprintf("%s: unexpected column %s", prefix, actualColumn.Name))
+		}
+	}
+
+	if !equalColumns(expected.PrimaryKey, actual.PrimaryKey) {
+		differences = append(differences, fmt.Sprintf("%s: primary key is %v, expected %v", prefix, actual.PrimaryKey, expected.PrimaryKey))
+	}
+
+	expectedIndexes, actualIndexes := describeIndexes(expected.Indexes), describeIndexes(actual.Indexes)
+	for _, index := range setDifference(expectedIndexes, actualIndexes) {
+		differences = append(differences, fmt.Sprintf("%s: index %s missing", prefix, index))
+	}
+	for _, index := range setDifference(actualIndexes, expectedIndexes) {
+		differences = append(differences, fmt.Sprintf("%s: unexpected index %s", prefix, index))
+	}
+
+	expectedKeys, actualKeys := describeForeignKeys(expected.ForeignKeys), describeForeignKeys(actual.ForeignKeys)
+	for _, foreignKey := range setDifference(expectedKeys, actualKeys) {
+		differences = append(differences, fmt.Sprintf("%s: foreign key %s missing", prefix, foreignKey))
+	}
+	for _, foreignKey := range setDifference(actualKeys, expectedKeys) {
+		differences = append(differences, fmt.Sprintf("%s: unexpected foreign key %s", prefix, foreignKey))
+	}
+
+	return differences
+}
+
+// schemaExecutor returns an executor and driver for introspecting the named connection
+func (dt *DatabaseTester) schemaExecutor(connectionName string) (statementExecutor, string, error) {
+	if _, err := dt.getPool(connectionName); err != nil {
+		return nil, "", err
+	}
+	return &connectionExecutor{tester: dt, connectionName: connectionName}, dt.config.Connections[connectionName].Driver, nil
+}
+
+// requireTable introspects a table, returning an assertion error if it does not exist
+func (dt *DatabaseTester) requireTable(connectionName, table string) (*TableSchema, error) {
+	schema, err := dt.TableSchema(connectionName, table)
+	if err != nil {
+		return nil, err
+	}
+	if schema == nil {
+		return nil, schemaAssertionError("table does not exist", table).WithContext("connection", connectionName)
+	}
+	return schema, nil
+}
+
+// loadSchema introspects every table visible to the executor
+func loadSchema(executor statementExecutor, driver string) (*DatabaseSchema, error) {
+	queries, err := schemaQueriesFor(driver)
+	if err != nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "failed to introspect schema", err)
+	}
+
+	result, err := executor.Execute(queries.tables)
+	if err != nil {
+		return nil, core.NewGowrightError(core.DatabaseError, "failed to list tables", err)
+	}
+
+	schema := &DatabaseSchema{Tables: make([]TableSchema, 0, len(result.Rows))}
+	for _, row := range result.Rows {
+		table, err := loadTableSchema(executor, driver, fmt.Sprint(row["name"]))
+		if err != nil {
+			return nil, err
+		}
+		if table != nil {
+			schema.Tables = append(schema.Tables, *table)
+		}
+	}
+
+	return schema, nil
+}
+
+// loadTableSchema introspects a single table, returning nil if it has no columns
+func loadTableSchema(executor statementExecutor, driver, table string) (*TableSchema, error) {
+	queries, err := schemaQueriesFor(driver)
+	if err != nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "failed to introspect schema", err)
+	}
+
+	wrap := func(message string, err error) error {
+		return core.NewGowrightError(core.DatabaseError, message, err).WithContext("table", table)
+	}
+
+	schemaName, tableName := splitQualifiedName(table, "public")
+	args := queries.args(schemaName, tableName)
+
+	columns, err := executor.Execute(queries.columns, args...)
+	if err != nil {
+		return nil, wrap("failed to read columns", err)
+	}
+	if len(columns.Rows) == 0 {
+		return nil, nil
+	}
+
+	schema := &TableSchema{Name: table, Columns: make([]ColumnSchema, 0, len(columns.Rows))}
+	for _, row := range columns.Rows {
+		column := ColumnSchema{
+			Name:     fmt.Sprint(row["name"]),
+			Type:     fmt.Sprint(row["type"]),
+			Nullable: strings.EqualFold(fmt.Sprint(row["nullable"]), "YES"),
+		}
+		if row["default"] != nil {
+			value := fmt.Sprint(row["default"])
+			column.Default = &value
+		}
+		schema.Columns = append(schema.Columns, column)
+	}
+
+	if schema.PrimaryKey, err = primaryKeyColumns(executor, driver, table); err != nil {
+		return nil, wrap("failed to read primary key", err)
+	}
+
+	indexes, err := executor.Execute(queries.indexes, args...)
+	if err != nil {
+		return nil, wrap("failed to read indexes", err)
+	}
+	for _, row := range indexes.Rows {
+		name := fmt.Sprint(row["name"])
+		if n := len(schema.Indexes); n == 0 || schema.Indexes[n-1].Name != name {
+			schema.Indexes = append(schema.Indexes, IndexSchema{Name: name, Unique: isTruthy(row["unique"])})
+		}
+		last := &schema.Indexes[len(schema.Indexes)-1]
+		last.Columns = append(last.Columns, fmt.Sprint(row["column"]))
+	}
+
+	foreignKeys, err := executor.Execute(queries.foreignKeys, args...)
+	if err != nil {
+		return nil, wrap("failed to read foreign keys", err)
+	}
+	var previous string
+	for _, row := range foreignKeys.Rows {
+		name := fmt.Sprint(row["name"])
+		if len(schema.ForeignKeys) == 0 || name != previous {
+			schema.ForeignKeys = append(schema.ForeignKeys, ForeignKeySchema{ReferencedTable: fmt.Sprint(row["referenced_table"])})
+			previous = name
+		}
+		last := &schema.ForeignKeys[len(schema.ForeignKeys)-1]
+		last.Columns = append(last.Columns, fmt.Sprint(row["column"]))
+		last.ReferencedColumns = append(last.ReferencedColumns, fmt.Sprint(row["referenced_column"]))
+	}
+
+	// Constraint order differs between databases, so keys are sorted for stable comparisons
+	sort.Slice(schema.ForeignKeys, func(i, j int) bool {
+		return describeForeignKey(schema.ForeignKeys[i]) < describeForeignKey(schema.ForeignKeys[j])
+	})
+
+	return schema, nil
+}
+
+// schemaAssertionError creates an assertion error for a schema expectation on a table
+func schemaAssertionError(message, table string) *core.GowrightError {
+	return core.NewGowrightError(core.AssertionError, message, nil).WithContext("table", table)
+}
+
+// columnTypeMatches compares column types case-insensitively, ignoring the length when the expected type has none
+func columnTypeMatches(expected, actual string) bool {
+	expected, actual = strings.ToLower(strings.TrimSpace(expected)), strings.ToLower(strings.TrimSpace(actual))
+	if expected == actual {
+		return true
+	}
+	if !strings.Contains(expected, "(") {
+		if idx := strings.Index(actual, "("); idx >= 0 {
+			return strings.TrimSpace(actual[:idx]) == expected
+		}
+	}
+	return false
+}
+
+// equalColumns compares two column lists case-insensitively and in order
+func equalColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTruthy interprets the boolean-like values drivers return for flags
+func isTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case string:
+		return v == "1" || strings.EqualFold(v, "true") || strings.EqualFold(v, "t")
+	default:
+		return false
+	}
+}
+
+// describeColumn formats a column definition for comparison
+func describeColumn(column ColumnSchema) string {
+	description := column.Type
+	if !column.Nullable {
+		description += " NOT NULL"
+	}
+	if column.Default != nil {
+		description += " DEFAULT " + *column.Default
+	}
+	return description
+}
+
+// describeIndexes formats indexes for comparison; names are included because they are chosen by the migration author
+func describeIndexes(indexes []IndexSchema) []string {
+	descriptions := make([]string, len(indexes))
+	for i, index := range indexes {
+		unique := ""
+		if index.Unique {
+			unique = "unique "
+		}
+		descriptions[i] = fmt.Sprintf("%s%s(%s)", unique, index.Name, strings.Join(index.Columns, ", "))
+	}
+	return descriptions
+}
+
+// describeForeignKeys formats foreign keys for comparison
+func describeForeignKeys(foreignKeys []ForeignKeySchema) []string {
+	descriptions := make([]string, len(foreignKeys))
+	for i, foreignKey := range foreignKeys {
+		descriptions[i] = describeForeignKey(foreignKey)
+	}
+	return descriptions
+}
+
+// describeForeignKey formats a foreign key as (columns) -> table(columns)
+func describeForeignKey(foreignKey ForeignKeySchema) string {
+	return fmt.Sprintf("(%s) -> %s(%s)", strings.Join(foreignKey.Columns, ", "),
+		foreignKey.ReferencedTable, strings.Join(foreignKey.ReferencedColumns, ", "))
+}
+
+// setDifference returns the values of a that do not appear in b
+func setDifference(a, b []string) []string {
+	present := make(map[string]bool, len(b))
+	for _, value := range b {
+		present[value] = true
+	}
+
+	difference := make([]string, 0)
+	for _, value := range a {
+		if !present[value] {
+			difference = append(difference, value)
+		}
+	}
+	return difference
+}
@@ -0,0 +1,208 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createSchemaTables creates tables covering columns, indexes and foreign keys
+func createSchemaTables(t *testing.T, tester *DatabaseTester) {
+	t.Helper()
+
+	for _, query := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email VARCHAR(255) NOT NULL UNIQUE, name TEXT, status TEXT NOT NULL DEFAULT 'active')",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users(id), placed_at TIMESTAMP)",
+		"CREATE INDEX idx_orders_user_placed ON orders (user_id, placed_at)",
+	} {
+		_, err := tester.Execute("test", query)
+		require.NoError(t, err)
+	}
+}
+
+func TestDatabaseTester_Schema(t *testing.T) {
+	tester := newSQLiteTester(t)
+	createSchemaTables(t, tester)
+
+	schema, err := tester.Schema("test")
+	require.NoError(t, err)
+	require.Len(t, schema.Tables, 2)
+	assert.Equal(t, "orders", schema.Tables[0].Name)
+	assert.Equal(t, "users", schema.Tables[1].Name)
+
+	users := schema.Table("users")
+	assert.Equal(t, []string{"id"}, users.PrimaryKey)
+	require.Len(t, users.Columns, 4)
+	assert.Equal(t, ColumnSchema{Name: "email", Type: "VARCHAR(255)", Nullable: false}, users.Columns[1])
+	assert.True(t, users.Column("name").Nullable)
+	require.NotNil(t, users.Column("status").Default)
+	assert.Equal(t, "'active'", *users.Column("status").Default)
+
+	orders := schema.Table("orders")
+	assert.Equal(t, []ForeignKeySchema{
+		{Columns: []string{"user_id"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}},
+	}, orders.ForeignKeys)
+	assert.Equal(t, []IndexSchema{
+		{Name: "idx_orders_user_placed", Columns: []string{"user_id", "placed_at"}},
+	}, orders.Indexes)
+
+	missing, err := tester.TableSchema("test", "missing")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestDatabaseTester_SchemaAssertions(t *testing.T) {
+	tester := newSQLiteTester(t)
+	createSchemaTables(t, tester)
+	notNull := false
+
+	assert.NoError(t, tester.AssertTableExists("test", "users"))
+	assert.NoError(t, tester.AssertColumn("test", "users", "email", ColumnExpectation{Type: "varchar", Nullable: &notNull}))
+	assert.NoError(t, tester.AssertColumn("test", "users", "email", ColumnExpectation{Type: "VARCHAR(255)"}))
+	assert.NoError(t, tester.AssertIndex("test", "users", []string{"email"}, true))
+	assert.NoError(t, tester.AssertIndex("test", "orders", []string{"user_id", "placed_at"}, false))
+	assert.NoError(t, tester.AssertForeignKey("test", "orders", []string{"user_id"}, "users", []string{"id"}))
+
+	tests := []struct {
+		name    string
+		err     error
+		message string
+	}{
+		{"missing table", tester.AssertTableExists("test", "payments"), "table does not exist"},
+		{"missing column", tester.AssertColumn("test", "users", "phone", ColumnExpectation{}), "column does not exist"},
+		{"wrong type", tester.AssertColumn("test", "users", "email", ColumnExpectation{Type: "varchar(100)"}), "column email has type VARCHAR(255), expected varchar(100)"},
+		{"wrong nullability", tester.AssertColumn("test", "users", "name", ColumnExpectation{Nullable: &notNull}), "column name nullable is true, expected false"},
+		{"index not unique", tester.AssertIndex("test", "orders", []string{"user_id", "placed_at"}, true), "unique index not found"},
+		{"index column order", tester.AssertIndex("test", "orders", []string{"placed_at", "user_id"}, false), "index not found"},
+		{"missing foreign key", tester.AssertForeignKey("test", "users", []string{"id"}, "orders", []string{"user_id"}), "foreign key not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.err)
+			assert.Equal(t, tt.message, tt.err.Error())
+		})
+	}
+}
+
+func TestDiffSchemas(t *testing.T) {
+	expected := &DatabaseSchema{Tables: []TableSchema{
+		{
+			Name:       "users",
+			Columns:    []ColumnSchema{{Name: "id", Type: "INTEGER"}, {Name: "email", Type: "TEXT"}},
+			PrimaryKey: []string{"id"},
+			Indexes:    []IndexSchema{{Name: "idx_email", Columns: []string{"email"}, Unique: true}},
+		},
+		{Name: "sessions", Columns: []ColumnSchema{{Name: "id", Type: "TEXT"}}},
+	}}
+	actual := &DatabaseSchema{Tables: []TableSchema{
+		{
+			Name:       "users",
+			Columns:    []ColumnSchema{{Name: "id", Type: "INTEGER"}, {Name: "email", Type: "TEXT", Nullable: true}, {Name: "name", Type: "TEXT"}},
+			PrimaryKey: []string{"id"},
+		},
+		{Name: "audit", Columns: []ColumnSchema{{Name: "id", Type: "INTEGER"}}},
+	}}
+
+	assert.Equal(t, []string{
+		"table users: column email is TEXT, expected TEXT NOT NULL",
+		"table users: unexpected column name",
+		"table users: index unique idx_email(email) missing",
+		"table sessions: missing",
+		"table audit: unexpected",
+	}, DiffSchemas(expected, actual))
+
+	assert.Empty(t, DiffSchemas(expected, expected))
+}
+
+// writeMigrations writes migration files into a new directory and returns it
+func writeMigrations(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	return dir
+}
+
+func TestLoadMigrations(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"10_add_orders.up.sql":    "CREATE TABLE orders (id INTEGER PRIMARY KEY);",
+		"10_add_orders.down.sql":  "DROP TABLE orders;",
+		"2_create_users.up.sql":   "CREATE TABLE users (id INTEGER PRIMARY KEY);",
+		"2_create_users.down.sql": "DROP TABLE users;",
+		"README.md":               "ignored",
+	})
+
+	migrations, err := LoadMigrations(dir)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, "2", migrations[0].Version)
+	assert.Equal(t, "create_users", migrations[0].Name)
+	assert.Equal(t, "10", migrations[1].Version)
+	assert.Equal(t, "DROP TABLE orders;", migrations[1].Down)
+
+	_, err = LoadMigrations(writeMigrations(t, map[string]string{"1_users.up.sql": "CREATE TABLE users (id INTEGER);"}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires both up and down scripts")
+
+	_, err = LoadMigrations(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestDatabaseTester_VerifyMigrations(t *testing.T) {
+	migrations := map[string]string{
+		"001_create_users.up.sql": `CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL);
+			CREATE UNIQUE INDEX idx_users_email ON users (email);`,
+		"001_create_users.down.sql":  "DROP TABLE users;",
+		"002_create_orders.up.sql":   "CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users(id));",
+		"002_create_orders.down.sql": "DROP TABLE orders;",
+	}
+	golden := filepath.Join(t.TempDir(), "golden", "schema.json")
+
+	tester := newSQLiteTester(t)
+	schema, err := tester.VerifyMigrations("test", MigrationVerification{
+		Dir:          writeMigrations(t, migrations),
+		GoldenSchema: golden,
+		UpdateGolden: true,
+	})
+	require.NoError(t, err)
+	assert.Len(t, schema.Tables, 2)
+	assert.FileExists(t, golden)
+
+	// A fresh database reproduces the golden schema
+	tester = newSQLiteTester(t)
+	_, err = tester.VerifyMigrations("test", MigrationVerification{Dir: writeMigrations(t, migrations), GoldenSchema: golden})
+	assert.NoError(t, err)
+
+	// The database must start empty
+	_, err = tester.VerifyMigrations("test", MigrationVerification{Dir: writeMigrations(t, migrations)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires an empty database")
+
+	t.Run("irreversible down", func(t *testing.T) {
+		broken := map[string]string{
+			"001_create_users.up.sql":   "CREATE TABLE users (id INTEGER PRIMARY KEY); CREATE TABLE audit (id INTEGER);",
+			"001_create_users.down.sql": "DROP TABLE users;",
+		}
+		_, err := newSQLiteTester(t).VerifyMigrations("test", MigrationVerification{Dir: writeMigrations(t, broken)})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "down did not restore the previous schema: table audit: unexpected")
+	})
+
+	t.Run("golden mismatch", func(t *testing.T) {
+		changed := map[string]string{
+			"001_create_users.up.sql":   "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);",
+			"001_create_users.down.sql": "DROP TABLE users;",
+		}
+		_, err := newSQLiteTester(t).VerifyMigrations("test", MigrationVerification{Dir: writeMigrations(t, changed), GoldenSchema: golden})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "schema does not match golden snapshot")
+		assert.Contains(t, err.Error(), "table users: column email is TEXT, expected TEXT NOT NULL")
+		assert.Contains(t, err.Error(), "table orders: missing")
+	})
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"time"
+
+	"github.com/gowright/framework/pkg/assertions"
+	"github.com/gowright/framework/pkg/core"
+)
+
+// SnapshotSource selects the rows captured for one entry of a snapshot
+type SnapshotSource struct {
+	// Name identifies the entry in diffs; it is also the table read when Query is empty
+	Name string `json:"name"`
+
+	// Query captures the rows of an arbitrary query instead of the whole table
+	Query string `json:"query,omitempty"`
+
+	// Key lists the columns that identify a row; tables default to their primary key.
+	// Without a key, rows are matched on their full contents.
+	Key []string `json:"key,omitempty"`
+}
+
+// TableSnapshot holds the rows captured for one snapshot source
+type TableSnapshot struct {
+	Source SnapshotSource           `json:"source"`
+	Rows   []map[string]interface{} `json:"rows"`
+}
+
+// Snapshot holds the contents of a set of tables or queries captured at one point in time
+type Snapshot struct {
+	Tables     []*TableSnapshot `json:"tables"`
+	CapturedAt time.Time        `json:"captured_at"`
+	executor   statementExecutor
+	driver     string
+}
+
+// Snapshot captures the rows selected by each source on the named connection
+func (dt *DatabaseTester) Snapshot(connectionName string, sources ...SnapshotSource) (*Snapshot, error) {
+	if _, err := dt.getPool(connectionName); err != nil {
+		return nil, err
+	}
+
+	executor := &connectionExecutor{tester: dt, connectionName: connectionName}
+	return takeSnapshot(executor, dt.config.Connections[connectionName].Driver, sources)
+}
+
+// SnapshotTables captures the full contents of the given tables, keyed by their primary keys
+func (dt *DatabaseTester) SnapshotTables(connectionName string, tables ...string) (*Snapshot, error) {
+	return dt.Snapshot(connectionName, tableSources(tables)...)
+}
+
+// SnapshotData captures the full contents of the given tables for the generic database test runner
+func (dt *DatabaseTester) SnapshotData(connectionName string, tables ...string) (core.DataSnapshot, error) {
+	snapshot, err := dt.SnapshotTables(connectionName, tables...)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// SnapshotDataInTransaction captures the full contents of the given tables through tx, so the
+// snapshot sees the transaction's uncommitted changes
+func (dt *DatabaseTester) SnapshotDataInTransaction(tx core.Transaction, connectionName string, tables ...string) (core.DataSnapshot, error) {
+	if _, err := dt.getPool(connectionName); err != nil {
+		return nil, err
+	}
+	snapshot, err := takeSnapshot(tx, dt.config.Connections[connectionName].Driver, tableSources(tables))
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// takeTestSnapshot captures a database test's snapshot tables through the executor running the test
+func (dt *DatabaseTester) takeTestSnapshot(executor statementExecutor, test *core.DatabaseTest) (*Snapshot, error) {
+	if _, err := dt.getPool(test.Connection); err != nil {
+		return nil, err
+	}
+
+	return takeSnapshot(executor, dt.config.Connections[test.Connection].Driver, tableSources(test.Snapshot))
+}
+
+// tableSources returns snapshot sources reading whole tables
+func tableSources(tables []string) []SnapshotSource {
+	sources := make([]SnapshotSource, len(tables))
+	for i, table := range tables {
+		sources[i] = SnapshotSource{Name: table}
+	}
+	return sources
+}
+
+// takeSnapshot resolves missing table keys and captures every source
+func takeSnapshot(executor statementExecutor, driver string, sources []SnapshotSource) (*Snapshot, error) {
+	resolved := make([]SnapshotSource, len(sources))
+	for i, source := range sources {
+		if source.Name == "" {
+			return nil, core.NewGowrightError(core.ConfigurationError, "snapshot source requires a name", nil)
+		}
+
+		if source.Query == "" && len(source.Key) == 0 {
+			key, err := primaryKeyColumns(executor, driver, source.Name)
+			if err != nil {
+				return nil, core.NewGowrightError(core.DatabaseError, "failed to read primary key", err).
+					WithContext("table", source.Name)
+			}
+			source.Key = key
+		}
+		resolved[i] = source
+	}
+
+	return captureSnapshot(executor, driver, resolved)
+}
+
+// captureSnapshot reads the rows of already resolved sources
+func captureSnapshot(executor statementExecutor, driver string, sources []SnapshotSource) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		Tables:     make([]*TableSnapshot, 0, len(sources)),
+		CapturedAt: time.Now(),
+		executor:   executor,
+		driver:     driver,
+	}
+
+	for _, source := range sources {
+		query := source.Query
+		if query == "" {
+			query = "SELECT * FROM " + quoteIdentifier(driver, source.Name)
+		}
+
+		result, err := executor.Execute(query)
+		if err != nil {
+			return nil, core.NewGowrightError(core.DatabaseError, "failed to capture snapshot", err).
+				WithContext("source", source.Name)
+		}
+		snapshot.Tables = append(snapshot.Tables, &TableSnapshot{Source: source, Rows: result.Rows})
+	}
+
+	return snapshot, nil
+}
+
+// Table returns the captured rows of the named source, or nil if it was not captured
+func (s *Snapshot) Table(name string) *TableSnapshot {
+	for _, table := range s.Tables {
+		if table.Source.Name == name {
+			return table
+		}
+	}
+	return nil
+}
+
+// Capture takes a new snapshot of the same sources on the same connection or transaction
+func (s *Snapshot) Capture() (*Snapshot, error) {
+	sources := make([]SnapshotSource, len(s.Tables))
+	for i, table := range s.Tables {
+		sources[i] = table.Source
+	}
+	return captureSnapshot(s.executor, s.driver, sources)
+}
+
+// Diff compares this snapshot with a later one and returns the inserted, updated and deleted rows of each source
+func (s *Snapshot) Diff(after *Snapshot) *core.DataDiff {
+	diff := &core.DataDiff{Tables: make([]core.TableChanges, 0, len(s.Tables))}
+	for _, table := range s.Tables {
+		var afterRows []map[string]interface{}
+		if afterTable := after.Table(table.Source.Name); afterTable != nil {
+			afterRows = afterTable.Rows
+		}
+		diff.Tables = append(diff.Tables, assertions.DiffRows(table.Source.Name, table.Rows, afterRows, table.Source.Key))
+	}
+	return diff
+}
+
+// Changes captures the sources again and returns the changes made since this snapshot
+func (s *Snapshot) Changes() (*core.DataDiff, error) {
+	after, err := s.Capture()
+	if err != nil {
+		return nil, err
+	}
+	return s.Diff(after), nil
+}
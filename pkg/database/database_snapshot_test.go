@@ -0,0 +1,170 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/gowright/framework/pkg/config"
+	"github.com/gowright/framework/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createSnapshotSchema creates an accounts table keyed by id and an unkeyed events table
+func createSnapshotSchema(t *testing.T, tester *DatabaseTester) {
+	t.Helper()
+
+	for _, query := range []string{
+		"CREATE TABLE accounts (id INTEGER PRIMARY KEY, owner TEXT NOT NULL, balance INTEGER NOT NULL)",
+		"CREATE TABLE events (kind TEXT NOT NULL)",
+		"INSERT INTO accounts (id, owner, balance) VALUES (1, 'alice', 100), (2, 'bob', 50), (3, 'carol', 0)",
+		"INSERT INTO events (kind) VALUES ('opened')",
+	} {
+		_, err := tester.Execute("test", query)
+		require.NoError(t, err)
+	}
+}
+
+func TestDatabaseTester_SnapshotTables(t *testing.T) {
+	tester := newSQLiteTester(t)
+	createSnapshotSchema(t, tester)
+
+	before, err := tester.SnapshotTables("test", "accounts", "events")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id"}, before.Table("accounts").Source.Key)
+	assert.Empty(t, before.Table("events").Source.Key)
+	assert.Len(t, before.Table("accounts").Rows, 3)
+
+	for _, query := range []string{
+		"UPDATE accounts SET balance = 70 WHERE id = 1",
+		"DELETE FROM accounts WHERE id = 3",
+		"INSERT INTO accounts (id, owner, balance) VALUES (4, 'dave', 30)",
+		"INSERT INTO events (kind) VALUES ('transfer')",
+	} {
+		_, err := tester.Execute("test", query)
+		require.NoError(t, err)
+	}
+
+	diff, err := before.Changes()
+	require.NoError(t, err)
+
+	accounts := diff.Table("accounts")
+	assert.Equal(t, []map[string]interface{}{{"id": int64(4), "owner": "dave", "balance": int64(30)}}, accounts.Inserted)
+	assert.Equal(t, []map[string]interface{}{{"id": int64(3), "owner": "carol", "balance": int64(0)}}, accounts.Deleted)
+	require.Len(t, accounts.Updated, 1)
+	assert.Equal(t, []core.ColumnChange{{Column: "balance", Before: int64(100), After: int64(70)}}, accounts.Updated[0].Columns)
+
+	events := diff.Table("events")
+	assert.Equal(t, []map[string]interface{}{{"kind": "transfer"}}, events.Inserted)
+	assert.Empty(t, events.Deleted)
+}
+
+func TestDatabaseTester_SnapshotData(t *testing.T) {
+	tester := newSQLiteTester(t)
+	createSnapshotSchema(t, tester)
+
+	var snapshotter core.DataSnapshotter = tester
+	snapshot, err := snapshotter.SnapshotData("test", "accounts")
+	require.NoError(t, err)
+	_, err = tester.Execute("test", "DELETE FROM accounts WHERE id = 2")
+	require.NoError(t, err)
+
+	diff, err := snapshot.Changes()
+	require.NoError(t, err)
+	assert.Len(t, diff.Table("accounts").Deleted, 1)
+
+	snapshot, err = snapshotter.SnapshotData("missing", "accounts")
+	assert.Error(t, err)
+	assert.Nil(t, snapshot)
+}
+
+func TestDatabaseTester_SnapshotDataInTransaction(t *testing.T) {
+	tester := newSQLiteTester(t)
+	createSnapshotSchema(t, tester)
+
+	tx, err := tester.BeginTransaction("test")
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+
+	// The in-memory database has a single connection, which the transaction holds
+	snapshot, err := tester.SnapshotDataInTransaction(tx, "test", "accounts")
+	require.NoError(t, err)
+	_, err = tx.Execute("INSERT INTO accounts (id, owner, balance) VALUES (4, 'dave', 5)")
+	require.NoError(t, err)
+
+	diff, err := snapshot.Changes()
+	require.NoError(t, err)
+	require.Len(t, diff.Table("accounts").Inserted, 1)
+	assert.Equal(t, "dave", diff.Table("accounts").Inserted[0]["owner"])
+}
+
+func TestDatabaseTester_Snapshot_Query(t *testing.T) {
+	tester := newSQLiteTester(t)
+	createSnapshotSchema(t, tester)
+
+	before, err := tester.Snapshot("test", SnapshotSource{
+		Name:  "rich",
+		Query: "SELECT owner, balance FROM accounts WHERE balance >= 50",
+		Key:   []string{"owner"},
+	})
+	require.NoError(t, err)
+
+	_, err = tester.Execute("test", "UPDATE accounts SET balance = balance + 10")
+	require.NoError(t, err)
+
+	diff, err := before.Changes()
+	require.NoError(t, err)
+
+	rich := diff.Table("rich")
+	assert.Empty(t, rich.Inserted)
+	assert.Empty(t, rich.Deleted)
+	assert.Len(t, rich.Updated, 2)
+
+	_, err = tester.Snapshot("test", SnapshotSource{Query: "SELECT 1"})
+	assert.Error(t, err)
+
+	_, err = tester.SnapshotTables("test", "missing")
+	assert.Error(t, err)
+}
+
+func TestDatabaseTester_ExecuteTest_Snapshot(t *testing.T) {
+	tester := newSQLiteTester(t)
+	createSnapshotSchema(t, tester)
+
+	test := &core.DatabaseTest{
+		Name:       "transfer",
+		Connection: "test",
+		Snapshot:   []string{"accounts", "events"},
+		Query:      "UPDATE accounts SET balance = balance - 20 WHERE id = 1",
+		Expected: &core.DatabaseExpectation{
+			RowsAffected: 1,
+			Changes: core.ExpectedChanges{
+				"accounts": {
+					Updated: []core.ExpectedRowUpdate{
+						{Key: map[string]interface{}{"id": 1}, Changes: map[string]interface{}{"balance": 80}},
+					},
+				},
+			},
+		},
+		Isolation: config.DatabaseIsolationTransaction,
+	}
+
+	result := tester.ExecuteTest(test)
+	assert.Equal(t, core.TestStatusPassed, result.Status, "%v", result.Error)
+
+	// The update touches every account, so the change set no longer matches
+	test.Query = "UPDATE accounts SET balance = balance - 20"
+	result = tester.ExecuteTest(test)
+	assert.Equal(t, core.TestStatusFailed, result.Status)
+	require.Error(t, result.Error)
+	assert.Contains(t, result.Error.Error(), "accounts: 0 inserted, 3 updated, 0 deleted")
+
+	var failed []core.AssertionStep
+	for _, step := range result.Steps {
+		if step.Status == core.TestStatusFailed {
+			failed = append(failed, step)
+		}
+	}
+	require.Len(t, failed, 2)
+	assert.Equal(t, "Data changes: accounts", failed[1].Description)
+	assert.Contains(t, failed[1].Error.Error(), "unexpected update of {id: 2}: balance: 50 -> 30")
+}
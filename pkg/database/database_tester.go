@@ -2,6 +2,13 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gowright/framework/pkg/assertions"
@@ -11,19 +18,59 @@ import (
 
 // DatabaseTester implements the DatabaseTester interface
 type DatabaseTester struct {
-	config      *config.DatabaseConfig
-	asserter    *assertions.Asserter
-	initialized bool
-	// Database connection fields would go here
+	config          *config.DatabaseConfig
+	asserter        *assertions.Asserter
+	pools           map[string]*DatabasePool
+	resourceManager *core.ResourceManager
+	mutex           sync.RWMutex
+	initialized     bool
+}
+
+// queryExecutor is satisfied by *sql.DB, *sql.Conn and *sql.Tx
+type queryExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 }
 
 // NewDatabaseTester creates a new database tester instance
 func NewDatabaseTester() *DatabaseTester {
 	return &DatabaseTester{
 		asserter: assertions.NewAsserter(),
+		pools:    make(map[string]*DatabasePool),
 	}
 }
 
+// SetResourceManager registers connection pools with a resource manager, which reports
+// their statistics and detects leaked connection leases. Pools opened earlier are registered immediately.
+func (dt *DatabaseTester) SetResourceManager(rm *core.ResourceManager) error {
+	dt.mutex.Lock()
+	defer dt.mutex.Unlock()
+
+	dt.resourceManager = rm
+	if rm == nil {
+		return nil
+	}
+
+	for _, name := range sortedPoolNames(dt.pools) {
+		if err := rm.RegisterResource(dt.pools[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PoolStats returns the statistics of every open connection pool keyed by connection name
+func (dt *DatabaseTester) PoolStats() map[string]*DBPoolStats {
+	dt.mutex.RLock()
+	defer dt.mutex.RUnlock()
+
+	stats := make(map[string]*DBPoolStats, len(dt.pools))
+	for name, pool := range dt.pools {
+		stats[name] = pool.GetStats()
+	}
+	return stats
+}
+
 // Initialize sets up the database tester with configuration
 func (dt *DatabaseTester) Initialize(cfg interface{}) error {
 	dbConfig, ok := cfg.(*config.DatabaseConfig)
@@ -38,17 +85,31 @@ func (dt *DatabaseTester) Initialize(cfg interface{}) error {
 	dt.config = dbConfig
 	dt.initialized = true
 
-	// Initialize database connections here
-	// This would involve setting up database drivers and connection pools
-
+	// Connections are opened lazily on first use so that unreachable
+	// databases only fail the tests that actually need them.
 	return nil
 }
 
 // Cleanup performs cleanup operations
 func (dt *DatabaseTester) Cleanup() error {
-	// Close database connections, cleanup resources
+	dt.mutex.Lock()
+	defer dt.mutex.Unlock()
+
+	var firstErr error
+	for _, name := range sortedPoolNames(dt.pools) {
+		pool := dt.pools[name]
+		if err := pool.Cleanup(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if dt.resourceManager != nil {
+			// The pool is already closed, so unregistering only updates the manager's bookkeeping
+			_ = dt.resourceManager.UnregisterResource(pool.GetID())
+		}
+	}
+
+	dt.pools = make(map[string]*DatabasePool)
 	dt.initialized = false
-	return nil
+	return firstErr
 }
 
 // GetName returns the name of the tester
@@ -58,63 +119,35 @@ func (dt *DatabaseTester) GetName() string {
 
 // Connect establishes a connection to the database
 func (dt *DatabaseTester) Connect(connectionName string) error {
-	if !dt.initialized {
-		return core.NewGowrightError(core.DatabaseError, "database tester not initialized", nil)
-	}
-
-	// Check if connection exists in configuration
-	if dt.config == nil || dt.config.Connections == nil {
-		return core.NewGowrightError(core.ConfigurationError, "no database connections configured", nil)
-	}
-
-	if _, exists := dt.config.Connections[connectionName]; !exists {
-		return core.NewGowrightError(core.ConfigurationError, "connection not found: "+connectionName, nil)
-	}
-
-	// Implementation would establish database connection
-	return nil
+	_, err := dt.getPool(connectionName)
+	return err
 }
 
 // Execute executes a SQL query and returns the result
 func (dt *DatabaseTester) Execute(connectionName, query string, args ...interface{}) (*core.DatabaseResult, error) {
-	if !dt.initialized {
-		return nil, core.NewGowrightError(core.DatabaseError, "database tester not initialized", nil)
+	pool, err := dt.getPool(connectionName)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check if connection exists in configuration
-	if dt.config == nil || dt.config.Connections == nil {
-		return nil, core.NewGowrightError(core.ConfigurationError, "no database connections configured", nil)
-	}
+	ctx, cancel := dt.queryContext()
+	defer cancel()
 
-	if _, exists := dt.config.Connections[connectionName]; !exists {
-		return nil, core.NewGowrightError(core.ConfigurationError, "connection not found: "+connectionName, nil)
-	}
-
-	// Implementation would execute SQL query
-	// For testing purposes, return realistic test data
-	result := &core.DatabaseResult{
-		Duration: 50 * time.Millisecond,
+	conn, err := pool.AcquireConnection(ctx)
+	if err != nil {
+		return nil, err
 	}
-
-	// Simulate different query results based on query content
-	switch query {
-	case "SELECT 1 as result":
-		result.Rows = []map[string]interface{}{
-			{"result": 1},
-		}
-		result.RowCount = 1
-		result.RowsAffected = 0
-	case "SELECT COUNT(*) as count FROM temp_test":
-		result.Rows = []map[string]interface{}{
-			{"count": 0},
+	defer func() {
+		if err := pool.ReleaseConnection(conn); err != nil {
+			fmt.Printf("Error releasing database connection: %v\n", err)
 		}
-		result.RowCount = 1
-		result.RowsAffected = 0
-	default:
-		// Default case for other queries (setup/teardown)
-		result.Rows = make([]map[string]interface{}, 0)
-		result.RowCount = 0
-		result.RowsAffected = 1
+	}()
+
+	result, err := runQuery(ctx, conn, query, args...)
+	if err != nil {
+		return nil, core.NewGowrightError(core.DatabaseError, "query execution failed", err).
+			WithContext("connection", connectionName).
+			WithContext("query", query)
 	}
 
 	return result, nil
@@ -122,22 +155,46 @@ func (dt *DatabaseTester) Execute(connectionName, query string, args ...interfac
 
 // BeginTransaction starts a new database transaction
 func (dt *DatabaseTester) BeginTransaction(connectionName string) (core.Transaction, error) {
-	if !dt.initialized {
-		return nil, core.NewGowrightError(core.DatabaseError, "database tester not initialized", nil)
+	pool, err := dt.getPool(connectionName)
+	if err != nil {
+		return nil, err
 	}
 
-	// Implementation would begin database transaction
-	return &DatabaseTransaction{}, nil
+	var timeout time.Duration
+	if dt.config != nil {
+		timeout = dt.config.TransactionTimeout
+	}
+
+	// The transaction holds its connection lease until it commits or rolls back
+	conn, err := pool.AcquireConnection(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := beginTransaction(conn, connectionName, timeout, dt.queryTimeout(), func() error {
+		return pool.ReleaseConnection(conn)
+	})
+	if err != nil {
+		if releaseErr := pool.ReleaseConnection(conn); releaseErr != nil {
+			fmt.Printf("Error releasing database connection: %v\n", releaseErr)
+		}
+		return nil, err
+	}
+
+	// Cancelling the transaction context rolls it back if the pool is cleaned up first
+	pool.abortOnCleanup(conn, tx.cancel)
+
+	return tx, nil
 }
 
 // ValidateData validates data against expected results
 func (dt *DatabaseTester) ValidateData(connectionName, query string, expected interface{}) error {
-	if !dt.initialized {
-		return core.NewGowrightError(core.DatabaseError, "database tester not initialized", nil)
+	result, err := dt.Execute(connectionName, query)
+	if err != nil {
+		return err
 	}
 
-	// Implementation would validate database data
-	return nil
+	return validateExpected(result, expected)
 }
 
 // ExecuteTest executes a database test and returns the result
@@ -149,11 +206,82 @@ func (dt *DatabaseTester) ExecuteTest(test *core.DatabaseTest) *core.TestCaseRes
 		Status:    core.TestStatusPassed,
 	}
 
-	dt.asserter.Reset()
+	// Each run records into its own asserter so tests can execute concurrently
+	asserter := assertions.NewAsserter()
+
+	var executor statementExecutor = &connectionExecutor{tester: dt, connectionName: test.Connection}
+	execute := func(query string) (*core.DatabaseResult, error) {
+		return executor.Execute(query)
+	}
+
+	isolation, err := core.ResolveDatabaseIsolation(test, dt)
+	if err != nil {
+		result.Status = core.TestStatusError
+		result.Error = err
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime)
+		return result
+	}
+
+	if isolation == config.DatabaseIsolationTransaction {
+		tx, err := dt.BeginTransaction(test.Connection)
+		if err != nil {
+			result.Status = core.TestStatusError
+			result.Error = err
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(result.StartTime)
+			return result
+		}
+
+		// The transaction is always rolled back so the test leaves no data behind
+		defer func() {
+			if err := tx.Rollback(); err != nil && result.Error == nil {
+				result.Error = core.NewGowrightError(core.DatabaseError, "isolation rollback failed", err)
+			}
+		}()
+
+		executor = tx
+	}
 
 	// Execute setup queries
 	for _, setupQuery := range test.Setup {
-		if _, err := dt.Execute(test.Connection, setupQuery); err != nil {
+		if _, err := execute(setupQuery); err != nil {
+			result.Status = core.TestStatusError
+			result.Error = err
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(result.StartTime)
+			return result
+		}
+	}
+
+	// Load fixtures once setup has created the schema; inside an isolation transaction they are rolled back with it
+	var fixtures *LoadedFixtures
+	if len(test.Fixtures) > 0 {
+		fixtures, err = dt.loadTestFixtures(executor, test)
+		if err != nil {
+			result.Status = core.TestStatusError
+			result.Error = err
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(result.StartTime)
+			return result
+		}
+		if isolation == config.DatabaseIsolationTransaction {
+			fixtures = nil
+		} else {
+			defer func() {
+				if err := fixtures.Restore(); err != nil {
+					fmt.Printf("Error restoring fixtures: %v\n", err)
+				}
+			}()
+		}
+	}
+
+	// Capture the tables whose changes the test asserts on
+	var snapshot *Snapshot
+	var changesErr error
+	if len(test.Snapshot) > 0 {
+		snapshot, err = dt.takeTestSnapshot(executor, test)
+		if err != nil {
 			result.Status = core.TestStatusError
 			result.Error = err
 			result.EndTime = time.Now()
@@ -163,7 +291,7 @@ func (dt *DatabaseTester) ExecuteTest(test *core.DatabaseTest) *core.TestCaseRes
 	}
 
 	// Execute main query
-	queryResult, err := dt.Execute(test.Connection, test.Query)
+	query, args, err := dt.ResolveQuery(test.Connection, test.QuerySource())
 	if err != nil {
 		result.Status = core.TestStatusError
 		result.Error = err
@@ -172,71 +300,289 @@ func (dt *DatabaseTester) ExecuteTest(test *core.DatabaseTest) *core.TestCaseRes
 		return result
 	}
 
-	// Validate results against expectations
-	if test.Expected != nil {
-		dt.validateResult(queryResult, test.Expected)
+	var queryResult *core.DatabaseResult
+	var pollErr error
+	if test.Eventually != nil && test.Expected != nil {
+		// Each poll attempt is recorded as a step, so the expectation is not validated again
+		poll, err := pollQuery(executor, query, args, test.Expected, test.Eventually)
+		if poll == nil {
+			result.Status = core.TestStatusError
+			result.Error = err
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(result.StartTime)
+			return result
+		}
+		for _, step := range poll.Steps {
+			asserter.AddStep(step)
+		}
+		queryResult, pollErr = poll.Result, err
+	} else {
+		queryResult, err = executor.Execute(query, args...)
+		if err != nil {
+			result.Status = core.TestStatusError
+			result.Error = err
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(result.StartTime)
+			return result
+		}
+
+		// Validate results against expectations
+		if test.Expected != nil {
+			validateInto(asserter, queryResult, test.Expected)
+		}
+	}
+
+	// Validate the data changes made by the query
+	if snapshot != nil {
+		changes, err := snapshot.Changes()
+		if err != nil {
+			result.Status = core.TestStatusError
+			result.Error = err
+			result.EndTime = time.Now()
+			result.Duration = result.EndTime.Sub(result.StartTime)
+			return result
+		}
+
+		var expected core.ExpectedChanges
+		if test.Expected != nil {
+			expected = test.Expected.Changes
+		}
+		if !asserter.DataChanges(expected, changes, "Data changes") {
+			// Carry the rendered diff on the test error so reports show what changed
+			changesErr = errors.New("unexpected data changes:\n" + changes.String())
+		}
+	}
+
+	// Restore fixture tables before teardown, which may drop them
+	if fixtures != nil {
+		if err := fixtures.Restore(); err != nil {
+			result.Error = err
+		}
 	}
 
 	// Execute teardown queries
 	for _, teardownQuery := range test.Teardown {
-		if _, err := dt.Execute(test.Connection, teardownQuery); err != nil {
+		if _, err := execute(teardownQuery); err != nil {
 			// Log teardown errors but don't fail the test
 			result.Error = core.NewGowrightError(core.DatabaseError, "teardown query failed", err)
 		}
 	}
 
 	// Check for assertion failures
-	if dt.asserter.HasFailures() {
+	if asserter.HasFailures() {
 		result.Status = core.TestStatusFailed
-		result.Error = core.NewGowrightError(core.AssertionError, "one or more assertions failed", nil)
+		result.Error = core.NewGowrightError(core.AssertionError, "one or more assertions failed", errors.Join(pollErr, changesErr))
 	}
 
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
-	result.Steps = dt.asserter.GetSteps()
+	result.Steps = asserter.GetSteps()
 
 	return result
 }
 
+// DefaultIsolation returns the suite-level isolation mode for database tests
+func (dt *DatabaseTester) DefaultIsolation() string {
+	if dt.config != nil && dt.config.TestIsolation != "" {
+		return dt.config.TestIsolation
+	}
+	return config.DatabaseIsolationNone
+}
+
 // validateResult validates database result against expectations
 func (dt *DatabaseTester) validateResult(result *core.DatabaseResult, expected *core.DatabaseExpectation) {
+	validateInto(dt.asserter, result, expected)
+}
+
+// validateInto records assertion steps comparing a database result with expectations
+func validateInto(asserter *assertions.Asserter, result *core.DatabaseResult, expected *core.DatabaseExpectation) {
 	// Validate row count
 	if expected.RowCount != 0 {
-		dt.asserter.Equal(expected.RowCount, result.RowCount, "Row count validation")
+		asserter.Equal(expected.RowCount, result.RowCount, "Row count validation")
 	}
 
 	// Validate rows affected
 	if expected.RowsAffected != 0 {
-		dt.asserter.Equal(expected.RowsAffected, result.RowsAffected, "Rows affected validation")
+		asserter.Equal(expected.RowsAffected, result.RowsAffected, "Rows affected validation")
 	}
 
-	// Additional row content validations would go here
+	// Validate row contents
+	if expected.Rows != nil {
+		asserter.RowsMatch(expected.Rows, result.Rows, expected.Match, "Row content validation")
+	}
 }
 
-// DatabaseTransaction implements the Transaction interface
-type DatabaseTransaction struct {
-	// Transaction-specific fields would go here
+// getPool returns the connection pool for a named connection, opening it on first use
+func (dt *DatabaseTester) getPool(connectionName string) (*DatabasePool, error) {
+	if !dt.initialized {
+		return nil, core.NewGowrightError(core.DatabaseError, "database tester not initialized", nil)
+	}
+
+	// Check if connection exists in configuration
+	if dt.config == nil || dt.config.Connections == nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "no database connections configured", nil)
+	}
+
+	connConfig, exists := dt.config.Connections[connectionName]
+	if !exists || connConfig == nil {
+		return nil, core.NewGowrightError(core.ConfigurationError, "connection not found: "+connectionName, nil)
+	}
+
+	dt.mutex.RLock()
+	pool, open := dt.pools[connectionName]
+	dt.mutex.RUnlock()
+	if open {
+		return pool, nil
+	}
+
+	dt.mutex.Lock()
+	defer dt.mutex.Unlock()
+
+	// Another goroutine may have connected while we waited for the lock
+	if pool, open := dt.pools[connectionName]; open {
+		return pool, nil
+	}
+
+	pool, err := NewDatabasePool(connectionName, connConfig, dt.config, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Initialize(); err != nil {
+		return nil, err
+	}
+
+	if dt.resourceManager != nil {
+		if err := dt.resourceManager.RegisterResource(pool); err != nil {
+			_ = pool.Cleanup()
+			return nil, err
+		}
+	}
+
+	dt.pools[connectionName] = pool
+	return pool, nil
 }
 
-// Commit commits the transaction
-func (dt *DatabaseTransaction) Commit() error {
-	// Implementation would commit the transaction
-	return nil
+// sortedPoolNames returns the connection names of the pools in sorted order
+func sortedPoolNames(pools map[string]*DatabasePool) []string {
+	names := make([]string, 0, len(pools))
+	for name := range pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
-// Rollback rolls back the transaction
-func (dt *DatabaseTransaction) Rollback() error {
-	// Implementation would rollback the transaction
-	return nil
+// queryContext returns a context bounded by the configured query timeout
+func (dt *DatabaseTester) queryContext() (context.Context, context.CancelFunc) {
+	return withOptionalTimeout(context.Background(), dt.queryTimeout())
+}
+
+// queryTimeout returns the configured per-query timeout, or zero when unbounded
+func (dt *DatabaseTester) queryTimeout() time.Duration {
+	if dt.config == nil {
+		return 0
+	}
+	return dt.config.QueryTimeout
+}
+
+// withOptionalTimeout derives a context with the given timeout, or a plain cancelable context when timeout is zero
+func withOptionalTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(parent, timeout)
+	}
+	return context.WithCancel(parent)
 }
 
-// Execute executes a query within the transaction
-func (dt *DatabaseTransaction) Execute(query string, args ...interface{}) (*core.DatabaseResult, error) {
-	// Implementation would execute query within transaction
-	return &core.DatabaseResult{
-		Rows:         make([]map[string]interface{}, 0),
-		RowCount:     0,
-		RowsAffected: 0,
-		Duration:     50 * time.Millisecond,
-	}, nil
+// runQuery executes a statement and collects its rows or affected row count
+func runQuery(ctx context.Context, executor queryExecutor, query string, args ...interface{}) (*core.DatabaseResult, error) {
+	startTime := time.Now()
+	result := &core.DatabaseResult{
+		Rows: make([]map[string]interface{}, 0),
+	}
+
+	if returnsRows(query) {
+		rows, err := executor.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				fmt.Printf("Error closing result set: %v\n", closeErr)
+			}
+		}()
+
+		result.Rows, err = scanRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		result.RowCount = len(result.Rows)
+	} else {
+		execResult, err := executor.ExecContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		// Not every driver reports affected rows for every statement type
+		if affected, err := execResult.RowsAffected(); err == nil {
+			result.RowsAffected = affected
+		}
+		if lastID, err := execResult.LastInsertId(); err == nil {
+			result.LastInsertID = lastID
+		}
+	}
+
+	result.Duration = time.Since(startTime)
+	return result, nil
+}
+
+// validateExpected checks a query result against an expectation passed to ValidateData
+func validateExpected(result *core.DatabaseResult, expected interface{}) error {
+	switch exp := expected.(type) {
+	case nil:
+		return nil
+	case *core.DatabaseExpectation:
+		if exp == nil {
+			return nil
+		}
+		return validateExpectation(result, exp)
+	case core.DatabaseExpectation:
+		return validateExpectation(result, &exp)
+	case []map[string]interface{}:
+		return validateExpectation(result, &core.DatabaseExpectation{Rows: exp})
+	case int:
+		return validateExpectation(result, &core.DatabaseExpectation{RowCount: exp})
+	default:
+		return core.NewGowrightError(core.ConfigurationError,
+			fmt.Sprintf("unsupported expectation type: %T", expected), nil)
+	}
+}
+
+// validateExpectation compares a query result with a DatabaseExpectation
+func validateExpectation(result *core.DatabaseResult, expected *core.DatabaseExpectation) error {
+	if expected.RowCount != 0 && expected.RowCount != result.RowCount {
+		return core.NewGowrightError(core.ValidationError,
+			fmt.Sprintf("expected %d rows, got %d", expected.RowCount, result.RowCount), nil)
+	}
+
+	if expected.RowsAffected != 0 && expected.RowsAffected != result.RowsAffected {
+		return core.NewGowrightError(core.ValidationError,
+			fmt.Sprintf("expected %d rows affected, got %d", expected.RowsAffected, result.RowsAffected), nil)
+	}
+
+	if expected.Rows == nil {
+		return nil
+	}
+
+	differences := assertions.CompareRows(expected.Rows, result.Rows, expected.Match)
+	if len(differences) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(differences))
+	for i, difference := range differences {
+		messages[i] = difference.String()
+	}
+
+	return core.NewGowrightError(core.ValidationError,
+		fmt.Sprintf("row content mismatch: %s", strings.Join(messages, "; ")), nil).
+		WithContext("differences", differences)
 }
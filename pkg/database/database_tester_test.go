@@ -1,14 +1,41 @@
 package database
 
 import (
+	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gowright/framework/pkg/config"
 	"github.com/gowright/framework/pkg/core"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// newSQLiteTester returns an initialized tester with an in-memory sqlite connection named "test"
+func newSQLiteTester(t *testing.T) *DatabaseTester {
+	t.Helper()
+
+	tester := NewDatabaseTester()
+	err := tester.Initialize(&config.DatabaseConfig{
+		Connections: map[string]*config.DatabaseConnection{
+			"test": {
+				Driver:   "sqlite3",
+				Database: ":memory:",
+			},
+		},
+		QueryTimeout: 5 * time.Second,
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = tester.Cleanup()
+	})
+
+	return tester
+}
+
 func TestNewDatabaseTester(t *testing.T) {
 	tester := NewDatabaseTester()
 
@@ -207,6 +234,80 @@ func TestDatabaseTester_ExecuteTest(t *testing.T) {
 	})
 }
 
+func TestDatabaseTester_ExecuteTest_TransactionIsolation(t *testing.T) {
+	tester := NewDatabaseTester()
+	err := tester.Initialize(&config.DatabaseConfig{
+		Connections: map[string]*config.DatabaseConnection{
+			"test": {
+				Driver:   "sqlite3",
+				Database: "file:" + filepath.Join(t.TempDir(), "isolation.db"),
+				Options: map[string]string{
+					"_busy_timeout": "10000",
+					"_txlock":       "immediate",
+				},
+			},
+		},
+		TestIsolation: config.DatabaseIsolationTransaction,
+	})
+	require.NoError(t, err)
+	defer func() { _ = tester.Cleanup() }()
+
+	_, err = tester.Execute("test", "CREATE TABLE orders (id INTEGER PRIMARY KEY, label TEXT)")
+	require.NoError(t, err)
+
+	tests := make([]core.Test, 0)
+	for i := 0; i < 8; i++ {
+		testCase := &core.DatabaseTest{
+			Name:       fmt.Sprintf("isolated insert %d", i),
+			Connection: "test",
+			Setup:      []string{fmt.Sprintf("INSERT INTO orders (label) VALUES ('order-%d')", i)},
+			Query:      "SELECT label FROM orders",
+			Expected: &core.DatabaseExpectation{
+				Rows: []map[string]interface{}{{"label": fmt.Sprintf("order-%d", i)}},
+			},
+		}
+		tests = append(tests, core.NewSimpleTest(testCase.Name, func() *core.TestCaseResult {
+			return tester.ExecuteTest(testCase)
+		}))
+	}
+
+	runner := core.NewParallelRunner(&config.Config{Timeout: 30 * time.Second}, &core.ParallelRunnerConfig{MaxConcurrency: 4})
+	defer func() { _ = runner.Shutdown() }()
+
+	results, err := runner.ExecuteTestsParallel(tests)
+	require.NoError(t, err)
+	for _, result := range results.TestCases {
+		assert.Equal(t, core.TestStatusPassed, result.Status, "%s: %v", result.Name, result.Error)
+	}
+
+	// Every test was rolled back, so nothing leaked into the table
+	assert.NoError(t, tester.ValidateData("test", "SELECT * FROM orders", &core.DatabaseExpectation{
+		Rows: []map[string]interface{}{},
+	}))
+
+	t.Run("test level override", func(t *testing.T) {
+		result := tester.ExecuteTest(&core.DatabaseTest{
+			Name:       "not isolated",
+			Connection: "test",
+			Isolation:  config.DatabaseIsolationNone,
+			Query:      "INSERT INTO orders (label) VALUES ('kept')",
+		})
+		require.Equal(t, core.TestStatusPassed, result.Status)
+		assert.NoError(t, tester.ValidateData("test", "SELECT * FROM orders", 1))
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		result := tester.ExecuteTest(&core.DatabaseTest{
+			Name:       "bad isolation",
+			Connection: "test",
+			Isolation:  "snapshot",
+			Query:      "SELECT 1",
+		})
+		assert.Equal(t, core.TestStatusError, result.Status)
+		assert.Contains(t, result.Error.Error(), "unsupported database isolation mode")
+	})
+}
+
 func TestDatabaseTester_Cleanup(t *testing.T) {
 	tester := NewDatabaseTester()
 	config := &config.DatabaseConfig{
@@ -273,6 +374,33 @@ func TestDatabaseTester_ValidateResult(t *testing.T) {
 		assert.False(t, tester.asserter.HasFailures())
 	})
 
+	t.Run("row content validation", func(t *testing.T) {
+		result := &core.DatabaseResult{
+			Rows: []map[string]interface{}{
+				{"id": int64(2), "name": "bob"},
+				{"id": int64(1), "name": "alice"},
+			},
+			RowCount: 2,
+		}
+
+		expected := &core.DatabaseExpectation{
+			Rows: []map[string]interface{}{
+				{"id": 1.0, "name": "alice"},
+				{"id": 2, "name": "bob"},
+			},
+			Match: &core.RowMatchOptions{Unordered: true},
+		}
+
+		tester.asserter.Reset()
+		tester.validateResult(result, expected)
+		assert.False(t, tester.asserter.HasFailures())
+
+		expected.Match = nil
+		tester.asserter.Reset()
+		tester.validateResult(result, expected)
+		assert.True(t, tester.asserter.HasFailures())
+	})
+
 	t.Run("failed validation", func(t *testing.T) {
 		result := &core.DatabaseResult{
 			RowCount: 3,
@@ -290,19 +418,308 @@ func TestDatabaseTester_ValidateResult(t *testing.T) {
 	})
 }
 
-func TestDatabaseTransaction_Methods(t *testing.T) {
-	tx := &DatabaseTransaction{}
+func TestDatabaseTester_Execute(t *testing.T) {
+	tester := newSQLiteTester(t)
+
+	_, err := tester.Execute("test", `CREATE TABLE users (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		score REAL,
+		active BOOLEAN,
+		avatar BLOB,
+		created_at DATETIME
+	)`)
+	require.NoError(t, err)
 
-	// Test Commit
-	err := tx.Commit()
-	assert.NoError(t, err)
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	insert, err := tester.Execute("test",
+		"INSERT INTO users (id, name, score, active, avatar, created_at) VALUES (?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?)",
+		1, "alice", 9.5, true, []byte{0x01, 0x02}, createdAt,
+		2, "bob", nil, false, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), insert.RowsAffected)
+	assert.Equal(t, 0, insert.RowCount)
+
+	result, err := tester.Execute("test", "SELECT * FROM users ORDER BY id")
+	require.NoError(t, err)
+	require.Equal(t, 2, result.RowCount)
+
+	alice := result.Rows[0]
+	assert.Equal(t, int64(1), alice["id"])
+	assert.Equal(t, "alice", alice["name"])
+	assert.Equal(t, 9.5, alice["score"])
+	assert.Equal(t, true, alice["active"])
+	assert.Equal(t, []byte{0x01, 0x02}, alice["avatar"])
+	assert.True(t, createdAt.Equal(alice["created_at"].(time.Time)))
+
+	bob := result.Rows[1]
+	assert.Nil(t, bob["score"])
+	assert.Equal(t, false, bob["active"])
+	assert.Nil(t, bob["avatar"])
+
+	update, err := tester.Execute("test", "UPDATE users SET score = 1 WHERE active = ?", false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), update.RowsAffected)
+
+	_, err = tester.Execute("test", "SELECT * FROM missing_table")
+	require.Error(t, err)
+	gowrightErr, ok := err.(*core.GowrightError)
+	require.True(t, ok)
+	assert.Equal(t, core.DatabaseError, gowrightErr.Type)
+	assert.Equal(t, "SELECT * FROM missing_table", gowrightErr.Context["query"])
+}
+
+func TestDatabaseTester_Connect_UnsupportedDriver(t *testing.T) {
+	tester := NewDatabaseTester()
+	err := tester.Initialize(&config.DatabaseConfig{
+		Connections: map[string]*config.DatabaseConnection{
+			"bad": {Driver: "oracle"},
+		},
+	})
+	require.NoError(t, err)
+
+	err = tester.Connect("bad")
+	require.Error(t, err)
+	gowrightErr, ok := err.(*core.GowrightError)
+	require.True(t, ok)
+	assert.Equal(t, core.DatabaseError, gowrightErr.Type)
+	assert.Contains(t, err.Error(), "unsupported database driver")
+}
+
+func TestDatabaseTester_ValidateData(t *testing.T) {
+	tester := newSQLiteTester(t)
+
+	_, err := tester.Execute("test", "CREATE TABLE items (id INTEGER, label TEXT)")
+	require.NoError(t, err)
+	_, err = tester.Execute("test", "INSERT INTO items VALUES (1, 'one'), (2, 'two')")
+	require.NoError(t, err)
 
-	// Test Rollback
-	err = tx.Rollback()
+	query := "SELECT id, label FROM items ORDER BY id"
+
+	assert.NoError(t, tester.ValidateData("test", query, 2))
+	assert.NoError(t, tester.ValidateData("test", query, &core.DatabaseExpectation{
+		RowCount: 2,
+		Rows: []map[string]interface{}{
+			{"id": 1, "label": "one"},
+			{"id": 2.0, "label": "two"},
+		},
+	}))
+
+	err = tester.ValidateData("test", query, []map[string]interface{}{
+		{"id": 1, "label": "one"},
+		{"id": 2, "label": "three"},
+	})
+	require.Error(t, err)
+	assert.Equal(t, core.ValidationError, err.(*core.GowrightError).Type)
+	assert.Contains(t, err.Error(), `row 1: label: expected "three" (string), got "two" (string)`)
+
+	assert.NoError(t, tester.ValidateData("test", query, &core.DatabaseExpectation{
+		Rows:  []map[string]interface{}{{"label": "two"}, {"label": "one"}},
+		Match: &core.RowMatchOptions{Unordered: true, Subset: true},
+	}))
+
+	err = tester.ValidateData("test", query, "unsupported")
+	require.Error(t, err)
+	assert.Equal(t, core.ConfigurationError, err.(*core.GowrightError).Type)
+}
+
+func TestBuildDSN(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *config.DatabaseConnection
+		expected string
+	}{
+		{
+			name: "mysql with options",
+			config: &config.DatabaseConnection{
+				Driver: "mysql", Host: "db", Port: 3306, Database: "app",
+				Username: "root", Password: "secret",
+				Options: map[string]string{"parseTime": "true", "charset": "utf8mb4"},
+			},
+			expected: "root:secret@tcp(db:3306)/app?charset=utf8mb4&parseTime=true",
+		},
+		{
+			name: "postgres with ssl mode and options",
+			config: &config.DatabaseConnection{
+				Driver: "postgres", Host: "db", Port: 5432, Database: "app",
+				Username: "admin", Password: "p w", SSLMode: "disable",
+				Options: map[string]string{"connect_timeout": "5"},
+			},
+			expected: "host=db user=admin password='p w' dbname=app port=5432 sslmode=disable connect_timeout=5",
+		},
+		{
+			name: "sqlite with options",
+			config: &config.DatabaseConnection{
+				Driver: "sqlite3", Database: "file:test.db",
+				Options: map[string]string{"_busy_timeout": "5000"},
+			},
+			expected: "file:test.db?_busy_timeout=5000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsn, err := buildDSN(tt.config)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, dsn)
+		})
+	}
+}
+
+func TestReturnsRows(t *testing.T) {
+	assert.True(t, returnsRows("SELECT 1"))
+	assert.True(t, returnsRows("  -- comment\n with x as (select 1) select * from x"))
+	assert.True(t, returnsRows("(SELECT 1) UNION (SELECT 2)"))
+	assert.True(t, returnsRows("INSERT INTO t (a) VALUES (1) RETURNING id"))
+	assert.True(t, returnsRows("UPDATE t\n   SET a = 2\n WHERE id = 1\nRETURNING\n\tid, a;"))
+	assert.True(t, returnsRows("DELETE FROM t WHERE id = 1 returning\tid"))
+	assert.False(t, returnsRows("UPDATE t SET returning_at = NULL"))
+	assert.False(t, returnsRows("INSERT INTO t (a) VALUES (1)"))
+	assert.False(t, returnsRows("/* setup */ CREATE TABLE t (id INTEGER)"))
+	assert.False(t, returnsRows("INSERT INTO t(v) VALUES ('RETURNING')"))
+	assert.False(t, returnsRows("INSERT INTO t(v) VALUES ('it''s returning')"))
+	assert.False(t, returnsRows(`UPDATE t SET "returning" = 1, `+"`returning`"+` = 2`))
+	assert.False(t, returnsRows("DELETE FROM t -- RETURNING id\nWHERE id = 1"))
+	assert.False(t, returnsRows("DELETE FROM t /* RETURNING id */ WHERE id = 1"))
+	assert.True(t, returnsRows("INSERT INTO t(v) VALUES ('x') /* log */ RETURNING id -- new id"))
+}
+
+func TestDatabaseTransaction_CommitAndRollback(t *testing.T) {
+	tester := newSQLiteTester(t)
+
+	_, err := tester.Execute("test", "CREATE TABLE accounts (id INTEGER PRIMARY KEY, balance INTEGER)")
+	require.NoError(t, err)
+
+	t.Run("commit persists changes", func(t *testing.T) {
+		tx, err := tester.BeginTransaction("test")
+		require.NoError(t, err)
+
+		result, err := tx.Execute("INSERT INTO accounts (id, balance) VALUES (?, ?)", 1, 100)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.RowsAffected)
+
+		inside, err := tx.Execute("SELECT balance FROM accounts WHERE id = 1")
+		require.NoError(t, err)
+		assert.Equal(t, 1, inside.RowCount)
+
+		require.NoError(t, tx.Commit())
+
+		assert.NoError(t, tester.ValidateData("test", "SELECT * FROM accounts WHERE id = 1", 1))
+	})
+
+	t.Run("rollback discards changes", func(t *testing.T) {
+		tx, err := tester.BeginTransaction("test")
+		require.NoError(t, err)
+
+		_, err = tx.Execute("INSERT INTO accounts (id, balance) VALUES (2, 200)")
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+
+		result, err := tester.Execute("test", "SELECT * FROM accounts WHERE id = 2")
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.RowCount)
+	})
+
+	t.Run("use after completion fails", func(t *testing.T) {
+		tx, err := tester.BeginTransaction("test")
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		_, err = tx.Execute("SELECT 1")
+		require.Error(t, err)
+		gowrightErr, ok := err.(*core.GowrightError)
+		require.True(t, ok)
+		assert.Equal(t, core.DatabaseError, gowrightErr.Type)
+		assert.Equal(t, "committed", gowrightErr.Context["state"])
+
+		assert.Error(t, tx.Commit())
+		assert.Error(t, tx.Rollback())
+		_, err = tx.BeginTransaction()
+		assert.Error(t, err)
+	})
+}
+
+func TestDatabaseTransaction_Savepoints(t *testing.T) {
+	tester := newSQLiteTester(t)
+
+	_, err := tester.Execute("test", "CREATE TABLE events (id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+
+	tx, err := tester.BeginTransaction("test")
+	require.NoError(t, err)
+
+	_, err = tx.Execute("INSERT INTO events (id) VALUES (1)")
+	require.NoError(t, err)
+
+	discarded, err := tx.BeginTransaction()
+	require.NoError(t, err)
+	_, err = discarded.Execute("INSERT INTO events (id) VALUES (2)")
+	require.NoError(t, err)
+	require.NoError(t, discarded.Rollback())
+
+	kept, err := tx.BeginTransaction()
+	require.NoError(t, err)
+	_, err = kept.Execute("INSERT INTO events (id) VALUES (3)")
+	require.NoError(t, err)
+
+	inner, err := kept.BeginTransaction()
+	require.NoError(t, err)
+	_, err = inner.Execute("INSERT INTO events (id) VALUES (4)")
+	require.NoError(t, err)
+	require.NoError(t, inner.Commit())
+	require.NoError(t, kept.Commit())
+
+	result, err := tx.Execute("SELECT id FROM events ORDER BY id")
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": int64(1)}, {"id": int64(3)}, {"id": int64(4)}}, result.Rows)
+
+	orphan, err := tx.BeginTransaction()
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+
+	_, err = orphan.Execute("SELECT 1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parent transaction already rolled back")
+
+	result, err = tester.Execute("test", "SELECT id FROM events")
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.RowCount)
+}
+
+func TestDatabaseTransaction_Timeout(t *testing.T) {
+	tester := NewDatabaseTester()
+	err := tester.Initialize(&config.DatabaseConfig{
+		Connections: map[string]*config.DatabaseConnection{
+			"test": {Driver: "sqlite3", Database: ":memory:"},
+		},
+		TransactionTimeout: 20 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer func() { _ = tester.Cleanup() }()
+
+	tx, err := tester.BeginTransaction("test")
+	require.NoError(t, err)
+
+	time.Sleep(50 * time.Millisecond)
+
+	_, err = tx.Execute("SELECT 1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "transaction timed out")
+
+	// The driver already rolled back, so an explicit rollback is a no-op
+	assert.NoError(t, tx.Rollback())
+
+	_, err = tester.Execute("test", "SELECT 1")
 	assert.NoError(t, err)
+}
+
+func TestDatabaseTransaction_NotStarted(t *testing.T) {
+	tx := &DatabaseTransaction{}
+
+	assert.Error(t, tx.Commit())
+	assert.Error(t, tx.Rollback())
 
-	// Test Execute
 	result, err := tx.Execute("SELECT 1")
-	assert.NoError(t, err)
-	assert.NotNil(t, result)
+	assert.Nil(t, result)
+	assert.Error(t, err)
 }
@@ -0,0 +1,243 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gowright/framework/pkg/core"
+)
+
+// transactionState tracks the lifecycle of a DatabaseTransaction
+type transactionState int
+
+const (
+	transactionActive transactionState = iota
+	transactionCommitted
+	transactionRolledBack
+)
+
+// String returns the string representation of transactionState
+func (ts transactionState) String() string {
+	switch ts {
+	case transactionActive:
+		return "active"
+	case transactionCommitted:
+		return "committed"
+	case transactionRolledBack:
+		return "rolled back"
+	default:
+		return "unknown"
+	}
+}
+
+// DatabaseTransaction implements the Transaction interface on top of a *sql.Tx.
+// Nested transactions share their parent's *sql.Tx and are backed by savepoints.
+type DatabaseTransaction struct {
+	tx             *sql.Tx
+	ctx            context.Context
+	cancel         context.CancelFunc
+	connectionName string
+	queryTimeout   time.Duration
+	parent         *DatabaseTransaction
+	savepoint      string
+	sequence       *atomic.Int64
+	release        func() error
+	state          transactionState
+	mutex          sync.Mutex
+}
+
+// beginTransaction starts a transaction on a leased connection that is rolled back automatically
+// once timeout elapses. release is called when the transaction finishes to end the lease.
+func beginTransaction(conn *sql.Conn, connectionName string, timeout, queryTimeout time.Duration, release func() error) (*DatabaseTransaction, error) {
+	ctx, cancel := withOptionalTimeout(context.Background(), timeout)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		cancel()
+		return nil, core.NewGowrightError(core.DatabaseError, "failed to begin transaction", err).
+			WithContext("connection", connectionName)
+	}
+
+	return &DatabaseTransaction{
+		tx:             tx,
+		ctx:            ctx,
+		cancel:         cancel,
+		connectionName: connectionName,
+		queryTimeout:   queryTimeout,
+		sequence:       &atomic.Int64{},
+		release:        release,
+		state:          transactionActive,
+	}, nil
+}
+
+// Commit commits the transaction, or releases the savepoint of a nested transaction
+func (dt *DatabaseTransaction) Commit() error {
+	dt.mutex.Lock()
+	defer dt.mutex.Unlock()
+
+	if err := dt.checkActive(); err != nil {
+		return err
+	}
+
+	if dt.parent != nil {
+		if _, err := dt.tx.ExecContext(dt.ctx, "RELEASE SAVEPOINT "+dt.savepoint); err != nil {
+			return dt.wrapError("failed to release savepoint", err)
+		}
+		dt.state = transactionCommitted
+		return nil
+	}
+
+	defer dt.finish()
+
+	if err := dt.tx.Commit(); err != nil {
+		// A failed commit leaves nothing to roll back; the driver has already discarded the transaction
+		dt.state = transactionRolledBack
+		return dt.wrapError("failed to commit transaction", err)
+	}
+
+	dt.state = transactionCommitted
+	return nil
+}
+
+// Rollback rolls back the transaction, or rolls back to the savepoint of a nested transaction
+func (dt *DatabaseTransaction) Rollback() error {
+	dt.mutex.Lock()
+	defer dt.mutex.Unlock()
+
+	if err := dt.checkActive(); err != nil {
+		return err
+	}
+
+	if dt.parent != nil {
+		if _, err := dt.tx.ExecContext(dt.ctx, "ROLLBACK TO SAVEPOINT "+dt.savepoint); err != nil {
+			return dt.wrapError("failed to roll back to savepoint", err)
+		}
+		if _, err := dt.tx.ExecContext(dt.ctx, "RELEASE SAVEPOINT "+dt.savepoint); err != nil {
+			return dt.wrapError("failed to release savepoint", err)
+		}
+		dt.state = transactionRolledBack
+		return nil
+	}
+
+	defer dt.finish()
+	dt.state = transactionRolledBack
+
+	if err := dt.tx.Rollback(); err != nil {
+		// database/sql rolls back on its own when the transaction context expires
+		if errors.Is(err, sql.ErrTxDone) && dt.ctx.Err() != nil {
+			return nil
+		}
+		return dt.wrapError("failed to roll back transaction", err)
+	}
+
+	return nil
+}
+
+// Execute executes a query within the transaction
+func (dt *DatabaseTransaction) Execute(query string, args ...interface{}) (*core.DatabaseResult, error) {
+	dt.mutex.Lock()
+	defer dt.mutex.Unlock()
+
+	if err := dt.checkActive(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withOptionalTimeout(dt.ctx, dt.queryTimeout)
+	defer cancel()
+
+	result, err := runQuery(ctx, dt.tx, query, args...)
+	if err != nil {
+		return nil, dt.wrapError("query execution failed", err).WithContext("query", query)
+	}
+
+	return result, nil
+}
+
+// BeginTransaction starts a nested transaction backed by a savepoint
+func (dt *DatabaseTransaction) BeginTransaction() (core.Transaction, error) {
+	dt.mutex.Lock()
+	defer dt.mutex.Unlock()
+
+	if err := dt.checkActive(); err != nil {
+		return nil, err
+	}
+
+	savepoint := fmt.Sprintf("gowright_sp_%d", dt.sequence.Add(1))
+	if _, err := dt.tx.ExecContext(dt.ctx, "SAVEPOINT "+savepoint); err != nil {
+		return nil, dt.wrapError("failed to create savepoint", err)
+	}
+
+	return &DatabaseTransaction{
+		tx:             dt.tx,
+		ctx:            dt.ctx,
+		cancel:         dt.cancel,
+		connectionName: dt.connectionName,
+		queryTimeout:   dt.queryTimeout,
+		parent:         dt,
+		savepoint:      savepoint,
+		sequence:       dt.sequence,
+		state:          transactionActive,
+	}, nil
+}
+
+// finish releases the transaction context and the connection lease of a root transaction
+func (dt *DatabaseTransaction) finish() {
+	dt.cancel()
+	if dt.release != nil {
+		if err := dt.release(); err != nil {
+			fmt.Printf("Error releasing database connection: %v\n", err)
+		}
+	}
+}
+
+// currentState returns the transaction state under lock
+func (dt *DatabaseTransaction) currentState() transactionState {
+	dt.mutex.Lock()
+	defer dt.mutex.Unlock()
+	return dt.state
+}
+
+// checkActive returns an error if the transaction or any of its parents has finished
+func (dt *DatabaseTransaction) checkActive() error {
+	if dt.tx == nil {
+		return core.NewGowrightError(core.DatabaseError, "transaction not started", nil)
+	}
+
+	if dt.state != transactionActive {
+		return core.NewGowrightError(core.DatabaseError,
+			fmt.Sprintf("transaction already %s", dt.state), nil).
+			WithContext("connection", dt.connectionName).
+			WithContext("state", dt.state.String())
+	}
+
+	for parent := dt.parent; parent != nil; parent = parent.parent {
+		if state := parent.currentState(); state != transactionActive {
+			return core.NewGowrightError(core.DatabaseError,
+				fmt.Sprintf("parent transaction already %s", state), nil).
+				WithContext("connection", dt.connectionName).
+				WithContext("state", state.String())
+		}
+	}
+
+	return nil
+}
+
+// wrapError wraps a driver error, reporting transaction timeouts explicitly
+func (dt *DatabaseTransaction) wrapError(message string, err error) *core.GowrightError {
+	if ctxErr := dt.ctx.Err(); ctxErr != nil {
+		return core.NewGowrightError(core.DatabaseError, "transaction timed out", ctxErr).
+			WithContext("connection", dt.connectionName)
+	}
+
+	wrapped := core.NewGowrightError(core.DatabaseError, message, err).
+		WithContext("connection", dt.connectionName)
+	if dt.savepoint != "" {
+		wrapped = wrapped.WithContext("savepoint", dt.savepoint)
+	}
+	return wrapped
+}
@@ -17,6 +17,7 @@ import (
 	"fmt"
 
 	"github.com/gowright/framework/pkg/api"
+	"github.com/gowright/framework/pkg/assertions"
 	"github.com/gowright/framework/pkg/config"
 	"github.com/gowright/framework/pkg/core"
 	"github.com/gowright/framework/pkg/database"
@@ -48,18 +49,44 @@ type (
 	TestAssertion    = core.TestAssertion
 
 	// Test types
-	UITest              = core.UITest
-	UIAction            = core.UIAction
-	UIAssertion         = core.UIAssertion
-	APITest             = core.APITest
-	APIExpectation      = core.APIExpectation
-	APIResponse         = core.APIResponse
-	DatabaseTest        = core.DatabaseTest
-	DatabaseExpectation = core.DatabaseExpectation
-	DatabaseResult      = core.DatabaseResult
-	IntegrationTest     = core.IntegrationTest
-	IntegrationStep     = core.IntegrationStep
-	IntegrationStepType = core.IntegrationStepType
+	UITest               = core.UITest
+	UIAction             = core.UIAction
+	UIAssertion          = core.UIAssertion
+	APITest              = core.APITest
+	APIExpectation       = core.APIExpectation
+	APICapture           = core.APICapture
+	RequestBody          = core.RequestBody
+	MultipartPart        = core.MultipartPart
+	Variables            = core.Variables
+	JSONSchemaSource     = core.JSONSchemaSource
+	APIResponse          = core.APIResponse
+	XMLNode              = core.XMLNode
+	GraphQLTest          = core.GraphQLTest
+	GraphQLExpectation   = core.GraphQLExpectation
+	StreamTest           = core.StreamTest
+	StreamStep           = core.StreamStep
+	StreamExpectation    = core.StreamExpectation
+	LoadTest             = core.LoadTest
+	LoadThresholds       = core.LoadThresholds
+	LoadMetrics          = core.LoadMetrics
+	LatencyStats         = core.LatencyStats
+	LoadInterval         = core.LoadInterval
+	ResponseTimings      = core.ResponseTimings
+	DatabaseTest         = core.DatabaseTest
+	DatabaseExpectation  = core.DatabaseExpectation
+	DatabaseResult       = core.DatabaseResult
+	RowMatchOptions      = core.RowMatchOptions
+	DataDiff             = core.DataDiff
+	TableChanges         = core.TableChanges
+	ExpectedChanges      = core.ExpectedChanges
+	ExpectedTableChanges = core.ExpectedTableChanges
+	ExpectedRowUpdate    = core.ExpectedRowUpdate
+	ValueMatcher         = core.ValueMatcher
+	QuerySource          = core.QuerySource
+	PollConfig           = core.PollConfig
+	IntegrationTest      = core.IntegrationTest
+	IntegrationStep      = core.IntegrationStep
+	IntegrationStepType  = core.IntegrationStepType
 
 	// Step actions and validations
 	UIStepAction           = core.UIStepAction
@@ -86,8 +113,11 @@ type (
 	ReportConfig       = config.ReportConfig
 	MobileConfig       = config.MobileConfig
 	AuthConfig         = config.AuthConfig
+	OAuth2Config       = config.OAuth2Config
+	JWTConfig          = config.JWTConfig
 	TLSConfig          = config.TLSConfig
 	ProxyConfig        = config.ProxyConfig
+	RecordingConfig    = config.RecordingConfig
 	AppiumServerConfig = config.AppiumServerConfig
 	DeviceConfig       = config.DeviceConfig
 
@@ -140,6 +170,16 @@ func NewAPITester() APITester {
 	return api.NewAPITester()
 }
 
+// NewVariables creates an empty variable store for captured values
+func NewVariables() *Variables {
+	return core.NewVariables()
+}
+
+// ParseXML parses an XML document into its root element
+func ParseXML(data []byte) (*XMLNode, error) {
+	return core.ParseXML(data)
+}
+
 // NewDatabaseTester creates a new database tester instance
 func NewDatabaseTester() DatabaseTester {
 	return database.NewDatabaseTester()
@@ -265,6 +305,26 @@ func NewFunctionTest(name string, testFunc func(*TestContext)) Test {
 	return core.NewFunctionTest(name, testFunc)
 }
 
+// MatchRegex creates a matcher for strings matching a regular expression
+func MatchRegex(pattern string) ValueMatcher {
+	return assertions.MatchRegex(pattern)
+}
+
+// MatchType creates a matcher for values of a JSON type
+func MatchType(name string) ValueMatcher {
+	return assertions.MatchType(name)
+}
+
+// GreaterThan creates a matcher for numbers greater than limit
+func GreaterThan(limit float64) ValueMatcher {
+	return assertions.GreaterThan(limit)
+}
+
+// AnyOf creates a matcher for values matching any of the given values
+func AnyOf(values ...interface{}) ValueMatcher {
+	return assertions.AnyOf(values...)
+}
+
 // NewMockUITester creates a new mock UI tester
 func NewMockUITester() *core.MockUITester {
 	return core.NewMockUITester()
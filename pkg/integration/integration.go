@@ -3,6 +3,8 @@ package integration
 
 import (
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gowright/framework/pkg/assertions"
@@ -17,6 +19,7 @@ type IntegrationTester struct {
 	apiTester   core.APITester
 	dbTester    core.DatabaseTester
 	asserter    *assertions.Asserter
+	fixtures    []core.Fixtures
 	initialized bool
 }
 
@@ -42,7 +45,7 @@ func (it *IntegrationTester) Initialize(cfg interface{}) error {
 // Cleanup performs cleanup operations
 func (it *IntegrationTester) Cleanup() error {
 	it.initialized = false
-	return nil
+	return it.restoreFixtures()
 }
 
 // GetName returns the name of the tester
@@ -126,6 +129,11 @@ func (it *IntegrationTester) ExecuteTest(test *core.IntegrationTest) *core.TestC
 		result.Error = core.NewGowrightError(core.AssertionError, "one or more assertions failed", nil)
 	}
 
+	// Restore tables seeded by fixture steps
+	if err := it.restoreFixtures(); err != nil && result.Error == nil {
+		result.Error = err
+	}
+
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
 	result.Steps = it.asserter.GetSteps()
@@ -184,23 +192,36 @@ func (it *IntegrationTester) executeAPIStep(step *core.IntegrationStep) error {
 		return core.NewGowrightError(core.ConfigurationError, "invalid API step action", nil)
 	}
 
+	body := action.Body
+	if action.RequestBody != nil {
+		if body != nil {
+			return core.NewGowrightError(core.ValidationError, "API step cannot set both a body and a request body", nil)
+		}
+		body = action.RequestBody
+	}
+
 	var response *core.APIResponse
 	var err error
 
-	// Execute API action based on method
-	switch action.Method {
-	case "GET":
+	// Execute API action based on method; other methods are left to the tester to accept or reject
+	switch strings.ToUpper(action.Method) {
+	case http.MethodGet:
 		response, err = it.apiTester.Get(action.Endpoint, action.Headers)
-	case "POST":
-		response, err = it.apiTester.Post(action.Endpoint, action.Body, action.Headers)
-	case "PUT":
-		response, err = it.apiTester.Put(action.Endpoint, action.Body, action.Headers)
-	case "DELETE":
+	case http.MethodPost:
+		response, err = it.apiTester.Post(action.Endpoint, body, action.Headers)
+	case http.MethodPut:
+		response, err = it.apiTester.Put(action.Endpoint, body, action.Headers)
+	case http.MethodPatch:
+		response, err = it.apiTester.Patch(action.Endpoint, body, action.Headers)
+	case http.MethodDelete:
 		response, err = it.apiTester.Delete(action.Endpoint, action.Headers)
+	case http.MethodHead:
+		response, err = it.apiTester.Head(action.Endpoint, action.Headers)
+	case http.MethodOptions:
+		response, err = it.apiTester.Options(action.Endpoint, action.Headers)
 	default:
-		return core.NewGowrightError(core.ConfigurationError, fmt.Sprintf("unsupported HTTP method: %s", action.Method), nil)
+		response, err = it.apiTester.Do(action.Method, action.Endpoint, body, action.Headers)
 	}
-
 	if err != nil {
 		return err
 	}
@@ -226,7 +247,37 @@ func (it *IntegrationTester) executeDatabaseStep(step *core.IntegrationStep) err
 		return core.NewGowrightError(core.ConfigurationError, "invalid database step action", nil)
 	}
 
-	result, err := it.dbTester.Execute(action.Connection, action.Query, action.Args...)
+	if len(action.Fixtures) > 0 {
+		loader, ok := it.dbTester.(core.FixtureLoader)
+		if !ok {
+			return core.NewGowrightError(core.ConfigurationError, "database tester does not support fixtures", nil)
+		}
+		fixtures, err := loader.LoadFixtures(action.Connection, action.Fixtures...)
+		if err != nil {
+			return err
+		}
+		it.fixtures = append(it.fixtures, fixtures)
+	}
+
+	// A step may only load fixtures
+	source := action.QuerySource()
+	if source.IsEmpty() {
+		return nil
+	}
+
+	query, args := action.Query, action.Args
+	if !source.IsPlain() {
+		resolver, ok := it.dbTester.(core.QueryResolver)
+		if !ok {
+			return core.NewGowrightError(core.ConfigurationError, "database tester does not support query files or named parameters", nil)
+		}
+		var err error
+		if query, args, err = resolver.ResolveQuery(action.Connection, source); err != nil {
+			return err
+		}
+	}
+
+	result, err := it.dbTester.Execute(action.Connection, query, args...)
 	if err != nil {
 		return err
 	}
@@ -257,7 +308,14 @@ func (it *IntegrationTester) validateAPIResponse(response *core.APIResponse, val
 		}
 	}
 
-	// Additional JSON path validations can be added here
+	if validation.ExpectedBody != nil || len(validation.JSONPath) > 0 {
+		document := assertions.ParseJSONBody(response.Body)
+		if len(response.Body) == 0 && response.JSON != nil {
+			document = response.JSON
+		}
+		it.asserter.JSONExpectations(document, validation.ExpectedBody, validation.JSONPath, "Expected response body", "Expected JSON path ")
+	}
+
 	return nil
 }
 
@@ -273,7 +331,10 @@ func (it *IntegrationTester) validateDatabaseResult(result *core.DatabaseResult,
 			fmt.Sprintf("Expected affected rows %d", *validation.ExpectedAffected))
 	}
 
-	// Additional row content validations can be added here
+	if validation.ExpectedRows != nil {
+		it.asserter.RowsMatch(validation.ExpectedRows, result.Rows, validation.Match, "Expected rows")
+	}
+
 	return nil
 }
 
@@ -296,3 +357,15 @@ func (it *IntegrationTester) rollbackSteps(steps []core.IntegrationStep) error {
 	}
 	return nil
 }
+
+// restoreFixtures restores loaded fixtures in reverse load order
+func (it *IntegrationTester) restoreFixtures() error {
+	var firstErr error
+	for i := len(it.fixtures) - 1; i >= 0; i-- {
+		if err := it.fixtures[i].Restore(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	it.fixtures = nil
+	return firstErr
+}
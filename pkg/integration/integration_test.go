@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gowright/framework/pkg/assertions"
 	"github.com/gowright/framework/pkg/config"
 	"github.com/gowright/framework/pkg/core"
 	"github.com/stretchr/testify/assert"
@@ -111,6 +112,26 @@ func (m *MockAPITester) Delete(endpoint string, headers map[string]string) (*cor
 	return args.Get(0).(*core.APIResponse), args.Error(1)
 }
 
+func (m *MockAPITester) Patch(endpoint string, body interface{}, headers map[string]string) (*core.APIResponse, error) {
+	args := m.Called(endpoint, body, headers)
+	return args.Get(0).(*core.APIResponse), args.Error(1)
+}
+
+func (m *MockAPITester) Head(endpoint string, headers map[string]string) (*core.APIResponse, error) {
+	args := m.Called(endpoint, headers)
+	return args.Get(0).(*core.APIResponse), args.Error(1)
+}
+
+func (m *MockAPITester) Options(endpoint string, headers map[string]string) (*core.APIResponse, error) {
+	args := m.Called(endpoint, headers)
+	return args.Get(0).(*core.APIResponse), args.Error(1)
+}
+
+func (m *MockAPITester) Do(method, endpoint string, body interface{}, headers map[string]string) (*core.APIResponse, error) {
+	args := m.Called(method, endpoint, body, headers)
+	return args.Get(0).(*core.APIResponse), args.Error(1)
+}
+
 func (m *MockAPITester) SetAuth(auth *config.AuthConfig) error {
 	args := m.Called(auth)
 	return args.Error(0)
@@ -166,6 +187,41 @@ func (m *MockDatabaseTester) ExecuteTest(test *core.DatabaseTest) *core.TestCase
 	return args.Get(0).(*core.TestCaseResult)
 }
 
+// MockFixtureDatabaseTester is a mock database tester that can load fixtures
+type MockFixtureDatabaseTester struct {
+	MockDatabaseTester
+}
+
+func (m *MockFixtureDatabaseTester) LoadFixtures(connectionName string, paths ...string) (core.Fixtures, error) {
+	args := m.Called(connectionName, paths)
+	return args.Get(0).(core.Fixtures), args.Error(1)
+}
+
+func (m *MockFixtureDatabaseTester) LoadFixturesInTransaction(tx core.Transaction, connectionName string, paths ...string) (core.Fixtures, error) {
+	args := m.Called(tx, connectionName, paths)
+	return args.Get(0).(core.Fixtures), args.Error(1)
+}
+
+// MockQueryDatabaseTester is a mock database tester that resolves query files and named parameters
+type MockQueryDatabaseTester struct {
+	MockDatabaseTester
+}
+
+func (m *MockQueryDatabaseTester) ResolveQuery(connectionName string, source core.QuerySource) (string, []interface{}, error) {
+	args := m.Called(connectionName, source)
+	return args.String(0), args.Get(1).([]interface{}), args.Error(2)
+}
+
+// MockFixtures is a mock implementation of loaded fixtures
+type MockFixtures struct {
+	mock.Mock
+}
+
+func (m *MockFixtures) Restore() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
 func TestNewIntegrationTester(t *testing.T) {
 	tester := NewIntegrationTester()
 
@@ -273,6 +329,39 @@ func TestIntegrationTester_ExecuteStep_APIStep(t *testing.T) {
 	mockAPI.AssertExpectations(t)
 }
 
+func TestIntegrationTester_ExecuteStep_APIStepMethods(t *testing.T) {
+	tester := NewIntegrationTester()
+	mockAPI := &MockAPITester{}
+	tester.SetAPITester(mockAPI)
+
+	err := tester.Initialize(&config.Config{})
+	assert.NoError(t, err)
+
+	response := &core.APIResponse{StatusCode: 200}
+	body := map[string]string{"status": "active"}
+	mockAPI.On("Patch", "/api/users/1", body, map[string]string(nil)).Return(response, nil)
+	mockAPI.On("Head", "/api/users", map[string]string(nil)).Return(response, nil)
+	mockAPI.On("Do", "PROPFIND", "/dav", nil, map[string]string(nil)).Return(response, nil)
+
+	for _, action := range []*core.APIStepAction{
+		{Method: "patch", Endpoint: "/api/users/1", Body: body},
+		{Method: "HEAD", Endpoint: "/api/users"},
+	} {
+		err = tester.ExecuteStep(&core.IntegrationStep{Type: core.StepTypeAPI, Name: action.Method, Action: action})
+		assert.NoError(t, err)
+	}
+
+	// Other methods are passed to Do
+	err = tester.ExecuteStep(&core.IntegrationStep{
+		Type:   core.StepTypeAPI,
+		Name:   "propfind",
+		Action: &core.APIStepAction{Method: "PROPFIND", Endpoint: "/dav"},
+	})
+	assert.NoError(t, err)
+
+	mockAPI.AssertExpectations(t)
+}
+
 func TestIntegrationTester_ExecuteStep_DatabaseStep(t *testing.T) {
 	tester := NewIntegrationTester()
 	mockDB := &MockDatabaseTester{}
@@ -305,6 +394,100 @@ func TestIntegrationTester_ExecuteStep_DatabaseStep(t *testing.T) {
 	mockDB.AssertExpectations(t)
 }
 
+func TestIntegrationTester_ExecuteTest_Fixtures(t *testing.T) {
+	tester := NewIntegrationTester()
+	mockDB := &MockFixtureDatabaseTester{}
+	tester.SetDatabaseTester(mockDB)
+
+	err := tester.Initialize(&config.Config{})
+	assert.NoError(t, err)
+
+	users := &MockFixtures{}
+	orders := &MockFixtures{}
+	var restored []string
+	users.On("Restore").Run(func(mock.Arguments) { restored = append(restored, "users") }).Return(nil)
+	orders.On("Restore").Run(func(mock.Arguments) { restored = append(restored, "orders") }).Return(nil)
+
+	mockDB.On("LoadFixtures", "main", []string{"users.yml"}).Return(users, nil)
+	mockDB.On("LoadFixtures", "main", []string{"orders.yml"}).Return(orders, nil)
+	mockDB.On("Execute", "main", "SELECT COUNT(*) AS count FROM orders", []interface{}(nil)).
+		Return(&core.DatabaseResult{Rows: []map[string]interface{}{{"count": int64(1)}}}, nil)
+
+	test := &core.IntegrationTest{
+		Name: "Fixture steps",
+		Steps: []core.IntegrationStep{
+			{
+				Type:   core.StepTypeDatabase,
+				Name:   "Seed users",
+				Action: &core.DatabaseStepAction{Connection: "main", Fixtures: []string{"users.yml"}},
+			},
+			{
+				Type: core.StepTypeDatabase,
+				Name: "Seed orders",
+				Action: &core.DatabaseStepAction{
+					Connection: "main",
+					Fixtures:   []string{"orders.yml"},
+					Query:      "SELECT COUNT(*) AS count FROM orders",
+				},
+			},
+		},
+	}
+
+	result := tester.ExecuteTest(test)
+	assert.Equal(t, core.TestStatusPassed, result.Status)
+	assert.Equal(t, []string{"orders", "users"}, restored)
+
+	mockDB.AssertExpectations(t)
+	users.AssertExpectations(t)
+	orders.AssertExpectations(t)
+}
+
+func TestIntegrationTester_ExecuteStep_FixturesUnsupported(t *testing.T) {
+	tester := NewIntegrationTester()
+	tester.SetDatabaseTester(&MockDatabaseTester{})
+
+	err := tester.Initialize(&config.Config{})
+	assert.NoError(t, err)
+
+	err = tester.ExecuteStep(&core.IntegrationStep{
+		Type:   core.StepTypeDatabase,
+		Name:   "Seed users",
+		Action: &core.DatabaseStepAction{Connection: "main", Fixtures: []string{"users.yml"}},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support fixtures")
+}
+
+func TestIntegrationTester_ExecuteStep_QueryFile(t *testing.T) {
+	tester := NewIntegrationTester()
+	mockDB := &MockQueryDatabaseTester{}
+	tester.SetDatabaseTester(mockDB)
+
+	err := tester.Initialize(&config.Config{})
+	assert.NoError(t, err)
+
+	action := &core.DatabaseStepAction{
+		Connection: "main",
+		QueryFile:  "queries/users.sql",
+		QueryName:  "find_user",
+		Params:     map[string]interface{}{"user_id": 1},
+	}
+	mockDB.On("ResolveQuery", "main", action.QuerySource()).
+		Return("SELECT * FROM users WHERE id = $1", []interface{}{1}, nil)
+	mockDB.On("Execute", "main", "SELECT * FROM users WHERE id = $1", []interface{}{1}).
+		Return(&core.DatabaseResult{RowCount: 1}, nil)
+
+	err = tester.ExecuteStep(&core.IntegrationStep{Type: core.StepTypeDatabase, Name: "Find user", Action: action})
+	assert.NoError(t, err)
+	mockDB.AssertExpectations(t)
+
+	// Testers without query resolution only accept plain queries
+	tester.SetDatabaseTester(&MockDatabaseTester{})
+	err = tester.ExecuteStep(&core.IntegrationStep{Type: core.StepTypeDatabase, Name: "Find user", Action: action})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support query files")
+}
+
 func TestIntegrationTester_ExecuteStep_NotInitialized(t *testing.T) {
 	tester := NewIntegrationTester()
 
@@ -443,6 +626,39 @@ func TestIntegrationTester_ValidateAPIResponse(t *testing.T) {
 	assert.False(t, tester.asserter.HasFailures())
 }
 
+func TestIntegrationTester_ValidateAPIResponse_JSONPath(t *testing.T) {
+	tester := NewIntegrationTester()
+	err := tester.Initialize(&config.Config{})
+	assert.NoError(t, err)
+
+	response := &core.APIResponse{
+		StatusCode: 200,
+		Body:       []byte(`{"users": [{"id": 1, "role": "admin"}, {"id": 2, "role": "member"}]}`),
+	}
+
+	validation := &core.APIStepValidation{
+		JSONPath: map[string]interface{}{
+			"$.users[*].id":                    assertions.GreaterThan(0),
+			"$.users[?(@.role == 'admin')].id": []interface{}{1},
+			"users[-1].role":                   map[string]interface{}{"$anyOf": []interface{}{"member", "guest"}},
+			"$..role":                          assertions.MatchRegex("^[a-z]+$"),
+		},
+	}
+
+	err = tester.validateAPIResponse(response, validation)
+	assert.NoError(t, err)
+	assert.False(t, tester.asserter.HasFailures())
+	assert.Len(t, tester.asserter.GetSteps(), 4)
+
+	tester.asserter.Reset()
+	validation = &core.APIStepValidation{
+		ExpectedBody: map[string]interface{}{"users": assertions.MatchType("object")},
+	}
+	err = tester.validateAPIResponse(response, validation)
+	assert.NoError(t, err)
+	assert.True(t, tester.asserter.HasFailures())
+}
+
 func TestIntegrationTester_ValidateDatabaseResult(t *testing.T) {
 	tester := NewIntegrationTester()
 	config := &config.Config{}
@@ -466,6 +682,36 @@ func TestIntegrationTester_ValidateDatabaseResult(t *testing.T) {
 	err = tester.validateDatabaseResult(result, validation)
 	assert.NoError(t, err)
 	assert.False(t, tester.asserter.HasFailures())
+
+	t.Run("expected rows unordered", func(t *testing.T) {
+		tester.asserter.Reset()
+		err := tester.validateDatabaseResult(result, &core.DatabaseStepValidation{
+			ExpectedRows: []map[string]interface{}{
+				{"name": "Jane"},
+				{"name": "John"},
+			},
+			Match: &core.RowMatchOptions{Unordered: true, Subset: true},
+		})
+		assert.NoError(t, err)
+		assert.False(t, tester.asserter.HasFailures())
+		assert.Len(t, tester.asserter.GetSteps(), 2)
+	})
+
+	t.Run("expected rows mismatch", func(t *testing.T) {
+		tester.asserter.Reset()
+		err := tester.validateDatabaseResult(result, &core.DatabaseStepValidation{
+			ExpectedRows: []map[string]interface{}{
+				{"id": 1, "name": "John"},
+				{"id": 2, "name": "Janet"},
+			},
+		})
+		assert.NoError(t, err)
+		assert.True(t, tester.asserter.HasFailures())
+
+		steps := tester.asserter.GetSteps()
+		assert.Len(t, steps, 2)
+		assert.Contains(t, steps[1].Error.Error(), `name: expected "Janet" (string), got "Jane" (string)`)
+	})
 }
 
 func TestIntegrationTester_Cleanup(t *testing.T) {
@@ -4,6 +4,7 @@ package reporting
 import (
 	"encoding/json"
 	"fmt"
+	htmlpkg "html"
 	"os"
 	"path/filepath"
 	"time"
@@ -170,6 +171,7 @@ func (hr *HTMLReporter) generateHTML(results *core.TestResults) string {
         table { border-collapse: collapse; width: 100%%; }
         th, td { border: 1px solid #ddd; padding: 8px; text-align: left; }
         th { background-color: #f2f2f2; }
+        pre { background: #f8f8f8; padding: 8px; overflow-x: auto; }
     </style>
 </head>
 <body>
@@ -208,16 +210,76 @@ func (hr *HTMLReporter) generateHTML(results *core.TestResults) string {
             <td>%s</td>
         </tr>
 `, testCase.Name, testCase.Status.String(), testCase.Status.String(), testCase.Duration, errorMsg)
+
+		// Failed API tests carry ready-to-paste commands that reproduce their requests
+		if snippets, ok := testCase.Metadata["reproduction"].([]string); ok && len(snippets) > 0 {
+			html += `
+        <tr>
+            <td colspan="4"><details><summary>Reproduce</summary>`
+			for _, snippet := range snippets {
+				html += "\n<pre>" + htmlpkg.EscapeString(snippet) + "</pre>"
+			}
+			html += `</details></td>
+        </tr>
+`
+		}
 	}
 
 	html += `
     </table>
-</body>
+`
+	html += loadTestsHTML(results.TestCases)
+	html += `</body>
 </html>`
 
 	return html
 }
 
+// loadTestsHTML returns a table of the metrics of the load tests among the test cases, or nothing
+func loadTestsHTML(testCases []core.TestCaseResult) string {
+	rows := ""
+	for _, testCase := range testCases {
+		metrics, ok := testCase.Metadata["load"].(*core.LoadMetrics)
+		if !ok {
+			continue
+		}
+		rows += fmt.Sprintf(`
+        <tr>
+            <td>%s</td>
+            <td>%d</td>
+            <td>%.1f/s</td>
+            <td>%.2f%%</td>
+            <td>%v</td>
+            <td>%v</td>
+            <td>%v</td>
+            <td>%v</td>
+            <td class="%s">%s</td>
+        </tr>
+`, htmlpkg.EscapeString(testCase.Name), metrics.Requests, metrics.Throughput, metrics.ErrorRate*100,
+			metrics.Latency.P50, metrics.Latency.P95, metrics.Latency.P99, metrics.Latency.Max,
+			testCase.Status.String(), testCase.Status.String())
+	}
+	if rows == "" {
+		return ""
+	}
+
+	return `    <h2>Load Tests</h2>
+    <table>
+        <tr>
+            <th>Name</th>
+            <th>Requests</th>
+            <th>Throughput</th>
+            <th>Error Rate</th>
+            <th>p50</th>
+            <th>p95</th>
+            <th>p99</th>
+            <th>Max</th>
+            <th>Status</th>
+        </tr>
+` + rows + `    </table>
+`
+}
+
 // XMLReporter generates XML reports
 type XMLReporter struct {
 	config *config.ReportConfig
@@ -403,6 +403,23 @@ func TestHTMLReporter_GenerateReport(t *testing.T) {
 				Error:     errors.New("HTTP 500 error"),
 				StartTime: time.Date(2024, 1, 1, 10, 0, 5, 0, time.UTC),
 				EndTime:   time.Date(2024, 1, 1, 10, 0, 8, 0, time.UTC),
+				Metadata: map[string]interface{}{
+					"reproduction": []string{"Reproduce GET https://api.local/ with curl:\ncurl 'https://api.local/?a=1&b=<2>'"},
+				},
+			},
+			{
+				Name:     "Checkout Load",
+				Status:   core.TestStatusPassed,
+				Duration: time.Second * 10,
+				Metadata: map[string]interface{}{
+					"load": &core.LoadMetrics{
+						Requests:   1200,
+						Errors:     6,
+						ErrorRate:  0.005,
+						Throughput: 120,
+						Latency:    core.LatencyStats{P50: 12 * time.Millisecond, P95: 40 * time.Millisecond, P99: 75 * time.Millisecond, Max: 90 * time.Millisecond},
+					},
+				},
 			},
 		},
 	}
@@ -432,6 +449,10 @@ func TestHTMLReporter_GenerateReport(t *testing.T) {
 	assert.Contains(t, htmlContent, "UI Test")
 	assert.Contains(t, htmlContent, "API Test")
 	assert.Contains(t, htmlContent, "HTTP 500 error")
+	assert.Contains(t, htmlContent, "<details><summary>Reproduce</summary>")
+	assert.Contains(t, htmlContent, "curl &#39;https://api.local/?a=1&amp;b=&lt;2&gt;&#39;</pre>")
+	assert.Contains(t, htmlContent, "<h2>Load Tests</h2>")
+	assert.Contains(t, htmlContent, "<td>Checkout Load</td>\n            <td>1200</td>\n            <td>120.0/s</td>\n            <td>0.50%</td>\n            <td>12ms</td>\n            <td>40ms</td>")
 
 	// Verify summary statistics
 	assert.Contains(t, htmlContent, "4") // Total tests
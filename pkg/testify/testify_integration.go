@@ -299,6 +299,115 @@ func (m *UITesterMock) ExecuteTest(test *core.UITest) *core.TestCaseResult {
 	return args.Get(0).(*core.TestCaseResult)
 }
 
+// APITesterMock provides a mock implementation of APITester for testing
+type APITesterMock struct {
+	*GowrightMock
+}
+
+// NewAPITesterMock creates a new APITester mock
+func NewAPITesterMock(testName string) *APITesterMock {
+	return &APITesterMock{
+		GowrightMock: NewGowrightMock(testName),
+	}
+}
+
+// Initialize mocks the Initialize method
+func (m *APITesterMock) Initialize(config interface{}) error {
+	args := m.Called(config)
+	m.Log("Initialize called")
+	return args.Error(0)
+}
+
+// Cleanup mocks the Cleanup method
+func (m *APITesterMock) Cleanup() error {
+	args := m.Called()
+	m.Log("Cleanup called")
+	return args.Error(0)
+}
+
+// GetName mocks the GetName method
+func (m *APITesterMock) GetName() string {
+	args := m.Called()
+	m.Log("GetName called")
+	return args.String(0)
+}
+
+// Get mocks the Get method
+func (m *APITesterMock) Get(endpoint string, headers map[string]string) (*core.APIResponse, error) {
+	args := m.Called(endpoint, headers)
+	m.Log("Get called with endpoint: " + endpoint)
+	return apiResponseArg(args), args.Error(1)
+}
+
+// Post mocks the Post method
+func (m *APITesterMock) Post(endpoint string, body interface{}, headers map[string]string) (*core.APIResponse, error) {
+	args := m.Called(endpoint, body, headers)
+	m.Log("Post called with endpoint: " + endpoint)
+	return apiResponseArg(args), args.Error(1)
+}
+
+// Put mocks the Put method
+func (m *APITesterMock) Put(endpoint string, body interface{}, headers map[string]string) (*core.APIResponse, error) {
+	args := m.Called(endpoint, body, headers)
+	m.Log("Put called with endpoint: " + endpoint)
+	return apiResponseArg(args), args.Error(1)
+}
+
+// Patch mocks the Patch method
+func (m *APITesterMock) Patch(endpoint string, body interface{}, headers map[string]string) (*core.APIResponse, error) {
+	args := m.Called(endpoint, body, headers)
+	m.Log("Patch called with endpoint: " + endpoint)
+	return apiResponseArg(args), args.Error(1)
+}
+
+// Delete mocks the Delete method
+func (m *APITesterMock) Delete(endpoint string, headers map[string]string) (*core.APIResponse, error) {
+	args := m.Called(endpoint, headers)
+	m.Log("Delete called with endpoint: " + endpoint)
+	return apiResponseArg(args), args.Error(1)
+}
+
+// Head mocks the Head method
+func (m *APITesterMock) Head(endpoint string, headers map[string]string) (*core.APIResponse, error) {
+	args := m.Called(endpoint, headers)
+	m.Log("Head called with endpoint: " + endpoint)
+	return apiResponseArg(args), args.Error(1)
+}
+
+// Options mocks the Options method
+func (m *APITesterMock) Options(endpoint string, headers map[string]string) (*core.APIResponse, error) {
+	args := m.Called(endpoint, headers)
+	m.Log("Options called with endpoint: " + endpoint)
+	return apiResponseArg(args), args.Error(1)
+}
+
+// Do mocks the Do method
+func (m *APITesterMock) Do(method, endpoint string, body interface{}, headers map[string]string) (*core.APIResponse, error) {
+	args := m.Called(method, endpoint, body, headers)
+	m.Log("Do called with method: " + method + ", endpoint: " + endpoint)
+	return apiResponseArg(args), args.Error(1)
+}
+
+// SetAuth mocks the SetAuth method
+func (m *APITesterMock) SetAuth(auth *config.AuthConfig) error {
+	args := m.Called(auth)
+	m.Log("SetAuth called")
+	return args.Error(0)
+}
+
+// ExecuteTest mocks the ExecuteTest method
+func (m *APITesterMock) ExecuteTest(test *core.APITest) *core.TestCaseResult {
+	args := m.Called(test)
+	m.Log("ExecuteTest called with test: " + test.Name)
+	return args.Get(0).(*core.TestCaseResult)
+}
+
+// apiResponseArg returns the first return value of a mocked request, allowing nil responses
+func apiResponseArg(args mock.Arguments) *core.APIResponse {
+	response, _ := args.Get(0).(*core.APIResponse)
+	return response
+}
+
 // TestifyIntegrationHelper provides helper methods for integrating Gowright with Go's testing package
 type TestifyIntegrationHelper struct {
 	t        *testing.T
@@ -191,6 +191,35 @@ func TestGowrightMock(t *testing.T) {
 	})
 }
 
+// TestAPITesterMock tests the APITester mock implementation
+func TestAPITesterMock(t *testing.T) {
+	var _ core.APITester = (*APITesterMock)(nil)
+
+	t.Run("PatchMock", func(t *testing.T) {
+		apiMock := NewAPITesterMock("PatchTest")
+		body := map[string]string{"status": "active"}
+		apiMock.On("Patch", "/users/1", body, map[string]string(nil)).Return(&core.APIResponse{StatusCode: 200}, nil)
+
+		response, err := apiMock.Patch("/users/1", body, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+
+		apiMock.AssertExpectations(t)
+		assert.Contains(t, apiMock.GetLogs()[0], "Patch called with endpoint: /users/1")
+	})
+
+	t.Run("DoMock", func(t *testing.T) {
+		apiMock := NewAPITesterMock("DoTest")
+		apiMock.On("Do", "OPTIONS", "/users", nil, map[string]string(nil)).Return(nil, assert.AnError)
+
+		response, err := apiMock.Do("OPTIONS", "/users", nil, nil)
+		assert.Nil(t, response)
+		assert.Equal(t, assert.AnError, err)
+
+		apiMock.AssertExpectations(t)
+	})
+}
+
 // TestUITesterMock tests the UITester mock implementation
 func TestUITesterMock(t *testing.T) {
 	t.Run("NavigateMock", func(t *testing.T) {